@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"infra-dashboard/internal/agent"
+	"infra-dashboard/internal/config"
+)
+
+func main() {
+	once := flag.Bool("once", false, "report the host once and exit")
+	flag.Parse()
+
+	// Load configuration
+	cfg := config.LoadAgent()
+
+	a, err := agent.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize agent: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *once {
+		if err := a.RunOnce(ctx); err != nil {
+			log.Fatalf("Failed to report host: %v", err)
+		}
+		return
+	}
+
+	log.Printf("Reporting to %s every %s", cfg.APIURL, cfg.Interval)
+	if err := a.Run(ctx); err != nil {
+		log.Fatalf("Agent stopped: %v", err)
+	}
+}
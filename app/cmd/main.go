@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
+	"infra-dashboard/internal/auth"
+	"infra-dashboard/internal/catalog"
 	"infra-dashboard/internal/config"
 	"infra-dashboard/internal/database"
 	"infra-dashboard/internal/handlers"
+	"infra-dashboard/internal/maintenance"
+	"infra-dashboard/internal/models"
 
 	"github.com/gorilla/mux"
 )
@@ -16,27 +25,60 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Connect to database
-	db, err := database.New(&cfg.Database)
+	stores, cleanup, err := openStores(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Failed to initialize storage: %v", err)
 	}
-	defer db.Close()
+	defer cleanup()
 
-	// Create tables if they don't exist
-	if err := db.CreateTablesIfNotExist(); err != nil {
-		log.Fatalf("Failed to create tables: %v", err)
+	// Handle the catalog subcommand instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "catalog" {
+		err := runCatalog(&cfg.Catalog, stores, os.Args[2:])
+		cleanup()
+		if err != nil {
+			log.Fatalf("Catalog sync failed: %v", err)
+		}
+		os.Exit(0)
 	}
 
-	// Initialize repositories
-	serverRepo := database.NewServerRepository(db)
-	osRepo := database.NewOSRepository(db)
-	changeHistoryRepo := database.NewChangeHistoryRepository(db)
+	policies, err := cfg.Compliance.LoadPolicies()
+	if err != nil {
+		log.Fatalf("Failed to load compliance policy: %v", err)
+	}
+	upgrades, err := cfg.Compliance.LoadUpgradeGraph()
+	if err != nil {
+		log.Fatalf("Failed to load upgrade graph: %v", err)
+	}
 
 	// Initialize handlers
-	serverHandler := handlers.NewServerHandler(serverRepo, osRepo)
-	osHandler := handlers.NewOSHandler(osRepo)
-	changeHistoryHandler := handlers.NewChangeHistoryHandler(changeHistoryRepo)
+	serverHandler := handlers.NewServerHandler(stores.Servers, stores.OS, stores.Waivers, stores.Changes, policies, upgrades)
+	osHandler := handlers.NewOSHandler(stores.OS, policies.Default)
+	changeHistoryHandler := handlers.NewChangeHistoryHandler(stores.ChangeHistory, stores.OSChangeHistory)
+	waiverHandler := handlers.NewWaiverHandler(stores.Waivers)
+	campaignHandler := handlers.NewCampaignHandler(stores.Campaigns, stores.Servers, stores.ChangeHistory)
+	scheduledChangeHandler := handlers.NewScheduledChangeHandler(stores.Changes, stores.Servers)
+	complianceHandler := handlers.NewComplianceHandler(stores.Snapshots, stores.Servers, stores.Waivers, policies.Default)
+	apiKeyHandler := handlers.NewAPIKeyHandler(stores.APIKeys)
+
+	// Record compliance snapshots in the background
+	if cfg.Compliance.SnapshotInterval > 0 {
+		go complianceHandler.ScheduleSnapshots(context.Background(), cfg.Compliance.SnapshotInterval)
+	}
+
+	// Sync the OS catalog in the background
+	if cfg.Catalog.Source != "" && cfg.Catalog.SyncInterval > 0 {
+		products, err := catalog.ParseProducts(cfg.Catalog.Products)
+		if err != nil {
+			log.Fatalf("Failed to configure catalog sync: %v", err)
+		}
+		syncer := catalog.NewSyncer(stores.OS, catalog.NewSource(cfg.Catalog.Source, cfg.Catalog.Timeout), products)
+		go syncer.Schedule(context.Background(), cfg.Catalog.SyncInterval)
+	}
+
+	// Apply scheduled OS changes in the background
+	if cfg.Maintenance.CheckInterval > 0 {
+		go maintenance.NewExecutor(stores.Changes, stores.Servers).Schedule(context.Background(), cfg.Maintenance.CheckInterval)
+	}
 
 	// Setup router
 	router := mux.NewRouter()
@@ -51,6 +93,8 @@ func main() {
 	api.HandleFunc("/servers/{id:[0-9]+}", serverHandler.UpdateServer).Methods("PUT")
 	api.HandleFunc("/servers/{id:[0-9]+}", serverHandler.DeleteServer).Methods("DELETE")
 	api.HandleFunc("/servers/compliance", serverHandler.GetComplianceReport).Methods("GET")
+	api.HandleFunc("/servers/import", serverHandler.ImportServers).Methods("POST")
+	api.HandleFunc("/servers/register", serverHandler.RegisterServer).Methods("POST")
 
 	// Operating System routes
 	api.HandleFunc("/os", osHandler.GetOperatingSystems).Methods("GET")
@@ -63,34 +107,311 @@ func main() {
 	api.HandleFunc("/history", changeHistoryHandler.GetChangeHistory).Methods("GET")
 	api.HandleFunc("/history/{id:[0-9]+}", changeHistoryHandler.GetChangeHistoryByID).Methods("GET")
 	api.HandleFunc("/servers/{id:[0-9]+}/history", changeHistoryHandler.GetServerChangeHistory).Methods("GET")
+	api.HandleFunc("/os/{id:[0-9]+}/history", changeHistoryHandler.GetOSChangeHistory).Methods("GET")
+
+	// Compliance waiver routes
+	api.HandleFunc("/waivers", waiverHandler.GetWaivers).Methods("GET")
+	api.HandleFunc("/waivers", waiverHandler.CreateWaiver).Methods("POST")
+	api.HandleFunc("/waivers/{id:[0-9]+}", waiverHandler.GetWaiver).Methods("GET")
+	api.HandleFunc("/waivers/{id:[0-9]+}", waiverHandler.UpdateWaiver).Methods("PUT")
+	api.HandleFunc("/waivers/{id:[0-9]+}", waiverHandler.RevokeWaiver).Methods("DELETE")
+
+	// Upgrade campaign routes
+	api.HandleFunc("/campaigns", campaignHandler.GetCampaigns).Methods("GET")
+	api.HandleFunc("/campaigns", campaignHandler.CreateCampaign).Methods("POST")
+	api.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.GetCampaign).Methods("GET")
+	api.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.UpdateCampaign).Methods("PUT")
+	api.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.DeleteCampaign).Methods("DELETE")
+	api.HandleFunc("/campaigns/{id:[0-9]+}/progress", campaignHandler.GetCampaignProgress).Methods("GET")
+	api.HandleFunc("/campaigns/{id:[0-9]+}/burndown", campaignHandler.GetCampaignBurndown).Methods("GET")
+
+	// Scheduled change routes
+	api.HandleFunc("/scheduled-changes", scheduledChangeHandler.GetScheduledChanges).Methods("GET")
+	api.HandleFunc("/scheduled-changes", scheduledChangeHandler.CreateScheduledChange).Methods("POST")
+	api.HandleFunc("/scheduled-changes/{id:[0-9]+}", scheduledChangeHandler.GetScheduledChange).Methods("GET")
+	api.HandleFunc("/scheduled-changes/{id:[0-9]+}", scheduledChangeHandler.CancelScheduledChange).Methods("DELETE")
+
+	// Compliance routes
+	api.HandleFunc("/compliance/snapshots", complianceHandler.GetSnapshots).Methods("GET")
+	api.HandleFunc("/compliance/snapshots", complianceHandler.CreateSnapshot).Methods("POST")
+	api.HandleFunc("/compliance/trend", complianceHandler.GetTrend).Methods("GET")
+	api.HandleFunc("/compliance/recommendations", serverHandler.GetComplianceRecommendations).Methods("GET")
+	api.HandleFunc("/compliance/schema", serverHandler.GetComplianceSchema).Methods("GET")
+
+	// API key routes
+	api.HandleFunc("/api-keys", apiKeyHandler.GetAPIKeys).Methods("GET")
+	api.HandleFunc("/api-keys", apiKeyHandler.CreateAPIKey).Methods("POST")
+	api.HandleFunc("/api-keys/{id:[0-9]+}", apiKeyHandler.GetAPIKey).Methods("GET")
+	api.HandleFunc("/api-keys/{id:[0-9]+}", apiKeyHandler.RevokeAPIKey).Methods("DELETE")
+	api.HandleFunc("/api-keys/{id:[0-9]+}/rotate", apiKeyHandler.RotateAPIKey).Methods("POST")
 
 	// Health check
 	router.HandleFunc("/health", serverHandler.HealthCheck).Methods("GET")
 
 	// Add CORS middleware
-	router.Use(corsMiddleware)
+	router.Use(corsMiddleware(cfg.Server.CORSAllowedOrigins))
 
 	// Add logging middleware
 	router.Use(loggingMiddleware)
 
+	// Add authentication middleware
+	if cfg.Auth.Enabled {
+		issued, err := auth.EnsureAdminKey(stores.APIKeys, func() (string, error) {
+			return auth.BootstrapSecret(cfg.Auth.BootstrapKey, cfg.Auth.BootstrapKeyFile)
+		})
+		if err != nil {
+			log.Fatalf("Failed to bootstrap admin API key: %v", err)
+		}
+		if issued != nil {
+			log.Printf("Issued admin API key %q with prefix %s", issued.Name, issued.Prefix)
+		}
+		authenticators, err := newAuthenticators(&cfg.Auth, stores)
+		if err != nil {
+			log.Fatalf("Failed to configure authentication: %v", err)
+		}
+		router.Use(auth.Middleware(auth.DefaultRules, authenticators...))
+	} else {
+		log.Printf("Authentication is disabled; every route is open")
+	}
+
 	log.Printf("Starting server on port %s", cfg.Server.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Server.Port, router))
 }
 
-// corsMiddleware adds CORS headers to responses
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// openStores initializes the storage backend selected by DB_DRIVER. For
+// PostgreSQL it also handles the migrate subcommand and applies pending
+// migrations at startup.
+func openStores(cfg *config.Config) (*database.Stores, func(), error) {
+	if cfg.Database.Driver == "memory" {
+		if len(os.Args) > 1 && (os.Args[1] == "migrate" || os.Args[1] == "catalog") {
+			return nil, nil, fmt.Errorf("the %s command requires DB_DRIVER=postgres", os.Args[1])
+		}
+		log.Printf("Using in-memory storage; data will be lost on restart")
+		return database.NewMemoryStores(database.NewMemoryDB()), func() {}, nil
+	}
+	if cfg.Database.Driver != "postgres" {
+		return nil, nil, fmt.Errorf("unsupported DB_DRIVER %q: expected postgres or memory", cfg.Database.Driver)
+	}
+
+	// Connect to database
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+	// Handle the migrate subcommand instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(migrator, os.Args[2:])
+		db.Close()
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
 		}
+		os.Exit(0)
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	// Apply pending schema migrations
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up(context.Background(), 0)
+		if err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+		for _, m := range applied {
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+		}
+	}
+
+	return database.NewPostgresStores(db), func() { db.Close() }, nil
+}
+
+// runMigrate implements the "migrate status|up|down [steps]" subcommand
+func runMigrate(migrator *database.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s migrate status|up|down [steps]", os.Args[0])
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid steps value: %s", args[1])
+		}
+		steps = n
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	case "up":
+		applied, err := migrator.Up(ctx, steps)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		for _, m := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No applied migrations")
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q: expected status, up or down", args[0])
+	}
+
+	return nil
+}
+
+// runCatalog implements the "catalog sync [-dry-run] [-source location]"
+// subcommand
+func runCatalog(cfg *config.CatalogConfig, stores *database.Stores, args []string) error {
+	if len(args) == 0 || args[0] != "sync" {
+		return fmt.Errorf("usage: %s catalog sync [-dry-run] [-source file, directory or URL]", os.Args[0])
+	}
+
+	flags := flag.NewFlagSet("catalog sync", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report the changes without writing them")
+	source := flags.String("source", cfg.Source, "endoflife.date product file, directory or base URL")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *source == "" {
+		return fmt.Errorf("no catalog source: set CATALOG_SOURCE or pass -source")
+	}
+
+	products, err := catalog.ParseProducts(cfg.Products)
+	if err != nil {
+		return err
+	}
+
+	syncer := catalog.NewSyncer(stores.OS, catalog.NewSource(*source, cfg.Timeout), products)
+	report, err := syncer.Sync(context.Background(), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, release := range report.Releases {
+		switch release.Action {
+		case models.ImportActionUnchanged:
+			continue
+		case catalog.ActionSkipped, catalog.ActionFailed:
+			fmt.Printf("%-9s %s %s: %s\n", release.Action, release.Name, release.Version, release.Reason)
+		default:
+			fmt.Printf("%-9s %s %s", release.Action, release.Name, release.Version)
+			for _, field := range []string{"lts", "release_date", "end_of_standard_support", "end_of_support", "end_of_extended_support"} {
+				change, changed := release.Changes[field]
+				switch {
+				case !changed:
+				case change.Old == nil || change.Old == "":
+					fmt.Printf(" %s=%v", field, change.New)
+				default:
+					fmt.Printf(" %s=%v->%v", field, change.Old, change.New)
+				}
+			}
+			fmt.Println()
+		}
+	}
+
+	summary := report.Summary()
+	if report.DryRun {
+		summary += " (dry run, nothing was written)"
+	}
+	fmt.Println(summary)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d releases could not be synced", report.Failed)
+	}
+	return nil
+}
+
+// newAuthenticators returns the authenticators accepting API keys and, when
+// a JWKS is configured, OIDC bearer tokens
+func newAuthenticators(cfg *config.AuthConfig, stores *database.Stores) ([]auth.Authenticator, error) {
+	authenticators := []auth.Authenticator{auth.NewAPIKeyAuthenticator(stores.APIKeys)}
+	if !cfg.OIDC.Enabled() {
+		return authenticators, nil
+	}
+
+	roleGroups, err := auth.ParseRoleGroups(cfg.OIDC.RoleGroups)
+	if err != nil {
+		return nil, err
+	}
+	jwtConfig := auth.JWTConfig{
+		Issuer:        cfg.OIDC.Issuer,
+		Audience:      cfg.OIDC.Audience,
+		ClockSkew:     cfg.OIDC.ClockSkew,
+		UsernameClaim: cfg.OIDC.UsernameClaim,
+		GroupsClaim:   cfg.OIDC.GroupsClaim,
+		RoleGroups:    roleGroups,
+	}
+	if err := jwtConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: set OIDC_ISSUER and OIDC_AUDIENCE, or unset OIDC_JWKS", err)
+	}
+	keys, err := auth.LoadKeySet(cfg.OIDC.JWKS, cfg.OIDC.JWKSRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Accepting OIDC bearer tokens signed by keys from %s", cfg.OIDC.JWKS)
+	return append(authenticators, auth.NewJWTAuthenticator(keys, jwtConfig)), nil
+}
+
+// corsMiddleware adds CORS headers to responses, allowing the configured
+// origins to call the API from a browser
+func corsMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowAny := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowAny {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else if origin := r.Header.Get("Origin"); allowed[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Actor, X-Request-ID")
+
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // responseWriter wraps http.ResponseWriter to capture status code
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"infra-dashboard/internal/config"
+	"infra-dashboard/internal/models"
+)
+
+// Agent reports its host to the dashboard API on an interval. Reports that
+// cannot be delivered are spooled on disk and sent, oldest first, once the
+// API is reachable again.
+type Agent struct {
+	cfg       *config.AgentConfig
+	collector *Collector
+	client    *Client
+	spool     *Spool
+
+	// sleep waits between retries and reports, and returns early with the
+	// error of the context when it is done. It can be replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+	// jitter returns a random duration in [0, n). It can be replaced in tests.
+	jitter func(n int64) int64
+}
+
+// New creates an agent from its configuration
+func New(cfg *config.AgentConfig) (*Agent, error) {
+	if cfg.APIURL == "" {
+		return nil, fmt.Errorf("the API URL is required")
+	}
+	// The API requires the environment of every registration
+	if err := models.NewServerUtils().ValidateEnvironment(cfg.Environment); err != nil {
+		return nil, fmt.Errorf("invalid environment %q: must be prod, staging or dev", cfg.Environment)
+	}
+
+	spool, err := NewSpool(cfg.SpoolDir, cfg.SpoolMax)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := NewCollector(cfg.Root)
+	collector.Hostname = cfg.Hostname
+	collector.Environment = cfg.Environment
+	collector.Role = cfg.Role
+	collector.OwnerTeam = cfg.OwnerTeam
+	collector.Location = cfg.Location
+
+	return &Agent{
+		cfg:       cfg,
+		collector: collector,
+		client:    NewClient(cfg.APIURL, cfg.APIKey, cfg.Timeout),
+		spool:     spool,
+		sleep:     sleepContext,
+		jitter:    rand.Int63n,
+	}, nil
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run reports the host every interval, varied by the jitter, until ctx is
+// done. Failed reports are logged and do not stop the agent.
+func (a *Agent) Run(ctx context.Context) error {
+	// Start at a random point of the first interval so that hosts booted
+	// together spread their reports
+	if err := a.sleep(ctx, a.randomDuration(a.cfg.Jitter)); err != nil {
+		return nil
+	}
+
+	for {
+		if err := a.RunOnce(ctx); err != nil {
+			log.Printf("Error reporting host: %v", err)
+		}
+
+		wait := a.cfg.Interval - a.cfg.Jitter + a.randomDuration(2*a.cfg.Jitter)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		if err := a.sleep(ctx, wait); err != nil {
+			return nil
+		}
+	}
+}
+
+// RunOnce delivers the spooled reports, then collects and sends a new
+// report. A report that cannot be delivered is spooled; the returned error
+// says why.
+func (a *Agent) RunOnce(ctx context.Context) error {
+	pending, flushErr := a.flush(ctx)
+
+	req, err := a.collector.Collect()
+	if err != nil {
+		return err
+	}
+
+	// Reports must reach the API in the order they were made, so the new
+	// report waits behind the spooled ones
+	if pending > 0 {
+		if err := a.spool.Add(req); err != nil {
+			return err
+		}
+		return fmt.Errorf("API unavailable, report spooled behind %d others: %w", pending, flushErr)
+	}
+
+	err = a.send(ctx, func(ctx context.Context) error {
+		registration, err := a.client.Register(ctx, req)
+		if err == nil {
+			log.Printf("Reported %s as %s %s (%s)", req.Name, registration.DetectedOS.Name, registration.DetectedOS.Version, registration.Action)
+		}
+		return err
+	})
+	if err != nil && Retryable(err) && ctx.Err() == nil {
+		if spoolErr := a.spool.Add(req); spoolErr != nil {
+			return fmt.Errorf("%w (and failed to spool the report: %v)", err, spoolErr)
+		}
+		return fmt.Errorf("API unavailable, report spooled: %w", err)
+	}
+	return err
+}
+
+// flush sends the spooled reports, oldest first, and stops at the first
+// one the API may accept later. Reports the API rejects are dropped. It
+// returns the number of reports left in the spool.
+func (a *Agent) flush(ctx context.Context) (int, error) {
+	names, err := a.spool.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, name := range names {
+		req, err := a.spool.Load(name)
+		if err != nil {
+			log.Printf("Error loading spooled report %s, dropping it: %v", name, err)
+			if err := a.spool.Remove(name); err != nil {
+				return len(names) - i, err
+			}
+			continue
+		}
+
+		err = a.send(ctx, func(ctx context.Context) error {
+			_, err := a.client.Register(ctx, req)
+			return err
+		})
+		if err != nil && (Retryable(err) || ctx.Err() != nil) {
+			return len(names) - i, err
+		}
+		if err != nil {
+			log.Printf("Error sending spooled report %s, dropping it: %v", name, err)
+		}
+		if err := a.spool.Remove(name); err != nil {
+			return len(names) - i, err
+		}
+	}
+	return 0, nil
+}
+
+// send calls fn, retrying retryable failures with an exponential backoff
+func (a *Agent) send(ctx context.Context, fn func(ctx context.Context) error) error {
+	backoff := a.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !Retryable(err) || attempt >= a.cfg.Retries {
+			return err
+		}
+
+		// Wait between half and all of the backoff so that hosts failing
+		// together do not retry together
+		wait := backoff/2 + a.randomDuration(backoff/2)
+		if sleepErr := a.sleep(ctx, wait); sleepErr != nil {
+			return err
+		}
+
+		backoff *= 2
+		if a.cfg.RetryMaxBackoff > 0 && backoff > a.cfg.RetryMaxBackoff {
+			backoff = a.cfg.RetryMaxBackoff
+		}
+	}
+}
+
+// randomDuration returns a random duration in [0, d)
+func (a *Agent) randomDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(a.jitter(int64(d)))
+}
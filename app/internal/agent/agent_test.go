@@ -0,0 +1,259 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/auth"
+	"infra-dashboard/internal/config"
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/handlers"
+	"infra-dashboard/internal/models"
+)
+
+// newTestAgent creates an agent reporting the fixture root to url, with a
+// spool in a temporary directory and no waiting between retries
+func newTestAgent(t *testing.T, url, root string) (*Agent, *[]time.Duration) {
+	t.Helper()
+
+	a, err := New(&config.AgentConfig{
+		APIURL:          url,
+		APIKey:          "secret",
+		Timeout:         5 * time.Second,
+		Interval:        time.Hour,
+		Jitter:          5 * time.Minute,
+		Retries:         2,
+		RetryBackoff:    time.Second,
+		RetryMaxBackoff: 3 * time.Second,
+		SpoolDir:        t.TempDir(),
+		SpoolMax:        3,
+		Root:            root,
+		Environment:     "prod",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	a.collector.uname = func() (string, error) { return "", nil }
+
+	var sleeps []time.Duration
+	a.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	a.jitter = func(n int64) int64 { return n - 1 }
+	return a, &sleeps
+}
+
+// recordingServer accepts registrations with the status set by the test
+type recordingServer struct {
+	mu       sync.Mutex
+	status   int
+	received []models.RegisterServerRequest
+}
+
+func (s *recordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != registerPath || r.Header.Get(auth.APIKeyHeader) != "secret" {
+		http.Error(w, "Unexpected request", http.StatusBadRequest)
+		return
+	}
+	var req models.RegisterServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	s.received = append(s.received, req)
+
+	if s.status != http.StatusOK {
+		http.Error(w, "Unavailable", s.status)
+		return
+	}
+	json.NewEncoder(w).Encode(models.ServerRegistration{Action: models.ImportActionUnchanged})
+}
+
+func (s *recordingServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	s.received = nil
+}
+
+func pending(t *testing.T, a *Agent) int {
+	t.Helper()
+
+	names, err := a.spool.Pending()
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	return len(names)
+}
+
+func TestAgent_RunOnceRegistersHost(t *testing.T) {
+	stores := database.NewMemoryStores(database.NewMemoryDB())
+	if _, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Ubuntu", Version: "22.04", EndOfSupport: "2027-04-01"}); err != nil {
+		t.Fatal(err)
+	}
+	handler := handlers.NewServerHandler(stores.Servers, stores.OS, stores.Waivers, stores.Changes, models.DefaultCompliancePolicySet(), models.DefaultUpgradeGraph())
+	server := httptest.NewServer(http.HandlerFunc(handler.RegisterServer))
+	defer server.Close()
+
+	a, _ := newTestAgent(t, server.URL, "testdata/ubuntu")
+	if err := a.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	servers, err := stores.Servers.GetAll(&models.ServerFilter{})
+	if err != nil || len(servers) != 1 {
+		t.Fatalf("Expected one server, got %+v, %v", servers, err)
+	}
+	if s := servers[0]; s.Name != "web-01" || s.Environment != "prod" || s.OS == nil || s.OS.Version != "22.04" {
+		t.Errorf("Unexpected server: %+v", s)
+	}
+	if n := pending(t, a); n != 0 {
+		t.Errorf("Expected an empty spool, got %d reports", n)
+	}
+}
+
+func TestAgent_SpoolsWhileAPIUnavailable(t *testing.T) {
+	api := &recordingServer{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	a, sleeps := newTestAgent(t, server.URL, "testdata/ubuntu")
+
+	// The report is retried with a growing, capped backoff, then spooled
+	if err := a.RunOnce(context.Background()); err == nil {
+		t.Fatal("RunOnce() succeeded against an unavailable API")
+	}
+	if len(api.received) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", len(api.received))
+	}
+	if want := []time.Duration{time.Second - 1, 2*time.Second - 1}; len(*sleeps) != 2 || (*sleeps)[0] != want[0] || (*sleeps)[1] != want[1] {
+		t.Errorf("Unexpected backoff %v, want %v", *sleeps, want)
+	}
+	if n := pending(t, a); n != 1 {
+		t.Fatalf("Expected 1 spooled report, got %d", n)
+	}
+
+	// Later reports queue behind the spooled ones, up to the spool limit
+	a.collector.Hostname = "web-02"
+	a.RunOnce(context.Background())
+	a.collector.Hostname = "web-03"
+	a.RunOnce(context.Background())
+	a.collector.Hostname = "web-04"
+	a.RunOnce(context.Background())
+	if n := pending(t, a); n != 3 {
+		t.Fatalf("Expected 3 spooled reports, got %d", n)
+	}
+
+	// Once the API is back the spool is delivered oldest first, before the
+	// new report
+	api.setStatus(http.StatusOK)
+	a.collector.Hostname = "web-05"
+	if err := a.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	var names []string
+	for _, req := range api.received {
+		names = append(names, req.Name)
+	}
+	if want := []string{"web-02", "web-03", "web-04", "web-05"}; len(names) != len(want) ||
+		names[0] != want[0] || names[1] != want[1] || names[2] != want[2] || names[3] != want[3] {
+		t.Errorf("Delivered %v, want %v", names, want)
+	}
+	if n := pending(t, a); n != 0 {
+		t.Errorf("Expected an empty spool, got %d reports", n)
+	}
+}
+
+func TestAgent_RejectedReportsAreNotSpooled(t *testing.T) {
+	api := &recordingServer{status: http.StatusUnprocessableEntity}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	a, sleeps := newTestAgent(t, server.URL, "testdata/ubuntu")
+	err := a.RunOnce(context.Background())
+	if err == nil || Retryable(err) {
+		t.Fatalf("RunOnce() error = %v, want a permanent error", err)
+	}
+	if len(api.received) != 1 || len(*sleeps) != 0 {
+		t.Errorf("Expected a single attempt, got %d attempts and %d waits", len(api.received), len(*sleeps))
+	}
+	if n := pending(t, a); n != 0 {
+		t.Errorf("Expected an empty spool, got %d reports", n)
+	}
+}
+
+func TestAgent_UnreachableAPI(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	a, _ := newTestAgent(t, url, "testdata/freebsd")
+	if err := a.RunOnce(context.Background()); err == nil {
+		t.Fatal("RunOnce() succeeded against an unreachable API")
+	}
+	if n := pending(t, a); n != 1 {
+		t.Fatalf("Expected 1 spooled report, got %d", n)
+	}
+	names, _ := a.spool.Pending()
+	req, err := a.spool.Load(names[0])
+	if err != nil || req.Name != "db-01.example.org" || req.FreeBSDVersion != "14.1-RELEASE-p3" {
+		t.Errorf("Unexpected spooled report %+v, %v", req, err)
+	}
+}
+
+func TestAgent_RunSpreadsReports(t *testing.T) {
+	api := &recordingServer{status: http.StatusOK}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	a, _ := newTestAgent(t, server.URL, "testdata/ubuntu")
+	var sleeps []time.Duration
+	ctx, cancel := context.WithCancel(context.Background())
+	a.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		if len(sleeps) == 3 {
+			cancel()
+		}
+		return ctx.Err()
+	}
+
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// A random start within the jitter, then the interval plus up to the
+	// jitter either way
+	want := []time.Duration{5*time.Minute - 1, time.Hour + 5*time.Minute - 1, time.Hour + 5*time.Minute - 1}
+	if len(sleeps) != 3 || sleeps[0] != want[0] || sleeps[1] != want[1] || sleeps[2] != want[2] {
+		t.Errorf("Unexpected waits %v, want %v", sleeps, want)
+	}
+	if len(api.received) != 2 {
+		t.Errorf("Expected 2 reports, got %d", len(api.received))
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{&StatusError{StatusCode: http.StatusUnauthorized}, false},
+		{&StatusError{StatusCode: http.StatusUnprocessableEntity}, false},
+		{context.DeadlineExceeded, true},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
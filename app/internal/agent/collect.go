@@ -0,0 +1,170 @@
+// Package agent implements the reporting agent that runs on each host and
+// registers it with the dashboard API, so that the inventory maintains
+// itself.
+package agent
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"infra-dashboard/internal/models"
+)
+
+// Collector detects what a host reports about itself from its root
+// filesystem: the OS from os-release or freebsd-version, the host name and
+// the kernel version
+type Collector struct {
+	// Root is the root filesystem, "/" on a host and a fixture in tests
+	Root string
+	// Hostname overrides the host name read from the root filesystem
+	Hostname string
+	// Environment, Role, OwnerTeam and Location are reported with the host
+	Environment string
+	Role        string
+	OwnerTeam   string
+	Location    string
+
+	// hostname and uname report the running system and are used when the
+	// root filesystem does not provide the information. They can be
+	// replaced in tests.
+	hostname func() (string, error)
+	uname    func() (string, error)
+}
+
+// NewCollector creates a collector reading the root filesystem at root
+func NewCollector(root string) *Collector {
+	return &Collector{Root: root, hostname: os.Hostname, uname: execUname}
+}
+
+// execUname runs uname -a
+func execUname() (string, error) {
+	out, err := exec.Command("uname", "-a").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run uname: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Collect returns the registration of the host. The OS data is checked with
+// the detection the API applies, so that hosts the API cannot register are
+// reported locally instead of being sent and spooled.
+func (c *Collector) Collect() (*models.RegisterServerRequest, error) {
+	name, err := c.detectHostname()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.RegisterServerRequest{
+		Name:        name,
+		Environment: c.Environment,
+		Role:        c.Role,
+		OwnerTeam:   c.OwnerTeam,
+		Location:    c.Location,
+	}
+
+	if req.OSRelease, err = c.readFirst("etc/os-release", "usr/lib/os-release"); err != nil {
+		return nil, err
+	}
+	if req.FreeBSDVersion, err = c.freeBSDVersion(); err != nil {
+		return nil, err
+	}
+	if req.Uname, err = c.kernel(name); err != nil && req.OSRelease == "" && req.FreeBSDVersion == "" {
+		return nil, err
+	}
+
+	if _, err := models.DetectOS(*req); err != nil {
+		return nil, fmt.Errorf("failed to detect the operating system: %w", err)
+	}
+
+	return req, nil
+}
+
+// path returns the path of a file of the root filesystem
+func (c *Collector) path(name string) string {
+	return filepath.Join(c.Root, filepath.FromSlash(name))
+}
+
+// readFirst returns the contents of the first of the files that exists, or
+// "" when none does
+func (c *Collector) readFirst(names ...string) (string, error) {
+	for _, name := range names {
+		data, err := os.ReadFile(c.path(name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return string(data), nil
+	}
+	return "", nil
+}
+
+// detectHostname returns the configured host name, else the one of
+// /etc/hostname, else the name of the running system
+func (c *Collector) detectHostname() (string, error) {
+	if c.Hostname != "" {
+		return c.Hostname, nil
+	}
+
+	content, err := c.readFirst("etc/hostname")
+	if err != nil {
+		return "", err
+	}
+	if name := strings.TrimSpace(content); name != "" {
+		return name, nil
+	}
+
+	if c.hostname == nil {
+		return "", fmt.Errorf("failed to detect the host name")
+	}
+	name, err := c.hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to detect the host name: %w", err)
+	}
+	return strings.TrimSpace(name), nil
+}
+
+// freeBSDVersion returns the userland version of FreeBSD, which the
+// freebsd-version script embeds as USERLAND_VERSION, or "" on other systems
+func (c *Collector) freeBSDVersion() (string, error) {
+	content, err := c.readFirst("bin/freebsd-version")
+	if err != nil || content == "" {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "USERLAND_VERSION="); found {
+			return strings.Trim(value, `"'`), nil
+		}
+	}
+	return "", fmt.Errorf("bin/freebsd-version does not define USERLAND_VERSION")
+}
+
+// kernel returns the kernel of the host in the form of uname -a: from
+// /proc/sys/kernel on Linux, else by running uname
+func (c *Collector) kernel(hostname string) (string, error) {
+	osType, err := c.readFirst("proc/sys/kernel/ostype")
+	if err != nil {
+		return "", err
+	}
+	release, err := c.readFirst("proc/sys/kernel/osrelease")
+	if err != nil {
+		return "", err
+	}
+	if osType != "" && release != "" {
+		return strings.Join([]string{strings.TrimSpace(osType), hostname, strings.TrimSpace(release)}, " "), nil
+	}
+
+	if c.uname == nil {
+		return "", fmt.Errorf("failed to detect the kernel version")
+	}
+	return c.uname()
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     string
+		hostname string
+		want     models.DetectedOS
+		wantName string
+		wantKern string
+	}{
+		{
+			name:     "os-release and proc on Linux",
+			root:     "testdata/ubuntu",
+			want:     models.DetectedOS{Name: "Ubuntu", Version: "22.04", Source: models.OSSourceOSRelease},
+			wantName: "web-01",
+			wantKern: "Linux web-01 5.15.0-105-generic",
+		},
+		{
+			name:     "freebsd-version",
+			root:     "testdata/freebsd",
+			want:     models.DetectedOS{Name: "FreeBSD", Version: "14.1", Source: models.OSSourceFreeBSDVersion},
+			wantName: "db-01.example.org",
+			wantKern: "FreeBSD db-01.example.org 14.1-RELEASE-p3 GENERIC amd64",
+		},
+		{
+			name:     "os-release under /usr/lib and the system host name",
+			root:     "testdata/centos",
+			hostname: "legacy-01",
+			want:     models.DetectedOS{Name: "CentOS", Version: "7", Source: models.OSSourceOSRelease},
+			wantName: "legacy-01",
+			wantKern: "FreeBSD db-01.example.org 14.1-RELEASE-p3 GENERIC amd64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector(tt.root)
+			c.Environment = "prod"
+			c.hostname = func() (string, error) {
+				if tt.hostname == "" {
+					return "", fmt.Errorf("unexpected call")
+				}
+				return tt.hostname + "\n", nil
+			}
+			c.uname = func() (string, error) {
+				return "FreeBSD db-01.example.org 14.1-RELEASE-p3 GENERIC amd64", nil
+			}
+
+			req, err := c.Collect()
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if req.Name != tt.wantName || req.Environment != "prod" || req.Uname != tt.wantKern {
+				t.Errorf("Collect() = %+v", req)
+			}
+			detected, err := models.DetectOS(*req)
+			if err != nil || detected != tt.want {
+				t.Errorf("DetectOS() = %+v, %v, want %+v", detected, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollector_HostnameOverride(t *testing.T) {
+	c := NewCollector("testdata/ubuntu")
+	c.Hostname = "web-01.prod.example.org"
+
+	req, err := c.Collect()
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if req.Name != "web-01.prod.example.org" || !strings.Contains(req.Uname, "web-01.prod.example.org") {
+		t.Errorf("Collect() = %+v", req)
+	}
+}
+
+func TestCollector_Undetectable(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "proc", "sys", "kernel"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(root, "proc", "sys", "kernel", "ostype"), []byte("Linux\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "proc", "sys", "kernel", "osrelease"), []byte("6.1.0\n"), 0o644)
+
+	// Without os-release the kernel does not tell the Linux distribution
+	c := NewCollector(root)
+	c.Hostname = "mystery-01"
+	if _, err := c.Collect(); err == nil || !strings.Contains(err.Error(), "os-release") {
+		t.Errorf("Collect() error = %v, want an os-release error", err)
+	}
+}
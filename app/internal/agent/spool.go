@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// spoolSequence keeps spool file names unique within a process
+var spoolSequence atomic.Uint64
+
+// Spool keeps the reports that could not be delivered on disk, one JSON file
+// per report, until they can be sent in the order they were made
+type Spool struct {
+	dir string
+	max int
+}
+
+// NewSpool creates a spool in dir holding at most max reports; the oldest
+// reports are dropped beyond that
+func NewSpool(dir string, max int) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return &Spool{dir: dir, max: max}, nil
+}
+
+// Add stores a report
+func (s *Spool) Add(req *models.RegisterServerRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode spooled report: %w", err)
+	}
+
+	// Names sort in the order reports were made. The file is written under a
+	// temporary name first so that a crash never leaves a partial report.
+	name := fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), spoolSequence.Add(1)%1000000)
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write spooled report: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write spooled report: %w", err)
+	}
+
+	return s.prune()
+}
+
+// Pending returns the names of the spooled reports, oldest first
+func (s *Spool) Pending() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name := entry.Name(); entry.Type().IsRegular() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".json") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load returns a spooled report
+func (s *Spool) Load(name string) (*models.RegisterServerRequest, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spooled report: %w", err)
+	}
+
+	var req models.RegisterServerRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid spooled report %s: %w", name, err)
+	}
+	return &req, nil
+}
+
+// Remove deletes a spooled report
+func (s *Spool) Remove(name string) error {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spooled report: %w", err)
+	}
+	return nil
+}
+
+// prune drops the oldest reports beyond the spool limit
+func (s *Spool) prune() error {
+	if s.max <= 0 {
+		return nil
+	}
+
+	names, err := s.Pending()
+	if err != nil {
+		return err
+	}
+	for len(names) > s.max {
+		if err := s.Remove(names[0]); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+const (
+	// APIKeyHeader is the request header carrying an API key
+	APIKeyHeader = "X-API-Key"
+	// apiKeyPrefix starts every issued key so that leaked keys are easy to spot
+	apiKeyPrefix = "idk_"
+	// apiKeyDisplayLength is the number of leading characters of a key stored
+	// in clear to recognise it
+	apiKeyDisplayLength = 12
+	// lastUsedResolution limits how often the last use of a key is recorded
+	lastUsedResolution = time.Minute
+	// BootstrapAdminKeyName names the admin key issued when none exists
+	BootstrapAdminKeyName = "bootstrap-admin"
+	// minBootstrapKeyLength is the shortest accepted bootstrap admin key
+	// secret, about as long as a generated key
+	minBootstrapKeyLength = 32
+)
+
+// GenerateAPIKey returns a new random API key
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash under which a key is stored
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// displayPrefix returns the leading characters of a key stored in clear
+func displayPrefix(key string) string {
+	if len(key) <= apiKeyDisplayLength {
+		return key
+	}
+	return key[:apiKeyDisplayLength]
+}
+
+// IssueAPIKey creates an API key granting role and returns it with its
+// secret, which cannot be retrieved later
+func IssueAPIKey(store database.APIKeyStore, name, role, createdBy string) (*models.IssuedAPIKey, error) {
+	if err := models.ValidateRole(role); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, database.ErrInvalidInput)
+	}
+
+	secret, err := GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return createAPIKey(store, name, role, createdBy, secret)
+}
+
+// createAPIKey stores an API key with the given secret
+func createAPIKey(store database.APIKeyStore, name, role, createdBy, secret string) (*models.IssuedAPIKey, error) {
+	key, err := store.Create(&models.APIKey{
+		Name:      name,
+		Role:      role,
+		Prefix:    displayPrefix(secret),
+		CreatedBy: createdBy,
+	}, HashAPIKey(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.IssuedAPIKey{APIKey: *key, Key: secret}, nil
+}
+
+// RotateAPIKey replaces the secret of an API key and returns the new one. The
+// previous secret stops working immediately.
+func RotateAPIKey(store database.APIKeyStore, id int) (*models.IssuedAPIKey, error) {
+	secret, err := GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := store.Rotate(id, displayPrefix(secret), HashAPIKey(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.IssuedAPIKey{APIKey: *key, Key: secret}, nil
+}
+
+// EnsureAdminKey issues a bootstrap admin key when no active admin key
+// exists, so that a fresh installation can be administered. Its secret is
+// returned by secret, which is only called when a key is issued, or generated
+// when secret is nil. It returns nil when an admin key already exists.
+func EnsureAdminKey(store database.APIKeyStore, secret func() (string, error)) (*models.IssuedAPIKey, error) {
+	status, role := models.APIKeyStatusActive, models.RoleAdmin
+	count, err := store.Count(&models.APIKeyFilter{Status: &status, Role: &role})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count admin API keys: %w", err)
+	}
+	if count > 0 {
+		return nil, nil
+	}
+
+	if secret == nil {
+		return IssueAPIKey(store, BootstrapAdminKeyName, models.RoleAdmin, "system")
+	}
+	key, err := secret()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) < minBootstrapKeyLength {
+		return nil, fmt.Errorf("bootstrap admin key must be at least %d characters long", minBootstrapKeyLength)
+	}
+
+	return createAPIKey(store, BootstrapAdminKeyName, models.RoleAdmin, "system", key)
+}
+
+// BootstrapSecret returns the secret of the bootstrap admin key: key when it
+// is set, otherwise the contents of file. When file does not exist, a new key
+// is generated and written to it, readable by its owner only, so that the
+// secret is never logged.
+func BootstrapSecret(key, file string) (string, error) {
+	if key != "" {
+		return key, nil
+	}
+	if file == "" {
+		return "", errors.New("no bootstrap admin key or key file configured")
+	}
+
+	data, err := os.ReadFile(file)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to read bootstrap admin key file: %w", err)
+	}
+
+	secret, err := GenerateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create bootstrap admin key file: %w", err)
+	}
+	if _, err := f.WriteString(secret + "\n"); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write bootstrap admin key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write bootstrap admin key file: %w", err)
+	}
+	log.Printf("Wrote the bootstrap admin API key to %s", file)
+
+	return secret, nil
+}
+
+// APIKeyAuthenticator authenticates requests carrying an API key in the
+// X-API-Key header
+type APIKeyAuthenticator struct {
+	store database.APIKeyStore
+	// now returns the current time and can be replaced in tests
+	now func() time.Time
+}
+
+// NewAPIKeyAuthenticator creates an authenticator looking keys up in store
+func NewAPIKeyAuthenticator(store database.APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{store: store, now: time.Now}
+}
+
+// Authenticate returns the principal of the active API key of the request
+// and records when the key was last used
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	secret := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	if secret == "" {
+		return nil, ErrNoCredentials
+	}
+
+	key, err := a.store.GetByHash(HashAPIKey(secret))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	if !key.Active() {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Recording every use would turn each read into a write
+	now := a.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := a.store.MarkUsed(key.ID, now); err != nil {
+			log.Printf("Error recording use of API key %d: %v", key.ID, err)
+		}
+	}
+
+	return &Principal{Name: key.Name, Role: key.Role, Method: MethodAPIKey, APIKeyID: key.ID}, nil
+}
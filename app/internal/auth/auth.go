@@ -0,0 +1,106 @@
+// Package auth authenticates API requests and authorizes them against the
+// role required by the requested route.
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"infra-dashboard/internal/models"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request does
+	// not carry the credentials it handles
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned when credentials are present but
+	// unknown, revoked or otherwise unacceptable
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authentication methods
+const (
+	MethodAPIKey = "api_key"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Method   string `json:"method"`
+	APIKeyID int    `json:"api_key_id,omitempty"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated principal of ctx, or nil
+// when the request was not authenticated
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Authenticator authenticates a request from one kind of credentials
+type Authenticator interface {
+	// Authenticate returns the principal of the request, ErrNoCredentials
+	// when the request carries none of the credentials handled, or
+	// ErrInvalidCredentials when they are rejected
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Middleware authenticates every request that is not public under rules with
+// the first authenticator finding credentials, checks the principal's role
+// against the role required by rules and stores the principal in the request
+// context
+func Middleware(rules []Rule, authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required := RequiredRole(rules, r.Method, r.URL.Path)
+			if required == "" || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authenticate(r, authenticators)
+			if err != nil {
+				switch {
+				case errors.Is(err, ErrNoCredentials):
+					http.Error(w, "Authentication required", http.StatusUnauthorized)
+				case errors.Is(err, ErrInvalidCredentials):
+					http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+				default:
+					log.Printf("Error authenticating request: %v", err)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+				}
+				return
+			}
+
+			if !models.RoleAllows(principal.Role, required) {
+				http.Error(w, "Insufficient permissions", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// authenticate returns the principal found by the first authenticator that
+// recognises credentials in the request
+func authenticate(r *http.Request, authenticators []Authenticator) (*Principal, error) {
+	for _, a := range authenticators {
+		principal, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrNoCredentials
+}
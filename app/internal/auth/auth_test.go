@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{http.MethodGet, "/health", ""},
+		{http.MethodGet, "/api/v1/servers", models.RoleViewer},
+		{http.MethodPost, "/api/v1/servers", models.RoleOperator},
+		{http.MethodDelete, "/api/v1/servers/1", models.RoleOperator},
+		{http.MethodGet, "/api/v1/os/1/history", models.RoleViewer},
+		{http.MethodPut, "/api/v1/os/1", models.RoleAdmin},
+		{http.MethodPost, "/api/v1/waivers", models.RoleAdmin},
+		{http.MethodGet, "/api/v1/api-keys", models.RoleAdmin},
+		{http.MethodPost, "/api/v1/oses", models.RoleOperator},
+		{http.MethodGet, "/metrics", models.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		if got := RequiredRole(DefaultRules, tt.method, tt.path); got != tt.expected {
+			t.Errorf("RequiredRole(%s %s) = %q, expected %q", tt.method, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	store := database.NewMemoryStores(database.NewMemoryDB()).APIKeys
+	viewer, err := IssueAPIKey(store, "dashboard", models.RoleViewer, "")
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	operator, err := IssueAPIKey(store, "ci", models.RoleOperator, "")
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	revoked, err := IssueAPIKey(store, "old", models.RoleAdmin, "")
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	if _, err := store.Revoke(revoked.ID); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+
+	var principal *Principal
+	handler := Middleware(DefaultRules, NewAPIKeyAuthenticator(store))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = PrincipalFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		key      string
+		expected int
+	}{
+		{"public route", http.MethodGet, "/health", "", http.StatusOK},
+		{"missing key", http.MethodGet, "/api/v1/servers", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/api/v1/servers", "idk_unknown", http.StatusUnauthorized},
+		{"revoked key", http.MethodGet, "/api/v1/servers", revoked.Key, http.StatusUnauthorized},
+		{"viewer reads", http.MethodGet, "/api/v1/servers", viewer.Key, http.StatusOK},
+		{"viewer writes", http.MethodPost, "/api/v1/servers", viewer.Key, http.StatusForbidden},
+		{"operator writes", http.MethodPost, "/api/v1/servers", operator.Key, http.StatusOK},
+		{"operator manages keys", http.MethodGet, "/api/v1/api-keys", operator.Key, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+			if tt.key != "" && rec.Code == http.StatusOK && (principal == nil || principal.Method != MethodAPIKey) {
+				t.Errorf("Expected an API key principal in the context, got %+v", principal)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticator_LastUsed(t *testing.T) {
+	store := database.NewMemoryStores(database.NewMemoryDB()).APIKeys
+	issued, err := IssueAPIKey(store, "dashboard", models.RoleViewer, "")
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	authenticator := NewAPIKeyAuthenticator(store)
+	authenticator.now = func() time.Time { return now }
+
+	authenticateAt := func(at time.Time) *time.Time {
+		t.Helper()
+		now = at
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/servers", nil)
+		req.Header.Set(APIKeyHeader, issued.Key)
+		principal, err := authenticator.Authenticate(req)
+		if err != nil || principal.Name != "dashboard" || principal.Role != models.RoleViewer {
+			t.Fatalf("Unexpected principal: %+v, %v", principal, err)
+		}
+		key, err := store.GetByID(issued.ID)
+		if err != nil {
+			t.Fatalf("Failed to get API key: %v", err)
+		}
+		return key.LastUsedAt
+	}
+
+	first := now
+	if used := authenticateAt(first); used == nil || !used.Equal(first) {
+		t.Fatalf("Expected last use at %v, got %v", first, used)
+	}
+	if used := authenticateAt(first.Add(30 * time.Second)); !used.Equal(first) {
+		t.Errorf("Expected uses within a minute not to be recorded, got %v", used)
+	}
+	if used := authenticateAt(first.Add(2 * time.Minute)); !used.Equal(first.Add(2 * time.Minute)) {
+		t.Errorf("Expected a later use to be recorded, got %v", used)
+	}
+}
+
+func TestEnsureAdminKey(t *testing.T) {
+	store := database.NewMemoryStores(database.NewMemoryDB()).APIKeys
+
+	// A secret too short to be safe is refused
+	short := func() (string, error) { return "changeme", nil }
+	if issued, err := EnsureAdminKey(store, short); err == nil {
+		t.Fatalf("Expected a short secret to be refused, got %+v", issued)
+	}
+
+	secret := func() (string, error) { return "bootstrap-secret-of-at-least-32-chars", nil }
+	issued, err := EnsureAdminKey(store, secret)
+	if err != nil || issued == nil || issued.Role != models.RoleAdmin || issued.Name != BootstrapAdminKeyName {
+		t.Fatalf("Expected a bootstrap admin key, got %+v, %v", issued, err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/servers", nil)
+	req.Header.Set(APIKeyHeader, "bootstrap-secret-of-at-least-32-chars")
+	if _, err := NewAPIKeyAuthenticator(store).Authenticate(req); err != nil {
+		t.Errorf("Expected the given secret to authenticate, got %v", err)
+	}
+
+	// The secret is not asked for when an admin key exists
+	unused := func() (string, error) { return "", errors.New("secret requested") }
+	if again, err := EnsureAdminKey(store, unused); err != nil || again != nil {
+		t.Errorf("Expected no key when an admin key exists, got %+v, %v", again, err)
+	}
+}
+
+func TestBootstrapSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bootstrap-admin.key")
+
+	if secret, err := BootstrapSecret("from-the-environment", file); err != nil || secret != "from-the-environment" {
+		t.Errorf("Expected the configured key, got %q, %v", secret, err)
+	}
+	if _, err := os.Stat(file); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected no key file when a key is configured, got %v", err)
+	}
+
+	// A generated key is written once, readable by its owner only
+	secret, err := BootstrapSecret("", file)
+	if err != nil || !strings.HasPrefix(secret, apiKeyPrefix) {
+		t.Fatalf("Expected a generated key, got %q, %v", secret, err)
+	}
+	info, err := os.Stat(file)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("Expected a key file with mode 0600, got %v, %v", info, err)
+	}
+	if again, err := BootstrapSecret("", file); err != nil || again != secret {
+		t.Errorf("Expected the key to be read back from the file, got %q, %v", again, err)
+	}
+
+	if _, err := BootstrapSecret("", ""); err == nil {
+		t.Error("Expected an error without a key or key file")
+	}
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeySet provides the public keys that JWT signatures are verified with
+type KeySet interface {
+	// Key returns the key with the given key ID. An empty ID selects the
+	// only key of a set holding a single key.
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// jwk is a JSON Web Key as published in a JWKS document
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// StaticKeySet is a fixed set of keys, loaded from a JWKS file or built in tests
+type StaticKeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// NewStaticKeySet creates a key set holding keys by key ID
+func NewStaticKeySet(keys map[string]crypto.PublicKey) *StaticKeySet {
+	return &StaticKeySet{keys: keys}
+}
+
+// ParseJWKS parses a JWKS document. RSA and EC signing keys are kept; keys
+// of other types or meant for encryption are skipped.
+func ParseJWKS(data []byte) (*StaticKeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %d: %w", i, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS document holds no usable signing keys")
+	}
+
+	return NewStaticKeySet(keys), nil
+}
+
+// publicKey decodes the key, returning nil for unsupported key types
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded unsigned big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := decodeSegment(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// decodeSegment decodes unpadded base64url, tolerating padding
+func decodeSegment(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// Key returns the key with the given key ID
+func (s *StaticKeySet) Key(_ context.Context, kid string) (crypto.PublicKey, error) {
+	return lookupKey(s.keys, kid)
+}
+
+// lookupKey returns a key by ID, or the only key when kid is empty
+func lookupKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, error) {
+	if key, exists := keys[kid]; exists {
+		return key, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q: %w", kid, ErrInvalidCredentials)
+}
+
+const (
+	// jwksFetchTimeout bounds the time spent fetching a remote JWKS
+	jwksFetchTimeout = 10 * time.Second
+	// jwksMinRefetch limits how often an unknown key ID triggers a fetch
+	jwksMinRefetch = time.Minute
+)
+
+// RemoteKeySet fetches keys from a JWKS URL, caches them and fetches them
+// again when they are older than the refresh interval or a token is signed
+// with an unknown key, as happens when the provider rotates its keys
+type RemoteKeySet struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// now returns the current time and can be replaced in tests
+	now func() time.Time
+}
+
+// NewRemoteKeySet creates a key set fetched from url and refreshed every
+// refresh interval
+func NewRemoteKeySet(url string, refresh time.Duration) *RemoteKeySet {
+	return &RemoteKeySet{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: jwksFetchTimeout},
+		now:     time.Now,
+	}
+}
+
+// Key returns the key with the given key ID, fetching the JWKS when needed.
+// Cached keys are used when a fetch fails.
+func (s *RemoteKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := s.now().Sub(s.fetchedAt)
+	if s.keys == nil || age >= s.refresh {
+		if err := s.fetch(ctx); err != nil && s.keys == nil {
+			return nil, err
+		}
+	}
+
+	key, err := lookupKey(s.keys, kid)
+	if err != nil && s.now().Sub(s.fetchedAt) >= jwksMinRefetch {
+		if fetchErr := s.fetch(ctx); fetchErr != nil {
+			return nil, err
+		}
+		return lookupKey(s.keys, kid)
+	}
+
+	return key, err
+}
+
+// fetch downloads and parses the JWKS. The caller must hold the lock.
+func (s *RemoteKeySet) fetch(ctx context.Context) error {
+	// Failed fetches count as attempts so that a provider outage does not
+	// trigger a fetch on every request
+	s.fetchedAt = s.now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	set, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	s.keys = set.keys
+
+	return nil
+}
+
+// LoadKeySet returns the key set at source: a JWKS URL, fetched when keys are
+// needed and refreshed every refresh interval, or the path of a JWKS file,
+// read once
+func LoadKeySet(source string, refresh time.Duration) (KeySet, error) {
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return NewRemoteKeySet(source, refresh), nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
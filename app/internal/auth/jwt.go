@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// MethodOIDC identifies principals authenticated with an OIDC bearer token
+const MethodOIDC = "oidc"
+
+// signingAlgorithms maps the supported JWS algorithms to their hash. Only
+// asymmetric algorithms are accepted, so tokens cannot be signed with a
+// public key or left unsigned.
+var signingAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// JWTConfig configures the validation of bearer tokens
+type JWTConfig struct {
+	// Issuer is the required iss claim
+	Issuer string
+	// Audience must be one of the aud claim values
+	Audience string
+	// ClockSkew is the tolerance applied to the exp, nbf and iat claims
+	ClockSkew time.Duration
+	// UsernameClaim names the claim used as the principal name, falling back
+	// to sub when it is missing
+	UsernameClaim string
+	// GroupsClaim names the claim listing the groups of the user
+	GroupsClaim string
+	// RoleGroups maps groups to the role they grant. A user in several groups
+	// gets the most privileged role.
+	RoleGroups map[string]string
+}
+
+// Validate checks that tokens are bound to an issuer and an audience, so that
+// tokens the provider signs for other applications are not accepted
+func (c JWTConfig) Validate() error {
+	if c.Issuer == "" || c.Audience == "" {
+		return fmt.Errorf("OIDC issuer and audience must both be set")
+	}
+	return nil
+}
+
+// ParseRoleGroups parses a comma-separated list of group:role pairs, such as
+// "infra-admins:admin,sre:operator"
+func ParseRoleGroups(value string) (map[string]string, error) {
+	roles := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid group mapping %q: expected group:role", pair)
+		}
+		group, role := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if err := models.ValidateRole(role); err != nil {
+			return nil, fmt.Errorf("invalid group mapping %q: %w", pair, err)
+		}
+		roles[group] = role
+	}
+	return roles, nil
+}
+
+// JWTAuthenticator authenticates requests carrying an OIDC JWT in the
+// Authorization header as a bearer token
+type JWTAuthenticator struct {
+	keys   KeySet
+	config JWTConfig
+	// now returns the current time and can be replaced in tests
+	now func() time.Time
+}
+
+// NewJWTAuthenticator creates an authenticator verifying tokens with keys
+func NewJWTAuthenticator(keys KeySet, config JWTConfig) *JWTAuthenticator {
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	return &JWTAuthenticator{keys: keys, config: config, now: time.Now}
+}
+
+// Authenticate returns the principal of the bearer token of the request.
+// Users in no mapped group are authenticated without a role and are
+// therefore denied every protected route.
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.verify(r, strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+
+	name := claims.stringClaim(a.config.UsernameClaim)
+	if name == "" {
+		name = claims.stringClaim("sub")
+	}
+
+	return &Principal{Name: name, Role: a.role(claims.stringsClaim(a.config.GroupsClaim)), Method: MethodOIDC}, nil
+}
+
+// role returns the most privileged role granted by the groups
+func (a *JWTAuthenticator) role(groups []string) string {
+	role := ""
+	for _, group := range groups {
+		if granted, exists := a.config.RoleGroups[group]; exists && (role == "" || !models.RoleAllows(role, granted)) {
+			role = granted
+		}
+	}
+	return role
+}
+
+// jwtClaims holds the decoded claims of a token
+type jwtClaims map[string]interface{}
+
+// stringClaim returns a string claim, or "" when it is missing or not a string
+func (c jwtClaims) stringClaim(name string) string {
+	value, _ := c[name].(string)
+	return value
+}
+
+// stringsClaim returns a claim holding a string or an array of strings
+func (c jwtClaims) stringsClaim(name string) []string {
+	switch value := c[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// timeClaim returns a NumericDate claim and whether it is present
+func (c jwtClaims) timeClaim(name string) (time.Time, bool, error) {
+	value, exists := c[name]
+	if !exists {
+		return time.Time{}, false, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("invalid %s claim", name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s claim", name)
+	}
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// verify checks the signature and claims of a token and returns its claims.
+// Rejected tokens are reported with ErrInvalidCredentials.
+func (a *JWTAuthenticator) verify(r *http.Request, token string) (jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token: %w", ErrInvalidCredentials)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJSONSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", ErrInvalidCredentials)
+	}
+	hash, supported := signingAlgorithms[header.Alg]
+	if !supported {
+		return nil, fmt.Errorf("unsupported signing algorithm %q: %w", header.Alg, ErrInvalidCredentials)
+	}
+
+	key, err := a.keys.Key(r.Context(), header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", ErrInvalidCredentials)
+	}
+	if err := verifySignature(header.Alg, hash, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrInvalidCredentials)
+	}
+
+	var claims jwtClaims
+	if err := decodeJSONSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", ErrInvalidCredentials)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrInvalidCredentials)
+	}
+
+	return claims, nil
+}
+
+// validateClaims checks the issuer, audience and validity period of a token
+func (a *JWTAuthenticator) validateClaims(claims jwtClaims) error {
+	if claims.stringClaim("iss") != a.config.Issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.stringClaim("iss"))
+	}
+
+	matched := false
+	for _, aud := range claims.stringsClaim("aud") {
+		if aud == a.config.Audience {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return fmt.Errorf("token is not intended for audience %q", a.config.Audience)
+	}
+
+	now := a.now()
+	skew := a.config.ClockSkew
+
+	expiresAt, exists, err := claims.timeClaim("exp")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("token has no expiry")
+	}
+	if !now.Before(expiresAt.Add(skew)) {
+		return fmt.Errorf("token expired at %s", expiresAt.Format(time.RFC3339))
+	}
+
+	notBefore, exists, err := claims.timeClaim("nbf")
+	if err != nil {
+		return err
+	}
+	if exists && now.Add(skew).Before(notBefore) {
+		return fmt.Errorf("token is not valid before %s", notBefore.Format(time.RFC3339))
+	}
+
+	issuedAt, exists, err := claims.timeClaim("iat")
+	if err != nil {
+		return err
+	}
+	if exists && now.Add(skew).Before(issuedAt) {
+		return fmt.Errorf("token is issued in the future at %s", issuedAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// decodeJSONSegment decodes a base64url-encoded JSON token segment, keeping
+// numbers as json.Number
+func decodeJSONSegment(segment string, v interface{}) error {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// verifySignature verifies the JWS signature of the signing input
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signingInput string, signature []byte) error {
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("signing key does not match algorithm %s", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("signing key does not match algorithm %s", alg)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		rInt := new(big.Int).SetBytes(signature[:size])
+		sInt := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, rInt, sInt) {
+			return errors.New("invalid token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// testSigner signs tokens like an OIDC provider would
+type testSigner struct {
+	kid string
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newRSASigner(t *testing.T, kid string) *testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	return &testSigner{kid: kid, rsa: key}
+}
+
+func newECSigner(t *testing.T, kid string) *testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	return &testSigner{kid: kid, ec: key}
+}
+
+// jwk returns the public key of the signer as a JWK
+func (s *testSigner) jwk() map[string]string {
+	enc := base64.RawURLEncoding.EncodeToString
+	if s.rsa != nil {
+		return map[string]string{
+			"kty": "RSA", "kid": s.kid, "use": "sig",
+			"n": enc(s.rsa.N.Bytes()), "e": enc(big.NewInt(int64(s.rsa.E)).Bytes()),
+		}
+	}
+	return map[string]string{
+		"kty": "EC", "kid": s.kid, "crv": "P-256",
+		"x": enc(s.ec.X.FillBytes(make([]byte, 32))), "y": enc(s.ec.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// testJWKS returns the JWKS document publishing the keys of signers
+func testJWKS(t *testing.T, signers ...*testSigner) []byte {
+	t.Helper()
+	var keys []map[string]string
+	for _, s := range signers {
+		keys = append(keys, s.jwk())
+	}
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatalf("Failed to marshal JWKS: %v", err)
+	}
+	return data
+}
+
+// sign returns a token holding claims signed by the signer
+func (s *testSigner) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	alg := "ES256"
+	if s.rsa != nil {
+		alg = "RS256"
+	}
+	return s.signWith(t, alg, claims)
+}
+
+func (s *testSigner) signWith(t *testing.T, alg string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": s.kid, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Failed to marshal claims: %v", err)
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch {
+	case alg == "none":
+	case s.rsa != nil:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, s.rsa, crypto.SHA256, digest[:])
+	default:
+		var r, sInt *big.Int
+		r, sInt, err = ecdsa.Sign(rand.Reader, s.ec, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), sInt.FillBytes(make([]byte, 32))...)
+	}
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func bearerRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/servers", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaSigner := newRSASigner(t, "rsa-1")
+	ecSigner := newECSigner(t, "ec-1")
+	other := newRSASigner(t, "rsa-1")
+
+	keys, err := ParseJWKS(testJWKS(t, rsaSigner, ecSigner))
+	if err != nil {
+		t.Fatalf("Failed to parse JWKS: %v", err)
+	}
+
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	authenticator := NewJWTAuthenticator(keys, JWTConfig{
+		Issuer:        "https://idp.example.com",
+		Audience:      "infra-dashboard",
+		ClockSkew:     time.Minute,
+		UsernameClaim: "preferred_username",
+		RoleGroups:    map[string]string{"sre": models.RoleOperator, "infra-admins": models.RoleAdmin, "staff": models.RoleViewer},
+	})
+	authenticator.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":                "https://idp.example.com",
+			"aud":                []string{"infra-dashboard", "other"},
+			"sub":                "user-42",
+			"preferred_username": "alice",
+			"groups":             []string{"staff", "sre"},
+			"iat":                now.Add(-time.Minute).Unix(),
+			"exp":                now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	principal, err := authenticator.Authenticate(bearerRequest(rsaSigner.sign(t, claims(nil))))
+	if err != nil || principal.Name != "alice" || principal.Role != models.RoleOperator || principal.Method != MethodOIDC {
+		t.Fatalf("Unexpected principal: %+v, %v", principal, err)
+	}
+
+	principal, err = authenticator.Authenticate(bearerRequest(ecSigner.sign(t, claims(map[string]interface{}{
+		"aud": "infra-dashboard", "groups": "infra-admins", "preferred_username": nil,
+	}))))
+	if err != nil || principal.Name != "user-42" || principal.Role != models.RoleAdmin {
+		t.Fatalf("Unexpected EC principal: %+v, %v", principal, err)
+	}
+
+	principal, err = authenticator.Authenticate(bearerRequest(rsaSigner.sign(t, claims(map[string]interface{}{"groups": []string{"contractors"}}))))
+	if err != nil || principal.Role != "" {
+		t.Errorf("Expected a principal without a role for unmapped groups, got %+v, %v", principal, err)
+	}
+
+	principal, err = authenticator.Authenticate(bearerRequest(rsaSigner.sign(t, claims(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()}))))
+	if err != nil || principal == nil {
+		t.Errorf("Expected a token expired within the clock skew to be accepted, got %v", err)
+	}
+
+	rejected := []struct {
+		name  string
+		token string
+	}{
+		{"wrong issuer", rsaSigner.sign(t, claims(map[string]interface{}{"iss": "https://evil.example.com"}))},
+		{"wrong audience", rsaSigner.sign(t, claims(map[string]interface{}{"aud": "other"}))},
+		{"expired", rsaSigner.sign(t, claims(map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()}))},
+		{"no expiry", rsaSigner.sign(t, claims(map[string]interface{}{"exp": nil}))},
+		{"not yet valid", rsaSigner.sign(t, claims(map[string]interface{}{"nbf": now.Add(5 * time.Minute).Unix()}))},
+		{"issued in the future", rsaSigner.sign(t, claims(map[string]interface{}{"iat": now.Add(5 * time.Minute).Unix()}))},
+		{"unknown signer", other.sign(t, claims(nil))},
+		{"unknown key", newRSASigner(t, "rsa-2").sign(t, claims(nil))},
+		{"unsigned", rsaSigner.signWith(t, "none", claims(nil))},
+		{"symmetric algorithm", rsaSigner.signWith(t, "HS256", claims(nil))},
+		{"malformed", "not-a-token"},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := authenticator.Authenticate(bearerRequest(tt.token)); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+			}
+		})
+	}
+
+	if _, err := authenticator.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected ErrNoCredentials without a bearer token, got %v", err)
+	}
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	first := newECSigner(t, "key-1")
+	second := newECSigner(t, "key-2")
+
+	published := testJWKS(t, first)
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write(published)
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	keys := NewRemoteKeySet(server.URL, time.Hour)
+	keys.now = func() time.Time { return now }
+
+	authenticator := NewJWTAuthenticator(keys, JWTConfig{Issuer: "https://idp.example.com", Audience: "infra-dashboard", RoleGroups: map[string]string{"staff": models.RoleViewer}})
+	authenticator.now = keys.now
+	claims := map[string]interface{}{"iss": "https://idp.example.com", "aud": "infra-dashboard", "sub": "bob", "groups": []string{"staff"}, "exp": now.Add(time.Hour).Unix()}
+
+	for i := 0; i < 2; i++ {
+		if _, err := authenticator.Authenticate(bearerRequest(first.sign(t, claims))); err != nil {
+			t.Fatalf("Failed to authenticate: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected keys to be cached, got %d fetches", fetches)
+	}
+
+	// The provider rotates its keys: an unknown key ID triggers a fetch, at
+	// most once a minute
+	published = testJWKS(t, first, second)
+	claims["exp"] = now.Add(2 * time.Hour).Unix()
+	if _, err := authenticator.Authenticate(bearerRequest(second.sign(t, claims))); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected the new key to be unknown within a minute of the last fetch, got %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := authenticator.Authenticate(bearerRequest(second.sign(t, claims))); err != nil {
+		t.Errorf("Expected the new key to be fetched, got %v", err)
+	}
+	if fetches != 2 {
+		t.Errorf("Expected 2 fetches, got %d", fetches)
+	}
+}
+
+func TestLoadKeySet_File(t *testing.T) {
+	signer := newECSigner(t, "")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, testJWKS(t, signer), 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+
+	keys, err := LoadKeySet(path, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to load JWKS: %v", err)
+	}
+	// A token without a key ID is verified with the only key of the set
+	token := signer.sign(t, map[string]interface{}{"iss": "https://idp.example.com", "aud": "infra-dashboard", "sub": "carol", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := NewJWTAuthenticator(keys, JWTConfig{Issuer: "https://idp.example.com", Audience: "infra-dashboard"}).Authenticate(bearerRequest(token)); err != nil {
+		t.Errorf("Failed to authenticate with a file key set: %v", err)
+	}
+
+	if _, err := LoadKeySet(filepath.Join(t.TempDir(), "missing.json"), time.Hour); err == nil {
+		t.Error("Expected an error for a missing JWKS file")
+	}
+}
+
+func TestJWTConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  JWTConfig
+		wantErr bool
+	}{
+		{"Issuer and audience", JWTConfig{Issuer: "https://idp.example.com", Audience: "infra-dashboard"}, false},
+		{"Missing issuer", JWTConfig{Audience: "infra-dashboard"}, true},
+		{"Missing audience", JWTConfig{Issuer: "https://idp.example.com"}, true},
+		{"Neither", JWTConfig{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRoleGroups(t *testing.T) {
+	roles, err := ParseRoleGroups("infra-admins:admin, sre:operator,")
+	if err != nil {
+		t.Fatalf("Failed to parse role groups: %v", err)
+	}
+	if roles["infra-admins"] != models.RoleAdmin || roles["sre"] != models.RoleOperator || len(roles) != 2 {
+		t.Errorf("Unexpected role groups: %v", roles)
+	}
+
+	for _, invalid := range []string{"sre", "sre:root", ":admin"} {
+		if _, err := ParseRoleGroups(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"infra-dashboard/internal/models"
+)
+
+// Rule grants access to the requests whose path is PathPrefix or below it
+// and whose method is one of Methods, or any method when Methods is empty,
+// to principals with at least Role. An empty Role makes the requests public.
+type Rule struct {
+	PathPrefix string
+	Methods    []string
+	Role       string
+}
+
+// readMethods and writeMethods group HTTP methods for rules
+var (
+	readMethods  = []string{http.MethodGet, http.MethodHead}
+	writeMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+)
+
+// DefaultRules lets viewers read the API, operators manage servers, upgrade
+// campaigns, scheduled changes and compliance snapshots, and admins manage
+// the operating system catalog, compliance waivers and API keys
+var DefaultRules = []Rule{
+	{PathPrefix: "/health"},
+	{PathPrefix: "/api/v1/api-keys", Role: models.RoleAdmin},
+	{PathPrefix: "/api/v1/os", Methods: writeMethods, Role: models.RoleAdmin},
+	{PathPrefix: "/api/v1/waivers", Methods: writeMethods, Role: models.RoleAdmin},
+	{PathPrefix: "/api/v1", Methods: writeMethods, Role: models.RoleOperator},
+	{PathPrefix: "/api/v1", Methods: readMethods, Role: models.RoleViewer},
+}
+
+// RequiredRole returns the role required by the first rule matching the
+// request, or "" when the request is public. Requests matching no rule
+// require the admin role.
+func RequiredRole(rules []Rule, method, path string) string {
+	for _, rule := range rules {
+		if rule.matches(method, path) {
+			return rule.Role
+		}
+	}
+	return models.RoleAdmin
+}
+
+// matches reports whether the rule applies to a request. Prefixes match
+// whole path segments so that /api/v1/os does not match /api/v1/oses.
+func (r Rule) matches(method, path string) bool {
+	if path != r.PathPrefix && !strings.HasPrefix(path, strings.TrimSuffix(r.PathPrefix, "/")+"/") {
+		return false
+	}
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
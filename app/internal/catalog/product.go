@@ -0,0 +1,155 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// KnownProducts maps endoflife.date product names to the catalog OS names
+// their cycles are stored under
+var KnownProducts = map[string]string{
+	"almalinux":   "AlmaLinux",
+	"centos":      "CentOS",
+	"debian":      "Debian",
+	"freebsd":     "FreeBSD",
+	"openbsd":     "OpenBSD",
+	"rhel":        "RedHat",
+	"rocky-linux": "Rocky Linux",
+	"ubuntu":      "Ubuntu",
+}
+
+// ParseProducts parses a comma-separated list of products to sync, each
+// given as "product" for a known product or "product=Catalog Name". An empty
+// list selects every known product.
+func ParseProducts(value string) (map[string]string, error) {
+	products := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		product, name, named := strings.Cut(entry, "=")
+		product, name = strings.ToLower(strings.TrimSpace(product)), strings.TrimSpace(name)
+		if !named {
+			name = KnownProducts[product]
+		}
+		if product == "" || name == "" {
+			return nil, fmt.Errorf("invalid catalog product %q: expected a known product or product=Catalog Name", entry)
+		}
+		products[product] = name
+	}
+
+	if len(products) == 0 {
+		for product, name := range KnownProducts {
+			products[product] = name
+		}
+	}
+	return products, nil
+}
+
+// Product is the release cycles of an endoflife.date product
+type Product struct {
+	Name   string
+	Cycles []Cycle
+}
+
+// Cycle is a release cycle of an endoflife.date product. Dates the product
+// does not publish, or publishes as a yes/no flag only, are nil, as is LTS
+// when the product does not flag long-term support cycles.
+type Cycle struct {
+	Cycle           string
+	LTS             *bool
+	ReleaseDate     *time.Time
+	Support         *time.Time // End of standard support
+	EOL             *time.Time
+	ExtendedSupport *time.Time
+}
+
+// UnmarshalJSON decodes a cycle of the endoflife.date product format, where
+// the cycle may be a string or a number and lifecycle fields may be a date
+// or a boolean. A cycle flagged LTS with a date becomes LTS on that date and
+// is treated as LTS.
+func (c *Cycle) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Cycle           json.RawMessage `json:"cycle"`
+		LTS             json.RawMessage `json:"lts"`
+		ReleaseDate     json.RawMessage `json:"releaseDate"`
+		Support         json.RawMessage `json:"support"`
+		EOL             json.RawMessage `json:"eol"`
+		ExtendedSupport json.RawMessage `json:"extendedSupport"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw.Cycle, &c.Cycle); err != nil {
+		// Some products publish cycles as numbers
+		c.Cycle = string(raw.Cycle)
+	}
+
+	if len(raw.LTS) > 0 && string(raw.LTS) != "null" {
+		var lts bool
+		if err := json.Unmarshal(raw.LTS, &lts); err != nil {
+			lts = true
+		}
+		c.LTS = &lts
+	}
+
+	var err error
+	if c.ReleaseDate, err = parseLifecycleDate("releaseDate", raw.ReleaseDate); err != nil {
+		return err
+	}
+	if c.Support, err = parseLifecycleDate("support", raw.Support); err != nil {
+		return err
+	}
+	if c.EOL, err = parseLifecycleDate("eol", raw.EOL); err != nil {
+		return err
+	}
+	if c.ExtendedSupport, err = parseLifecycleDate("extendedSupport", raw.ExtendedSupport); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseLifecycleDate parses a lifecycle field holding a YYYY-MM-DD date,
+// returning nil when it is missing, null or a boolean
+func parseLifecycleDate(field string, raw json.RawMessage) (*time.Time, error) {
+	var value interface{}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	s, isString := value.(string)
+	if !isString {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s date %q: expected YYYY-MM-DD", field, s)
+	}
+	return &date, nil
+}
+
+// ParseProduct parses the cycles of a product in the endoflife.date format,
+// a JSON array of cycles as served by https://endoflife.date/api/<product>.json
+func ParseProduct(name string, data []byte) (*Product, error) {
+	var cycles []Cycle
+	if err := json.Unmarshal(data, &cycles); err != nil {
+		return nil, fmt.Errorf("invalid %s product file: %w", name, err)
+	}
+
+	// Sync in a stable order whatever the order of the file
+	sort.SliceStable(cycles, func(i, j int) bool {
+		return models.CompareVersions(cycles[i].Cycle, cycles[j].Cycle) < 0
+	})
+	return &Product{Name: name, Cycles: cycles}, nil
+}
@@ -0,0 +1,63 @@
+package catalog
+
+import (
+	"testing"
+)
+
+func TestParseProducts(t *testing.T) {
+	products, err := ParseProducts("ubuntu, rhel, oracle-linux=Oracle Linux")
+	if err != nil {
+		t.Fatalf("ParseProducts() error = %v", err)
+	}
+	if len(products) != 3 || products["ubuntu"] != "Ubuntu" || products["rhel"] != "RedHat" || products["oracle-linux"] != "Oracle Linux" {
+		t.Errorf("Unexpected products %v", products)
+	}
+
+	all, err := ParseProducts("")
+	if err != nil || len(all) != len(KnownProducts) {
+		t.Errorf("Expected every known product, got %v, %v", all, err)
+	}
+
+	for _, value := range []string{"oracle-linux", "=Oracle Linux", "ubuntu="} {
+		if _, err := ParseProducts(value); err == nil {
+			t.Errorf("ParseProducts(%q) succeeded", value)
+		}
+	}
+}
+
+func TestParseProduct(t *testing.T) {
+	product, err := ParseProduct("rhel", []byte(`[
+		{"cycle": "9", "releaseDate": "2022-05-17", "support": "2027-05-31", "eol": "2032-05-31", "extendedSupport": true, "lts": "2022-05-17"},
+		{"cycle": 8, "releaseDate": "2019-05-07", "eol": "2029-05-31", "extendedSupport": "2032-05-31"},
+		{"cycle": "10", "eol": false, "lts": false}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseProduct() error = %v", err)
+	}
+
+	// Cycles are ordered by version
+	if len(product.Cycles) != 3 {
+		t.Fatalf("Expected 3 cycles, got %+v", product.Cycles)
+	}
+	eight, nine, ten := product.Cycles[0], product.Cycles[1], product.Cycles[2]
+	if eight.Cycle != "8" || eight.ExtendedSupport == nil || eight.ExtendedSupport.Format("2006-01-02") != "2032-05-31" {
+		t.Errorf("Unexpected cycle %+v", eight)
+	}
+	if nine.Cycle != "9" || nine.EOL == nil || nine.ReleaseDate == nil || nine.ExtendedSupport != nil ||
+		nine.Support == nil || nine.LTS == nil || !*nine.LTS {
+		t.Errorf("Unexpected cycle %+v", nine)
+	}
+	if eight.LTS != nil || eight.Support != nil {
+		t.Errorf("Expected cycle 8 without LTS flag or standard support, got %+v", eight)
+	}
+	if ten.Cycle != "10" || ten.EOL != nil || ten.ReleaseDate != nil || ten.LTS == nil || *ten.LTS {
+		t.Errorf("Unexpected cycle %+v", ten)
+	}
+
+	if _, err := ParseProduct("rhel", []byte(`[{"cycle": "9", "eol": "May 2032"}]`)); err == nil {
+		t.Error("ParseProduct() accepted an invalid date")
+	}
+	if _, err := ParseProduct("rhel", []byte(`{"result": []}`)); err == nil {
+		t.Error("ParseProduct() accepted an object")
+	}
+}
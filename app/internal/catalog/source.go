@@ -0,0 +1,156 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxProductBytes bounds the size of a product file
+const maxProductBytes = 10 << 20
+
+// Source loads endoflife.date products from a product file, a directory of
+// product files or a URL
+type Source struct {
+	Location string
+	http     *http.Client
+}
+
+// NewSource creates a source reading location, a file or directory path or
+// an http(s) URL. Requests to a URL are bounded by timeout.
+func NewSource(location string, timeout time.Duration) *Source {
+	return &Source{Location: location, http: &http.Client{Timeout: timeout}}
+}
+
+// isURL reports whether the source is read over HTTP
+func (s *Source) isURL() bool {
+	return strings.HasPrefix(s.Location, "http://") || strings.HasPrefix(s.Location, "https://")
+}
+
+// Load reads the selected products, which map product names to catalog OS
+// names. A file, or a URL ending in .json, holds the product named by its
+// base name. A directory holds a <product>.json file for each product, and
+// any other URL is a base URL serving <product>.json, such as
+// https://endoflife.date/api.
+func (s *Source) Load(ctx context.Context, products map[string]string) ([]*Product, error) {
+	if s.Location == "" {
+		return nil, fmt.Errorf("no catalog source configured")
+	}
+
+	if strings.HasSuffix(s.Location, ".json") {
+		name := strings.TrimSuffix(path.Base(s.Location), ".json")
+		if _, selected := products[name]; !selected {
+			return nil, fmt.Errorf("product %q of %s is not selected: add it to the catalog products as %s=Catalog Name", name, s.Location, name)
+		}
+		product, err := s.load(ctx, s.Location, name)
+		if err != nil {
+			return nil, err
+		}
+		return []*Product{product}, nil
+	}
+
+	names := make([]string, 0, len(products))
+	for name := range products {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var loaded []*Product
+	for _, name := range names {
+		var location string
+		if s.isURL() {
+			location = strings.TrimRight(s.Location, "/") + "/" + name + ".json"
+		} else {
+			location = filepath.Join(s.Location, name+".json")
+		}
+
+		product, err := s.load(ctx, location, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// A directory may hold some of the products only
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, product)
+	}
+
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("no product files found in %s", s.Location)
+	}
+	return loaded, nil
+}
+
+// load reads and parses the product file at location
+func (s *Source) load(ctx context.Context, location, name string) (*Product, error) {
+	var data []byte
+	var err error
+	if s.isURL() {
+		data, err = s.fetch(ctx, location)
+	} else {
+		data, err = readFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseProduct(name, data)
+}
+
+// readFile reads a product file, bounded by maxProductBytes
+func readFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxProductBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(data) > maxProductBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxProductBytes)
+	}
+	return data, nil
+}
+
+// fetch downloads a product file, bounded by maxProductBytes
+func (s *Source) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "infra-dashboard")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, fs.ErrNotExist)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxProductBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	if len(data) > maxProductBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxProductBytes)
+	}
+	return data, nil
+}
@@ -0,0 +1,237 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+// Actions of a synced release cycle, besides the create, update and
+// unchanged import actions
+const (
+	ActionSkipped = "skipped"
+	ActionFailed  = "failed"
+)
+
+// ReleaseResult is the outcome of syncing a release cycle
+type ReleaseResult struct {
+	Product string
+	Name    string
+	Version string
+	Action  string // 'create', 'update', 'unchanged', 'skipped', 'failed'
+	OSID    *int
+	Changes map[string]models.FieldChange
+	// Reason explains why a cycle was skipped or failed
+	Reason string
+}
+
+// SyncReport reports the outcome of a catalog sync
+type SyncReport struct {
+	Source    string
+	DryRun    bool
+	Created   int
+	Updated   int
+	Unchanged int
+	Skipped   int
+	Failed    int
+	Releases  []ReleaseResult
+}
+
+// Syncer creates and updates the operating systems of the catalog from the
+// release cycles of endoflife.date products. Operating systems are matched
+// by name and version and are never deleted.
+type Syncer struct {
+	oss      database.OSStore
+	source   *Source
+	products map[string]string
+}
+
+// NewSyncer creates a syncer of the selected products, which map product
+// names to catalog OS names, from source
+func NewSyncer(oss database.OSStore, source *Source, products map[string]string) *Syncer {
+	return &Syncer{oss: oss, source: source, products: products}
+}
+
+// Sync loads the products and applies their release cycles to the catalog,
+// recording each change in the OS change history with the catalog sync
+// source. Every end of support date change is logged. Nothing is written on
+// a dry run; the report then describes what the sync would do. A release
+// that cannot be written is reported as failed without stopping the sync.
+func (s *Syncer) Sync(ctx context.Context, dryRun bool) (*SyncReport, error) {
+	products, err := s.source.Load(ctx, s.products)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = models.WithChangeMetadata(ctx, models.ChangeMetadata{Source: models.ChangeSourceCatalogSync})
+	report := &SyncReport{Source: s.source.Location, DryRun: dryRun}
+	for _, product := range products {
+		name := s.products[product.Name]
+		existing, err := s.oss.GetAll(&models.OSFilter{Family: &name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s operating systems: %w", name, err)
+		}
+		byVersion := make(map[string]models.OS, len(existing))
+		for _, os := range existing {
+			byVersion[os.Version] = os
+		}
+
+		for _, cycle := range product.Cycles {
+			result := s.syncCycle(ctx, product.Name, name, cycle, byVersion, dryRun)
+			switch result.Action {
+			case models.ImportActionCreate:
+				report.Created++
+			case models.ImportActionUpdate:
+				report.Updated++
+			case models.ImportActionUnchanged:
+				report.Unchanged++
+			case ActionSkipped:
+				report.Skipped++
+			case ActionFailed:
+				report.Failed++
+			}
+			report.Releases = append(report.Releases, result)
+		}
+	}
+
+	return report, nil
+}
+
+// syncCycle applies a release cycle to the catalog OS of the same version,
+// adding the OS it creates to byVersion
+func (s *Syncer) syncCycle(ctx context.Context, product, name string, cycle Cycle, byVersion map[string]models.OS, dryRun bool) ReleaseResult {
+	result := ReleaseResult{Product: product, Name: name, Version: cycle.Cycle}
+	switch {
+	case cycle.Cycle == "":
+		result.Action, result.Reason = ActionSkipped, "no cycle"
+		return result
+	case cycle.EOL == nil:
+		// The catalog requires an end of support date
+		result.Action, result.Reason = ActionSkipped, "no end of support date"
+		return result
+	}
+
+	after := models.OS{Name: name, Version: cycle.Cycle, EndOfSupport: *cycle.EOL}
+	current, exists := byVersion[cycle.Cycle]
+	var before *models.OS
+	if exists {
+		before = &current
+		// Dates a product stops publishing keep their current value
+		after = current
+		after.EndOfSupport = *cycle.EOL
+		if cycle.LTS != nil {
+			after.LTS = *cycle.LTS
+		}
+		if cycle.ReleaseDate != nil {
+			after.ReleaseDate = cycle.ReleaseDate
+		}
+		if cycle.Support != nil {
+			after.EndOfStandardSupport = cycle.Support
+		}
+		if cycle.ExtendedSupport != nil {
+			after.EndOfExtendedSupport = cycle.ExtendedSupport
+		}
+		result.OSID = &current.ID
+	} else {
+		after.LTS = cycle.LTS != nil && *cycle.LTS
+		after.ReleaseDate = cycle.ReleaseDate
+		after.EndOfStandardSupport = cycle.Support
+		after.EndOfExtendedSupport = cycle.ExtendedSupport
+	}
+
+	result.Changes = models.DiffOS(before, &after)
+	switch {
+	case !exists:
+		result.Action = models.ImportActionCreate
+	case len(result.Changes) > 0:
+		result.Action = models.ImportActionUpdate
+	default:
+		result.Action = models.ImportActionUnchanged
+		result.Changes = nil
+		return result
+	}
+	if dryRun {
+		return result
+	}
+
+	var os *models.OS
+	var err error
+	if exists {
+		os, err = s.oss.Update(ctx, current.ID, &models.UpdateOSRequest{
+			LTS:                  &after.LTS,
+			ReleaseDate:          formatDate(after.ReleaseDate),
+			EndOfStandardSupport: formatDate(after.EndOfStandardSupport),
+			EndOfSupport:         after.EndOfSupport.Format("2006-01-02"),
+			EndOfExtendedSupport: formatDate(after.EndOfExtendedSupport),
+		})
+	} else {
+		os, err = s.oss.Create(ctx, &models.CreateOSRequest{
+			Name:                 name,
+			Version:              cycle.Cycle,
+			LTS:                  after.LTS,
+			ReleaseDate:          formatDate(after.ReleaseDate),
+			EndOfStandardSupport: formatDate(after.EndOfStandardSupport),
+			EndOfSupport:         after.EndOfSupport.Format("2006-01-02"),
+			EndOfExtendedSupport: formatDate(after.EndOfExtendedSupport),
+		})
+	}
+	if err != nil {
+		log.Printf("Error syncing %s %s from the catalog: %v", name, cycle.Cycle, err)
+		result.Action, result.Reason = ActionFailed, err.Error()
+		return result
+	}
+
+	if change, changed := result.Changes["end_of_support"]; changed && exists {
+		log.Printf("Catalog sync changed the end of support of %s %s from %v to %v", name, cycle.Cycle, change.Old, change.New)
+	}
+	result.OSID = &os.ID
+	byVersion[os.Version] = *os
+	return result
+}
+
+// formatDate formats an optional date as YYYY-MM-DD, or "" when it is not
+// set
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// Schedule syncs the catalog right away and then every interval until ctx
+// is done, logging the outcome of each sync
+func (s *Syncer) Schedule(ctx context.Context, interval time.Duration) {
+	s.scheduledSync(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.scheduledSync(ctx)
+		}
+	}
+}
+
+// scheduledSync runs a scheduled sync, logging the outcome
+func (s *Syncer) scheduledSync(ctx context.Context) {
+	report, err := s.Sync(ctx, false)
+	if err != nil {
+		log.Printf("Error syncing the OS catalog from %s: %v", s.source.Location, err)
+		return
+	}
+	log.Printf("Synced the OS catalog from %s: %s", s.source.Location, report.Summary())
+}
+
+// Summary describes the counts of a report in a sentence
+func (r *SyncReport) Summary() string {
+	return fmt.Sprintf("%d created, %d updated, %d unchanged, %d skipped, %d failed",
+		r.Created, r.Updated, r.Unchanged, r.Skipped, r.Failed)
+}
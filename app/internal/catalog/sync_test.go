@@ -0,0 +1,132 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+func newTestSyncer(t *testing.T, location string, products string) (*Syncer, *database.Stores) {
+	t.Helper()
+
+	stores := database.NewMemoryStores(database.NewMemoryDB())
+	selected, err := ParseProducts(products)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSyncer(stores.OS, NewSource(location, 5*time.Second), selected), stores
+}
+
+func findRelease(report *SyncReport, name, version string) *ReleaseResult {
+	for i := range report.Releases {
+		if report.Releases[i].Name == name && report.Releases[i].Version == version {
+			return &report.Releases[i]
+		}
+	}
+	return nil
+}
+
+func TestSyncer_Directory(t *testing.T) {
+	syncer, stores := newTestSyncer(t, "testdata", "")
+	ctx := context.Background()
+
+	// Ubuntu 22.04 is already in the catalog with an older end of support
+	existing, err := stores.OS.Create(ctx, &models.CreateOSRequest{Name: "Ubuntu", Version: "22.04", EndOfSupport: "2027-04-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run reports the changes without writing them
+	report, err := syncer.Sync(ctx, true)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !report.DryRun || report.Created != 4 || report.Updated != 1 || report.Skipped != 1 {
+		t.Errorf("Unexpected dry run report: %s", report.Summary())
+	}
+	if count, _ := stores.OS.Count(nil); count != 1 {
+		t.Errorf("Dry run wrote %d operating systems", count)
+	}
+
+	report, err = syncer.Sync(ctx, false)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 4 || report.Updated != 1 || report.Unchanged != 0 || report.Skipped != 1 || report.Failed != 0 {
+		t.Errorf("Unexpected report: %s", report.Summary())
+	}
+
+	// Unannounced end of support dates cannot be stored
+	if release := findRelease(report, "Ubuntu", "26.04"); release == nil || release.Action != ActionSkipped {
+		t.Errorf("Expected Ubuntu 26.04 to be skipped, got %+v", release)
+	}
+
+	updated, err := stores.OS.GetByID(existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.EndOfSupport.Format("2006-01-02") != "2027-06-01" || updated.ReleaseDate == nil ||
+		updated.EndOfExtendedSupport == nil || updated.EndOfExtendedSupport.Format("2006-01-02") != "2032-04-09" ||
+		updated.EndOfStandardSupport == nil || updated.EndOfStandardSupport.Format("2006-01-02") != "2027-04-01" || !updated.LTS {
+		t.Errorf("Unexpected updated OS %+v", updated)
+	}
+
+	// Products map to catalog names, and numeric cycles to versions
+	family := "RedHat"
+	rhel, err := stores.OS.GetAll(&models.OSFilter{Family: &family})
+	if err != nil || len(rhel) != 2 || rhel[0].Version != "8" || rhel[1].Version != "9" {
+		t.Errorf("Unexpected RedHat releases %+v, %v", rhel, err)
+	}
+
+	// The end of support change is recorded with the catalog sync source
+	history, err := stores.OSChangeHistory.GetByOSID(existing.ID, 10)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("Expected 2 history records, got %+v, %v", history, err)
+	}
+	if latest := history[0]; latest.Source != models.ChangeSourceCatalogSync || latest.Changes["end_of_support"].New != "2027-06-01" {
+		t.Errorf("Unexpected history record %+v", latest)
+	}
+
+	// Syncing again changes nothing
+	report, err = syncer.Sync(ctx, false)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Unchanged != 5 || report.Created+report.Updated+report.Failed != 0 {
+		t.Errorf("Unexpected second report: %s", report.Summary())
+	}
+}
+
+func TestSyncer_URL(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	// Products the source does not serve are skipped
+	syncer, stores := newTestSyncer(t, server.URL, "rhel,debian")
+	report, err := syncer.Sync(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 2 {
+		t.Errorf("Unexpected report: %s", report.Summary())
+	}
+	if count, _ := stores.OS.Count(nil); count != 2 {
+		t.Errorf("Expected 2 operating systems, got %d", count)
+	}
+
+	// A single product file must be selected
+	syncer, _ = newTestSyncer(t, server.URL+"/ubuntu.json", "rhel")
+	if _, err := syncer.Sync(context.Background(), false); err == nil {
+		t.Error("Sync() accepted a product that is not selected")
+	}
+
+	syncer, _ = newTestSyncer(t, server.URL+"/missing", "ubuntu")
+	if _, err := syncer.Sync(context.Background(), false); err == nil {
+		t.Error("Sync() succeeded without any product")
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import "time"
+
+// AgentConfig holds the configuration of the reporting agent
+type AgentConfig struct {
+	// APIURL is the base URL of the dashboard API
+	APIURL string
+	// APIKey authenticates the agent; it needs the operator role
+	APIKey string
+	// Timeout bounds each request to the API
+	Timeout time.Duration
+	// Interval is the time between reports, varied by up to Jitter in either
+	// direction so that hosts started together do not report together
+	Interval time.Duration
+	Jitter   time.Duration
+	// Retries is the number of retries of a failed report, waiting
+	// RetryBackoff and then twice as long each time, up to RetryMaxBackoff
+	Retries         int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	// SpoolDir keeps the reports that could not be delivered, at most
+	// SpoolMax of them, until the API is reachable again
+	SpoolDir string
+	SpoolMax int
+	// Root is the root filesystem the host is detected from
+	Root string
+	// Hostname overrides the detected host name
+	Hostname string
+	// Environment, Role, OwnerTeam and Location are reported with the host;
+	// empty values leave the current value of the server unchanged
+	Environment string
+	Role        string
+	OwnerTeam   string
+	Location    string
+}
+
+// LoadAgent loads the agent configuration from environment variables
+func LoadAgent() *AgentConfig {
+	return &AgentConfig{
+		APIURL:          getEnv("AGENT_API_URL", "http://localhost:8080"),
+		APIKey:          getEnv("AGENT_API_KEY", ""),
+		Timeout:         getEnvAsDuration("AGENT_TIMEOUT", 30*time.Second),
+		Interval:        getEnvAsDuration("AGENT_INTERVAL", time.Hour),
+		Jitter:          getEnvAsDuration("AGENT_JITTER", 5*time.Minute),
+		Retries:         getEnvAsInt("AGENT_RETRIES", 3),
+		RetryBackoff:    getEnvAsDuration("AGENT_RETRY_BACKOFF", 2*time.Second),
+		RetryMaxBackoff: getEnvAsDuration("AGENT_RETRY_MAX_BACKOFF", time.Minute),
+		SpoolDir:        getEnv("AGENT_SPOOL_DIR", "/var/lib/infra-dashboard-agent/spool"),
+		SpoolMax:        getEnvAsInt("AGENT_SPOOL_MAX", 100),
+		Root:            getEnv("AGENT_ROOT", "/"),
+		Hostname:        getEnv("AGENT_HOSTNAME", ""),
+		Environment:     getEnv("AGENT_ENVIRONMENT", ""),
+		Role:            getEnv("AGENT_ROLE", ""),
+		OwnerTeam:       getEnv("AGENT_OWNER_TEAM", ""),
+		Location:        getEnv("AGENT_LOCATION", ""),
+	}
+}
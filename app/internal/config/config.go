@@ -4,42 +4,193 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
+	Database    DatabaseConfig
+	Server      ServerConfig
+	Compliance  ComplianceConfig
+	Auth        AuthConfig
+	Catalog     CatalogConfig
+	Maintenance MaintenanceConfig
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
+	// Driver selects the storage backend: "postgres" or "memory"
+	Driver   string
 	Host     string
 	Port     int
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate applies pending schema migrations at startup
+	AutoMigrate bool
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Port string
+	// CORSAllowedOrigins lists the origins allowed to call the API from a
+	// browser. "*" allows any origin; browsers cannot call the API from
+	// another origin when it is empty.
+	CORSAllowedOrigins []string
+}
+
+// AuthConfig holds authentication configuration
+type AuthConfig struct {
+	// Enabled requires every API request to be authenticated
+	Enabled bool
+	// BootstrapKey is the secret of the admin key issued when no active admin
+	// key exists. BootstrapKeyFile is read instead when it is empty, or
+	// receives a generated secret when it does not exist.
+	BootstrapKey     string
+	BootstrapKeyFile string
+	OIDC             OIDCConfig
+}
+
+// OIDCConfig holds the configuration of OIDC bearer token authentication
+type OIDCConfig struct {
+	// JWKS is the URL or file path of the provider's signing keys. Bearer
+	// tokens are not accepted when it is empty.
+	JWKS string
+	// JWKSRefresh is how often keys fetched from a URL are refreshed
+	JWKSRefresh time.Duration
+	// Issuer and Audience are the required iss and aud claims. Both must be
+	// set when JWKS is.
+	Issuer   string
+	Audience string
+	// ClockSkew is the tolerance applied to token validity times
+	ClockSkew     time.Duration
+	UsernameClaim string
+	GroupsClaim   string
+	// RoleGroups maps groups to roles as "group:role,group:role"
+	RoleGroups string
+}
+
+// Enabled reports whether bearer tokens are accepted
+func (o *OIDCConfig) Enabled() bool {
+	return o.JWKS != ""
+}
+
+// ComplianceConfig holds compliance reporting configuration
+type ComplianceConfig struct {
+	// PolicyFile is the path of a JSON compliance policy set. The built-in
+	// default policy is used when it is empty.
+	PolicyFile string
+	// UpgradeGraphFile is the path of a JSON upgrade graph driving upgrade
+	// recommendations. The built-in graph is used when it is empty.
+	UpgradeGraphFile string
+	// SnapshotInterval is how often compliance snapshots are recorded for
+	// trend reports. Scheduled snapshots are disabled when it is zero.
+	SnapshotInterval time.Duration
+}
+
+// CatalogConfig holds the configuration of the OS catalog sync
+type CatalogConfig struct {
+	// Source is the endoflife.date product file, directory of product files
+	// or base URL the catalog is synced from. Scheduled syncs are disabled
+	// when it is empty.
+	Source string
+	// Products selects the products to sync as "product" or
+	// "product=Catalog Name" entries separated by commas. Every known
+	// product is synced when it is empty.
+	Products string
+	// SyncInterval is how often the catalog is synced in the background.
+	// Scheduled syncs are disabled when it is zero.
+	SyncInterval time.Duration
+	// Timeout bounds each request to a Source URL
+	Timeout time.Duration
+}
+
+// MaintenanceConfig holds the configuration of the scheduled change executor
+type MaintenanceConfig struct {
+	// CheckInterval is how often due scheduled changes are applied. The
+	// executor is disabled when it is zero.
+	CheckInterval time.Duration
+}
+
+// LoadPolicies returns the compliance policy set from PolicyFile, or the
+// default policy set when no file is configured
+func (c *ComplianceConfig) LoadPolicies() (models.CompliancePolicySet, error) {
+	if c.PolicyFile == "" {
+		return models.DefaultCompliancePolicySet(), nil
+	}
+
+	data, err := os.ReadFile(c.PolicyFile)
+	if err != nil {
+		return models.CompliancePolicySet{}, fmt.Errorf("failed to read compliance policy file: %w", err)
+	}
+
+	return models.ParseCompliancePolicySet(data)
+}
+
+// LoadUpgradeGraph returns the upgrade graph from UpgradeGraphFile, or the
+// default graph when no file is configured
+func (c *ComplianceConfig) LoadUpgradeGraph() (models.UpgradeGraph, error) {
+	if c.UpgradeGraphFile == "" {
+		return models.DefaultUpgradeGraph(), nil
+	}
+
+	data, err := os.ReadFile(c.UpgradeGraphFile)
+	if err != nil {
+		return models.UpgradeGraph{}, fmt.Errorf("failed to read upgrade graph file: %w", err)
+	}
+
+	return models.ParseUpgradeGraph(data)
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "infra_dashboard"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Driver:      getEnv("DB_DRIVER", "postgres"),
+			Host:        getEnv("DB_HOST", "localhost"),
+			Port:        getEnvAsInt("DB_PORT", 5432),
+			User:        getEnv("DB_USER", "postgres"),
+			Password:    getEnv("DB_PASSWORD", "postgres"),
+			DBName:      getEnv("DB_NAME", "infra_dashboard"),
+			SSLMode:     getEnv("DB_SSLMODE", "disable"),
+			AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", true),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:               getEnv("SERVER_PORT", "8080"),
+			CORSAllowedOrigins: getEnvAsList("CORS_ALLOWED_ORIGINS", nil),
+		},
+		Compliance: ComplianceConfig{
+			PolicyFile:       getEnv("COMPLIANCE_POLICY_FILE", ""),
+			UpgradeGraphFile: getEnv("UPGRADE_GRAPH_FILE", ""),
+			SnapshotInterval: getEnvAsDuration("COMPLIANCE_SNAPSHOT_INTERVAL", 24*time.Hour),
+		},
+		Auth: AuthConfig{
+			Enabled:          getEnvAsBool("AUTH_ENABLED", true),
+			BootstrapKey:     getEnv("AUTH_BOOTSTRAP_KEY", ""),
+			BootstrapKeyFile: getEnv("AUTH_BOOTSTRAP_KEY_FILE", "bootstrap-admin.key"),
+			OIDC: OIDCConfig{
+				JWKS:          getEnv("OIDC_JWKS", ""),
+				JWKSRefresh:   getEnvAsDuration("OIDC_JWKS_REFRESH", time.Hour),
+				Issuer:        getEnv("OIDC_ISSUER", ""),
+				Audience:      getEnv("OIDC_AUDIENCE", ""),
+				ClockSkew:     getEnvAsDuration("OIDC_CLOCK_SKEW", time.Minute),
+				UsernameClaim: getEnv("OIDC_USERNAME_CLAIM", "preferred_username"),
+				GroupsClaim:   getEnv("OIDC_GROUPS_CLAIM", "groups"),
+				RoleGroups:    getEnv("OIDC_ROLE_GROUPS", ""),
+			},
+		},
+		Catalog: CatalogConfig{
+			Source:       getEnv("CATALOG_SOURCE", ""),
+			Products:     getEnv("CATALOG_PRODUCTS", ""),
+			SyncInterval: getEnvAsDuration("CATALOG_SYNC_INTERVAL", 24*time.Hour),
+			Timeout:      getEnvAsDuration("CATALOG_TIMEOUT", 30*time.Second),
+		},
+		Maintenance: MaintenanceConfig{
+			CheckInterval: getEnvAsDuration("MAINTENANCE_CHECK_INTERVAL", time.Minute),
 		},
 	}
 }
@@ -66,3 +217,36 @@ func getEnvAsInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+// getEnvAsBool gets an environment variable as a boolean with a fallback value
+func getEnvAsBool(key string, fallback bool) bool {
+	strValue := getEnv(key, "")
+	if value, err := strconv.ParseBool(strValue); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// getEnvAsDuration gets an environment variable as a duration with a fallback value
+func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	strValue := getEnv(key, "")
+	if value, err := time.ParseDuration(strValue); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
+
+// getEnvAsList gets a comma-separated environment variable as a list with a
+// fallback value
+func getEnvAsList(key string, fallback []string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// APIKeyRepository provides database operations for API keys
+type APIKeyRepository struct {
+	db *DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeySelect = `
+	SELECT id, name, role, key_prefix, created_by, last_used_at, rotated_at, revoked_at, created_at, updated_at
+	FROM api_keys
+`
+
+// scanAPIKey scans an API key row selected with apiKeySelect
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Role,
+		&key.Prefix,
+		&key.CreatedBy,
+		&key.LastUsedAt,
+		&key.RotatedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	return key, err
+}
+
+// apiKeyWhere builds the WHERE clause and arguments for an API key filter
+func apiKeyWhere(filter *models.APIKeyFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter != nil {
+		if filter.Status != nil {
+			if *filter.Status == models.APIKeyStatusRevoked {
+				conditions = append(conditions, "revoked_at IS NOT NULL")
+			} else {
+				conditions = append(conditions, "revoked_at IS NULL")
+			}
+		}
+		if filter.Role != nil {
+			args = append(args, *filter.Role)
+			conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+		}
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAll retrieves API keys with optional filters and pagination, oldest first
+func (r *APIKeyRepository) GetAll(filter *models.APIKeyFilter) ([]models.APIKey, error) {
+	where, args := apiKeyWhere(filter)
+	query := apiKeySelect + where + " ORDER BY id"
+
+	// Apply pagination
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter != nil && filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Count returns the number of API keys matching a filter, ignoring pagination
+func (r *APIKeyRepository) Count(filter *models.APIKeyFilter) (int, error) {
+	where, args := apiKeyWhere(filter)
+	query := `SELECT COUNT(*) FROM api_keys` + where
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetByID retrieves an API key by its ID
+func (r *APIKeyRepository) GetByID(id int) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(apiKeySelect+` WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API key with id %d %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// GetByHash retrieves an API key, active or revoked, by the hash of its secret
+func (r *APIKeyRepository) GetByHash(hash string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(apiKeySelect+` WHERE key_hash = $1`, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API key %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// Create stores a new API key with the hash of its secret. Names are unique
+// among active keys.
+func (r *APIKeyRepository) Create(key *models.APIKey, hash string) (*models.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (name, role, key_prefix, key_hash, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING id
+	`
+
+	var id int
+	err := r.db.QueryRow(query, key.Name, key.Role, key.Prefix, hash, key.CreatedBy).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("active API key named %q already exists: %w", key.Name, ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return r.GetByID(id)
+}
+
+// Rotate replaces the secret of an active API key, keeping its name and role
+func (r *APIKeyRepository) Rotate(id int, prefix, hash string) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys
+		SET key_prefix = $1, key_hash = $2, rotated_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND revoked_at IS NULL
+	`
+
+	return r.updateActive(id, "rotate", query, prefix, hash, id)
+}
+
+// Revoke revokes an active API key. Revoked keys are kept for auditing.
+func (r *APIKeyRepository) Revoke(id int) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	return r.updateActive(id, "revoke", query, id)
+}
+
+// updateActive runs an update of an active API key, returning ErrConflict
+// when the key has been revoked and ErrNotFound when it does not exist
+func (r *APIKeyRepository) updateActive(id int, action, query string, args ...interface{}) (*models.APIKey, error) {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s API key: %w", action, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	key, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("cannot %s API key %d: it has been revoked: %w", action, id, ErrConflict)
+	}
+
+	return key, nil
+}
+
+// MarkUsed records when an API key was last used
+func (r *APIKeyRepository) MarkUsed(id int, at time.Time) error {
+	if _, err := r.db.Exec(`UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, at, id); err != nil {
+		return fmt.Errorf("failed to mark API key as used: %w", err)
+	}
+	return nil
+}
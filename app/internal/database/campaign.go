@@ -0,0 +1,308 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// parseCampaignDeadline parses a campaign deadline in YYYY-MM-DD format
+func parseCampaignDeadline(value string) (time.Time, error) {
+	deadline, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid deadline format: %v", ErrInvalidInput, err)
+	}
+	return deadline, nil
+}
+
+// validateCampaignRequest mirrors the checks of upgrade_campaigns that do
+// not depend on other tables
+func validateCampaignRequest(req *models.CreateCampaignRequest) error {
+	if req.SourceOSID == req.TargetOSID {
+		return fmt.Errorf("%w: a campaign must target another operating system than its source", ErrInvalidInput)
+	}
+	if req.Scope != nil && !req.Scope.IsEmpty() && len(req.ServerIDs) > 0 {
+		return fmt.Errorf("%w: a campaign selects its servers by scope or by ID, not both", ErrInvalidInput)
+	}
+	return nil
+}
+
+// uniqueSortedIDs returns the distinct IDs in ascending order
+func uniqueSortedIDs(ids []int) []int {
+	var unique []int
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Ints(unique)
+	return unique
+}
+
+// CampaignRepository provides database operations for upgrade campaigns
+type CampaignRepository struct {
+	db *DB
+}
+
+// NewCampaignRepository creates a new campaign repository
+func NewCampaignRepository(db *DB) *CampaignRepository {
+	return &CampaignRepository{db: db}
+}
+
+const campaignSelect = `
+	SELECT c.id, c.name, c.source_os_id, c.target_os_id, c.deadline, c.owner, c.selection,
+		c.scope_environment, c.scope_role, c.scope_owner_team, c.scope_location,
+		ARRAY(SELECT cs.server_id FROM campaign_servers cs WHERE cs.campaign_id = c.id ORDER BY cs.server_id),
+		c.created_at, c.updated_at
+	FROM upgrade_campaigns c
+`
+
+// scanCampaign scans a campaign row selected with campaignSelect
+func scanCampaign(row rowScanner) (models.Campaign, error) {
+	var campaign models.Campaign
+	var serverIDs []int64
+
+	err := row.Scan(
+		&campaign.ID,
+		&campaign.Name,
+		&campaign.SourceOSID,
+		&campaign.TargetOSID,
+		&campaign.Deadline,
+		&campaign.Owner,
+		&campaign.Selection,
+		&campaign.Scope.Environment,
+		&campaign.Scope.Role,
+		&campaign.Scope.OwnerTeam,
+		&campaign.Scope.Location,
+		pq.Array(&serverIDs),
+		&campaign.CreatedAt,
+		&campaign.UpdatedAt,
+	)
+	if err != nil {
+		return campaign, err
+	}
+
+	for _, id := range serverIDs {
+		campaign.ServerIDs = append(campaign.ServerIDs, int(id))
+	}
+
+	return campaign, nil
+}
+
+// campaignWhere builds the WHERE clause and arguments for a campaign filter
+func campaignWhere(filter *models.CampaignFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter == nil {
+		return " WHERE " + strings.Join(conditions, " AND "), args
+	}
+
+	if filter.Owner != nil {
+		args = append(args, *filter.Owner)
+		conditions = append(conditions, fmt.Sprintf("c.owner = $%d", len(args)))
+	}
+	if filter.SourceOSID != nil {
+		args = append(args, *filter.SourceOSID)
+		conditions = append(conditions, fmt.Sprintf("c.source_os_id = $%d", len(args)))
+	}
+	if filter.TargetOSID != nil {
+		args = append(args, *filter.TargetOSID)
+		conditions = append(conditions, fmt.Sprintf("c.target_os_id = $%d", len(args)))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAll retrieves campaigns with optional filters and pagination, soonest deadline first
+func (r *CampaignRepository) GetAll(filter *models.CampaignFilter) ([]models.Campaign, error) {
+	where, args := campaignWhere(filter)
+	query := campaignSelect + where + " ORDER BY c.deadline, c.id"
+
+	// Apply pagination
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter != nil && filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []models.Campaign
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan campaign: %w", err)
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+// Count returns the number of campaigns matching a filter, ignoring pagination
+func (r *CampaignRepository) Count(filter *models.CampaignFilter) (int, error) {
+	where, args := campaignWhere(filter)
+	query := `SELECT COUNT(*) FROM upgrade_campaigns c` + where
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count campaigns: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetByID retrieves a campaign by its ID
+func (r *CampaignRepository) GetByID(id int) (*models.Campaign, error) {
+	campaign, err := scanCampaign(r.db.QueryRow(campaignSelect+` WHERE c.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("campaign with id %d %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+
+	return &campaign, nil
+}
+
+// Create creates a new campaign with its explicit servers in a single transaction
+func (r *CampaignRepository) Create(req *models.CreateCampaignRequest) (*models.Campaign, error) {
+	deadline, err := parseCampaignDeadline(req.Deadline)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCampaignRequest(req); err != nil {
+		return nil, err
+	}
+
+	var scope models.CampaignScope
+	if req.Scope != nil {
+		scope = *req.Scope
+	}
+	serverIDs := uniqueSortedIDs(req.ServerIDs)
+	selection := models.CampaignSelectionScope
+	if len(serverIDs) > 0 {
+		selection = models.CampaignSelectionServers
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO upgrade_campaigns (name, source_os_id, target_os_id, deadline, owner, selection,
+			scope_environment, scope_role, scope_owner_team, scope_location, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		RETURNING id
+	`
+
+	var id int
+	err = tx.QueryRow(query, req.Name, req.SourceOSID, req.TargetOSID, deadline, req.Owner, selection,
+		scope.Environment, scope.Role, scope.OwnerTeam, scope.Location).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, fmt.Errorf("campaign operating system or server does not exist: %w", ErrInvalidReference)
+		}
+		return nil, fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	for _, serverID := range serverIDs {
+		if _, err := tx.Exec(`INSERT INTO campaign_servers (campaign_id, server_id) VALUES ($1, $2)`, id, serverID); err != nil {
+			if isForeignKeyViolation(err) {
+				return nil, fmt.Errorf("campaign operating system or server does not exist: %w", ErrInvalidReference)
+			}
+			return nil, fmt.Errorf("failed to add campaign server: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit campaign creation: %w", err)
+	}
+	return r.GetByID(id)
+}
+
+// Update updates the name, deadline or owner of a campaign
+func (r *CampaignRepository) Update(id int, req *models.UpdateCampaignRequest) (*models.Campaign, error) {
+	setParts := []string{}
+	args := []interface{}{}
+
+	if req.Name != "" {
+		args = append(args, req.Name)
+		setParts = append(setParts, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if req.Deadline != "" {
+		deadline, err := parseCampaignDeadline(req.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, deadline)
+		setParts = append(setParts, fmt.Sprintf("deadline = $%d", len(args)))
+	}
+	if req.Owner != "" {
+		args = append(args, req.Owner)
+		setParts = append(setParts, fmt.Sprintf("owner = $%d", len(args)))
+	}
+
+	if len(setParts) == 0 {
+		return r.GetByID(id) // No updates, return existing campaign
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf(`
+		UPDATE upgrade_campaigns
+		SET %s, updated_at = NOW()
+		WHERE id = $%d
+		RETURNING id
+	`, strings.Join(setParts, ", "), len(args))
+
+	var campaignID int
+	if err := r.db.QueryRow(query, args...).Scan(&campaignID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("campaign with id %d %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update campaign: %w", err)
+	}
+
+	return r.GetByID(campaignID)
+}
+
+// Delete removes a campaign along with its explicit server list
+func (r *CampaignRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM upgrade_campaigns WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete campaign: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("campaign with id %d %w", id, ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// changeFeedKey identifies a record of the merged change history feed
+type changeFeedKey struct {
+	resourceType string
+	id           int64
+}
+
+// GetFeed retrieves a page of the merged server and operating system change
+// history, newest first and ordered like models.MergeChangeHistory. The page
+// is selected in SQL over the keys of both tables, so only its records are
+// loaded whatever the offset.
+func (r *ChangeHistoryRepository) GetFeed(filter *models.ChangeFeedFilter) ([]interface{}, error) {
+	var feeds []string
+	var args []interface{}
+	if filter.ResourceType != models.ResourceTypeOS {
+		var where string
+		where, args = changeHistoryWhere(&filter.Servers, args)
+		feeds = append(feeds, "SELECT 'server' AS resource_type, id, changed_at FROM server_change_history"+where)
+	}
+	if filter.ResourceType != models.ResourceTypeServer {
+		var where string
+		where, args = osChangeHistoryWhere(&filter.OS, args)
+		feeds = append(feeds, "SELECT 'os' AS resource_type, id, changed_at FROM os_change_history"+where)
+	}
+
+	// Server records sort before operating system records with the same
+	// time and ID
+	query := strings.Join(feeds, " UNION ALL ") + " ORDER BY changed_at DESC, id DESC, resource_type DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change history feed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []changeFeedKey
+	var serverIDs, osIDs []int64
+	for rows.Next() {
+		var key changeFeedKey
+		var changedAt time.Time
+		if err := rows.Scan(&key.resourceType, &key.id, &changedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan change history feed row: %w", err)
+		}
+		keys = append(keys, key)
+		if key.resourceType == models.ResourceTypeOS {
+			osIDs = append(osIDs, key.id)
+		} else {
+			serverIDs = append(serverIDs, key.id)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	servers, err := r.recordsByID(changeHistorySelect, serverIDs, func(row rowScanner) (interface{}, int, error) {
+		record, err := scanChangeHistory(row)
+		return record, record.ID, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	oss, err := r.recordsByID(osChangeHistorySelect, osIDs, func(row rowScanner) (interface{}, int, error) {
+		record, err := scanOSChangeHistory(row)
+		return record, record.ID, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	feed := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		records := servers
+		if key.resourceType == models.ResourceTypeOS {
+			records = oss
+		}
+		if record, exists := records[int(key.id)]; exists {
+			feed = append(feed, record)
+		}
+	}
+
+	return feed, nil
+}
+
+// recordsByID loads the change history records with the given IDs using a
+// select of their table, keyed by ID
+func (r *ChangeHistoryRepository) recordsByID(selectQuery string, ids []int64, scan func(rowScanner) (interface{}, int, error)) (map[int]interface{}, error) {
+	records := make(map[int]interface{}, len(ids))
+	if len(ids) == 0 {
+		return records, nil
+	}
+
+	rows, err := r.db.Query(selectQuery+" WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change history records: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, id, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change history row: %w", err)
+		}
+		records[id] = record
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return records, nil
+}
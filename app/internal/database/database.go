@@ -1,14 +1,18 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"infra-dashboard/internal/config"
 	"infra-dashboard/internal/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // DB wraps the database connection
@@ -35,40 +39,16 @@ func New(cfg *config.DatabaseConfig) (*DB, error) {
 	return &DB{db}, nil
 }
 
-// CreateTablesIfNotExist creates the servers table if it doesn't exist
-func (db *DB) CreateTablesIfNotExist() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS operating_systems (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(100) NOT NULL,
-		version VARCHAR(100) NOT NULL,
-		end_of_support DATE NOT NULL,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-		UNIQUE(name, version)
-	);
-
-	CREATE TABLE IF NOT EXISTS servers (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL UNIQUE,
-		os_id INTEGER NOT NULL,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-		FOREIGN KEY (os_id) REFERENCES operating_systems(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_servers_name ON servers(name);
-	CREATE INDEX IF NOT EXISTS idx_servers_os_id ON servers(os_id);
-	CREATE INDEX IF NOT EXISTS idx_os_name ON operating_systems(name);
-	CREATE INDEX IF NOT EXISTS idx_os_end_of_support ON operating_systems(end_of_support);
-	`
-
-	_, err := db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create tables: %w", err)
-	}
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
 
-	return nil
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key violation
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
 }
 
 // ServerRepository provides database operations for servers
@@ -81,17 +61,344 @@ func NewServerRepository(db *DB) *ServerRepository {
 	return &ServerRepository{db: db}
 }
 
-// GetAll retrieves all servers from the database
-func (r *ServerRepository) GetAll() ([]models.Server, error) {
-	query := `
-		SELECT s.id, s.name, s.os_id, s.created_at, s.updated_at,
-		       os.id, os.name, os.version, os.end_of_support, os.created_at, os.updated_at
+// serverSelect selects servers joined with their operating system, in the
+// column order expected by scanServer
+const serverSelect = `
+		SELECT s.id, s.name, s.os_id, s.environment, s.role, s.owner_team, s.location, s.description,
+		       s.extended_support, s.last_seen_at, s.created_at, s.updated_at,
+		       os.id, os.name, os.version, os.lts, os.release_date, os.end_of_standard_support,
+		       os.end_of_support, os.end_of_extended_support, os.created_at, os.updated_at
 		FROM servers s
 		JOIN operating_systems os ON s.os_id = os.id
-		ORDER BY s.created_at DESC
-	`
+`
 
-	rows, err := r.db.Query(query)
+// serverEndOfSupport is the SQL expression of the end of support a server is
+// evaluated against, as returned by models.Server.EndOfSupport
+const serverEndOfSupport = `CASE WHEN s.extended_support AND os.end_of_extended_support IS NOT NULL
+		THEN os.end_of_extended_support ELSE os.end_of_support END`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanServer scans a row produced by serverSelect
+func scanServer(row rowScanner) (models.Server, error) {
+	var server models.Server
+	var os models.OS
+	var lastSeenAt, releaseDate, endOfStandardSupport, endOfExtendedSupport sql.NullTime
+	err := row.Scan(
+		&server.ID,
+		&server.Name,
+		&server.OSID,
+		&server.Environment,
+		&server.Role,
+		&server.OwnerTeam,
+		&server.Location,
+		&server.Description,
+		&server.ExtendedSupport,
+		&lastSeenAt,
+		&server.CreatedAt,
+		&server.UpdatedAt,
+		&os.ID,
+		&os.Name,
+		&os.Version,
+		&os.LTS,
+		&releaseDate,
+		&endOfStandardSupport,
+		&os.EndOfSupport,
+		&endOfExtendedSupport,
+		&os.CreatedAt,
+		&os.UpdatedAt,
+	)
+	if err != nil {
+		return server, err
+	}
+	server.LastSeenAt = nullTimePtr(lastSeenAt)
+	os.ReleaseDate = nullTimePtr(releaseDate)
+	os.EndOfStandardSupport = nullTimePtr(endOfStandardSupport)
+	os.EndOfExtendedSupport = nullTimePtr(endOfExtendedSupport)
+	server.OS = &os
+	return server, nil
+}
+
+// sortColumn is a column list queries can be ordered by
+type sortColumn struct {
+	expr string
+	// typ is the SQL type cursor values of the column are cast to
+	typ string
+	// version orders the column like models.CompareVersions
+	version bool
+}
+
+// keys returns the SQL expressions ordering value, the column or a cursor
+// value of it
+func (c sortColumn) keys(value string) []string {
+	if c.version {
+		return versionSortKeys(value)
+	}
+	return []string{value}
+}
+
+// serverSortColumns maps server sort fields to the columns they order by
+var serverSortColumns = map[string]sortColumn{
+	"id":             {expr: "s.id", typ: "integer"},
+	"name":           {expr: "s.name", typ: "text"},
+	"os_name":        {expr: "os.name", typ: "text"},
+	"os_version":     {expr: "os.version", typ: "text", version: true},
+	"end_of_support": {expr: "os.end_of_support", typ: "date"},
+	"environment":    {expr: "s.environment", typ: "text"},
+	"role":           {expr: "s.role", typ: "text"},
+	"owner_team":     {expr: "s.owner_team", typ: "text"},
+	"location":       {expr: "s.location", typ: "text"},
+	"last_seen_at":   {expr: "s.last_seen_at", typ: "timestamptz"},
+	"created_at":     {expr: "s.created_at", typ: "timestamptz"},
+	"updated_at":     {expr: "s.updated_at", typ: "timestamptz"},
+}
+
+// versionSortKeys returns the SQL expressions ordering a version column the
+// same way as models.CompareVersions: by its numeric components, then its
+// pre-release rank and number, then the raw value. Versions without a
+// numeric part yield NULL and therefore sort last in ascending order.
+func versionSortKeys(column string) []string {
+	prerelease := fmt.Sprintf("regexp_match(lower(%s), '%s')", column, models.VersionPrereleasePattern)
+
+	return []string{
+		fmt.Sprintf("string_to_array(substring(%s from '%s'), '.')::numeric[]", column, models.VersionNumberPattern),
+		fmt.Sprintf("CASE (%s)[1] WHEN 'dev' THEN %d WHEN 'alpha' THEN %d WHEN 'beta' THEN %d WHEN 'rc' THEN %d WHEN 'pre' THEN %d WHEN 'preview' THEN %d ELSE %d END",
+			prerelease, models.RankDev, models.RankAlpha, models.RankBeta,
+			models.RankReleaseCandidate, models.RankReleaseCandidate, models.RankReleaseCandidate, models.RankRelease),
+		fmt.Sprintf("COALESCE(NULLIF((%s)[2], '')::numeric, 0)", prerelease),
+		column,
+	}
+}
+
+// sortOrder returns the sort fields of a list query, or the default ones
+// when it does not specify any
+func sortOrder(sortFields, fallback []models.SortField) []models.SortField {
+	if len(sortFields) == 0 {
+		return fallback
+	}
+	return sortFields
+}
+
+// endingSoonCutoff returns the end of the ending soon window of a filter,
+// falling back to the default compliance policy
+func endingSoonCutoff(cutoff *time.Time, now time.Time) time.Time {
+	if cutoff != nil {
+		return *cutoff
+	}
+	return models.DefaultCompliancePolicy().EndingSoonCutoff(now)
+}
+
+// staleCutoff returns the time servers last seen before are stale under a
+// filter, falling back to the default compliance policy, and false when
+// stale detection is disabled
+func staleCutoff(cutoff *time.Time, now time.Time) (time.Time, bool) {
+	if cutoff != nil {
+		return *cutoff, true
+	}
+	return models.DefaultCompliancePolicy().StaleCutoff(now)
+}
+
+// supportStatusCondition returns the SQL condition matching a support status
+// for the given end of support expression, appending its arguments to args
+func supportStatusCondition(column, status string, cutoff *time.Time, args []interface{}) (string, []interface{}) {
+	now := time.Now()
+	endingSoonCutoff := endingSoonCutoff(cutoff, now)
+
+	switch status {
+	case models.StatusEndOfLife:
+		args = append(args, now)
+		return fmt.Sprintf("(%s) < $%d", column, len(args)), args
+	case models.StatusEndingSoon:
+		args = append(args, now, endingSoonCutoff)
+		return fmt.Sprintf("(%s) > $%d AND (%s) < $%d", column, len(args)-1, column, len(args)), args
+	default:
+		args = append(args, endingSoonCutoff)
+		return fmt.Sprintf("(%s) >= $%d", column, len(args)), args
+	}
+}
+
+// timeRangeConditions appends conditions for the optional bounds of a timestamp column
+func timeRangeConditions(conditions []string, args []interface{}, column string, after, before *time.Time) ([]string, []interface{}) {
+	if after != nil {
+		args = append(args, *after)
+		conditions = append(conditions, fmt.Sprintf("%s >= $%d", column, len(args)))
+	}
+	if before != nil {
+		args = append(args, *before)
+		conditions = append(conditions, fmt.Sprintf("%s <= $%d", column, len(args)))
+	}
+	return conditions, args
+}
+
+// orderByClause builds an ORDER BY clause from sort fields, always ending
+// with the tie breaker for stable pages
+func orderByClause(sortFields []models.SortField, columns map[string]sortColumn, tieBreaker string) string {
+	var parts []string
+	for _, f := range sortFields {
+		column := columns[f.Field]
+		for _, key := range column.keys(column.expr) {
+			if f.Desc {
+				key += " DESC"
+			}
+			parts = append(parts, key)
+		}
+	}
+	parts = append(parts, tieBreaker)
+
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// keysetCondition returns the SQL condition matching the rows sorting after
+// a cursor in the order of orderByClause, appending its arguments to args.
+// Each sort key may differ in direction and be NULL, which PostgreSQL sorts
+// last in ascending order and first in descending order, so the condition
+// spells out the comparison of (keys..., tieBreaker) with the cursor row
+// rather than comparing row values.
+func keysetCondition(sortFields []models.SortField, columns map[string]sortColumn, cursor *models.Cursor, tieBreaker string, args []interface{}) (string, []interface{}) {
+	var equal, terms []string
+	for i, f := range sortFields {
+		column := columns[f.Field]
+		args = append(args, cursor.Values[i])
+		rowKeys := column.keys(column.expr)
+		cursorKeys := column.keys(fmt.Sprintf("$%d::%s", len(args), column.typ))
+
+		for j := range rowKeys {
+			term := append(append([]string{}, equal...), sortsAfter(rowKeys[j], cursorKeys[j], f.Desc))
+			terms = append(terms, "("+strings.Join(term, " AND ")+")")
+			equal = append(equal, fmt.Sprintf("%s IS NOT DISTINCT FROM %s", rowKeys[j], cursorKeys[j]))
+		}
+	}
+
+	args = append(args, cursor.ID)
+	term := append(equal, fmt.Sprintf("%s > $%d", tieBreaker, len(args)))
+	terms = append(terms, "("+strings.Join(term, " AND ")+")")
+
+	return "(" + strings.Join(terms, " OR ") + ")", args
+}
+
+// sortsAfter returns the SQL condition of a sort key sorting after the value
+// of the cursor row
+func sortsAfter(key, value string, desc bool) string {
+	if desc {
+		return fmt.Sprintf("(%s < %s OR (%s IS NOT NULL AND %s IS NULL))", key, value, key, value)
+	}
+	return fmt.Sprintf("(%s > %s OR (%s IS NULL AND %s IS NOT NULL))", key, value, key, value)
+}
+
+// serverWhere builds the WHERE clause and arguments for a server filter
+func serverWhere(filter *models.ServerFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter == nil {
+		return " WHERE " + strings.Join(conditions, " AND "), args
+	}
+
+	if filter.Name != nil {
+		args = append(args, *filter.Name)
+		conditions = append(conditions, fmt.Sprintf("strpos(lower(s.name), lower($%d)) > 0", len(args)))
+	}
+	if filter.Family != nil {
+		args = append(args, *filter.Family)
+		conditions = append(conditions, fmt.Sprintf("os.name = $%d", len(args)))
+	}
+	if filter.OSID != nil {
+		args = append(args, *filter.OSID)
+		conditions = append(conditions, fmt.Sprintf("s.os_id = $%d", len(args)))
+	}
+	if filter.SupportStatus != nil {
+		var condition string
+		condition, args = supportStatusCondition(serverEndOfSupport, *filter.SupportStatus, filter.EndingSoonCutoff, args)
+		conditions = append(conditions, condition)
+	}
+
+	for _, f := range []struct {
+		column string
+		value  *string
+	}{
+		{"s.environment", filter.Environment},
+		{"s.role", filter.Role},
+		{"s.owner_team", filter.OwnerTeam},
+		{"s.location", filter.Location},
+	} {
+		if f.value != nil {
+			args = append(args, *f.value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", f.column, len(args)))
+		}
+	}
+
+	if filter.Stale != nil {
+		cutoff, enabled := staleCutoff(filter.StaleCutoff, time.Now())
+		switch {
+		case !enabled && *filter.Stale:
+			conditions = append(conditions, "FALSE")
+		case !enabled:
+		case *filter.Stale:
+			args = append(args, cutoff)
+			conditions = append(conditions, fmt.Sprintf("s.last_seen_at < $%d", len(args)))
+		default:
+			args = append(args, cutoff)
+			conditions = append(conditions, fmt.Sprintf("(s.last_seen_at IS NULL OR s.last_seen_at >= $%d)", len(args)))
+		}
+	}
+
+	conditions, args = timeRangeConditions(conditions, args, "s.created_at", filter.CreatedAfter, filter.CreatedBefore)
+	conditions, args = timeRangeConditions(conditions, args, "s.updated_at", filter.UpdatedAfter, filter.UpdatedBefore)
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// serversAsOf rebuilds the servers that existed at filter.AsOf from the
+// change history and returns those satisfying the filter at that time
+func (r *ServerRepository) serversAsOf(filter *models.ServerFilter) ([]models.Server, error) {
+	history, err := NewChangeHistoryRepository(r.db).GetAll(&models.ChangeHistoryFilter{EndDate: filter.AsOf})
+	if err != nil {
+		return nil, err
+	}
+
+	oss, err := NewOSRepository(r.db).GetAll(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return serversAsOf(history, oss, filter), nil
+}
+
+// GetAll retrieves servers from the database with optional filters, ordering and pagination
+func (r *ServerRepository) GetAll(filter *models.ServerFilter) ([]models.Server, error) {
+	if filter != nil && filter.AsOf != nil {
+		servers, err := r.serversAsOf(filter)
+		if err != nil {
+			return nil, err
+		}
+		return pageServers(servers, filter)
+	}
+
+	where, args := serverWhere(filter)
+	query := serverSelect + where
+
+	var sortFields []models.SortField
+	if filter != nil {
+		sortFields = filter.Sort
+	}
+	sortFields = sortOrder(sortFields, models.DefaultServerSort)
+
+	// Apply keyset pagination
+	if filter != nil && filter.After != nil {
+		var condition string
+		condition, args = keysetCondition(sortFields, serverSortColumns, filter.After, "s.id", args)
+		query += " AND " + condition
+	}
+	query += orderByClause(sortFields, serverSortColumns, "s.id")
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query servers: %w", err)
 	}
@@ -99,25 +406,10 @@ func (r *ServerRepository) GetAll() ([]models.Server, error) {
 
 	var servers []models.Server
 	for rows.Next() {
-		var server models.Server
-		var os models.OS
-		err := rows.Scan(
-			&server.ID,
-			&server.Name,
-			&server.OSID,
-			&server.CreatedAt,
-			&server.UpdatedAt,
-			&os.ID,
-			&os.Name,
-			&os.Version,
-			&os.EndOfSupport,
-			&os.CreatedAt,
-			&os.UpdatedAt,
-		)
+		server, err := scanServer(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan server: %w", err)
 		}
-		server.OS = &os
 		servers = append(servers, server)
 	}
 
@@ -128,81 +420,129 @@ func (r *ServerRepository) GetAll() ([]models.Server, error) {
 	return servers, nil
 }
 
-// GetByID retrieves a server by its ID
-func (r *ServerRepository) GetByID(id int) (*models.Server, error) {
+// Count returns the number of servers matching a filter, ignoring pagination
+func (r *ServerRepository) Count(filter *models.ServerFilter) (int, error) {
+	if filter != nil && filter.AsOf != nil {
+		servers, err := r.serversAsOf(filter)
+		return len(servers), err
+	}
+
+	where, args := serverWhere(filter)
 	query := `
-		SELECT s.id, s.name, s.os_id, s.created_at, s.updated_at,
-		       os.id, os.name, os.version, os.end_of_support, os.created_at, os.updated_at
+		SELECT COUNT(*)
 		FROM servers s
 		JOIN operating_systems os ON s.os_id = os.id
+	` + where
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count servers: %w", err)
+	}
+
+	return count, nil
+}
+
+// queryer is implemented by *DB and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// getServer retrieves a server by its ID, locking its row when forUpdate is
+// set so that concurrent changes are recorded in order
+func getServer(q queryer, id int, forUpdate bool) (*models.Server, error) {
+	query := serverSelect + `
 		WHERE s.id = $1
 	`
+	if forUpdate {
+		query += " FOR UPDATE OF s"
+	}
 
-	var server models.Server
-	var os models.OS
-	err := r.db.QueryRow(query, id).Scan(
-		&server.ID,
-		&server.Name,
-		&server.OSID,
-		&server.CreatedAt,
-		&server.UpdatedAt,
-		&os.ID,
-		&os.Name,
-		&os.Version,
-		&os.EndOfSupport,
-		&os.CreatedAt,
-		&os.UpdatedAt,
-	)
-
+	server, err := scanServer(q.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("server with id %d not found", id)
+			return nil, fmt.Errorf("server with id %d %w", id, ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get server: %w", err)
 	}
 
-	server.OS = &os
 	return &server, nil
 }
 
-// Create creates a new server in the database
-func (r *ServerRepository) Create(req *models.CreateServerRequest) (*models.Server, error) {
-	// First verify the OS exists
+// GetByID retrieves a server by its ID
+func (r *ServerRepository) GetByID(id int) (*models.Server, error) {
+	return getServer(r.db, id, false)
+}
+
+// checkOSExists returns ErrInvalidReference when the operating system does not exist
+func checkOSExists(q queryer, osID int) error {
 	var osExists bool
 	checkQuery := `SELECT EXISTS(SELECT 1 FROM operating_systems WHERE id = $1)`
-	err := r.db.QueryRow(checkQuery, req.OSID).Scan(&osExists)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check OS existence: %w", err)
+	if err := q.QueryRow(checkQuery, osID).Scan(&osExists); err != nil {
+		return fmt.Errorf("failed to check OS existence: %w", err)
 	}
 	if !osExists {
-		return nil, fmt.Errorf("operating system with id %d does not exist", req.OSID)
+		return fmt.Errorf("operating system with id %d does not exist: %w", osID, ErrInvalidReference)
+	}
+	return nil
+}
+
+// Create creates a new server in the database and records the change with
+// the metadata of ctx in the same transaction
+func (r *ServerRepository) Create(ctx context.Context, req *models.CreateServerRequest) (*models.Server, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkOSExists(tx, req.OSID); err != nil {
+		return nil, err
 	}
 
 	query := `
-		INSERT INTO servers (name, os_id, created_at, updated_at)
-		VALUES ($1, $2, NOW(), NOW())
-		RETURNING id, name, os_id, created_at, updated_at
+		INSERT INTO servers (name, os_id, environment, role, owner_team, location, description, extended_support, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		RETURNING id
 	`
 
-	var server models.Server
-	err = r.db.QueryRow(query, req.Name, req.OSID).Scan(
-		&server.ID,
-		&server.Name,
-		&server.OSID,
-		&server.CreatedAt,
-		&server.UpdatedAt,
-	)
+	var id int
+	err = tx.QueryRow(query,
+		req.Name,
+		req.OSID,
+		req.Environment,
+		req.Role,
+		req.OwnerTeam,
+		req.Location,
+		req.Description,
+		req.ExtendedSupport,
+	).Scan(&id)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("server with name %q already exists: %w", req.Name, ErrConflict)
+		}
 		return nil, fmt.Errorf("failed to create server: %w", err)
 	}
 
 	// Fetch the full server with OS details
-	return r.GetByID(server.ID)
+	server, err := getServer(tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordServerChange(ctx, tx, nil, server); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit server creation: %w", err)
+	}
+	return server, nil
 }
 
-// Update updates an existing server in the database
-func (r *ServerRepository) Update(id int, req *models.UpdateServerRequest) (*models.Server, error) {
+// Update updates an existing server in the database and records the change
+// with the metadata of ctx in the same transaction
+func (r *ServerRepository) Update(ctx context.Context, id int, req *models.UpdateServerRequest) (*models.Server, error) {
 	// Build dynamic update query
 	setParts := []string{}
 	args := []interface{}{}
@@ -215,22 +555,34 @@ func (r *ServerRepository) Update(id int, req *models.UpdateServerRequest) (*mod
 	}
 
 	if req.OSID != 0 {
-		// First verify the OS exists
-		var osExists bool
-		checkQuery := `SELECT EXISTS(SELECT 1 FROM operating_systems WHERE id = $1)`
-		err := r.db.QueryRow(checkQuery, req.OSID).Scan(&osExists)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check OS existence: %w", err)
-		}
-		if !osExists {
-			return nil, fmt.Errorf("operating system with id %d does not exist", req.OSID)
-		}
-
 		setParts = append(setParts, fmt.Sprintf("os_id = $%d", argCount))
 		args = append(args, req.OSID)
 		argCount++
 	}
 
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"environment", req.Environment},
+		{"role", req.Role},
+		{"owner_team", req.OwnerTeam},
+		{"location", req.Location},
+		{"description", req.Description},
+	} {
+		if f.value != "" {
+			setParts = append(setParts, fmt.Sprintf("%s = $%d", f.column, argCount))
+			args = append(args, f.value)
+			argCount++
+		}
+	}
+
+	if req.ExtendedSupport != nil {
+		setParts = append(setParts, fmt.Sprintf("extended_support = $%d", argCount))
+		args = append(args, *req.ExtendedSupport)
+		argCount++
+	}
+
 	if len(setParts) == 0 {
 		return r.GetByID(id) // No updates, return existing server
 	}
@@ -243,60 +595,87 @@ func (r *ServerRepository) Update(id int, req *models.UpdateServerRequest) (*mod
 	// Add the ID for the WHERE clause
 	args = append(args, id)
 
-	// Build the SET clause
-	setClause := ""
-	for i, part := range setParts {
-		if i > 0 {
-			setClause += ", "
-		}
-		setClause += part
-	}
-
 	query := fmt.Sprintf(`
 		UPDATE servers
 		SET %s
 		WHERE id = $%d
-		RETURNING id, name, os_id, created_at, updated_at
-	`, setClause, argCount)
+	`, strings.Join(setParts, ", "), argCount)
 
-	var server models.Server
-	err := r.db.QueryRow(query, args...).Scan(
-		&server.ID,
-		&server.Name,
-		&server.OSID,
-		&server.CreatedAt,
-		&server.UpdatedAt,
-	)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
+	if req.OSID != 0 {
+		if err := checkOSExists(tx, req.OSID); err != nil {
+			return nil, err
+		}
+	}
+
+	before, err := getServer(tx, id, true)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("server with id %d not found", id)
+		return nil, err
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("server with name %q already exists: %w", req.Name, ErrConflict)
 		}
 		return nil, fmt.Errorf("failed to update server: %w", err)
 	}
 
 	// Fetch the full server with OS details
-	return r.GetByID(server.ID)
-}
+	after, err := getServer(tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordServerChange(ctx, tx, before, after); err != nil {
+		return nil, err
+	}
 
-// Delete removes a server from the database
-func (r *ServerRepository) Delete(id int) error {
-	query := `DELETE FROM servers WHERE id = $1`
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit server update: %w", err)
+	}
+	return after, nil
+}
 
-	result, err := r.db.Exec(query, id)
+// Delete removes a server from the database and records the change with the
+// metadata of ctx in the same transaction
+func (r *ServerRepository) Delete(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete server: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	server, err := getServer(tx, id, true)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("server with id %d not found", id)
+	// Recorded first: deleting the server sets server_id to NULL on its
+	// history, scheduled changes and waivers
+	if err := recordServerChange(ctx, tx, server, nil); err != nil {
+		return err
+	}
+	if err := resolveDeletedServerChanges(tx, server); err != nil {
+		return err
+	}
+	if err := recordDeletedServerWaivers(tx, server); err != nil {
+		return err
 	}
 
+	if _, err := tx.Exec(`DELETE FROM servers WHERE id = $1`, id); err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("cannot delete server: campaigns list it: %w", ErrConflict)
+		}
+		return fmt.Errorf("failed to delete server: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit server deletion: %w", err)
+	}
 	return nil
 }
 
@@ -310,15 +689,138 @@ func NewOSRepository(db *DB) *OSRepository {
 	return &OSRepository{db: db}
 }
 
-// GetAll retrieves all operating systems from the database
-func (r *OSRepository) GetAll() ([]models.OS, error) {
-	query := `
-		SELECT id, name, version, end_of_support, created_at, updated_at
-		FROM operating_systems
-		ORDER BY name, version
-	`
+// osColumns are the operating system columns, in the order expected by scanOS
+const osColumns = `id, name, version, lts, release_date, end_of_standard_support, end_of_support, end_of_extended_support, created_at, updated_at`
+
+// scanOS scans a row of osColumns
+func scanOS(row rowScanner) (models.OS, error) {
+	var os models.OS
+	var releaseDate, endOfStandardSupport, endOfExtendedSupport sql.NullTime
+	err := row.Scan(
+		&os.ID,
+		&os.Name,
+		&os.Version,
+		&os.LTS,
+		&releaseDate,
+		&endOfStandardSupport,
+		&os.EndOfSupport,
+		&endOfExtendedSupport,
+		&os.CreatedAt,
+		&os.UpdatedAt,
+	)
+	if err != nil {
+		return os, err
+	}
+	os.ReleaseDate = nullTimePtr(releaseDate)
+	os.EndOfStandardSupport = nullTimePtr(endOfStandardSupport)
+	os.EndOfExtendedSupport = nullTimePtr(endOfExtendedSupport)
+	return os, nil
+}
+
+// nullTimePtr returns the time of a nullable column, or nil when it is NULL
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// parseOSDate parses an optional YYYY-MM-DD date of an operating system
+// request, returning nil when it is empty
+func parseOSDate(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s date format: %v", ErrInvalidInput, field, err)
+	}
+	return &date, nil
+}
+
+// osSortColumns maps operating system sort fields to the columns they order by
+var osSortColumns = map[string]sortColumn{
+	"id":             {expr: "id", typ: "integer"},
+	"name":           {expr: "name", typ: "text"},
+	"version":        {expr: "version", typ: "text", version: true},
+	"end_of_support": {expr: "end_of_support", typ: "date"},
+	"created_at":     {expr: "created_at", typ: "timestamptz"},
+	"updated_at":     {expr: "updated_at", typ: "timestamptz"},
+}
+
+// osWhere builds the WHERE clause and arguments for an operating system filter
+func osWhere(filter *models.OSFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter == nil {
+		return " WHERE " + strings.Join(conditions, " AND "), args
+	}
+
+	if filter.Name != nil {
+		args = append(args, *filter.Name)
+		conditions = append(conditions, fmt.Sprintf(
+			"strpos(lower(name || ' ' || version), lower($%d)) > 0", len(args)))
+	}
+	if filter.Family != nil {
+		args = append(args, *filter.Family)
+		conditions = append(conditions, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if filter.SupportStatus != nil {
+		var condition string
+		condition, args = supportStatusCondition("end_of_support", *filter.SupportStatus, filter.EndingSoonCutoff, args)
+		conditions = append(conditions, condition)
+	}
+	if filter.Phase != nil {
+		args = append(args, time.Now())
+		conditions = append(conditions, phaseCondition(*filter.Phase, len(args)))
+	}
+
+	conditions, args = timeRangeConditions(conditions, args, "created_at", filter.CreatedAfter, filter.CreatedBefore)
+	conditions, args = timeRangeConditions(conditions, args, "updated_at", filter.UpdatedAfter, filter.UpdatedBefore)
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// phaseCondition returns the SQL condition matching operating systems in a
+// lifecycle phase at the time of argument $arg, as computed by models.OS.Phase
+func phaseCondition(phase string, arg int) string {
+	switch phase {
+	case models.PhaseMaintenance:
+		return fmt.Sprintf("end_of_support >= $%d AND end_of_standard_support < $%d", arg, arg)
+	case models.PhaseExtended:
+		return fmt.Sprintf("end_of_support < $%d AND end_of_extended_support >= $%d", arg, arg)
+	case models.PhaseEndOfLife:
+		return fmt.Sprintf("end_of_support < $%d AND (end_of_extended_support IS NULL OR end_of_extended_support < $%d)", arg, arg)
+	default:
+		return fmt.Sprintf("end_of_support >= $%d AND (end_of_standard_support IS NULL OR end_of_standard_support >= $%d)", arg, arg)
+	}
+}
 
-	rows, err := r.db.Query(query)
+// GetAll retrieves operating systems from the database with optional filters, ordering and pagination
+func (r *OSRepository) GetAll(filter *models.OSFilter) ([]models.OS, error) {
+	where, args := osWhere(filter)
+	query := `SELECT ` + osColumns + ` FROM operating_systems` + where
+
+	var sortFields []models.SortField
+	if filter != nil {
+		sortFields = filter.Sort
+	}
+	sortFields = sortOrder(sortFields, models.DefaultOSSort)
+
+	// Apply keyset pagination
+	if filter != nil && filter.After != nil {
+		var condition string
+		condition, args = keysetCondition(sortFields, osSortColumns, filter.After, "id", args)
+		query += " AND " + condition
+	}
+	query += orderByClause(sortFields, osSortColumns, "id")
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query operating systems: %w", err)
 	}
@@ -326,15 +828,7 @@ func (r *OSRepository) GetAll() ([]models.OS, error) {
 
 	var oss []models.OS
 	for rows.Next() {
-		var os models.OS
-		err := rows.Scan(
-			&os.ID,
-			&os.Name,
-			&os.Version,
-			&os.EndOfSupport,
-			&os.CreatedAt,
-			&os.UpdatedAt,
-		)
+		os, err := scanOS(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan OS: %w", err)
 		}
@@ -348,27 +842,31 @@ func (r *OSRepository) GetAll() ([]models.OS, error) {
 	return oss, nil
 }
 
-// GetByID retrieves an operating system by its ID
-func (r *OSRepository) GetByID(id int) (*models.OS, error) {
-	query := `
-		SELECT id, name, version, end_of_support, created_at, updated_at
-		FROM operating_systems
-		WHERE id = $1
-	`
+// Count returns the number of operating systems matching a filter, ignoring pagination
+func (r *OSRepository) Count(filter *models.OSFilter) (int, error) {
+	where, args := osWhere(filter)
+	query := `SELECT COUNT(*) FROM operating_systems` + where
 
-	var os models.OS
-	err := r.db.QueryRow(query, id).Scan(
-		&os.ID,
-		&os.Name,
-		&os.Version,
-		&os.EndOfSupport,
-		&os.CreatedAt,
-		&os.UpdatedAt,
-	)
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count operating systems: %w", err)
+	}
+
+	return count, nil
+}
 
+// getOS retrieves an operating system by its ID, locking its row when
+// forUpdate is set so that concurrent changes are recorded in order
+func getOS(q queryer, id int, forUpdate bool) (*models.OS, error) {
+	query := `SELECT ` + osColumns + ` FROM operating_systems WHERE id = $1`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	os, err := scanOS(q.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("operating system with id %d not found", id)
+			return nil, fmt.Errorf("operating system with id %d %w", id, ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get operating system: %w", err)
 	}
@@ -376,39 +874,64 @@ func (r *OSRepository) GetByID(id int) (*models.OS, error) {
 	return &os, nil
 }
 
-// Create creates a new operating system in the database
-func (r *OSRepository) Create(req *models.CreateOSRequest) (*models.OS, error) {
-	// Parse the end of support date
+// GetByID retrieves an operating system by its ID
+func (r *OSRepository) GetByID(id int) (*models.OS, error) {
+	return getOS(r.db, id, false)
+}
+
+// Create creates a new operating system in the database and records the
+// change with the metadata of ctx in the same transaction
+func (r *OSRepository) Create(ctx context.Context, req *models.CreateOSRequest) (*models.OS, error) {
+	// Parse the lifecycle dates
 	endOfSupport, err := time.Parse("2006-01-02", req.EndOfSupport)
 	if err != nil {
-		return nil, fmt.Errorf("invalid end of support date format: %w", err)
+		return nil, fmt.Errorf("%w: invalid end of support date format: %v", ErrInvalidInput, err)
+	}
+	releaseDate, err := parseOSDate("release", req.ReleaseDate)
+	if err != nil {
+		return nil, err
+	}
+	endOfStandardSupport, err := parseOSDate("end of standard support", req.EndOfStandardSupport)
+	if err != nil {
+		return nil, err
+	}
+	endOfExtendedSupport, err := parseOSDate("end of extended support", req.EndOfExtendedSupport)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
-		INSERT INTO operating_systems (name, version, end_of_support, created_at, updated_at)
-		VALUES ($1, $2, $3, NOW(), NOW())
-		RETURNING id, name, version, end_of_support, created_at, updated_at
-	`
+		INSERT INTO operating_systems (name, version, lts, release_date, end_of_standard_support, end_of_support, end_of_extended_support, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING ` + osColumns
 
-	var os models.OS
-	err = r.db.QueryRow(query, req.Name, req.Version, endOfSupport).Scan(
-		&os.ID,
-		&os.Name,
-		&os.Version,
-		&os.EndOfSupport,
-		&os.CreatedAt,
-		&os.UpdatedAt,
-	)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
+	os, err := scanOS(tx.QueryRow(query, req.Name, req.Version, req.LTS, releaseDate, endOfStandardSupport, endOfSupport, endOfExtendedSupport))
 	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("operating system %s %s already exists: %w", req.Name, req.Version, ErrConflict)
+		}
 		return nil, fmt.Errorf("failed to create operating system: %w", err)
 	}
 
+	if err := recordOSChange(ctx, tx, nil, &os); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit operating system creation: %w", err)
+	}
 	return &os, nil
 }
 
-// Update updates an existing operating system in the database
-func (r *OSRepository) Update(id int, req *models.UpdateOSRequest) (*models.OS, error) {
+// Update updates an existing operating system in the database and records
+// the change with the metadata of ctx in the same transaction
+func (r *OSRepository) Update(ctx context.Context, id int, req *models.UpdateOSRequest) (*models.OS, error) {
 	// Build dynamic update query
 	setParts := []string{}
 	args := []interface{}{}
@@ -429,13 +952,49 @@ func (r *OSRepository) Update(id int, req *models.UpdateOSRequest) (*models.OS,
 	if req.EndOfSupport != "" {
 		endOfSupport, err := time.Parse("2006-01-02", req.EndOfSupport)
 		if err != nil {
-			return nil, fmt.Errorf("invalid end of support date format: %w", err)
+			return nil, fmt.Errorf("%w: invalid end of support date format: %v", ErrInvalidInput, err)
 		}
 		setParts = append(setParts, fmt.Sprintf("end_of_support = $%d", argCount))
 		args = append(args, endOfSupport)
 		argCount++
 	}
 
+	if req.ReleaseDate != "" {
+		releaseDate, err := parseOSDate("release", req.ReleaseDate)
+		if err != nil {
+			return nil, err
+		}
+		setParts = append(setParts, fmt.Sprintf("release_date = $%d", argCount))
+		args = append(args, *releaseDate)
+		argCount++
+	}
+
+	if req.EndOfStandardSupport != "" {
+		endOfStandardSupport, err := parseOSDate("end of standard support", req.EndOfStandardSupport)
+		if err != nil {
+			return nil, err
+		}
+		setParts = append(setParts, fmt.Sprintf("end_of_standard_support = $%d", argCount))
+		args = append(args, *endOfStandardSupport)
+		argCount++
+	}
+
+	if req.EndOfExtendedSupport != "" {
+		endOfExtendedSupport, err := parseOSDate("end of extended support", req.EndOfExtendedSupport)
+		if err != nil {
+			return nil, err
+		}
+		setParts = append(setParts, fmt.Sprintf("end_of_extended_support = $%d", argCount))
+		args = append(args, *endOfExtendedSupport)
+		argCount++
+	}
+
+	if req.LTS != nil {
+		setParts = append(setParts, fmt.Sprintf("lts = $%d", argCount))
+		args = append(args, *req.LTS)
+		argCount++
+	}
+
 	if len(setParts) == 0 {
 		return r.GetByID(id) // No updates, return existing OS
 	}
@@ -448,71 +1007,85 @@ func (r *OSRepository) Update(id int, req *models.UpdateOSRequest) (*models.OS,
 	// Add the ID for the WHERE clause
 	args = append(args, id)
 
-	// Build the SET clause
-	setClause := ""
-	for i, part := range setParts {
-		if i > 0 {
-			setClause += ", "
-		}
-		setClause += part
-	}
-
 	query := fmt.Sprintf(`
 		UPDATE operating_systems
 		SET %s
 		WHERE id = $%d
-		RETURNING id, name, version, end_of_support, created_at, updated_at
-	`, setClause, argCount)
+		RETURNING %s
+	`, strings.Join(setParts, ", "), argCount, osColumns)
 
-	var os models.OS
-	err := r.db.QueryRow(query, args...).Scan(
-		&os.ID,
-		&os.Name,
-		&os.Version,
-		&os.EndOfSupport,
-		&os.CreatedAt,
-		&os.UpdatedAt,
-	)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
+	before, err := getOS(tx, id, true)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("operating system with id %d not found", id)
+		return nil, err
+	}
+
+	os, err := scanOS(tx.QueryRow(query, args...))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("operating system with the same name and version already exists: %w", ErrConflict)
 		}
 		return nil, fmt.Errorf("failed to update operating system: %w", err)
 	}
 
+	if err := recordOSChange(ctx, tx, before, &os); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit operating system update: %w", err)
+	}
 	return &os, nil
 }
 
-// Delete removes an operating system from the database
-func (r *OSRepository) Delete(id int) error {
+// Delete removes an operating system from the database and records the
+// change with the metadata of ctx in the same transaction
+func (r *OSRepository) Delete(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	os, err := getOS(tx, id, true)
+	if err != nil {
+		return err
+	}
+
 	// Check if any servers are using this OS
 	var count int
 	checkQuery := `SELECT COUNT(*) FROM servers WHERE os_id = $1`
-	err := r.db.QueryRow(checkQuery, id).Scan(&count)
-	if err != nil {
+	if err := tx.QueryRow(checkQuery, id).Scan(&count); err != nil {
 		return fmt.Errorf("failed to check OS usage: %w", err)
 	}
 	if count > 0 {
-		return fmt.Errorf("cannot delete operating system: %d servers are using it", count)
+		return fmt.Errorf("cannot delete operating system: %d servers are using it: %w", count, ErrConflict)
 	}
 
-	query := `DELETE FROM operating_systems WHERE id = $1`
-
-	result, err := r.db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete operating system: %w", err)
+	// Recorded first: deleting the operating system sets os_id to NULL on its
+	// history and waivers
+	if err := recordOSChange(ctx, tx, os, nil); err != nil {
+		return err
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if err := recordDeletedOSWaivers(tx, os); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("operating system with id %d not found", id)
+	if _, err := tx.Exec(`DELETE FROM operating_systems WHERE id = $1`, id); err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("cannot delete operating system: servers, scheduled changes or campaigns reference it: %w", ErrConflict)
+		}
+		return fmt.Errorf("failed to delete operating system: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit operating system deletion: %w", err)
+	}
 	return nil
 }
 
@@ -526,53 +1099,135 @@ func NewChangeHistoryRepository(db *DB) *ChangeHistoryRepository {
 	return &ChangeHistoryRepository{db: db}
 }
 
-// GetAll retrieves all change history records with optional filters
-func (r *ChangeHistoryRepository) GetAll(filter *models.ChangeHistoryFilter) ([]models.ServerChangeHistory, error) {
-	query := `
+// changeHistorySelect selects change history records in the column order
+// expected by scanChangeHistory
+const changeHistorySelect = `
 		SELECT id, server_id, server_name, change_type,
 		       old_os_id, new_os_id, old_os_name, old_os_version,
-		       new_os_name, new_os_version, changed_at
+		       new_os_name, new_os_version, environment, role, owner_team, location,
+		       changed_by, request_id, source, changes, changed_at
 		FROM server_change_history
-		WHERE 1=1
+`
+
+// scanChangeHistory scans a row produced by changeHistorySelect
+func scanChangeHistory(row rowScanner) (models.ServerChangeHistory, error) {
+	var record models.ServerChangeHistory
+	var changes []byte
+	err := row.Scan(
+		&record.ID,
+		&record.ServerID,
+		&record.ServerName,
+		&record.ChangeType,
+		&record.OldOSID,
+		&record.NewOSID,
+		&record.OldOSName,
+		&record.OldOSVersion,
+		&record.NewOSName,
+		&record.NewOSVersion,
+		&record.Environment,
+		&record.Role,
+		&record.OwnerTeam,
+		&record.Location,
+		&record.ChangedBy,
+		&record.RequestID,
+		&record.Source,
+		&changes,
+		&record.ChangedAt,
+	)
+	if err != nil {
+		return record, err
+	}
+	// Records written by the former triggers have no diff
+	if changes != nil {
+		if err := json.Unmarshal(changes, &record.Changes); err != nil {
+			return record, fmt.Errorf("failed to decode changes: %w", err)
+		}
+	}
+	return record, nil
+}
+
+// recordServerChange inserts the change history record of a server mutation
+// within its transaction. See models.NewServerChange for before and after.
+func recordServerChange(ctx context.Context, q queryer, before, after *models.Server) error {
+	record, changed := models.NewServerChange(before, after, models.ChangeMetadataFromContext(ctx))
+	if !changed {
+		return nil
+	}
+
+	changes, err := json.Marshal(record.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO server_change_history (
+			server_id, server_name, change_type,
+			old_os_id, new_os_id, old_os_name, old_os_version, new_os_name, new_os_version,
+			environment, role, owner_team, location,
+			changed_by, request_id, source, changes
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
-	args := []interface{}{}
-	argCount := 1
 
-	// Apply filters
+	_, err = q.Exec(query,
+		record.ServerID,
+		record.ServerName,
+		record.ChangeType,
+		record.OldOSID,
+		record.NewOSID,
+		record.OldOSName,
+		record.OldOSVersion,
+		record.NewOSName,
+		record.NewOSVersion,
+		record.Environment,
+		record.Role,
+		record.OwnerTeam,
+		record.Location,
+		record.ChangedBy,
+		record.RequestID,
+		record.Source,
+		string(changes),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record server change: %w", err)
+	}
+
+	return nil
+}
+
+// changeHistoryWhere builds the WHERE clause for a server change history
+// filter, appending its arguments to args
+func changeHistoryWhere(filter *models.ChangeHistoryFilter, args []interface{}) (string, []interface{}) {
+	conditions := []string{"1=1"}
+
 	if filter != nil {
 		if filter.ServerID != nil {
-			query += fmt.Sprintf(" AND server_id = $%d", argCount)
 			args = append(args, *filter.ServerID)
-			argCount++
+			conditions = append(conditions, fmt.Sprintf("server_id = $%d", len(args)))
 		}
 		if filter.ChangeType != nil {
-			query += fmt.Sprintf(" AND change_type = $%d", argCount)
 			args = append(args, *filter.ChangeType)
-			argCount++
-		}
-		if filter.StartDate != nil {
-			query += fmt.Sprintf(" AND changed_at >= $%d", argCount)
-			args = append(args, *filter.StartDate)
-			argCount++
-		}
-		if filter.EndDate != nil {
-			query += fmt.Sprintf(" AND changed_at <= $%d", argCount)
-			args = append(args, *filter.EndDate)
-			argCount++
+			conditions = append(conditions, fmt.Sprintf("change_type = $%d", len(args)))
 		}
+		conditions, args = timeRangeConditions(conditions, args, "changed_at", filter.StartDate, filter.EndDate)
 	}
 
-	query += " ORDER BY changed_at DESC"
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAll retrieves all change history records with optional filters
+func (r *ChangeHistoryRepository) GetAll(filter *models.ChangeHistoryFilter) ([]models.ServerChangeHistory, error) {
+	where, args := changeHistoryWhere(filter, nil)
+	query := changeHistorySelect + where + " ORDER BY changed_at DESC, id DESC"
 
 	// Apply pagination
 	if filter != nil && filter.Limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d", argCount)
 		args = append(args, filter.Limit)
-		argCount++
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
 	}
 	if filter != nil && filter.Offset > 0 {
-		query += fmt.Sprintf(" OFFSET $%d", argCount)
 		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
 	}
 
 	rows, err := r.db.Query(query, args...)
@@ -583,20 +1238,7 @@ func (r *ChangeHistoryRepository) GetAll(filter *models.ChangeHistoryFilter) ([]
 
 	var history []models.ServerChangeHistory
 	for rows.Next() {
-		var record models.ServerChangeHistory
-		err := rows.Scan(
-			&record.ID,
-			&record.ServerID,
-			&record.ServerName,
-			&record.ChangeType,
-			&record.OldOSID,
-			&record.NewOSID,
-			&record.OldOSName,
-			&record.OldOSVersion,
-			&record.NewOSName,
-			&record.NewOSVersion,
-			&record.ChangedAt,
-		)
+		record, err := scanChangeHistory(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan change history row: %w", err)
 		}
@@ -621,32 +1263,15 @@ func (r *ChangeHistoryRepository) GetByServerID(serverID int, limit int) ([]mode
 
 // GetByID retrieves a single change history record by its ID
 func (r *ChangeHistoryRepository) GetByID(id int) (*models.ServerChangeHistory, error) {
-	query := `
-		SELECT id, server_id, server_name, change_type,
-		       old_os_id, new_os_id, old_os_name, old_os_version,
-		       new_os_name, new_os_version, changed_at
-		FROM server_change_history
+	query := changeHistorySelect + `
 		WHERE id = $1
 	`
 
-	var record models.ServerChangeHistory
-	err := r.db.QueryRow(query, id).Scan(
-		&record.ID,
-		&record.ServerID,
-		&record.ServerName,
-		&record.ChangeType,
-		&record.OldOSID,
-		&record.NewOSID,
-		&record.OldOSName,
-		&record.OldOSVersion,
-		&record.NewOSName,
-		&record.NewOSVersion,
-		&record.ChangedAt,
-	)
+	record, err := scanChangeHistory(r.db.QueryRow(query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("change history record with id %d not found", id)
+			return nil, fmt.Errorf("change history record with id %d %w", id, ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get change history record: %w", err)
 	}
@@ -0,0 +1,928 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// MemoryDB is a thread-safe in-memory data store that mirrors the behaviour
+// of the PostgreSQL repositories, including their unique constraints, foreign
+// keys and change history. It is intended for demos and tests.
+type MemoryDB struct {
+	mu sync.RWMutex
+
+	servers   map[int]models.Server
+	oss       map[int]models.OS
+	history   []models.ServerChangeHistory
+	osHistory []models.OSChangeHistory
+	waivers   map[int]models.Waiver
+	campaigns map[int]models.Campaign
+	changes   map[int]models.ScheduledChange
+	snapshots []models.ComplianceSnapshot
+	apiKeys   map[int]memoryAPIKey
+
+	nextServerID    int
+	nextOSID        int
+	nextHistoryID   int
+	nextOSHistoryID int
+	nextWaiverID    int
+	nextCampaignID  int
+	nextChangeID    int
+	nextSnapshotID  int
+	nextAPIKeyID    int
+
+	// now returns the current time and can be replaced in tests
+	now func() time.Time
+}
+
+// NewMemoryDB creates an empty in-memory database
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		servers:         make(map[int]models.Server),
+		oss:             make(map[int]models.OS),
+		waivers:         make(map[int]models.Waiver),
+		campaigns:       make(map[int]models.Campaign),
+		changes:         make(map[int]models.ScheduledChange),
+		apiKeys:         make(map[int]memoryAPIKey),
+		nextServerID:    1,
+		nextOSID:        1,
+		nextHistoryID:   1,
+		nextOSHistoryID: 1,
+		nextWaiverID:    1,
+		nextCampaignID:  1,
+		nextChangeID:    1,
+		nextSnapshotID:  1,
+		nextAPIKeyID:    1,
+		now:             time.Now,
+	}
+}
+
+// memoryAPIKey is an API key stored along with the hash of its secret
+type memoryAPIKey struct {
+	models.APIKey
+	hash string
+}
+
+// withOS returns a copy of the server with its operating system attached.
+// The caller must hold the lock.
+func (db *MemoryDB) withOS(server models.Server) models.Server {
+	if os, exists := db.oss[server.OSID]; exists {
+		server.OS = &os
+	}
+	return server
+}
+
+// serverNameTaken reports whether another server already uses the name.
+// The caller must hold the lock.
+func (db *MemoryDB) serverNameTaken(name string, exceptID int) bool {
+	for id, server := range db.servers {
+		if id != exceptID && server.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// osTaken reports whether another operating system already uses the name and
+// version. The caller must hold the lock.
+func (db *MemoryDB) osTaken(name, version string, exceptID int) bool {
+	for id, os := range db.oss {
+		if id != exceptID && os.Name == name && os.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// recordChange appends the change history record of a server mutation,
+// mirroring the database repository. Servers must have their operating
+// system attached. The caller must hold the write lock.
+func (db *MemoryDB) recordChange(ctx context.Context, before, after *models.Server) {
+	record, changed := models.NewServerChange(before, after, models.ChangeMetadataFromContext(ctx))
+	if !changed {
+		return
+	}
+
+	record.ID = db.nextHistoryID
+	record.ChangedAt = db.now()
+	db.nextHistoryID++
+	db.history = append(db.history, record)
+}
+
+// supportStatusMatches reports whether an end of support date has the given
+// support status, mirroring supportStatusCondition
+func supportStatusMatches(endOfSupport time.Time, status string, cutoff *time.Time, now time.Time) bool {
+	endingSoonCutoff := endingSoonCutoff(cutoff, now)
+
+	switch status {
+	case models.StatusEndOfLife:
+		return endOfSupport.Before(now)
+	case models.StatusEndingSoon:
+		return endOfSupport.After(now) && endOfSupport.Before(endingSoonCutoff)
+	default:
+		return !endOfSupport.Before(endingSoonCutoff)
+	}
+}
+
+// timeInRange reports whether t lies within the optional inclusive bounds
+func timeInRange(t time.Time, after, before *time.Time) bool {
+	if after != nil && t.Before(*after) {
+		return false
+	}
+	if before != nil && t.After(*before) {
+		return false
+	}
+	return true
+}
+
+// compareByFields compares two items by the given fields, then by ID
+func compareByFields[T any](a, b T, fields []models.SortField, compare func(a, b T, field string) int, id func(T) int) int {
+	for _, f := range fields {
+		c := compare(a, b, f.Field)
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(id(a), id(b))
+}
+
+// sortByFields sorts items by the given fields, breaking ties by ID so that
+// pages are stable, mirroring orderByClause
+func sortByFields[T any](items []T, fields []models.SortField, compare func(a, b T, field string) int, id func(T) int) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return compareByFields(items[i], items[j], fields, compare, id) < 0
+	})
+}
+
+// seekAfter returns the items of a slice sorted by sortByFields that sort
+// after the cursor item, mirroring keysetCondition
+func seekAfter[T any](items []T, cursor T, fields []models.SortField, compare func(a, b T, field string) int, id func(T) int) []T {
+	i := sort.Search(len(items), func(i int) bool {
+		return compareByFields(items[i], cursor, fields, compare, id) > 0
+	})
+	return items[i:]
+}
+
+// paginate applies an offset and a limit to a slice
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return nil
+		}
+		items = items[offset:]
+	}
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// MemoryServerRepository provides in-memory operations for servers
+type MemoryServerRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryServerRepository creates a new in-memory server repository
+func NewMemoryServerRepository(db *MemoryDB) *MemoryServerRepository {
+	return &MemoryServerRepository{db: db}
+}
+
+// GetAll retrieves servers with optional filters, ordering and pagination
+func (r *MemoryServerRepository) GetAll(filter *models.ServerFilter) ([]models.Server, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return pageServers(r.matching(filter), filter)
+}
+
+// Count returns the number of servers matching a filter, ignoring pagination
+func (r *MemoryServerRepository) Count(filter *models.ServerFilter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// matching returns the servers satisfying a filter with their OS attached.
+// The caller must hold the lock.
+func (r *MemoryServerRepository) matching(filter *models.ServerFilter) []models.Server {
+	if filter != nil && filter.AsOf != nil {
+		oss := make([]models.OS, 0, len(r.db.oss))
+		for _, os := range r.db.oss {
+			oss = append(oss, os)
+		}
+		return serversAsOf(r.db.history, oss, filter)
+	}
+
+	now := r.db.now()
+
+	var servers []models.Server
+	for _, server := range r.db.servers {
+		server = r.db.withOS(server)
+		if matchesServerFilter(server, filter, now) {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers
+}
+
+// serversAsOf rebuilds the servers that existed at filter.AsOf from the
+// change history and returns those satisfying the filter at that time
+func serversAsOf(history []models.ServerChangeHistory, oss []models.OS, filter *models.ServerFilter) []models.Server {
+	var servers []models.Server
+	for _, server := range models.ReplayServerHistory(history, oss, *filter.AsOf) {
+		if matchesServerFilter(server, filter, *filter.AsOf) {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// pageServers orders servers by the filter sort fields and applies its
+// pagination, mirroring orderByClause and keysetCondition
+func pageServers(servers []models.Server, filter *models.ServerFilter) ([]models.Server, error) {
+	var sortFields []models.SortField
+	if filter != nil {
+		sortFields = filter.Sort
+	}
+	sortFields = sortOrder(sortFields, models.DefaultServerSort)
+	id := func(s models.Server) int { return s.ID }
+	sortByFields(servers, sortFields, compareServerField, id)
+
+	if filter != nil && filter.After != nil {
+		after, err := filter.After.Server(sortFields)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		servers = seekAfter(servers, after, sortFields, compareServerField, id)
+	}
+	if filter != nil {
+		servers = paginate(servers, filter.Limit, 0)
+	}
+
+	return servers, nil
+}
+
+// matchesServerFilter reports whether a server satisfies every set filter
+func matchesServerFilter(server models.Server, filter *models.ServerFilter, now time.Time) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.Name != nil && !strings.Contains(strings.ToLower(server.Name), strings.ToLower(*filter.Name)) {
+		return false
+	}
+	if filter.Family != nil && (server.OS == nil || server.OS.Name != *filter.Family) {
+		return false
+	}
+	if filter.OSID != nil && server.OSID != *filter.OSID {
+		return false
+	}
+	if filter.SupportStatus != nil && (server.OS == nil || !supportStatusMatches(server.EndOfSupport(), *filter.SupportStatus, filter.EndingSoonCutoff, now)) {
+		return false
+	}
+
+	for _, f := range []struct {
+		value  string
+		filter *string
+	}{
+		{server.Environment, filter.Environment},
+		{server.Role, filter.Role},
+		{server.OwnerTeam, filter.OwnerTeam},
+		{server.Location, filter.Location},
+	} {
+		if f.filter != nil && f.value != *f.filter {
+			return false
+		}
+	}
+
+	if filter.Stale != nil {
+		cutoff, enabled := staleCutoff(filter.StaleCutoff, now)
+		stale := enabled && server.LastSeenAt != nil && server.LastSeenAt.Before(cutoff)
+		if stale != *filter.Stale {
+			return false
+		}
+	}
+
+	return timeInRange(server.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) &&
+		timeInRange(server.UpdatedAt, filter.UpdatedAfter, filter.UpdatedBefore)
+}
+
+// compareServerField compares two servers on a sort field
+func compareServerField(a, b models.Server, field string) int {
+	var osA, osB models.OS
+	if a.OS != nil {
+		osA = *a.OS
+	}
+	if b.OS != nil {
+		osB = *b.OS
+	}
+
+	switch field {
+	case "id":
+		return compareInts(a.ID, b.ID)
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "os_name":
+		return strings.Compare(osA.Name, osB.Name)
+	case "os_version":
+		return models.CompareVersions(osA.Version, osB.Version)
+	case "end_of_support":
+		return osA.EndOfSupport.Compare(osB.EndOfSupport)
+	case "environment":
+		return strings.Compare(a.Environment, b.Environment)
+	case "role":
+		return strings.Compare(a.Role, b.Role)
+	case "owner_team":
+		return strings.Compare(a.OwnerTeam, b.OwnerTeam)
+	case "location":
+		return strings.Compare(a.Location, b.Location)
+	case "last_seen_at":
+		return compareOptionalTimes(a.LastSeenAt, b.LastSeenAt)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return 0
+}
+
+// compareOptionalTimes compares two optional times, ordering nil after any
+// time as PostgreSQL orders NULL values
+func compareOptionalTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// GetByID retrieves a server by its ID
+func (r *MemoryServerRepository) GetByID(id int) (*models.Server, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	server, exists := r.db.servers[id]
+	if !exists {
+		return nil, fmt.Errorf("server with id %d %w", id, ErrNotFound)
+	}
+
+	server = r.db.withOS(server)
+	return &server, nil
+}
+
+// Create creates a new server, recording the change with the metadata of ctx
+func (r *MemoryServerRepository) Create(ctx context.Context, req *models.CreateServerRequest) (*models.Server, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, exists := r.db.oss[req.OSID]; !exists {
+		return nil, fmt.Errorf("operating system with id %d does not exist: %w", req.OSID, ErrInvalidReference)
+	}
+	if r.db.serverNameTaken(req.Name, 0) {
+		return nil, fmt.Errorf("server with name %q already exists: %w", req.Name, ErrConflict)
+	}
+
+	now := r.db.now()
+	server := models.Server{
+		ID:          r.db.nextServerID,
+		Name:        req.Name,
+		OSID:        req.OSID,
+		Environment: req.Environment,
+		Role:        req.Role,
+		OwnerTeam:   req.OwnerTeam,
+		Location:    req.Location,
+		Description: req.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+
+		ExtendedSupport: req.ExtendedSupport,
+	}
+	r.db.nextServerID++
+	r.db.servers[server.ID] = server
+
+	server = r.db.withOS(server)
+	r.db.recordChange(ctx, nil, &server)
+	return &server, nil
+}
+
+// Update updates an existing server, recording the change with the metadata of ctx
+func (r *MemoryServerRepository) Update(ctx context.Context, id int, req *models.UpdateServerRequest) (*models.Server, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if req.OSID != 0 {
+		if _, exists := r.db.oss[req.OSID]; !exists {
+			return nil, fmt.Errorf("operating system with id %d does not exist: %w", req.OSID, ErrInvalidReference)
+		}
+	}
+
+	server, exists := r.db.servers[id]
+	if !exists {
+		return nil, fmt.Errorf("server with id %d %w", id, ErrNotFound)
+	}
+
+	if req.Name == "" && req.OSID == 0 && req.Environment == "" && req.Role == "" &&
+		req.OwnerTeam == "" && req.Location == "" && req.Description == "" && req.ExtendedSupport == nil {
+		server = r.db.withOS(server)
+		return &server, nil // No updates, return existing server
+	}
+
+	if req.Name != "" {
+		if r.db.serverNameTaken(req.Name, id) {
+			return nil, fmt.Errorf("server with name %q already exists: %w", req.Name, ErrConflict)
+		}
+		server.Name = req.Name
+	}
+
+	before := r.db.withOS(r.db.servers[id])
+	if req.OSID != 0 {
+		server.OSID = req.OSID
+	}
+	if req.Environment != "" {
+		server.Environment = req.Environment
+	}
+	if req.Role != "" {
+		server.Role = req.Role
+	}
+	if req.OwnerTeam != "" {
+		server.OwnerTeam = req.OwnerTeam
+	}
+	if req.Location != "" {
+		server.Location = req.Location
+	}
+	if req.Description != "" {
+		server.Description = req.Description
+	}
+	if req.ExtendedSupport != nil {
+		server.ExtendedSupport = *req.ExtendedSupport
+	}
+
+	server.UpdatedAt = r.db.now()
+	r.db.servers[id] = server
+
+	server = r.db.withOS(server)
+	r.db.recordChange(ctx, &before, &server)
+	return &server, nil
+}
+
+// Delete removes a server, recording the change with the metadata of ctx
+func (r *MemoryServerRepository) Delete(ctx context.Context, id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	server, exists := r.db.servers[id]
+	if !exists {
+		return fmt.Errorf("server with id %d %w", id, ErrNotFound)
+	}
+
+	// Mirror ON DELETE RESTRICT on campaign_servers.server_id
+	for _, campaign := range r.db.campaigns {
+		for _, serverID := range campaign.ServerIDs {
+			if serverID == id {
+				return fmt.Errorf("cannot delete server: campaigns list it: %w", ErrConflict)
+			}
+		}
+	}
+
+	server = r.db.withOS(server)
+	r.db.recordChange(ctx, &server, nil)
+	delete(r.db.servers, id)
+
+	// Mirror ON DELETE SET NULL on server_change_history.server_id
+	for i := range r.db.history {
+		if r.db.history[i].ServerID != nil && *r.db.history[i].ServerID == id {
+			r.db.history[i].ServerID = nil
+		}
+	}
+
+	// Mirror recordDeletedServerWaivers and ON DELETE SET NULL on
+	// compliance_waivers.server_id
+	for waiverID, waiver := range r.db.waivers {
+		if waiver.ServerID != nil && *waiver.ServerID == id {
+			waiver.ServerID = nil
+			waiver.ServerName = server.Name
+			waiver.UpdatedAt = r.db.now()
+			r.db.waivers[waiverID] = waiver
+		}
+	}
+
+	// Mirror resolveDeletedServerChanges and ON DELETE SET NULL on
+	// scheduled_changes.server_id
+	now := r.db.now()
+	for changeID, change := range r.db.changes {
+		if change.ServerID == nil || *change.ServerID != id {
+			continue
+		}
+		if status, open := deletedServerResolutions[change.Status]; open {
+			change.Status = status
+			change.Reason = serverDeletedReason
+			change.ResolvedAt = &now
+		}
+		change.ServerID = nil
+		change.ServerName = server.Name
+		change.UpdatedAt = now
+		r.db.changes[changeID] = change
+	}
+
+	return nil
+}
+
+// MemoryOSRepository provides in-memory operations for operating systems
+type MemoryOSRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryOSRepository creates a new in-memory OS repository
+func NewMemoryOSRepository(db *MemoryDB) *MemoryOSRepository {
+	return &MemoryOSRepository{db: db}
+}
+
+// GetAll retrieves operating systems with optional filters, ordering and pagination
+func (r *MemoryOSRepository) GetAll(filter *models.OSFilter) ([]models.OS, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	oss := r.matching(filter)
+
+	var sortFields []models.SortField
+	if filter != nil {
+		sortFields = filter.Sort
+	}
+	sortFields = sortOrder(sortFields, models.DefaultOSSort)
+	id := func(os models.OS) int { return os.ID }
+	sortByFields(oss, sortFields, compareOSField, id)
+
+	if filter != nil && filter.After != nil {
+		after, err := filter.After.OS(sortFields)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		oss = seekAfter(oss, after, sortFields, compareOSField, id)
+	}
+	if filter != nil {
+		oss = paginate(oss, filter.Limit, 0)
+	}
+
+	return oss, nil
+}
+
+// Count returns the number of operating systems matching a filter, ignoring pagination
+func (r *MemoryOSRepository) Count(filter *models.OSFilter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// matching returns the operating systems satisfying a filter. The caller
+// must hold the lock.
+func (r *MemoryOSRepository) matching(filter *models.OSFilter) []models.OS {
+	now := r.db.now()
+
+	var oss []models.OS
+	for _, os := range r.db.oss {
+		if filter != nil {
+			if filter.Name != nil && !strings.Contains(strings.ToLower(os.Name+" "+os.Version), strings.ToLower(*filter.Name)) {
+				continue
+			}
+			if filter.Family != nil && os.Name != *filter.Family {
+				continue
+			}
+			if filter.SupportStatus != nil && !supportStatusMatches(os.EndOfSupport, *filter.SupportStatus, filter.EndingSoonCutoff, now) {
+				continue
+			}
+			if filter.Phase != nil && os.Phase(now) != *filter.Phase {
+				continue
+			}
+			if !timeInRange(os.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) ||
+				!timeInRange(os.UpdatedAt, filter.UpdatedAfter, filter.UpdatedBefore) {
+				continue
+			}
+		}
+		oss = append(oss, os)
+	}
+
+	return oss
+}
+
+// compareOSField compares two operating systems on a sort field
+func compareOSField(a, b models.OS, field string) int {
+	switch field {
+	case "id":
+		return compareInts(a.ID, b.ID)
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "version":
+		return models.CompareVersions(a.Version, b.Version)
+	case "end_of_support":
+		return a.EndOfSupport.Compare(b.EndOfSupport)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return 0
+}
+
+// GetByID retrieves an operating system by its ID
+func (r *MemoryOSRepository) GetByID(id int) (*models.OS, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	os, exists := r.db.oss[id]
+	if !exists {
+		return nil, fmt.Errorf("operating system with id %d %w", id, ErrNotFound)
+	}
+
+	return &os, nil
+}
+
+// Create creates a new operating system, recording the change with the metadata of ctx
+func (r *MemoryOSRepository) Create(ctx context.Context, req *models.CreateOSRequest) (*models.OS, error) {
+	endOfSupport, err := time.Parse("2006-01-02", req.EndOfSupport)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid end of support date format: %v", ErrInvalidInput, err)
+	}
+	releaseDate, err := parseOSDate("release", req.ReleaseDate)
+	if err != nil {
+		return nil, err
+	}
+	endOfStandardSupport, err := parseOSDate("end of standard support", req.EndOfStandardSupport)
+	if err != nil {
+		return nil, err
+	}
+	endOfExtendedSupport, err := parseOSDate("end of extended support", req.EndOfExtendedSupport)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.osTaken(req.Name, req.Version, 0) {
+		return nil, fmt.Errorf("operating system %s %s already exists: %w", req.Name, req.Version, ErrConflict)
+	}
+
+	now := r.db.now()
+	os := models.OS{
+		ID:                   r.db.nextOSID,
+		Name:                 req.Name,
+		Version:              req.Version,
+		LTS:                  req.LTS,
+		ReleaseDate:          releaseDate,
+		EndOfStandardSupport: endOfStandardSupport,
+		EndOfSupport:         endOfSupport,
+		EndOfExtendedSupport: endOfExtendedSupport,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	r.db.nextOSID++
+	r.db.oss[os.ID] = os
+	r.db.recordOSChange(ctx, nil, &os)
+
+	return &os, nil
+}
+
+// Update updates an existing operating system, recording the change with the metadata of ctx
+func (r *MemoryOSRepository) Update(ctx context.Context, id int, req *models.UpdateOSRequest) (*models.OS, error) {
+	var endOfSupport time.Time
+	if req.EndOfSupport != "" {
+		parsed, err := time.Parse("2006-01-02", req.EndOfSupport)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid end of support date format: %v", ErrInvalidInput, err)
+		}
+		endOfSupport = parsed
+	}
+	releaseDate, err := parseOSDate("release", req.ReleaseDate)
+	if err != nil {
+		return nil, err
+	}
+	endOfStandardSupport, err := parseOSDate("end of standard support", req.EndOfStandardSupport)
+	if err != nil {
+		return nil, err
+	}
+	endOfExtendedSupport, err := parseOSDate("end of extended support", req.EndOfExtendedSupport)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	os, exists := r.db.oss[id]
+	if !exists {
+		return nil, fmt.Errorf("operating system with id %d %w", id, ErrNotFound)
+	}
+
+	if req.Name == "" && req.Version == "" && req.EndOfSupport == "" && req.LTS == nil &&
+		releaseDate == nil && endOfStandardSupport == nil && endOfExtendedSupport == nil {
+		return &os, nil // No updates, return existing OS
+	}
+
+	before := os
+	if req.Name != "" {
+		os.Name = req.Name
+	}
+	if req.Version != "" {
+		os.Version = req.Version
+	}
+	if req.EndOfSupport != "" {
+		os.EndOfSupport = endOfSupport
+	}
+	if req.LTS != nil {
+		os.LTS = *req.LTS
+	}
+	if releaseDate != nil {
+		os.ReleaseDate = releaseDate
+	}
+	if endOfStandardSupport != nil {
+		os.EndOfStandardSupport = endOfStandardSupport
+	}
+	if endOfExtendedSupport != nil {
+		os.EndOfExtendedSupport = endOfExtendedSupport
+	}
+
+	if r.db.osTaken(os.Name, os.Version, id) {
+		return nil, fmt.Errorf("operating system with the same name and version already exists: %w", ErrConflict)
+	}
+
+	os.UpdatedAt = r.db.now()
+	r.db.oss[id] = os
+	r.db.recordOSChange(ctx, &before, &os)
+
+	return &os, nil
+}
+
+// Delete removes an operating system that is not used by any server,
+// recording the change with the metadata of ctx
+func (r *MemoryOSRepository) Delete(ctx context.Context, id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	count := 0
+	for _, server := range r.db.servers {
+		if server.OSID == id {
+			count++
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("cannot delete operating system: %d servers are using it: %w", count, ErrConflict)
+	}
+
+	// Mirror ON DELETE RESTRICT on scheduled_changes.os_id
+	for _, change := range r.db.changes {
+		if change.OSID == id {
+			return fmt.Errorf("cannot delete operating system: scheduled changes target it: %w", ErrConflict)
+		}
+	}
+
+	// Mirror ON DELETE RESTRICT on the upgrade_campaigns OS references
+	for _, campaign := range r.db.campaigns {
+		if campaign.SourceOSID == id || campaign.TargetOSID == id {
+			return fmt.Errorf("cannot delete operating system: campaigns upgrade from or to it: %w", ErrConflict)
+		}
+	}
+
+	os, exists := r.db.oss[id]
+	if !exists {
+		return fmt.Errorf("operating system with id %d %w", id, ErrNotFound)
+	}
+	r.db.recordOSChange(ctx, &os, nil)
+	delete(r.db.oss, id)
+
+	// Mirror ON DELETE SET NULL on the change history OS references
+	for i := range r.db.history {
+		if r.db.history[i].OldOSID != nil && *r.db.history[i].OldOSID == id {
+			r.db.history[i].OldOSID = nil
+		}
+		if r.db.history[i].NewOSID != nil && *r.db.history[i].NewOSID == id {
+			r.db.history[i].NewOSID = nil
+		}
+	}
+	for i := range r.db.osHistory {
+		if r.db.osHistory[i].OSID != nil && *r.db.osHistory[i].OSID == id {
+			r.db.osHistory[i].OSID = nil
+		}
+	}
+
+	// Mirror recordDeletedOSWaivers and ON DELETE SET NULL on
+	// compliance_waivers.os_id
+	for waiverID, waiver := range r.db.waivers {
+		if waiver.OSID != nil && *waiver.OSID == id {
+			waiver.OSID = nil
+			waiver.OSName, waiver.OSVersion = os.Name, os.Version
+			waiver.UpdatedAt = r.db.now()
+			r.db.waivers[waiverID] = waiver
+		}
+	}
+
+	return nil
+}
+
+// MemoryChangeHistoryRepository provides in-memory access to server change history
+type MemoryChangeHistoryRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryChangeHistoryRepository creates a new in-memory change history repository
+func NewMemoryChangeHistoryRepository(db *MemoryDB) *MemoryChangeHistoryRepository {
+	return &MemoryChangeHistoryRepository{db: db}
+}
+
+// GetAll retrieves change history records with optional filters, newest first
+func (r *MemoryChangeHistoryRepository) GetAll(filter *models.ChangeHistoryFilter) ([]models.ServerChangeHistory, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	history := r.db.serverChangeHistory(filter)
+	if filter != nil {
+		history = paginate(history, filter.Limit, filter.Offset)
+	}
+
+	return history, nil
+}
+
+// serverChangeHistory returns the server change history records matching
+// filter, newest first, ignoring its limit and offset. The caller must hold
+// the lock.
+func (db *MemoryDB) serverChangeHistory(filter *models.ChangeHistoryFilter) []models.ServerChangeHistory {
+	var history []models.ServerChangeHistory
+	for _, record := range db.history {
+		if filter != nil {
+			if filter.ServerID != nil && (record.ServerID == nil || *record.ServerID != *filter.ServerID) {
+				continue
+			}
+			if filter.ChangeType != nil && record.ChangeType != *filter.ChangeType {
+				continue
+			}
+			if filter.StartDate != nil && record.ChangedAt.Before(*filter.StartDate) {
+				continue
+			}
+			if filter.EndDate != nil && record.ChangedAt.After(*filter.EndDate) {
+				continue
+			}
+		}
+		history = append(history, record)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		if !history[i].ChangedAt.Equal(history[j].ChangedAt) {
+			return history[i].ChangedAt.After(history[j].ChangedAt)
+		}
+		return history[i].ID > history[j].ID
+	})
+
+	return history
+}
+
+// GetByServerID retrieves change history for a specific server
+func (r *MemoryChangeHistoryRepository) GetByServerID(serverID int, limit int) ([]models.ServerChangeHistory, error) {
+	filter := &models.ChangeHistoryFilter{
+		ServerID: &serverID,
+		Limit:    limit,
+	}
+	return r.GetAll(filter)
+}
+
+// GetByID retrieves a single change history record by its ID
+func (r *MemoryChangeHistoryRepository) GetByID(id int) (*models.ServerChangeHistory, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, record := range r.db.history {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+
+	return nil, fmt.Errorf("change history record with id %d %w", id, ErrNotFound)
+}
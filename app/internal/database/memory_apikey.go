@@ -0,0 +1,179 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// MemoryAPIKeyRepository provides in-memory operations for API keys
+type MemoryAPIKeyRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryAPIKeyRepository creates a new in-memory API key repository
+func NewMemoryAPIKeyRepository(db *MemoryDB) *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{db: db}
+}
+
+// GetAll retrieves API keys with optional filters and pagination, oldest first
+func (r *MemoryAPIKeyRepository) GetAll(filter *models.APIKeyFilter) ([]models.APIKey, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	keys := r.matching(filter)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	if filter != nil {
+		keys = paginate(keys, filter.Limit, filter.Offset)
+	}
+
+	return keys, nil
+}
+
+// Count returns the number of API keys matching a filter, ignoring pagination
+func (r *MemoryAPIKeyRepository) Count(filter *models.APIKeyFilter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// matching returns the API keys satisfying a filter. The caller must hold the lock.
+func (r *MemoryAPIKeyRepository) matching(filter *models.APIKeyFilter) []models.APIKey {
+	var keys []models.APIKey
+	for _, stored := range r.db.apiKeys {
+		key := stored.APIKey
+		if filter != nil {
+			if filter.Status != nil && key.Active() == (*filter.Status == models.APIKeyStatusRevoked) {
+				continue
+			}
+			if filter.Role != nil && key.Role != *filter.Role {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// GetByID retrieves an API key by its ID
+func (r *MemoryAPIKeyRepository) GetByID(id int) (*models.APIKey, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	stored, exists := r.db.apiKeys[id]
+	if !exists {
+		return nil, fmt.Errorf("API key with id %d %w", id, ErrNotFound)
+	}
+
+	return &stored.APIKey, nil
+}
+
+// GetByHash retrieves an API key, active or revoked, by the hash of its secret
+func (r *MemoryAPIKeyRepository) GetByHash(hash string) (*models.APIKey, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, stored := range r.db.apiKeys {
+		if stored.hash == hash {
+			key := stored.APIKey
+			return &key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("API key %w", ErrNotFound)
+}
+
+// Create stores a new API key with the hash of its secret. Names are unique
+// among active keys.
+func (r *MemoryAPIKeyRepository) Create(key *models.APIKey, hash string) (*models.APIKey, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, stored := range r.db.apiKeys {
+		if stored.Active() && stored.Name == key.Name {
+			return nil, fmt.Errorf("active API key named %q already exists: %w", key.Name, ErrConflict)
+		}
+	}
+
+	now := r.db.now()
+	created := models.APIKey{
+		ID:        r.db.nextAPIKeyID,
+		Name:      key.Name,
+		Role:      key.Role,
+		Prefix:    key.Prefix,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.db.nextAPIKeyID++
+	r.db.apiKeys[created.ID] = memoryAPIKey{APIKey: created, hash: hash}
+
+	return &created, nil
+}
+
+// Rotate replaces the secret of an active API key, keeping its name and role
+func (r *MemoryAPIKeyRepository) Rotate(id int, prefix, hash string) (*models.APIKey, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, err := r.active(id, "rotate")
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.db.now()
+	stored.Prefix = prefix
+	stored.hash = hash
+	stored.RotatedAt = &now
+	stored.UpdatedAt = now
+	r.db.apiKeys[id] = stored
+
+	return &stored.APIKey, nil
+}
+
+// Revoke revokes an active API key. Revoked keys are kept for auditing.
+func (r *MemoryAPIKeyRepository) Revoke(id int) (*models.APIKey, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, err := r.active(id, "revoke")
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.db.now()
+	stored.RevokedAt = &now
+	stored.UpdatedAt = now
+	r.db.apiKeys[id] = stored
+
+	return &stored.APIKey, nil
+}
+
+// active returns an active API key, ErrConflict when it has been revoked and
+// ErrNotFound when it does not exist. The caller must hold the lock.
+func (r *MemoryAPIKeyRepository) active(id int, action string) (memoryAPIKey, error) {
+	stored, exists := r.db.apiKeys[id]
+	if !exists {
+		return stored, fmt.Errorf("API key with id %d %w", id, ErrNotFound)
+	}
+	if !stored.Active() {
+		return stored, fmt.Errorf("cannot %s API key %d: it has been revoked: %w", action, id, ErrConflict)
+	}
+	return stored, nil
+}
+
+// MarkUsed records when an API key was last used
+func (r *MemoryAPIKeyRepository) MarkUsed(id int, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if stored, exists := r.db.apiKeys[id]; exists {
+		stored.LastUsedAt = &at
+		r.db.apiKeys[id] = stored
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+
+	"infra-dashboard/internal/models"
+)
+
+// MemoryCampaignRepository provides in-memory operations for upgrade campaigns
+type MemoryCampaignRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryCampaignRepository creates a new in-memory campaign repository
+func NewMemoryCampaignRepository(db *MemoryDB) *MemoryCampaignRepository {
+	return &MemoryCampaignRepository{db: db}
+}
+
+// GetAll retrieves campaigns with optional filters and pagination, soonest deadline first
+func (r *MemoryCampaignRepository) GetAll(filter *models.CampaignFilter) ([]models.Campaign, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	campaigns := r.matching(filter)
+
+	sort.Slice(campaigns, func(i, j int) bool {
+		if !campaigns[i].Deadline.Equal(campaigns[j].Deadline) {
+			return campaigns[i].Deadline.Before(campaigns[j].Deadline)
+		}
+		return campaigns[i].ID < campaigns[j].ID
+	})
+
+	if filter != nil {
+		campaigns = paginate(campaigns, filter.Limit, filter.Offset)
+	}
+
+	return campaigns, nil
+}
+
+// Count returns the number of campaigns matching a filter, ignoring pagination
+func (r *MemoryCampaignRepository) Count(filter *models.CampaignFilter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// matching returns copies of the campaigns satisfying a filter. The caller
+// must hold the lock.
+func (r *MemoryCampaignRepository) matching(filter *models.CampaignFilter) []models.Campaign {
+	var campaigns []models.Campaign
+	for _, campaign := range r.db.campaigns {
+		if filter != nil {
+			if filter.Owner != nil && campaign.Owner != *filter.Owner {
+				continue
+			}
+			if filter.SourceOSID != nil && campaign.SourceOSID != *filter.SourceOSID {
+				continue
+			}
+			if filter.TargetOSID != nil && campaign.TargetOSID != *filter.TargetOSID {
+				continue
+			}
+		}
+		campaigns = append(campaigns, copyCampaign(campaign))
+	}
+
+	return campaigns
+}
+
+// GetByID retrieves a campaign by its ID
+func (r *MemoryCampaignRepository) GetByID(id int) (*models.Campaign, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	campaign, exists := r.db.campaigns[id]
+	if !exists {
+		return nil, fmt.Errorf("campaign with id %d %w", id, ErrNotFound)
+	}
+
+	campaign = copyCampaign(campaign)
+	return &campaign, nil
+}
+
+// Create creates a new campaign
+func (r *MemoryCampaignRepository) Create(req *models.CreateCampaignRequest) (*models.Campaign, error) {
+	deadline, err := parseCampaignDeadline(req.Deadline)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCampaignRequest(req); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// Mirror the foreign keys on upgrade_campaigns and campaign_servers
+	for _, osID := range []int{req.SourceOSID, req.TargetOSID} {
+		if _, exists := r.db.oss[osID]; !exists {
+			return nil, fmt.Errorf("campaign operating system or server does not exist: %w", ErrInvalidReference)
+		}
+	}
+	serverIDs := uniqueSortedIDs(req.ServerIDs)
+	for _, serverID := range serverIDs {
+		if _, exists := r.db.servers[serverID]; !exists {
+			return nil, fmt.Errorf("campaign operating system or server does not exist: %w", ErrInvalidReference)
+		}
+	}
+
+	now := r.db.now()
+	campaign := models.Campaign{
+		ID:         r.db.nextCampaignID,
+		Name:       req.Name,
+		SourceOSID: req.SourceOSID,
+		TargetOSID: req.TargetOSID,
+		Deadline:   deadline,
+		Owner:      req.Owner,
+		Selection:  models.CampaignSelectionScope,
+		ServerIDs:  serverIDs,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if req.Scope != nil {
+		campaign.Scope = *req.Scope
+	}
+	if len(serverIDs) > 0 {
+		campaign.Selection = models.CampaignSelectionServers
+	}
+	r.db.nextCampaignID++
+	r.db.campaigns[campaign.ID] = campaign
+
+	campaign = copyCampaign(campaign)
+	return &campaign, nil
+}
+
+// Update updates the name, deadline or owner of a campaign
+func (r *MemoryCampaignRepository) Update(id int, req *models.UpdateCampaignRequest) (*models.Campaign, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	campaign, exists := r.db.campaigns[id]
+	if !exists {
+		return nil, fmt.Errorf("campaign with id %d %w", id, ErrNotFound)
+	}
+
+	if req.Name == "" && req.Deadline == "" && req.Owner == "" {
+		campaign = copyCampaign(campaign)
+		return &campaign, nil // No updates, return existing campaign
+	}
+
+	if req.Deadline != "" {
+		deadline, err := parseCampaignDeadline(req.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		campaign.Deadline = deadline
+	}
+	if req.Name != "" {
+		campaign.Name = req.Name
+	}
+	if req.Owner != "" {
+		campaign.Owner = req.Owner
+	}
+
+	campaign.UpdatedAt = r.db.now()
+	r.db.campaigns[id] = campaign
+
+	campaign = copyCampaign(campaign)
+	return &campaign, nil
+}
+
+// Delete removes a campaign
+func (r *MemoryCampaignRepository) Delete(id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, exists := r.db.campaigns[id]; !exists {
+		return fmt.Errorf("campaign with id %d %w", id, ErrNotFound)
+	}
+	delete(r.db.campaigns, id)
+
+	return nil
+}
+
+// copyCampaign returns a copy of a campaign so stored records do not alias
+// the server IDs returned to callers
+func copyCampaign(campaign models.Campaign) models.Campaign {
+	campaign.ServerIDs = append([]int(nil), campaign.ServerIDs...)
+	return campaign
+}
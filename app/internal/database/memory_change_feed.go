@@ -0,0 +1,23 @@
+package database
+
+import (
+	"infra-dashboard/internal/models"
+)
+
+// GetFeed retrieves a page of the merged server and operating system change
+// history, newest first and ordered like models.MergeChangeHistory
+func (r *MemoryChangeHistoryRepository) GetFeed(filter *models.ChangeFeedFilter) ([]interface{}, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var servers []models.ServerChangeHistory
+	var oss []models.OSChangeHistory
+	if filter.ResourceType != models.ResourceTypeOS {
+		servers = r.db.serverChangeHistory(&filter.Servers)
+	}
+	if filter.ResourceType != models.ResourceTypeServer {
+		oss = r.db.osChangeHistory(&filter.OS)
+	}
+
+	return paginate(models.MergeChangeHistory(servers, oss), filter.Limit, filter.Offset), nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"infra-dashboard/internal/models"
+)
+
+// recordOSChange appends the change history record of an operating system
+// mutation, mirroring the database repository. The caller must hold the
+// write lock.
+func (db *MemoryDB) recordOSChange(ctx context.Context, before, after *models.OS) {
+	record, changed := models.NewOSChange(before, after, models.ChangeMetadataFromContext(ctx))
+	if !changed {
+		return
+	}
+
+	record.ID = db.nextOSHistoryID
+	record.ChangedAt = db.now()
+	db.nextOSHistoryID++
+	db.osHistory = append(db.osHistory, record)
+}
+
+// MemoryOSChangeHistoryRepository provides in-memory access to operating system change history
+type MemoryOSChangeHistoryRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryOSChangeHistoryRepository creates a new in-memory operating system change history repository
+func NewMemoryOSChangeHistoryRepository(db *MemoryDB) *MemoryOSChangeHistoryRepository {
+	return &MemoryOSChangeHistoryRepository{db: db}
+}
+
+// GetAll retrieves operating system change history records with optional filters, newest first
+func (r *MemoryOSChangeHistoryRepository) GetAll(filter *models.OSChangeHistoryFilter) ([]models.OSChangeHistory, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	history := r.db.osChangeHistory(filter)
+	if filter != nil {
+		history = paginate(history, filter.Limit, filter.Offset)
+	}
+
+	return history, nil
+}
+
+// osChangeHistory returns the operating system change history records
+// matching filter, newest first, ignoring its limit and offset. The caller
+// must hold the lock.
+func (db *MemoryDB) osChangeHistory(filter *models.OSChangeHistoryFilter) []models.OSChangeHistory {
+	var history []models.OSChangeHistory
+	for _, record := range db.osHistory {
+		if filter != nil {
+			if filter.OSID != nil && (record.OSID == nil || *record.OSID != *filter.OSID) {
+				continue
+			}
+			if filter.ChangeType != nil && record.ChangeType != *filter.ChangeType {
+				continue
+			}
+			if !timeInRange(record.ChangedAt, filter.StartDate, filter.EndDate) {
+				continue
+			}
+		}
+		history = append(history, record)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		if !history[i].ChangedAt.Equal(history[j].ChangedAt) {
+			return history[i].ChangedAt.After(history[j].ChangedAt)
+		}
+		return history[i].ID > history[j].ID
+	})
+
+	return history
+}
+
+// GetByOSID retrieves change history for a specific operating system
+func (r *MemoryOSChangeHistoryRepository) GetByOSID(osID int, limit int) ([]models.OSChangeHistory, error) {
+	return r.GetAll(&models.OSChangeHistoryFilter{OSID: &osID, Limit: limit})
+}
+
+// GetByID retrieves a single operating system change history record by its ID
+func (r *MemoryOSChangeHistoryRepository) GetByID(id int) (*models.OSChangeHistory, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, record := range r.db.osHistory {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+
+	return nil, fmt.Errorf("OS change history record with id %d %w", id, ErrNotFound)
+}
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"infra-dashboard/internal/models"
+)
+
+// MemoryScheduledChangeRepository provides in-memory operations for scheduled OS changes
+type MemoryScheduledChangeRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryScheduledChangeRepository creates a new in-memory scheduled change repository
+func NewMemoryScheduledChangeRepository(db *MemoryDB) *MemoryScheduledChangeRepository {
+	return &MemoryScheduledChangeRepository{db: db}
+}
+
+// GetAll retrieves scheduled changes with optional filters and pagination, earliest window first
+func (r *MemoryScheduledChangeRepository) GetAll(filter *models.ScheduledChangeFilter) ([]models.ScheduledChange, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	changes := r.matching(filter)
+
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].WindowStart.Equal(changes[j].WindowStart) {
+			return changes[i].WindowStart.Before(changes[j].WindowStart)
+		}
+		return changes[i].ID < changes[j].ID
+	})
+
+	if filter != nil {
+		changes = paginate(changes, filter.Limit, filter.Offset)
+	}
+
+	return changes, nil
+}
+
+// Count returns the number of scheduled changes matching a filter, ignoring pagination
+func (r *MemoryScheduledChangeRepository) Count(filter *models.ScheduledChangeFilter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// matching returns the scheduled changes satisfying a filter. The caller must hold the lock.
+func (r *MemoryScheduledChangeRepository) matching(filter *models.ScheduledChangeFilter) []models.ScheduledChange {
+	var changes []models.ScheduledChange
+	for _, change := range r.db.changes {
+		if filter != nil {
+			if filter.ServerID != nil && (change.ServerID == nil || *change.ServerID != *filter.ServerID) {
+				continue
+			}
+			if filter.Status != nil && change.Status != *filter.Status {
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// GetByID retrieves a scheduled change by its ID
+func (r *MemoryScheduledChangeRepository) GetByID(id int) (*models.ScheduledChange, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	change, exists := r.db.changes[id]
+	if !exists {
+		return nil, fmt.Errorf("scheduled change with id %d %w", id, ErrNotFound)
+	}
+
+	return &change, nil
+}
+
+// Create schedules a change requested by the actor of ctx
+func (r *MemoryScheduledChangeRepository) Create(ctx context.Context, req *models.CreateScheduledChangeRequest) (*models.ScheduledChange, error) {
+	windowStart, windowEnd, err := parseScheduledWindow(req.WindowStart, req.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// Mirror the foreign keys on scheduled_changes
+	server, exists := r.db.servers[req.ServerID]
+	if !exists {
+		return nil, fmt.Errorf("scheduled server or operating system does not exist: %w", ErrInvalidReference)
+	}
+	if _, exists := r.db.oss[req.OSID]; !exists {
+		return nil, fmt.Errorf("scheduled server or operating system does not exist: %w", ErrInvalidReference)
+	}
+
+	// Mirror the unique index on the open change of each server
+	for _, change := range r.db.changes {
+		if change.ServerID != nil && *change.ServerID == req.ServerID &&
+			(change.Status == models.ScheduledChangePending || change.Status == models.ScheduledChangeApplying) {
+			return nil, fmt.Errorf("server %d already has an open scheduled change: %w", req.ServerID, ErrConflict)
+		}
+	}
+
+	now := r.db.now()
+	change := models.ScheduledChange{
+		ID:          r.db.nextChangeID,
+		ServerID:    intPtr(req.ServerID),
+		ServerName:  server.Name,
+		OSID:        req.OSID,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+		Status:      models.ScheduledChangePending,
+		RequestedBy: models.ChangeMetadataFromContext(ctx).Actor,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.db.nextChangeID++
+	r.db.changes[change.ID] = change
+
+	return &change, nil
+}
+
+// Claim moves a pending change to applying
+func (r *MemoryScheduledChangeRepository) Claim(id int) (*models.ScheduledChange, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	change, exists := r.db.changes[id]
+	if !exists {
+		return nil, fmt.Errorf("scheduled change with id %d %w", id, ErrNotFound)
+	}
+	if change.Status != models.ScheduledChangePending {
+		return nil, fmt.Errorf("cannot claim scheduled change %d: it is %s: %w", id, change.Status, ErrConflict)
+	}
+
+	change.Status = models.ScheduledChangeApplying
+	change.UpdatedAt = r.db.now()
+	r.db.changes[id] = change
+
+	return &change, nil
+}
+
+// Resolve moves a claimed change to applied or failed, or a pending change
+// to expired or cancelled
+func (r *MemoryScheduledChangeRepository) Resolve(id int, status, reason string) (*models.ScheduledChange, error) {
+	from, err := resolvedFrom(status)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	change, exists := r.db.changes[id]
+	if !exists {
+		return nil, fmt.Errorf("scheduled change with id %d %w", id, ErrNotFound)
+	}
+	if change.Status != from {
+		return nil, fmt.Errorf("cannot resolve scheduled change %d: it is %s: %w", id, change.Status, ErrConflict)
+	}
+
+	now := r.db.now()
+	change.Status = status
+	change.Reason = reason
+	change.ResolvedAt = &now
+	change.UpdatedAt = now
+	r.db.changes[id] = change
+
+	return &change, nil
+}
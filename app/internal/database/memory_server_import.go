@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+
+	"infra-dashboard/internal/models"
+)
+
+// Import creates and updates servers by name atomically, recording each
+// change with the metadata of ctx. Every imported server, changed or not, is
+// marked as seen. Nothing is written when a row is invalid or on a dry run;
+// the report then describes what the import would do.
+func (r *MemoryServerRepository) Import(ctx context.Context, rows []models.ServerImportRow, dryRun bool) (*models.ServerImportReport, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	report, err := models.PlanServerImport(rows, models.ServerImportLookup{
+		OS: func(name, version string) (*models.OS, error) {
+			for _, os := range r.db.oss {
+				if os.Name == name && os.Version == version {
+					return &os, nil
+				}
+			}
+			return nil, nil
+		},
+		Server: func(name string) (*models.Server, error) {
+			for _, server := range r.db.servers {
+				if server.Name == name {
+					server = r.db.withOS(server)
+					return &server, nil
+				}
+			}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.DryRun = dryRun
+	if dryRun || report.Failed > 0 {
+		return report, nil
+	}
+
+	now := r.db.now()
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		server := *row.After
+		switch row.Action {
+		case models.ImportActionCreate:
+			server.ID = r.db.nextServerID
+			server.CreatedAt = now
+			r.db.nextServerID++
+			row.ServerID = intPtr(server.ID)
+		case models.ImportActionUpdate:
+		case models.ImportActionUnchanged:
+			seen := r.db.servers[server.ID]
+			seen.LastSeenAt = &now
+			r.db.servers[server.ID] = seen
+			continue
+		default:
+			continue
+		}
+
+		server.OS = nil
+		server.LastSeenAt = &now
+		server.UpdatedAt = now
+		r.db.servers[server.ID] = server
+
+		server = r.db.withOS(server)
+		r.db.recordChange(ctx, row.Before, &server)
+	}
+
+	report.Applied = true
+	return report, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"sort"
+
+	"infra-dashboard/internal/models"
+)
+
+// MemorySnapshotRepository provides in-memory operations for compliance snapshots
+type MemorySnapshotRepository struct {
+	db *MemoryDB
+}
+
+// NewMemorySnapshotRepository creates a new in-memory compliance snapshot repository
+func NewMemorySnapshotRepository(db *MemoryDB) *MemorySnapshotRepository {
+	return &MemorySnapshotRepository{db: db}
+}
+
+// GetAll retrieves snapshots taken within the optional bounds of a filter,
+// oldest first unless the filter asks for the latest ones
+func (r *MemorySnapshotRepository) GetAll(filter *models.SnapshotFilter) ([]models.ComplianceSnapshot, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	snapshots := r.matching(filter)
+
+	latest := filter != nil && filter.Latest
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		a, b := snapshots[i], snapshots[j]
+		if latest {
+			a, b = b, a
+		}
+		if !a.TakenAt.Equal(b.TakenAt) {
+			return a.TakenAt.Before(b.TakenAt)
+		}
+		return a.ID < b.ID
+	})
+
+	if filter != nil {
+		snapshots = paginate(snapshots, filter.Limit, filter.Offset)
+	}
+
+	return snapshots, nil
+}
+
+// Count returns the number of snapshots matching a filter, ignoring pagination
+func (r *MemorySnapshotRepository) Count(filter *models.SnapshotFilter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// matching returns the snapshots satisfying a filter. The caller must hold the lock.
+func (r *MemorySnapshotRepository) matching(filter *models.SnapshotFilter) []models.ComplianceSnapshot {
+	var snapshots []models.ComplianceSnapshot
+	for _, s := range r.db.snapshots {
+		if filter == nil || timeInRange(s.TakenAt, filter.From, filter.To) {
+			snapshots = append(snapshots, s)
+		}
+	}
+	return snapshots
+}
+
+// Create stores a compliance snapshot, taken now unless TakenAt is set
+func (r *MemorySnapshotRepository) Create(snapshot *models.ComplianceSnapshot) (*models.ComplianceSnapshot, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	created := *snapshot
+	created.ID = r.db.nextSnapshotID
+	if created.TakenAt.IsZero() {
+		created.TakenAt = r.db.now()
+	}
+	r.db.nextSnapshotID++
+	r.db.snapshots = append(r.db.snapshots, created)
+
+	return &created, nil
+}
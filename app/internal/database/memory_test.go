@@ -0,0 +1,763 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+func newTestMemoryStores(t *testing.T) (*Stores, *models.OS, *models.OS) {
+	t.Helper()
+
+	stores := NewMemoryStores(NewMemoryDB())
+	ubuntu, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Ubuntu", Version: "22.04", EndOfSupport: "2027-04-01"})
+	if err != nil {
+		t.Fatalf("Failed to create OS: %v", err)
+	}
+	debian, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Debian", Version: "12", EndOfSupport: "2028-06-30"})
+	if err != nil {
+		t.Fatalf("Failed to create OS: %v", err)
+	}
+
+	return stores, ubuntu, debian
+}
+
+func TestMemoryServerRepository_Constraints(t *testing.T) {
+	stores, ubuntu, _ := newTestMemoryStores(t)
+
+	server, err := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if server.OS == nil || server.OS.ID != ubuntu.ID {
+		t.Errorf("Expected server to include its operating system")
+	}
+
+	if _, err := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for duplicate name, got %v", err)
+	}
+
+	if _, err := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "web-02", OSID: 999}); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("Expected ErrInvalidReference for unknown OS, got %v", err)
+	}
+
+	if _, err := stores.Servers.Update(context.Background(), 999, &models.UpdateServerRequest{Name: "x"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown server, got %v", err)
+	}
+
+	if err := stores.OS.Delete(context.Background(), ubuntu.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict when deleting an OS in use, got %v", err)
+	}
+
+	if err := stores.Servers.Delete(context.Background(), server.ID); err != nil {
+		t.Fatalf("Failed to delete server: %v", err)
+	}
+	if err := stores.Servers.Delete(context.Background(), server.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for deleted server, got %v", err)
+	}
+	if err := stores.OS.Delete(context.Background(), ubuntu.ID); err != nil {
+		t.Errorf("Expected OS to be deletable once unused, got %v", err)
+	}
+}
+
+func TestMemoryServerRepository_History(t *testing.T) {
+	stores, ubuntu, debian := newTestMemoryStores(t)
+	ctx := models.WithChangeMetadata(context.Background(), models.ChangeMetadata{Actor: "alice", RequestID: "req-1", Source: models.ChangeSourceAPI})
+
+	server, err := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: "db-01", OSID: ubuntu.ID, Role: "database"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	if _, err := stores.Servers.Update(ctx, server.ID, &models.UpdateServerRequest{Name: "db-01a"}); err != nil {
+		t.Fatalf("Failed to rename server: %v", err)
+	}
+	if _, err := stores.Servers.Update(ctx, server.ID, &models.UpdateServerRequest{OSID: debian.ID}); err != nil {
+		t.Fatalf("Failed to change server OS: %v", err)
+	}
+	if _, err := stores.Servers.Update(ctx, server.ID, &models.UpdateServerRequest{OwnerTeam: "dba", Location: "eu-west"}); err != nil {
+		t.Fatalf("Failed to update server: %v", err)
+	}
+	// Setting a field to its current value records nothing
+	if _, err := stores.Servers.Update(ctx, server.ID, &models.UpdateServerRequest{Role: "database"}); err != nil {
+		t.Fatalf("Failed to update server: %v", err)
+	}
+
+	history, err := stores.ChangeHistory.GetByServerID(server.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("Expected 4 history records, got %d", len(history))
+	}
+	if history[0].ChangeType != models.ChangeTypeUpdated || len(history[0].Changes) != 2 || history[0].Changes["owner_team"].New != "dba" {
+		t.Errorf("Unexpected updated record: %+v", history[0])
+	}
+	if history[1].ChangeType != models.ChangeTypeOSChanged || *history[1].OldOSName != "Ubuntu" || *history[1].NewOSName != "Debian" {
+		t.Errorf("Unexpected os_changed record: %+v", history[1])
+	}
+	rename := history[2]
+	if rename.ChangeType != models.ChangeTypeRenamed || rename.Changes["name"] != (models.FieldChange{Old: "db-01", New: "db-01a"}) {
+		t.Errorf("Unexpected renamed record: %+v", rename)
+	}
+	if rename.ChangedBy != "alice" || rename.RequestID != "req-1" || rename.Source != models.ChangeSourceAPI {
+		t.Errorf("Expected change metadata to be recorded, got %+v", rename)
+	}
+	if history[3].ChangeType != models.ChangeTypeCreated || history[3].Changes["role"].New != "database" {
+		t.Errorf("Unexpected created record: %+v", history[3])
+	}
+
+	if err := stores.Servers.Delete(ctx, server.ID); err != nil {
+		t.Fatalf("Failed to delete server: %v", err)
+	}
+
+	changeType := "deleted"
+	deleted, err := stores.ChangeHistory.GetAll(&models.ChangeHistoryFilter{ChangeType: &changeType})
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ServerName != "db-01a" {
+		t.Fatalf("Expected one deleted record for db-01a, got %+v", deleted)
+	}
+	if deleted[0].ServerID != nil {
+		t.Errorf("Expected server_id to be cleared after deletion")
+	}
+}
+
+func TestMemoryChangeHistoryRepository_Feed(t *testing.T) {
+	db := NewMemoryDB()
+	stores := NewMemoryStores(db)
+	clock := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	db.now = func() time.Time { return clock }
+
+	// OS 1 created, server 1 created, then OS 1 updated a day later
+	ubuntu, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Ubuntu", Version: "22.04", EndOfSupport: "2027-04-01"})
+	if err != nil {
+		t.Fatalf("Failed to create OS: %v", err)
+	}
+	if _, err := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID}); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	clock = clock.AddDate(0, 0, 1)
+	if _, err := stores.OS.Update(context.Background(), ubuntu.ID, &models.UpdateOSRequest{EndOfSupport: "2028-04-01"}); err != nil {
+		t.Fatalf("Failed to update OS: %v", err)
+	}
+
+	describe := func(filter *models.ChangeFeedFilter) string {
+		feed, err := stores.ChangeHistory.GetFeed(filter)
+		if err != nil {
+			t.Fatalf("Failed to get the change history feed: %v", err)
+		}
+		var entries []string
+		for _, record := range feed {
+			switch record := record.(type) {
+			case models.ServerChangeHistory:
+				entries = append(entries, "server "+record.ChangeType)
+			case models.OSChangeHistory:
+				entries = append(entries, "os "+record.ChangeType)
+			}
+		}
+		return fmt.Sprint(entries)
+	}
+
+	// Records at the same time and with the same ID list servers first
+	if got := describe(&models.ChangeFeedFilter{}); got != "[os updated server created os created]" {
+		t.Errorf("Unexpected feed %s", got)
+	}
+	if got := describe(&models.ChangeFeedFilter{Limit: 1, Offset: 1}); got != "[server created]" {
+		t.Errorf("Unexpected page %s", got)
+	}
+	if got := describe(&models.ChangeFeedFilter{ResourceType: models.ResourceTypeOS, Offset: 1}); got != "[os created]" {
+		t.Errorf("Unexpected OS page %s", got)
+	}
+	created := models.ChangeTypeCreated
+	if got := describe(&models.ChangeFeedFilter{Servers: models.ChangeHistoryFilter{ChangeType: &created}, OS: models.OSChangeHistoryFilter{ChangeType: &created}}); got != "[server created os created]" {
+		t.Errorf("Unexpected filtered feed %s", got)
+	}
+}
+
+func TestMemoryOSRepository_Validation(t *testing.T) {
+	stores, ubuntu, debian := newTestMemoryStores(t)
+
+	if _, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Ubuntu", Version: "24.04", EndOfSupport: "04/2029"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for bad date, got %v", err)
+	}
+	if _, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Ubuntu", Version: "22.04", EndOfSupport: "2027-04-01"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for duplicate OS, got %v", err)
+	}
+	if _, err := stores.OS.Update(context.Background(), debian.ID, &models.UpdateOSRequest{Name: ubuntu.Name, Version: ubuntu.Version}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict when updating to an existing name and version, got %v", err)
+	}
+
+	updated, err := stores.OS.Update(context.Background(), ubuntu.ID, &models.UpdateOSRequest{EndOfSupport: "2032-04-01"})
+	if err != nil {
+		t.Fatalf("Failed to update OS: %v", err)
+	}
+	if updated.EndOfSupport.Year() != 2032 {
+		t.Errorf("Expected end of support in 2032, got %v", updated.EndOfSupport)
+	}
+}
+
+func TestMemoryOSRepository_VersionOrdering(t *testing.T) {
+	stores := NewMemoryStores(NewMemoryDB())
+	for _, version := range []string{"14.3", "9.3", "10.0", "14.0"} {
+		if _, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "FreeBSD", Version: version, EndOfSupport: "2030-01-01"}); err != nil {
+			t.Fatalf("Failed to create OS: %v", err)
+		}
+	}
+
+	oss, err := stores.OS.GetAll(&models.OSFilter{Sort: []models.SortField{{Field: "version", Desc: true}}})
+	if err != nil {
+		t.Fatalf("Failed to list operating systems: %v", err)
+	}
+
+	expected := []string{"14.3", "14.0", "10.0", "9.3"}
+	for i, os := range oss {
+		if os.Version != expected[i] {
+			t.Fatalf("Expected versions %v, got %+v", expected, oss)
+		}
+	}
+}
+
+func TestMemoryWaiverRepository(t *testing.T) {
+	stores, ubuntu, debian := newTestMemoryStores(t)
+
+	server, err := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	unknown := 999
+	invalid := []*models.CreateWaiverRequest{
+		{Justification: "j", Approver: "a", ExpiresAt: "2099-01-01"},
+		{ServerID: &server.ID, OSID: &ubuntu.ID, Justification: "j", Approver: "a", ExpiresAt: "2099-01-01"},
+		{ServerID: &server.ID, Justification: "j", Approver: "a", ExpiresAt: "01/01/2099"},
+	}
+	for _, req := range invalid {
+		if _, err := stores.Waivers.Create(req); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", req, err)
+		}
+	}
+	if _, err := stores.Waivers.Create(&models.CreateWaiverRequest{ServerID: &unknown, Justification: "j", Approver: "a", ExpiresAt: "2099-01-01"}); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("Expected ErrInvalidReference for unknown server, got %v", err)
+	}
+
+	serverWaiver, err := stores.Waivers.Create(&models.CreateWaiverRequest{ServerID: &server.ID, Justification: "Vendor appliance", Approver: "alice", ExpiresAt: "2099-01-01"})
+	if err != nil {
+		t.Fatalf("Failed to create waiver: %v", err)
+	}
+	osWaiver, err := stores.Waivers.Create(&models.CreateWaiverRequest{OSID: &debian.ID, Justification: "Legacy", Approver: "bob", ExpiresAt: "2000-01-01"})
+	if err != nil {
+		t.Fatalf("Failed to create waiver: %v", err)
+	}
+
+	for status, expected := range map[string]int{models.WaiverStatusActive: 1, models.WaiverStatusExpired: 1} {
+		status := status
+		waivers, err := stores.Waivers.GetAll(&models.WaiverFilter{Status: &status})
+		if err != nil || len(waivers) != expected {
+			t.Errorf("Expected %d %s waivers, got %v (%v)", expected, status, waivers, err)
+		}
+	}
+
+	updated, err := stores.Waivers.Update(serverWaiver.ID, &models.UpdateWaiverRequest{Approver: "carol"})
+	if err != nil || updated.Approver != "carol" || updated.Justification != "Vendor appliance" {
+		t.Errorf("Unexpected updated waiver: %+v (%v)", updated, err)
+	}
+
+	// Revoked waivers are kept, and can neither be updated nor revoked again
+	revoked, err := stores.Waivers.Revoke(serverWaiver.ID)
+	if err != nil || revoked.RevokedAt == nil || revoked.Status(time.Now()) != models.WaiverStatusRevoked {
+		t.Fatalf("Unexpected revoked waiver: %+v (%v)", revoked, err)
+	}
+	if _, err := stores.Waivers.Revoke(serverWaiver.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict revoking a revoked waiver, got %v", err)
+	}
+	if _, err := stores.Waivers.Update(serverWaiver.ID, &models.UpdateWaiverRequest{ExpiresAt: "2100-01-01"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict updating a revoked waiver, got %v", err)
+	}
+	if _, err := stores.Waivers.Revoke(unknown); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound revoking an unknown waiver, got %v", err)
+	}
+	for status, expected := range map[string]int{models.WaiverStatusActive: 0, models.WaiverStatusExpired: 1, models.WaiverStatusRevoked: 1} {
+		status := status
+		if count, _ := stores.Waivers.Count(&models.WaiverFilter{Status: &status}); count != expected {
+			t.Errorf("Expected %d %s waivers after revoking, got %d", expected, status, count)
+		}
+	}
+
+	// Deleting the server or operating system keeps their waivers with their names
+	if err := stores.Servers.Delete(context.Background(), server.ID); err != nil {
+		t.Fatalf("Failed to delete server: %v", err)
+	}
+	kept, err := stores.Waivers.GetByID(serverWaiver.ID)
+	if err != nil || kept.ServerID != nil || kept.ServerName != "web-01" || kept.RevokedAt == nil {
+		t.Errorf("Expected server waiver to be kept without its server, got %+v (%v)", kept, err)
+	}
+	if err := stores.OS.Delete(context.Background(), debian.ID); err != nil {
+		t.Fatalf("Failed to delete operating system: %v", err)
+	}
+	kept, err = stores.Waivers.GetByID(osWaiver.ID)
+	if err != nil || kept.OSID != nil || kept.OSName != "Debian" || kept.OSVersion != "12" {
+		t.Errorf("Expected OS waiver to be kept without its operating system, got %+v (%v)", kept, err)
+	}
+}
+
+func TestMemoryCampaignRepository(t *testing.T) {
+	stores, ubuntu, debian := newTestMemoryStores(t)
+	ctx := context.Background()
+
+	web, _ := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID, Environment: "prod"})
+	db01, _ := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: "db-01", OSID: ubuntu.ID})
+
+	unknown := 999
+	invalid := []*models.CreateCampaignRequest{
+		{Name: "c", SourceOSID: ubuntu.ID, TargetOSID: ubuntu.ID, Deadline: "2027-01-01", Owner: "o"},
+		{Name: "c", SourceOSID: ubuntu.ID, TargetOSID: debian.ID, Deadline: "2027-01-01", Owner: "o", Scope: &models.CampaignScope{Environment: "prod"}, ServerIDs: []int{web.ID}},
+		{Name: "c", SourceOSID: ubuntu.ID, TargetOSID: debian.ID, Deadline: "01/01/2027", Owner: "o"},
+	}
+	for _, req := range invalid {
+		if _, err := stores.Campaigns.Create(req); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", req, err)
+		}
+	}
+	for _, req := range []*models.CreateCampaignRequest{
+		{Name: "c", SourceOSID: unknown, TargetOSID: debian.ID, Deadline: "2027-01-01", Owner: "o"},
+		{Name: "c", SourceOSID: ubuntu.ID, TargetOSID: debian.ID, Deadline: "2027-01-01", Owner: "o", ServerIDs: []int{unknown}},
+	} {
+		if _, err := stores.Campaigns.Create(req); !errors.Is(err, ErrInvalidReference) {
+			t.Errorf("Expected ErrInvalidReference for %+v, got %v", req, err)
+		}
+	}
+
+	explicit, err := stores.Campaigns.Create(&models.CreateCampaignRequest{Name: "Databases", SourceOSID: ubuntu.ID, TargetOSID: debian.ID, Deadline: "2027-01-01", Owner: "dba", ServerIDs: []int{db01.ID, web.ID, db01.ID}})
+	if err != nil {
+		t.Fatalf("Failed to create campaign: %v", err)
+	}
+	if explicit.Selection != models.CampaignSelectionServers || len(explicit.ServerIDs) != 2 || explicit.ServerIDs[0] != web.ID {
+		t.Errorf("Expected the distinct servers in ascending order, got %+v", explicit)
+	}
+	scoped, err := stores.Campaigns.Create(&models.CreateCampaignRequest{Name: "Production", SourceOSID: ubuntu.ID, TargetOSID: debian.ID, Deadline: "2026-06-30", Owner: "ops", Scope: &models.CampaignScope{Environment: "prod"}})
+	if err != nil || scoped.Selection != models.CampaignSelectionScope {
+		t.Fatalf("Unexpected campaign %+v, %v", scoped, err)
+	}
+
+	campaigns, err := stores.Campaigns.GetAll(nil)
+	if err != nil || len(campaigns) != 2 || campaigns[0].ID != scoped.ID {
+		t.Errorf("Expected the soonest deadline first, got %+v, %v", campaigns, err)
+	}
+	owner := "dba"
+	if count, _ := stores.Campaigns.Count(&models.CampaignFilter{Owner: &owner}); count != 1 {
+		t.Errorf("Expected 1 campaign owned by dba, got %d", count)
+	}
+
+	updated, err := stores.Campaigns.Update(explicit.ID, &models.UpdateCampaignRequest{Deadline: "2027-03-31"})
+	if err != nil || updated.Deadline.Format("2006-01-02") != "2027-03-31" || updated.Name != "Databases" {
+		t.Errorf("Unexpected updated campaign: %+v (%v)", updated, err)
+	}
+
+	// A server cannot be deleted while campaigns list it
+	if err := stores.Servers.Delete(ctx, web.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict deleting a server listed in a campaign, got %v", err)
+	}
+	if campaign, _ := stores.Campaigns.GetByID(explicit.ID); len(campaign.ServerIDs) != 2 {
+		t.Errorf("Expected both servers to remain in the campaign, got %+v", campaign)
+	}
+
+	// An operating system cannot be deleted while campaigns reference it
+	if err := stores.OS.Delete(ctx, debian.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict deleting the target OS of campaigns, got %v", err)
+	}
+	if count, _ := stores.Campaigns.Count(nil); count != 2 {
+		t.Errorf("Expected the campaigns to be kept, got %d", count)
+	}
+	for _, campaign := range []*models.Campaign{explicit, scoped} {
+		if err := stores.Campaigns.Delete(campaign.ID); err != nil {
+			t.Fatalf("Failed to delete campaign: %v", err)
+		}
+	}
+	if err := stores.OS.Delete(ctx, debian.ID); err != nil {
+		t.Errorf("Expected the OS to be deleted once its campaigns are, got %v", err)
+	}
+	if err := stores.Servers.Delete(ctx, web.ID); err != nil {
+		t.Errorf("Expected the server to be deleted once its campaigns are, got %v", err)
+	}
+	if err := stores.Campaigns.Delete(explicit.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemoryScheduledChangeRepository(t *testing.T) {
+	stores, ubuntu, debian := newTestMemoryStores(t)
+	ctx := models.WithChangeMetadata(context.Background(), models.ChangeMetadata{Actor: "alice"})
+
+	web, _ := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	db01, _ := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: "db-01", OSID: ubuntu.ID})
+
+	for _, req := range []*models.CreateScheduledChangeRequest{
+		{ServerID: web.ID, OSID: debian.ID, WindowStart: "2026-01-10", WindowEnd: "2026-01-11T02:00:00Z"},
+		{ServerID: web.ID, OSID: debian.ID, WindowStart: "2026-01-10T22:00:00Z", WindowEnd: "2026-01-10T22:00:00Z"},
+	} {
+		if _, err := stores.Changes.Create(ctx, req); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %+v, got %v", req, err)
+		}
+	}
+	for _, req := range []*models.CreateScheduledChangeRequest{
+		{ServerID: 999, OSID: debian.ID, WindowStart: "2026-01-10T22:00:00Z", WindowEnd: "2026-01-11T02:00:00Z"},
+		{ServerID: web.ID, OSID: 999, WindowStart: "2026-01-10T22:00:00Z", WindowEnd: "2026-01-11T02:00:00Z"},
+	} {
+		if _, err := stores.Changes.Create(ctx, req); !errors.Is(err, ErrInvalidReference) {
+			t.Errorf("Expected ErrInvalidReference for %+v, got %v", req, err)
+		}
+	}
+
+	late, err := stores.Changes.Create(ctx, &models.CreateScheduledChangeRequest{ServerID: web.ID, OSID: debian.ID, WindowStart: "2026-02-10T22:00:00Z", WindowEnd: "2026-02-11T02:00:00Z"})
+	if err != nil {
+		t.Fatalf("Failed to schedule change: %v", err)
+	}
+	if late.Status != models.ScheduledChangePending || late.RequestedBy != "alice" || late.ResolvedAt != nil {
+		t.Errorf("Unexpected scheduled change: %+v", late)
+	}
+	if _, err := stores.Changes.Create(ctx, &models.CreateScheduledChangeRequest{ServerID: web.ID, OSID: debian.ID, WindowStart: "2026-03-10T22:00:00Z", WindowEnd: "2026-03-11T02:00:00Z"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a second pending change, got %v", err)
+	}
+	early, err := stores.Changes.Create(ctx, &models.CreateScheduledChangeRequest{ServerID: db01.ID, OSID: debian.ID, WindowStart: "2026-01-10T22:00:00Z", WindowEnd: "2026-01-11T02:00:00Z"})
+	if err != nil {
+		t.Fatalf("Failed to schedule change: %v", err)
+	}
+
+	changes, err := stores.Changes.GetAll(nil)
+	if err != nil || len(changes) != 2 || changes[0].ID != early.ID {
+		t.Errorf("Expected the earliest window first, got %+v, %v", changes, err)
+	}
+
+	if _, err := stores.Changes.Resolve(late.ID, models.ScheduledChangePending, ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput resolving to pending, got %v", err)
+	}
+	if _, err := stores.Changes.Resolve(late.ID, models.ScheduledChangeApplied, ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict applying an unclaimed change, got %v", err)
+	}
+
+	// A claimed change is applied or fails, and can no longer be cancelled
+	claimed, err := stores.Changes.Claim(early.ID)
+	if err != nil || claimed.Status != models.ScheduledChangeApplying || claimed.ResolvedAt != nil {
+		t.Errorf("Unexpected claimed change: %+v, %v", claimed, err)
+	}
+	if _, err := stores.Changes.Claim(early.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict claiming a change twice, got %v", err)
+	}
+	if _, err := stores.Changes.Resolve(early.ID, models.ScheduledChangeCancelled, ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict cancelling a claimed change, got %v", err)
+	}
+	if _, err := stores.Changes.Create(ctx, &models.CreateScheduledChangeRequest{ServerID: db01.ID, OSID: debian.ID, WindowStart: "2026-03-10T22:00:00Z", WindowEnd: "2026-03-11T02:00:00Z"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict scheduling a change while one is applying, got %v", err)
+	}
+	cancelled, err := stores.Changes.Resolve(late.ID, models.ScheduledChangeCancelled, "cancelled by bob")
+	if err != nil || cancelled.Status != models.ScheduledChangeCancelled || cancelled.ResolvedAt == nil || cancelled.Reason != "cancelled by bob" {
+		t.Errorf("Unexpected cancelled change: %+v, %v", cancelled, err)
+	}
+	if _, err := stores.Changes.Resolve(late.ID, models.ScheduledChangeApplied, ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict resolving a cancelled change, got %v", err)
+	}
+	if _, err := stores.Changes.Resolve(999, models.ScheduledChangeCancelled, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	// A resolved change no longer blocks scheduling another one
+	if _, err := stores.Changes.Create(ctx, &models.CreateScheduledChangeRequest{ServerID: web.ID, OSID: debian.ID, WindowStart: "2026-03-10T22:00:00Z", WindowEnd: "2026-03-11T02:00:00Z"}); err != nil {
+		t.Errorf("Failed to reschedule change: %v", err)
+	}
+	status := models.ScheduledChangePending
+	if count, _ := stores.Changes.Count(&models.ScheduledChangeFilter{Status: &status}); count != 1 {
+		t.Errorf("Expected 1 pending change, got %d", count)
+	}
+
+	// Deleting a server resolves its open changes and keeps them with its name
+	for _, server := range []*models.Server{web, db01} {
+		if err := stores.Servers.Delete(ctx, server.ID); err != nil {
+			t.Fatalf("Failed to delete server: %v", err)
+		}
+	}
+	changes, _ = stores.Changes.GetAll(nil)
+	if len(changes) != 3 {
+		t.Fatalf("Expected the changes of the deleted servers to be kept, got %+v", changes)
+	}
+	for _, change := range changes {
+		if change.ServerID != nil || change.ResolvedAt == nil {
+			t.Errorf("Expected a resolved change without server ID, got %+v", change)
+		}
+	}
+	if change, _ := stores.Changes.GetByID(early.ID); change.Status != models.ScheduledChangeFailed || change.ServerName != "db-01" || change.Reason != serverDeletedReason {
+		t.Errorf("Expected the applying change to fail, got %+v", change)
+	}
+	if change, _ := stores.Changes.GetByID(late.ID); change.Status != models.ScheduledChangeCancelled || change.Reason != "cancelled by bob" || change.ServerName != "web-01" {
+		t.Errorf("Expected the resolved change to be unchanged, got %+v", change)
+	}
+	if count, _ := stores.Changes.Count(&models.ScheduledChangeFilter{Status: &status}); count != 0 {
+		t.Errorf("Expected the pending change to be cancelled, got %d pending", count)
+	}
+
+	// An operating system targeted by scheduled changes cannot be deleted
+	if err := stores.OS.Delete(ctx, debian.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict deleting a targeted OS, got %v", err)
+	}
+}
+
+func TestMemoryServerRepository_AsOf(t *testing.T) {
+	db := NewMemoryDB()
+	stores := NewMemoryStores(db)
+	clock := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	db.now = func() time.Time { return clock }
+
+	centos, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "CentOS", Version: "7", EndOfSupport: "2024-06-30"})
+	if err != nil {
+		t.Fatalf("Failed to create OS: %v", err)
+	}
+	rocky, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Rocky Linux", Version: "9", EndOfSupport: "2032-05-31"})
+	if err != nil {
+		t.Fatalf("Failed to create OS: %v", err)
+	}
+
+	web, _ := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "web-01", OSID: centos.ID, Environment: "prod"})
+	db01, _ := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "db-01", OSID: centos.ID})
+
+	clock = clock.AddDate(0, 0, 10)
+	if _, err := stores.Servers.Update(context.Background(), web.ID, &models.UpdateServerRequest{OSID: rocky.ID}); err != nil {
+		t.Fatalf("Failed to update server: %v", err)
+	}
+	clock = clock.AddDate(0, 1, 0)
+	if err := stores.Servers.Delete(context.Background(), db01.ID); err != nil {
+		t.Fatalf("Failed to delete server: %v", err)
+	}
+
+	// On the day CentOS 7 reached end of life only db-01 was still on it
+	asOf := time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC)
+	family := "CentOS"
+	servers, err := stores.Servers.GetAll(&models.ServerFilter{AsOf: &asOf, Family: &family})
+	if err != nil {
+		t.Fatalf("Failed to get servers: %v", err)
+	}
+	if len(servers) != 1 || servers[0].Name != "db-01" {
+		t.Errorf("Expected only db-01 on CentOS as of %v, got %+v", asOf, servers)
+	}
+
+	if count, _ := stores.Servers.Count(&models.ServerFilter{AsOf: &asOf}); count != 2 {
+		t.Errorf("Expected 2 servers as of %v, got %d", asOf, count)
+	}
+	if count, _ := stores.Servers.Count(&models.ServerFilter{AsOf: &clock}); count != 1 {
+		t.Errorf("Expected 1 server after the deletion, got %d", count)
+	}
+
+	// A server renamed before its deletion does not come back under its old name
+	renamed, _ := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "a", OSID: rocky.ID})
+	if _, err := stores.Servers.Update(context.Background(), renamed.ID, &models.UpdateServerRequest{Name: "b"}); err != nil {
+		t.Fatalf("Failed to rename server: %v", err)
+	}
+	if err := stores.Servers.Delete(context.Background(), renamed.ID); err != nil {
+		t.Fatalf("Failed to delete server: %v", err)
+	}
+	clock = clock.AddDate(0, 0, 1)
+	if servers, _ := stores.Servers.GetAll(&models.ServerFilter{AsOf: &clock}); len(servers) != 1 || servers[0].Name != "web-01" {
+		t.Errorf("Expected only web-01 after the deletion, got %+v", servers)
+	}
+}
+
+func TestMemoryServerRepository_LastSeen(t *testing.T) {
+	db := NewMemoryDB()
+	stores := NewMemoryStores(db)
+	clock := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	db.now = func() time.Time { return clock }
+
+	if _, err := stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: "Ubuntu", Version: "22.04", EndOfSupport: "2027-04-01"}); err != nil {
+		t.Fatalf("Failed to create OS: %v", err)
+	}
+	rows := []models.ServerImportRow{
+		{Line: 2, Name: "web-01", OSName: "Ubuntu", OSVersion: "22.04", Environment: "prod"},
+		{Line: 3, Name: "db-01", OSName: "Ubuntu", OSVersion: "22.04", Environment: "prod"},
+	}
+	if _, err := stores.Servers.Import(context.Background(), rows, false); err != nil {
+		t.Fatalf("Failed to import servers: %v", err)
+	}
+	manual, _ := stores.Servers.Create(context.Background(), &models.CreateServerRequest{Name: "manual-01", OSID: 1, Environment: "prod"})
+	if manual.LastSeenAt != nil {
+		t.Errorf("Expected a server created by hand to never have been seen, got %v", manual.LastSeenAt)
+	}
+
+	// Reimporting web-01 unchanged marks it as seen without an update
+	created := clock
+	clock = clock.AddDate(0, 0, 40)
+	report, err := stores.Servers.Import(context.Background(), rows[:1], false)
+	if err != nil || report.Unchanged != 1 {
+		t.Fatalf("Unexpected import: %+v, %v", report, err)
+	}
+	web, _ := stores.Servers.GetByID(*report.Rows[0].ServerID)
+	if web.LastSeenAt == nil || !web.LastSeenAt.Equal(clock) || !web.UpdatedAt.Equal(created) {
+		t.Errorf("Expected web-01 seen at %v and updated at %v, got %v and %v", clock, created, web.LastSeenAt, web.UpdatedAt)
+	}
+	if history, _ := stores.ChangeHistory.GetAll(nil); len(history) != 3 {
+		t.Errorf("Expected no history entry for a heartbeat, got %d entries", len(history))
+	}
+
+	names := func(filter *models.ServerFilter) []string {
+		servers, err := stores.Servers.GetAll(filter)
+		if err != nil {
+			t.Fatalf("Failed to get servers: %v", err)
+		}
+		var names []string
+		for _, server := range servers {
+			names = append(names, server.Name)
+		}
+		return names
+	}
+
+	stale, fresh := true, false
+	if got := names(&models.ServerFilter{Stale: &stale}); len(got) != 1 || got[0] != "db-01" {
+		t.Errorf("Expected db-01 to be stale, got %v", got)
+	}
+	if got := names(&models.ServerFilter{Stale: &fresh, Sort: []models.SortField{{Field: "name"}}}); len(got) != 2 || got[0] != "manual-01" || got[1] != "web-01" {
+		t.Errorf("Expected manual-01 and web-01 not to be stale, got %v", got)
+	}
+	cutoff := clock.AddDate(0, 0, -60)
+	if got := names(&models.ServerFilter{Stale: &stale, StaleCutoff: &cutoff}); len(got) != 0 {
+		t.Errorf("Expected no stale server with a 60 day threshold, got %v", got)
+	}
+
+	// Servers that never reported sort last, as NULL values do in PostgreSQL
+	if got := names(&models.ServerFilter{Sort: []models.SortField{{Field: "last_seen_at"}}}); len(got) != 3 || got[0] != "db-01" || got[1] != "web-01" || got[2] != "manual-01" {
+		t.Errorf("Unexpected order by last seen: %v", got)
+	}
+
+	// Cursors seek past servers that never reported in either direction
+	for _, sort := range [][]models.SortField{{{Field: "last_seen_at"}}, {{Field: "last_seen_at", Desc: true}}} {
+		var paged []string
+		filter := &models.ServerFilter{Sort: sort, Limit: 1}
+		for page := 0; page < 4; page++ {
+			servers, err := stores.Servers.GetAll(filter)
+			if err != nil {
+				t.Fatalf("Failed to get servers: %v", err)
+			}
+			if len(servers) == 0 {
+				break
+			}
+			paged = append(paged, servers[0].Name)
+			cursor := models.ServerCursor(servers[0], sort)
+			filter.After = &cursor
+		}
+		if all := names(&models.ServerFilter{Sort: sort}); fmt.Sprint(paged) != fmt.Sprint(all) {
+			t.Errorf("Expected pages of %v to list %v, got %v", models.FormatSort(sort), all, paged)
+		}
+	}
+}
+
+func TestMemoryRepository_ExtendedSupport(t *testing.T) {
+	db := NewMemoryDB()
+	stores := NewMemoryStores(db)
+	db.now = func() time.Time { return time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	for _, req := range []models.CreateOSRequest{
+		{Name: "Ubuntu", Version: "18.04", LTS: true, EndOfSupport: "2023-05-31", EndOfExtendedSupport: "2033-04-01"},
+		{Name: "Ubuntu", Version: "22.04", LTS: true, EndOfStandardSupport: "2027-04-01", EndOfSupport: "2032-04-09"},
+		{Name: "Ubuntu", Version: "24.04", LTS: true, EndOfSupport: "2034-04-25"},
+		{Name: "CentOS", Version: "7", EndOfSupport: "2024-06-30"},
+	} {
+		if _, err := stores.OS.Create(ctx, &req); err != nil {
+			t.Fatalf("Failed to create OS: %v", err)
+		}
+	}
+
+	for phase, want := range map[string][]string{
+		models.PhaseCurrent:     {"Ubuntu 24.04"},
+		models.PhaseMaintenance: {"Ubuntu 22.04"},
+		models.PhaseExtended:    {"Ubuntu 18.04"},
+		models.PhaseEndOfLife:   {"CentOS 7"},
+	} {
+		oss, err := stores.OS.GetAll(&models.OSFilter{Phase: &phase})
+		if err != nil || len(oss) != len(want) || oss[0].Name+" "+oss[0].Version != want[0] {
+			t.Errorf("Expected %v in the %s phase, got %+v, %v", want, phase, oss, err)
+		}
+	}
+
+	// Only the server enrolled in extended support is supported
+	enrolled, err := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: "web-01", OSID: 1, ExtendedSupport: true})
+	if err != nil || !enrolled.ExtendedSupport {
+		t.Fatalf("Unexpected server %+v, %v", enrolled, err)
+	}
+	if _, err := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: "web-02", OSID: 1}); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	eol := models.StatusEndOfLife
+	servers, err := stores.Servers.GetAll(&models.ServerFilter{SupportStatus: &eol})
+	if err != nil || len(servers) != 1 || servers[0].Name != "web-02" {
+		t.Errorf("Expected web-02 only to be end of life, got %+v, %v", servers, err)
+	}
+
+	// Withdrawing from extended support is recorded in the history
+	withdrawn := false
+	updated, err := stores.Servers.Update(ctx, enrolled.ID, &models.UpdateServerRequest{ExtendedSupport: &withdrawn})
+	if err != nil || updated.ExtendedSupport {
+		t.Fatalf("Unexpected server %+v, %v", updated, err)
+	}
+	history, err := stores.ChangeHistory.GetByServerID(enrolled.ID, 10)
+	if err != nil || len(history) != 2 || history[0].Changes["extended_support"].New != false {
+		t.Errorf("Unexpected history %+v, %v", history, err)
+	}
+	if count, _ := stores.Servers.Count(&models.ServerFilter{SupportStatus: &eol}); count != 2 {
+		t.Errorf("Expected 2 end of life servers, got %d", count)
+	}
+}
+
+func TestMemoryAPIKeyRepository(t *testing.T) {
+	stores := NewMemoryStores(NewMemoryDB())
+
+	key, err := stores.APIKeys.Create(&models.APIKey{Name: "ci", Role: models.RoleOperator, Prefix: "idk_abc"}, "hash-1")
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if _, err := stores.APIKeys.Create(&models.APIKey{Name: "ci", Role: models.RoleViewer}, "hash-2"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a duplicate active name, got %v", err)
+	}
+
+	if found, err := stores.APIKeys.GetByHash("hash-1"); err != nil || found.ID != key.ID {
+		t.Fatalf("Expected to find the key by hash, got %+v, %v", found, err)
+	}
+
+	rotated, err := stores.APIKeys.Rotate(key.ID, "idk_def", "hash-3")
+	if err != nil || rotated.Prefix != "idk_def" || rotated.RotatedAt == nil {
+		t.Fatalf("Unexpected rotated key: %+v, %v", rotated, err)
+	}
+	if _, err := stores.APIKeys.GetByHash("hash-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the previous hash to be forgotten, got %v", err)
+	}
+
+	usedAt := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	if err := stores.APIKeys.MarkUsed(key.ID, usedAt); err != nil {
+		t.Fatalf("Failed to mark key as used: %v", err)
+	}
+
+	revoked, err := stores.APIKeys.Revoke(key.ID)
+	if err != nil || revoked.Active() || !revoked.LastUsedAt.Equal(usedAt) {
+		t.Fatalf("Unexpected revoked key: %+v, %v", revoked, err)
+	}
+	if _, err := stores.APIKeys.Revoke(key.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict revoking twice, got %v", err)
+	}
+	if _, err := stores.APIKeys.Rotate(key.ID, "idk_ghi", "hash-4"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict rotating a revoked key, got %v", err)
+	}
+
+	// The name can be reused once the key is revoked
+	if _, err := stores.APIKeys.Create(&models.APIKey{Name: "ci", Role: models.RoleViewer}, "hash-5"); err != nil {
+		t.Fatalf("Failed to reuse the name of a revoked key: %v", err)
+	}
+	for status, expected := range map[string]int{models.APIKeyStatusActive: 1, models.APIKeyStatusRevoked: 1} {
+		count, err := stores.APIKeys.Count(&models.APIKeyFilter{Status: &status})
+		if err != nil || count != expected {
+			t.Errorf("Expected %d %s keys, got %d, %v", expected, status, count, err)
+		}
+	}
+}
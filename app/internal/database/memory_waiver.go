@@ -0,0 +1,201 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// MemoryWaiverRepository provides in-memory operations for compliance waivers
+type MemoryWaiverRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryWaiverRepository creates a new in-memory waiver repository
+func NewMemoryWaiverRepository(db *MemoryDB) *MemoryWaiverRepository {
+	return &MemoryWaiverRepository{db: db}
+}
+
+// GetAll retrieves waivers with optional filters and pagination, soonest expiry first
+func (r *MemoryWaiverRepository) GetAll(filter *models.WaiverFilter) ([]models.Waiver, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	waivers := r.matching(filter)
+
+	sort.Slice(waivers, func(i, j int) bool {
+		if !waivers[i].ExpiresAt.Equal(waivers[j].ExpiresAt) {
+			return waivers[i].ExpiresAt.Before(waivers[j].ExpiresAt)
+		}
+		return waivers[i].ID < waivers[j].ID
+	})
+
+	if filter != nil {
+		waivers = paginate(waivers, filter.Limit, filter.Offset)
+	}
+
+	return waivers, nil
+}
+
+// Count returns the number of waivers matching a filter, ignoring pagination
+func (r *MemoryWaiverRepository) Count(filter *models.WaiverFilter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return len(r.matching(filter)), nil
+}
+
+// matching returns the waivers satisfying a filter. The caller must hold the lock.
+func (r *MemoryWaiverRepository) matching(filter *models.WaiverFilter) []models.Waiver {
+	now := r.db.now()
+
+	var waivers []models.Waiver
+	for _, waiver := range r.db.waivers {
+		if filter != nil {
+			if filter.ServerID != nil && (waiver.ServerID == nil || *waiver.ServerID != *filter.ServerID) {
+				continue
+			}
+			if filter.OSID != nil && (waiver.OSID == nil || *waiver.OSID != *filter.OSID) {
+				continue
+			}
+			if filter.Status != nil && waiver.Status(now) != *filter.Status {
+				continue
+			}
+		}
+		waivers = append(waivers, waiver)
+	}
+
+	return waivers
+}
+
+// GetByID retrieves a waiver by its ID
+func (r *MemoryWaiverRepository) GetByID(id int) (*models.Waiver, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	waiver, exists := r.db.waivers[id]
+	if !exists {
+		return nil, fmt.Errorf("waiver with id %d %w", id, ErrNotFound)
+	}
+
+	return &waiver, nil
+}
+
+// Create creates a new waiver
+func (r *MemoryWaiverRepository) Create(req *models.CreateWaiverRequest) (*models.Waiver, error) {
+	expiresAt, err := parseWaiverExpiry(req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// Mirror the foreign keys and scope check on compliance_waivers
+	if (req.ServerID == nil) == (req.OSID == nil) {
+		return nil, fmt.Errorf("%w: a waiver must reference exactly one of a server or an operating system", ErrInvalidInput)
+	}
+	now := r.db.now()
+	waiver := models.Waiver{
+		ID:            r.db.nextWaiverID,
+		ServerID:      copyIntPtr(req.ServerID),
+		OSID:          copyIntPtr(req.OSID),
+		Justification: req.Justification,
+		Approver:      req.Approver,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if req.ServerID != nil {
+		server, exists := r.db.servers[*req.ServerID]
+		if !exists {
+			return nil, fmt.Errorf("waived server or operating system does not exist: %w", ErrInvalidReference)
+		}
+		waiver.ServerName = server.Name
+	}
+	if req.OSID != nil {
+		os, exists := r.db.oss[*req.OSID]
+		if !exists {
+			return nil, fmt.Errorf("waived server or operating system does not exist: %w", ErrInvalidReference)
+		}
+		waiver.OSName, waiver.OSVersion = os.Name, os.Version
+	}
+	r.db.nextWaiverID++
+	r.db.waivers[waiver.ID] = waiver
+
+	return &waiver, nil
+}
+
+// Update updates the justification, approver or expiry of a waiver that has
+// not been revoked
+func (r *MemoryWaiverRepository) Update(id int, req *models.UpdateWaiverRequest) (*models.Waiver, error) {
+	var expiresAt time.Time
+	if req.ExpiresAt != "" {
+		var err error
+		if expiresAt, err = parseWaiverExpiry(req.ExpiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	waiver, exists := r.db.waivers[id]
+	if !exists {
+		return nil, fmt.Errorf("waiver with id %d %w", id, ErrNotFound)
+	}
+
+	if req.Justification == "" && req.Approver == "" && req.ExpiresAt == "" {
+		return &waiver, nil // No updates, return existing waiver
+	}
+	if waiver.RevokedAt != nil {
+		return nil, fmt.Errorf("cannot update waiver %d: it has been revoked: %w", id, ErrConflict)
+	}
+
+	if req.Justification != "" {
+		waiver.Justification = req.Justification
+	}
+	if req.Approver != "" {
+		waiver.Approver = req.Approver
+	}
+	if req.ExpiresAt != "" {
+		waiver.ExpiresAt = expiresAt
+	}
+
+	waiver.UpdatedAt = r.db.now()
+	r.db.waivers[id] = waiver
+
+	return &waiver, nil
+}
+
+// Revoke ends a waiver that has not been revoked. Revoked waivers are kept
+// for auditing.
+func (r *MemoryWaiverRepository) Revoke(id int) (*models.Waiver, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	waiver, exists := r.db.waivers[id]
+	if !exists {
+		return nil, fmt.Errorf("waiver with id %d %w", id, ErrNotFound)
+	}
+	if waiver.RevokedAt != nil {
+		return nil, fmt.Errorf("cannot revoke waiver %d: it has been revoked: %w", id, ErrConflict)
+	}
+
+	now := r.db.now()
+	waiver.RevokedAt = &now
+	waiver.UpdatedAt = now
+	r.db.waivers[id] = waiver
+
+	return &waiver, nil
+}
+
+// copyIntPtr returns a copy of an optional ID so stored records do not alias request values
+func copyIntPtr(v *int) *int {
+	if v == nil {
+		return nil
+	}
+	return intPtr(*v)
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX a ON b(c);")},
+		"0002_add_index.down.sql": {Data: []byte("DROP INDEX a;")},
+		"0001_init.up.sql":        {Data: []byte("CREATE TABLE b (c INT);")},
+		"0001_init.down.sql":      {Data: []byte("DROP TABLE b;")},
+		"README.md":               {Data: []byte("ignored")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "init" {
+		t.Errorf("Expected 0001_init first, got %d_%s", migrations[0].Version, migrations[0].Name)
+	}
+	if migrations[1].Down != "DROP INDEX a;" {
+		t.Errorf("Unexpected down step: %q", migrations[1].Down)
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missing direction",
+			fsys: fstest.MapFS{"0001_init.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "invalid version",
+			fsys: fstest.MapFS{"abc_init.up.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{"0001_init.down.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":  {Data: []byte("SELECT 1;")},
+				"0001_other.up.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadMigrations(tt.fsys); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := NewMigrator(nil)
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+
+	for i, migration := range m.migrations {
+		if migration.Version != i+1 {
+			t.Errorf("Expected migration version %d, got %d", i+1, migration.Version)
+		}
+		if migration.Down == "" {
+			t.Errorf("Migration %d_%s has no down step", migration.Version, migration.Name)
+		}
+	}
+}
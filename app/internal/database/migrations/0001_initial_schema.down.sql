@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS server_change_history;
+DROP TABLE IF EXISTS servers;
+DROP TABLE IF EXISTS operating_systems;
+
+DROP FUNCTION IF EXISTS log_server_deletion();
+DROP FUNCTION IF EXISTS log_server_os_change();
+DROP FUNCTION IF EXISTS log_server_creation();
+DROP FUNCTION IF EXISTS update_updated_at_column();
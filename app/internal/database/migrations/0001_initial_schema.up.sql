@@ -0,0 +1,171 @@
+-- Initial schema: operating system catalog, server inventory and server
+-- change history. Every statement is idempotent so that databases that were
+-- bootstrapped from the former init.sql can adopt the migration runner.
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE TABLE IF NOT EXISTS operating_systems (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    version VARCHAR(100) NOT NULL,
+    end_of_support DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(name, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_os_name ON operating_systems(name);
+CREATE INDEX IF NOT EXISTS idx_os_end_of_support ON operating_systems(end_of_support);
+
+DROP TRIGGER IF EXISTS update_operating_systems_updated_at ON operating_systems;
+CREATE TRIGGER update_operating_systems_updated_at
+    BEFORE UPDATE ON operating_systems
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS servers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    os_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    FOREIGN KEY (os_id) REFERENCES operating_systems(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_servers_name ON servers(name);
+CREATE INDEX IF NOT EXISTS idx_servers_os_id ON servers(os_id);
+
+DROP TRIGGER IF EXISTS update_servers_updated_at ON servers;
+CREATE TRIGGER update_servers_updated_at
+    BEFORE UPDATE ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS server_change_history (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER,
+    server_name VARCHAR(255) NOT NULL,
+    change_type VARCHAR(50) NOT NULL, -- 'created', 'os_changed', 'deleted'
+    old_os_id INTEGER,
+    new_os_id INTEGER,
+    old_os_name VARCHAR(100),
+    old_os_version VARCHAR(100),
+    new_os_name VARCHAR(100),
+    new_os_version VARCHAR(100),
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE SET NULL,
+    FOREIGN KEY (old_os_id) REFERENCES operating_systems(id) ON DELETE SET NULL,
+    FOREIGN KEY (new_os_id) REFERENCES operating_systems(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_change_history_server_id ON server_change_history(server_id);
+CREATE INDEX IF NOT EXISTS idx_change_history_change_type ON server_change_history(change_type);
+CREATE INDEX IF NOT EXISTS idx_change_history_changed_at ON server_change_history(changed_at);
+
+CREATE OR REPLACE FUNCTION log_server_creation()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        new_os_id,
+        new_os_name,
+        new_os_version
+    )
+    SELECT
+        NEW.id,
+        NEW.name,
+        'created',
+        NEW.os_id,
+        os.name,
+        os.version
+    FROM operating_systems os
+    WHERE os.id = NEW.os_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_os_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Only log if os_id actually changed
+    IF OLD.os_id != NEW.os_id THEN
+        INSERT INTO server_change_history (
+            server_id,
+            server_name,
+            change_type,
+            old_os_id,
+            new_os_id,
+            old_os_name,
+            old_os_version,
+            new_os_name,
+            new_os_version
+        )
+        SELECT
+            NEW.id,
+            NEW.name,
+            'os_changed',
+            OLD.os_id,
+            NEW.os_id,
+            old_os.name,
+            old_os.version,
+            new_os.name,
+            new_os.version
+        FROM operating_systems old_os, operating_systems new_os
+        WHERE old_os.id = OLD.os_id AND new_os.id = NEW.os_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        old_os_id,
+        old_os_name,
+        old_os_version
+    )
+    SELECT
+        OLD.id,
+        OLD.name,
+        'deleted',
+        OLD.os_id,
+        os.name,
+        os.version
+    FROM operating_systems os
+    WHERE os.id = OLD.os_id;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE 'plpgsql';
+
+DROP TRIGGER IF EXISTS log_server_creation_trigger ON servers;
+CREATE TRIGGER log_server_creation_trigger
+    AFTER INSERT ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION log_server_creation();
+
+DROP TRIGGER IF EXISTS log_server_os_change_trigger ON servers;
+CREATE TRIGGER log_server_os_change_trigger
+    AFTER UPDATE ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION log_server_os_change();
+
+DROP TRIGGER IF EXISTS log_server_deletion_trigger ON servers;
+CREATE TRIGGER log_server_deletion_trigger
+    BEFORE DELETE ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION log_server_deletion();
@@ -0,0 +1,106 @@
+-- Remove seeded catalog entries that are not referenced by any server.
+
+DELETE FROM operating_systems os
+USING (VALUES
+    ('Debian','4'),
+    ('Debian','5'),
+    ('Debian','6'),
+    ('Debian','7'),
+    ('Debian','8'),
+    ('Debian','9'),
+    ('Debian','10'),
+    ('Debian','11'),
+    ('Debian','12'),
+    ('Debian','13'),
+    ('Ubuntu','10.04'),
+    ('Ubuntu','10.10'),
+    ('Ubuntu','11.04'),
+    ('Ubuntu','11.10'),
+    ('Ubuntu','12.04'),
+    ('Ubuntu','12.10'),
+    ('Ubuntu','13.04'),
+    ('Ubuntu','13.10'),
+    ('Ubuntu','14.04'),
+    ('Ubuntu','14.10'),
+    ('Ubuntu','15.04'),
+    ('Ubuntu','15.10'),
+    ('Ubuntu','16.04'),
+    ('Ubuntu','16.10'),
+    ('Ubuntu','17.04'),
+    ('Ubuntu','17.10'),
+    ('Ubuntu','18.04'),
+    ('Ubuntu','20.04'),
+    ('Ubuntu','22.04'),
+    ('Ubuntu','24.04'),
+    ('Ubuntu','26.04'),
+    ('CentOS','4'),
+    ('CentOS','5'),
+    ('CentOS','6'),
+    ('CentOS','7'),
+    ('CentOS','8'),
+    ('RedHat','5'),
+    ('RedHat','6'),
+    ('RedHat','7'),
+    ('RedHat','8'),
+    ('RedHat','9'),
+    ('RedHat','10'),
+    ('FreeBSD','9.0'),
+    ('FreeBSD','9.1'),
+    ('FreeBSD','9.2'),
+    ('FreeBSD','9.3'),
+    ('FreeBSD','10.0'),
+    ('FreeBSD','10.1'),
+    ('FreeBSD','10.2'),
+    ('FreeBSD','10.3'),
+    ('FreeBSD','10.4'),
+    ('FreeBSD','11.0'),
+    ('FreeBSD','11.1'),
+    ('FreeBSD','11.2'),
+    ('FreeBSD','11.3'),
+    ('FreeBSD','11.4'),
+    ('FreeBSD','12.0'),
+    ('FreeBSD','12.1'),
+    ('FreeBSD','12.2'),
+    ('FreeBSD','12.3'),
+    ('FreeBSD','12.4'),
+    ('FreeBSD','13.0'),
+    ('FreeBSD','13.1'),
+    ('FreeBSD','13.2'),
+    ('FreeBSD','13.3'),
+    ('FreeBSD','13.4'),
+    ('FreeBSD','13.5'),
+    ('FreeBSD','14.0'),
+    ('FreeBSD','14.1'),
+    ('FreeBSD','14.2'),
+    ('FreeBSD','14.3'),
+    ('OpenBSD','5.0'),
+    ('OpenBSD','5.1'),
+    ('OpenBSD','5.2'),
+    ('OpenBSD','5.3'),
+    ('OpenBSD','5.4'),
+    ('OpenBSD','5.5'),
+    ('OpenBSD','5.6'),
+    ('OpenBSD','5.7'),
+    ('OpenBSD','5.8'),
+    ('OpenBSD','5.9'),
+    ('OpenBSD','6.0'),
+    ('OpenBSD','6.1'),
+    ('OpenBSD','6.2'),
+    ('OpenBSD','6.3'),
+    ('OpenBSD','6.4'),
+    ('OpenBSD','6.5'),
+    ('OpenBSD','6.6'),
+    ('OpenBSD','6.7'),
+    ('OpenBSD','6.8'),
+    ('OpenBSD','6.9'),
+    ('OpenBSD','7.0'),
+    ('OpenBSD','7.1'),
+    ('OpenBSD','7.2'),
+    ('OpenBSD','7.3'),
+    ('OpenBSD','7.4'),
+    ('OpenBSD','7.5'),
+    ('OpenBSD','7.6')
+) AS seed(name, version)
+WHERE os.name = seed.name
+  AND os.version = seed.version
+  AND NOT EXISTS (SELECT 1 FROM servers s WHERE s.os_id = os.id);
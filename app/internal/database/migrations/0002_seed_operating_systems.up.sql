@@ -0,0 +1,103 @@
+-- Seed the operating system catalog with the lifecycle data that used to be
+-- shipped in init.sql. Existing rows are left untouched.
+
+INSERT INTO operating_systems (name, version, end_of_support) VALUES
+    ('Debian','4','2010-02-28'),
+    ('Debian','5','2012-02-06'),
+    ('Debian','6','2016-02-06'),
+    ('Debian','7','2018-04-01'),
+    ('Debian','8','2020-04-01'),
+    ('Debian','9','2022-06-30'),
+    ('Debian','10','2024-06-30'),
+    ('Debian','11','2026-06-30'),
+    ('Debian','12','2028-06-30'),
+    ('Debian','13','2030-06-30'),
+    ('Ubuntu','10.04','2015-04-30'),
+    ('Ubuntu','10.10','2012-04-10'),
+    ('Ubuntu','11.04','2012-10-28'),
+    ('Ubuntu','11.10','2013-05-09'),
+    ('Ubuntu','12.04','2017-04-01'),
+    ('Ubuntu','12.10','2014-05-16'),
+    ('Ubuntu','13.04','2014-01-27'),
+    ('Ubuntu','13.10','2014-07-17'),
+    ('Ubuntu','14.04','2019-04-01'),
+    ('Ubuntu','14.10','2015-07-23'),
+    ('Ubuntu','15.04','2016-02-04'),
+    ('Ubuntu','15.10','2016-07-28'),
+    ('Ubuntu','16.04','2021-04-01'),
+    ('Ubuntu','16.10','2017-07-01'),
+    ('Ubuntu','17.04','2018-01-31'),
+    ('Ubuntu','17.10','2018-07-31'),
+    ('Ubuntu','18.04','2023-04-01'),
+    ('Ubuntu','20.04','2025-04-01'),
+    ('Ubuntu','22.04','2027-04-01'),
+    ('Ubuntu','24.04','2029-04-01'),
+    ('Ubuntu','26.04','2031-04-01'),
+    ('CentOS','4','2012-02-29'),
+    ('CentOS','5','2017-03-31'),
+    ('CentOS','6','2020-11-30'),
+    ('CentOS','7','2024-06-30'),
+    ('CentOS','8','2021-12-31'),
+    ('RedHat','5','2017-03-31'),
+    ('RedHat','6','2020-11-30'),
+    ('RedHat','7','2024-06-30'),
+    ('RedHat','8','2029-05-31'),
+    ('RedHat','9','2032-05-31'),
+    ('RedHat','10','2035-05-31'),
+    ('FreeBSD','9.0','2013-03-31'),
+    ('FreeBSD','9.1','2014-12-31'),
+    ('FreeBSD','9.2','2014-12-31'),
+    ('FreeBSD','9.3','2016-12-31'),
+    ('FreeBSD','10.0','2015-02-28'),
+    ('FreeBSD','10.1','2016-12-31'),
+    ('FreeBSD','10.2','2016-12-31'),
+    ('FreeBSD','10.3','2018-04-30'),
+    ('FreeBSD','10.4','2018-10-31'),
+    ('FreeBSD','11.0','2017-11-30'),
+    ('FreeBSD','11.1','2018-09-30'),
+    ('FreeBSD','11.2','2019-10-31'),
+    ('FreeBSD','11.3','2020-09-30'),
+    ('FreeBSD','11.4','2021-09-30'),
+    ('FreeBSD','12.0','2020-02-04'),
+    ('FreeBSD','12.1','2021-01-31'),
+    ('FreeBSD','12.2','2022-03-31'),
+    ('FreeBSD','12.3','2023-03-31'),
+    ('FreeBSD','12.4','2023-12-31'),
+    ('FreeBSD','13.0','2022-08-31'),
+    ('FreeBSD','13.1','2023-07-31'),
+    ('FreeBSD','13.2','2024-06-30'),
+    ('FreeBSD','13.3','2024-12-31'),
+    ('FreeBSD','13.4','2025-06-30'),
+    ('FreeBSD','13.5','2026-04-30'),
+    ('FreeBSD','14.0','2024-09-30'),
+    ('FreeBSD','14.1','2025-03-31'),
+    ('FreeBSD','14.2','2025-09-30'),
+    ('FreeBSD','14.3','2026-06-30'),
+    ('OpenBSD','5.0','2012-11-01'),
+    ('OpenBSD','5.1','2013-05-01'),
+    ('OpenBSD','5.2','2013-11-01'),
+    ('OpenBSD','5.3','2014-05-01'),
+    ('OpenBSD','5.4','2014-11-01'),
+    ('OpenBSD','5.5','2015-05-01'),
+    ('OpenBSD','5.6','2015-10-18'),
+    ('OpenBSD','5.7','2016-03-29'),
+    ('OpenBSD','5.8','2016-09-01'),
+    ('OpenBSD','5.9','2017-04-11'),
+    ('OpenBSD','6.0','2017-11-09'),
+    ('OpenBSD','6.1','2018-05-01'),
+    ('OpenBSD','6.2','2018-11-01'),
+    ('OpenBSD','6.3','2019-05-01'),
+    ('OpenBSD','6.4','2019-11-01'),
+    ('OpenBSD','6.5','2020-05-19'),
+    ('OpenBSD','6.6','2020-10-18'),
+    ('OpenBSD','6.7','2021-05-01'),
+    ('OpenBSD','6.8','2021-10-14'),
+    ('OpenBSD','6.9','2022-04-21'),
+    ('OpenBSD','7.0','2022-10-20'),
+    ('OpenBSD','7.1','2023-04-10'),
+    ('OpenBSD','7.2','2023-10-16'),
+    ('OpenBSD','7.3','2024-04-05'),
+    ('OpenBSD','7.4','2024-10-08'),
+    ('OpenBSD','7.5','2025-04-28'),
+    ('OpenBSD','7.6','2025-11-01')
+ON CONFLICT (name, version) DO NOTHING;
@@ -0,0 +1,102 @@
+-- Restore the original history triggers before dropping the attribute columns.
+
+CREATE OR REPLACE FUNCTION log_server_creation()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        new_os_id,
+        new_os_name,
+        new_os_version
+    )
+    SELECT
+        NEW.id,
+        NEW.name,
+        'created',
+        NEW.os_id,
+        os.name,
+        os.version
+    FROM operating_systems os
+    WHERE os.id = NEW.os_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_os_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Only log if os_id actually changed
+    IF OLD.os_id != NEW.os_id THEN
+        INSERT INTO server_change_history (
+            server_id,
+            server_name,
+            change_type,
+            old_os_id,
+            new_os_id,
+            old_os_name,
+            old_os_version,
+            new_os_name,
+            new_os_version
+        )
+        SELECT
+            NEW.id,
+            NEW.name,
+            'os_changed',
+            OLD.os_id,
+            NEW.os_id,
+            old_os.name,
+            old_os.version,
+            new_os.name,
+            new_os.version
+        FROM operating_systems old_os, operating_systems new_os
+        WHERE old_os.id = OLD.os_id AND new_os.id = NEW.os_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        old_os_id,
+        old_os_name,
+        old_os_version
+    )
+    SELECT
+        OLD.id,
+        OLD.name,
+        'deleted',
+        OLD.os_id,
+        os.name,
+        os.version
+    FROM operating_systems os
+    WHERE os.id = OLD.os_id;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE 'plpgsql';
+
+ALTER TABLE server_change_history
+    DROP COLUMN IF EXISTS environment,
+    DROP COLUMN IF EXISTS role,
+    DROP COLUMN IF EXISTS owner_team,
+    DROP COLUMN IF EXISTS location;
+
+DROP INDEX IF EXISTS idx_servers_owner_team;
+DROP INDEX IF EXISTS idx_servers_environment;
+
+ALTER TABLE servers DROP CONSTRAINT IF EXISTS servers_environment_check;
+ALTER TABLE servers
+    DROP COLUMN IF EXISTS environment,
+    DROP COLUMN IF EXISTS role,
+    DROP COLUMN IF EXISTS owner_team,
+    DROP COLUMN IF EXISTS location,
+    DROP COLUMN IF EXISTS description;
@@ -0,0 +1,131 @@
+-- Inventory attributes used to triage servers: environment, role, owning
+-- team, location and a free-form description. Change history records keep a
+-- snapshot of the categorical attributes at the time of the change.
+
+ALTER TABLE servers
+    ADD COLUMN IF NOT EXISTS environment VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS role VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS owner_team VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS location VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE servers DROP CONSTRAINT IF EXISTS servers_environment_check;
+ALTER TABLE servers ADD CONSTRAINT servers_environment_check
+    CHECK (environment IN ('', 'prod', 'staging', 'dev'));
+
+CREATE INDEX IF NOT EXISTS idx_servers_environment ON servers(environment);
+CREATE INDEX IF NOT EXISTS idx_servers_owner_team ON servers(owner_team);
+
+ALTER TABLE server_change_history
+    ADD COLUMN IF NOT EXISTS environment VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS role VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS owner_team VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS location VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE OR REPLACE FUNCTION log_server_creation()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        new_os_id,
+        new_os_name,
+        new_os_version,
+        environment,
+        role,
+        owner_team,
+        location
+    )
+    SELECT
+        NEW.id,
+        NEW.name,
+        'created',
+        NEW.os_id,
+        os.name,
+        os.version,
+        NEW.environment,
+        NEW.role,
+        NEW.owner_team,
+        NEW.location
+    FROM operating_systems os
+    WHERE os.id = NEW.os_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_os_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Only log if os_id actually changed
+    IF OLD.os_id != NEW.os_id THEN
+        INSERT INTO server_change_history (
+            server_id,
+            server_name,
+            change_type,
+            old_os_id,
+            new_os_id,
+            old_os_name,
+            old_os_version,
+            new_os_name,
+            new_os_version,
+            environment,
+            role,
+            owner_team,
+            location
+        )
+        SELECT
+            NEW.id,
+            NEW.name,
+            'os_changed',
+            OLD.os_id,
+            NEW.os_id,
+            old_os.name,
+            old_os.version,
+            new_os.name,
+            new_os.version,
+            NEW.environment,
+            NEW.role,
+            NEW.owner_team,
+            NEW.location
+        FROM operating_systems old_os, operating_systems new_os
+        WHERE old_os.id = OLD.os_id AND new_os.id = NEW.os_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        old_os_id,
+        old_os_name,
+        old_os_version,
+        environment,
+        role,
+        owner_team,
+        location
+    )
+    SELECT
+        OLD.id,
+        OLD.name,
+        'deleted',
+        OLD.os_id,
+        os.name,
+        os.version,
+        OLD.environment,
+        OLD.role,
+        OLD.owner_team,
+        OLD.location
+    FROM operating_systems os
+    WHERE os.id = OLD.os_id;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE 'plpgsql';
@@ -0,0 +1,23 @@
+-- Compliance waivers accept the risk of running a server, or every server on
+-- an operating system, on an unsupported OS until the waiver expires.
+
+CREATE TABLE compliance_waivers (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER REFERENCES servers(id) ON DELETE CASCADE,
+    os_id INTEGER REFERENCES operating_systems(id) ON DELETE CASCADE,
+    justification TEXT NOT NULL,
+    approver VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT compliance_waivers_scope_check CHECK ((server_id IS NULL) <> (os_id IS NULL))
+);
+
+CREATE INDEX idx_compliance_waivers_server_id ON compliance_waivers(server_id);
+CREATE INDEX idx_compliance_waivers_os_id ON compliance_waivers(os_id);
+CREATE INDEX idx_compliance_waivers_expires_at ON compliance_waivers(expires_at);
+
+CREATE TRIGGER update_compliance_waivers_updated_at
+    BEFORE UPDATE ON compliance_waivers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,16 @@
+-- Compliance snapshots persist the fleet compliance summary over time so
+-- that trends can be reported.
+
+CREATE TABLE compliance_snapshots (
+    id SERIAL PRIMARY KEY,
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    source VARCHAR(20) NOT NULL CHECK (source IN ('scheduled', 'manual')),
+    total_servers INTEGER NOT NULL,
+    supported_servers INTEGER NOT NULL,
+    end_of_life_servers INTEGER NOT NULL,
+    ending_soon_servers INTEGER NOT NULL,
+    waived_servers INTEGER NOT NULL DEFAULT 0,
+    compliance_score DOUBLE PRECISION NOT NULL
+);
+
+CREATE INDEX idx_compliance_snapshots_taken_at ON compliance_snapshots(taken_at);
@@ -0,0 +1,130 @@
+-- Restore the history triggers before dropping the change capture columns.
+
+CREATE OR REPLACE FUNCTION log_server_creation()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        new_os_id,
+        new_os_name,
+        new_os_version,
+        environment,
+        role,
+        owner_team,
+        location
+    )
+    SELECT
+        NEW.id,
+        NEW.name,
+        'created',
+        NEW.os_id,
+        os.name,
+        os.version,
+        NEW.environment,
+        NEW.role,
+        NEW.owner_team,
+        NEW.location
+    FROM operating_systems os
+    WHERE os.id = NEW.os_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_os_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Only log if os_id actually changed
+    IF OLD.os_id != NEW.os_id THEN
+        INSERT INTO server_change_history (
+            server_id,
+            server_name,
+            change_type,
+            old_os_id,
+            new_os_id,
+            old_os_name,
+            old_os_version,
+            new_os_name,
+            new_os_version,
+            environment,
+            role,
+            owner_team,
+            location
+        )
+        SELECT
+            NEW.id,
+            NEW.name,
+            'os_changed',
+            OLD.os_id,
+            NEW.os_id,
+            old_os.name,
+            old_os.version,
+            new_os.name,
+            new_os.version,
+            NEW.environment,
+            NEW.role,
+            NEW.owner_team,
+            NEW.location
+        FROM operating_systems old_os, operating_systems new_os
+        WHERE old_os.id = OLD.os_id AND new_os.id = NEW.os_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION log_server_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO server_change_history (
+        server_id,
+        server_name,
+        change_type,
+        old_os_id,
+        old_os_name,
+        old_os_version,
+        environment,
+        role,
+        owner_team,
+        location
+    )
+    SELECT
+        OLD.id,
+        OLD.name,
+        'deleted',
+        OLD.os_id,
+        os.name,
+        os.version,
+        OLD.environment,
+        OLD.role,
+        OLD.owner_team,
+        OLD.location
+    FROM operating_systems os
+    WHERE os.id = OLD.os_id;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE TRIGGER log_server_creation_trigger
+    AFTER INSERT ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION log_server_creation();
+
+CREATE TRIGGER log_server_os_change_trigger
+    AFTER UPDATE ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION log_server_os_change();
+
+CREATE TRIGGER log_server_deletion_trigger
+    BEFORE DELETE ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION log_server_deletion();
+
+ALTER TABLE server_change_history
+    DROP COLUMN IF EXISTS changed_by,
+    DROP COLUMN IF EXISTS request_id,
+    DROP COLUMN IF EXISTS source,
+    DROP COLUMN IF EXISTS changes;
@@ -0,0 +1,17 @@
+-- Server changes are now recorded by the application in the same transaction
+-- as the mutation, with the actor, request and a diff of every changed field,
+-- so the history triggers are dropped.
+
+DROP TRIGGER IF EXISTS log_server_creation_trigger ON servers;
+DROP TRIGGER IF EXISTS log_server_os_change_trigger ON servers;
+DROP TRIGGER IF EXISTS log_server_deletion_trigger ON servers;
+
+DROP FUNCTION IF EXISTS log_server_deletion();
+DROP FUNCTION IF EXISTS log_server_os_change();
+DROP FUNCTION IF EXISTS log_server_creation();
+
+ALTER TABLE server_change_history
+    ADD COLUMN IF NOT EXISTS changed_by VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS request_id VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS source VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS changes JSONB; -- {"field": {"old": ..., "new": ...}}, NULL for trigger records
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS os_change_history;
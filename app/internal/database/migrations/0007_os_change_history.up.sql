@@ -0,0 +1,19 @@
+-- OS change history records changes to the operating system catalog, such as
+-- an end of support date being moved, which change the compliance of every
+-- server running it.
+
+CREATE TABLE os_change_history (
+    id SERIAL PRIMARY KEY,
+    os_id INTEGER REFERENCES operating_systems(id) ON DELETE SET NULL,
+    os_name VARCHAR(100) NOT NULL,
+    os_version VARCHAR(100) NOT NULL,
+    change_type VARCHAR(50) NOT NULL CHECK (change_type IN ('created', 'updated', 'deleted')),
+    changed_by VARCHAR(255) NOT NULL DEFAULT '',
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    source VARCHAR(50) NOT NULL DEFAULT '',
+    changes JSONB NOT NULL, -- {"field": {"old": ..., "new": ...}}
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_os_change_history_os_id ON os_change_history(os_id);
+CREATE INDEX idx_os_change_history_changed_at ON os_change_history(changed_at);
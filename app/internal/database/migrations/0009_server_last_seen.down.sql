@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS update_servers_updated_at ON servers;
+CREATE TRIGGER update_servers_updated_at
+    BEFORE UPDATE ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+DROP INDEX IF EXISTS idx_servers_last_seen_at;
+
+ALTER TABLE servers DROP COLUMN IF EXISTS last_seen_at;
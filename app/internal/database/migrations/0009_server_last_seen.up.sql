@@ -0,0 +1,15 @@
+-- Track when each host last reported itself through a registration or an
+-- import, so that servers which stopped reporting can be flagged as stale.
+-- Servers that never reported keep a NULL last_seen_at.
+
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_servers_last_seen_at ON servers(last_seen_at);
+
+-- Heartbeats only set last_seen_at and must not change updated_at, so the
+-- trigger now fires for updates of the inventory columns only
+DROP TRIGGER IF EXISTS update_servers_updated_at ON servers;
+CREATE TRIGGER update_servers_updated_at
+    BEFORE UPDATE OF name, os_id, environment, role, owner_team, location, description ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
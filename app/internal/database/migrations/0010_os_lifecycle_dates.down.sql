@@ -0,0 +1,2 @@
+ALTER TABLE operating_systems DROP COLUMN IF EXISTS end_of_extended_support;
+ALTER TABLE operating_systems DROP COLUMN IF EXISTS release_date;
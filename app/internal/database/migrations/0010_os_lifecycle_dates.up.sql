@@ -0,0 +1,7 @@
+-- Record the release date and the end of paid extended support (such as
+-- Ubuntu ESM or RHEL ELS) of each operating system, as published by
+-- endoflife.date and loaded by the catalog sync. Both are unknown for rows
+-- entered by hand.
+
+ALTER TABLE operating_systems ADD COLUMN IF NOT EXISTS release_date DATE;
+ALTER TABLE operating_systems ADD COLUMN IF NOT EXISTS end_of_extended_support DATE;
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS update_servers_updated_at ON servers;
+CREATE TRIGGER update_servers_updated_at
+    BEFORE UPDATE OF name, os_id, environment, role, owner_team, location, description ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE servers DROP COLUMN IF EXISTS extended_support;
+
+ALTER TABLE operating_systems DROP COLUMN IF EXISTS lts;
+ALTER TABLE operating_systems DROP COLUMN IF EXISTS end_of_standard_support;
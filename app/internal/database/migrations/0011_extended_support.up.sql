@@ -0,0 +1,16 @@
+-- Record the end of standard support and LTS flag of each operating system,
+-- from which its lifecycle phase is derived, and let servers opt in to the
+-- paid extended support of their OS. A server that opted in is evaluated
+-- against the end of extended support instead of the end of support.
+
+ALTER TABLE operating_systems ADD COLUMN IF NOT EXISTS end_of_standard_support DATE;
+ALTER TABLE operating_systems ADD COLUMN IF NOT EXISTS lts BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS extended_support BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Opting in to extended support is an inventory change
+DROP TRIGGER IF EXISTS update_servers_updated_at ON servers;
+CREATE TRIGGER update_servers_updated_at
+    BEFORE UPDATE OF name, os_id, environment, role, owner_team, location, description, extended_support ON servers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS campaign_servers;
+DROP TABLE IF EXISTS upgrade_campaigns;
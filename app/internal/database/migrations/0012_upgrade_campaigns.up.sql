@@ -0,0 +1,40 @@
+-- Upgrade campaigns plan the migration of the servers running a source
+-- operating system to a target operating system by a deadline. Servers are
+-- selected by the scope columns, or listed in campaign_servers when the
+-- selection is 'servers'.
+
+CREATE TABLE upgrade_campaigns (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    source_os_id INTEGER NOT NULL REFERENCES operating_systems(id) ON DELETE CASCADE,
+    target_os_id INTEGER NOT NULL REFERENCES operating_systems(id) ON DELETE CASCADE,
+    deadline DATE NOT NULL,
+    owner VARCHAR(255) NOT NULL,
+    selection VARCHAR(20) NOT NULL DEFAULT 'scope',
+    scope_environment VARCHAR(20) NOT NULL DEFAULT '',
+    scope_role VARCHAR(100) NOT NULL DEFAULT '',
+    scope_owner_team VARCHAR(100) NOT NULL DEFAULT '',
+    scope_location VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT upgrade_campaigns_os_check CHECK (source_os_id <> target_os_id),
+    CONSTRAINT upgrade_campaigns_selection_check CHECK (selection IN ('scope', 'servers'))
+);
+
+CREATE INDEX idx_upgrade_campaigns_source_os_id ON upgrade_campaigns(source_os_id);
+CREATE INDEX idx_upgrade_campaigns_target_os_id ON upgrade_campaigns(target_os_id);
+CREATE INDEX idx_upgrade_campaigns_owner ON upgrade_campaigns(owner);
+
+CREATE TRIGGER update_upgrade_campaigns_updated_at
+    BEFORE UPDATE ON upgrade_campaigns
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Servers listed explicitly in a campaign
+CREATE TABLE campaign_servers (
+    campaign_id INTEGER NOT NULL REFERENCES upgrade_campaigns(id) ON DELETE CASCADE,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    PRIMARY KEY (campaign_id, server_id)
+);
+
+CREATE INDEX idx_campaign_servers_server_id ON campaign_servers(server_id);
@@ -0,0 +1,31 @@
+-- Scheduled changes move a server to another operating system during a
+-- maintenance window. The maintenance executor applies pending changes once
+-- their window opens and records the outcome; resolved changes are kept for
+-- auditing.
+
+CREATE TABLE scheduled_changes (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    os_id INTEGER NOT NULL REFERENCES operating_systems(id) ON DELETE CASCADE,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    window_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    requested_by VARCHAR(255) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT scheduled_changes_window_check CHECK (window_end > window_start),
+    CONSTRAINT scheduled_changes_status_check CHECK (status IN ('pending', 'applied', 'failed', 'expired', 'cancelled'))
+);
+
+-- A server has at most one pending change
+CREATE UNIQUE INDEX idx_scheduled_changes_pending_server ON scheduled_changes(server_id) WHERE status = 'pending';
+CREATE INDEX idx_scheduled_changes_server_id ON scheduled_changes(server_id);
+CREATE INDEX idx_scheduled_changes_os_id ON scheduled_changes(os_id);
+CREATE INDEX idx_scheduled_changes_status_window_start ON scheduled_changes(status, window_start);
+
+CREATE TRIGGER update_scheduled_changes_updated_at
+    BEFORE UPDATE ON scheduled_changes
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
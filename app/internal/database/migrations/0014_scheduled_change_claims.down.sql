@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_scheduled_changes_open_server;
+
+-- Claimed changes that were never resolved are pending again
+UPDATE scheduled_changes SET status = 'pending' WHERE status = 'applying';
+
+ALTER TABLE scheduled_changes DROP CONSTRAINT scheduled_changes_status_check;
+ALTER TABLE scheduled_changes ADD CONSTRAINT scheduled_changes_status_check
+    CHECK (status IN ('pending', 'applied', 'failed', 'expired', 'cancelled'));
+
+CREATE UNIQUE INDEX idx_scheduled_changes_pending_server ON scheduled_changes(server_id) WHERE status = 'pending';
@@ -0,0 +1,12 @@
+-- The maintenance executor claims a pending change by moving it to
+-- 'applying' before it updates the server, so that a change cancelled or
+-- claimed by another instance in the meantime is not applied. A server
+-- still has at most one open change.
+
+ALTER TABLE scheduled_changes DROP CONSTRAINT scheduled_changes_status_check;
+ALTER TABLE scheduled_changes ADD CONSTRAINT scheduled_changes_status_check
+    CHECK (status IN ('pending', 'applying', 'applied', 'failed', 'expired', 'cancelled'));
+
+DROP INDEX IF EXISTS idx_scheduled_changes_pending_server;
+CREATE UNIQUE INDEX idx_scheduled_changes_open_server ON scheduled_changes(server_id)
+    WHERE status IN ('pending', 'applying');
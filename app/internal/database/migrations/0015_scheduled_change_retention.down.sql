@@ -0,0 +1,13 @@
+-- Changes of deleted servers cannot reference them again
+DELETE FROM scheduled_changes WHERE server_id IS NULL;
+
+ALTER TABLE scheduled_changes DROP CONSTRAINT scheduled_changes_os_id_fkey;
+ALTER TABLE scheduled_changes ADD CONSTRAINT scheduled_changes_os_id_fkey
+    FOREIGN KEY (os_id) REFERENCES operating_systems(id) ON DELETE CASCADE;
+
+ALTER TABLE scheduled_changes DROP CONSTRAINT scheduled_changes_server_id_fkey;
+ALTER TABLE scheduled_changes ADD CONSTRAINT scheduled_changes_server_id_fkey
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE;
+ALTER TABLE scheduled_changes ALTER COLUMN server_id SET NOT NULL;
+
+ALTER TABLE scheduled_changes DROP COLUMN IF EXISTS server_name;
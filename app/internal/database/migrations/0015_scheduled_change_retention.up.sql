@@ -0,0 +1,15 @@
+-- Scheduled changes are kept for auditing. Deleting a server resolves its
+-- open changes and keeps every change of the server with its name, and an
+-- operating system targeted by scheduled changes cannot be deleted.
+
+ALTER TABLE scheduled_changes ADD COLUMN server_name VARCHAR(255) NOT NULL DEFAULT '';
+UPDATE scheduled_changes c SET server_name = s.name FROM servers s WHERE s.id = c.server_id;
+
+ALTER TABLE scheduled_changes ALTER COLUMN server_id DROP NOT NULL;
+ALTER TABLE scheduled_changes DROP CONSTRAINT scheduled_changes_server_id_fkey;
+ALTER TABLE scheduled_changes ADD CONSTRAINT scheduled_changes_server_id_fkey
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE SET NULL;
+
+ALTER TABLE scheduled_changes DROP CONSTRAINT scheduled_changes_os_id_fkey;
+ALTER TABLE scheduled_changes ADD CONSTRAINT scheduled_changes_os_id_fkey
+    FOREIGN KEY (os_id) REFERENCES operating_systems(id) ON DELETE RESTRICT;
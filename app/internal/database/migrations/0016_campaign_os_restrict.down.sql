@@ -0,0 +1,7 @@
+ALTER TABLE upgrade_campaigns DROP CONSTRAINT upgrade_campaigns_target_os_id_fkey;
+ALTER TABLE upgrade_campaigns ADD CONSTRAINT upgrade_campaigns_target_os_id_fkey
+    FOREIGN KEY (target_os_id) REFERENCES operating_systems(id) ON DELETE CASCADE;
+
+ALTER TABLE upgrade_campaigns DROP CONSTRAINT upgrade_campaigns_source_os_id_fkey;
+ALTER TABLE upgrade_campaigns ADD CONSTRAINT upgrade_campaigns_source_os_id_fkey
+    FOREIGN KEY (source_os_id) REFERENCES operating_systems(id) ON DELETE CASCADE;
@@ -0,0 +1,10 @@
+-- An operating system planned as the source or target of an upgrade campaign
+-- cannot be deleted, rather than silently deleting the campaign.
+
+ALTER TABLE upgrade_campaigns DROP CONSTRAINT upgrade_campaigns_source_os_id_fkey;
+ALTER TABLE upgrade_campaigns ADD CONSTRAINT upgrade_campaigns_source_os_id_fkey
+    FOREIGN KEY (source_os_id) REFERENCES operating_systems(id) ON DELETE RESTRICT;
+
+ALTER TABLE upgrade_campaigns DROP CONSTRAINT upgrade_campaigns_target_os_id_fkey;
+ALTER TABLE upgrade_campaigns ADD CONSTRAINT upgrade_campaigns_target_os_id_fkey
+    FOREIGN KEY (target_os_id) REFERENCES operating_systems(id) ON DELETE RESTRICT;
@@ -0,0 +1,3 @@
+ALTER TABLE campaign_servers DROP CONSTRAINT campaign_servers_server_id_fkey;
+ALTER TABLE campaign_servers ADD CONSTRAINT campaign_servers_server_id_fkey
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE;
@@ -0,0 +1,6 @@
+-- A server listed in an upgrade campaign cannot be deleted, rather than
+-- silently leaving the campaign and dropping out of its progress.
+
+ALTER TABLE campaign_servers DROP CONSTRAINT campaign_servers_server_id_fkey;
+ALTER TABLE campaign_servers ADD CONSTRAINT campaign_servers_server_id_fkey
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE RESTRICT;
@@ -0,0 +1,20 @@
+-- Waivers of deleted resources cannot reference them again, and revoked
+-- waivers would become active again
+DELETE FROM compliance_waivers WHERE (server_id IS NULL AND os_id IS NULL) OR revoked_at IS NOT NULL;
+
+ALTER TABLE compliance_waivers DROP CONSTRAINT compliance_waivers_os_id_fkey;
+ALTER TABLE compliance_waivers ADD CONSTRAINT compliance_waivers_os_id_fkey
+    FOREIGN KEY (os_id) REFERENCES operating_systems(id) ON DELETE CASCADE;
+
+ALTER TABLE compliance_waivers DROP CONSTRAINT compliance_waivers_server_id_fkey;
+ALTER TABLE compliance_waivers ADD CONSTRAINT compliance_waivers_server_id_fkey
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE;
+
+ALTER TABLE compliance_waivers DROP CONSTRAINT compliance_waivers_scope_check;
+ALTER TABLE compliance_waivers ADD CONSTRAINT compliance_waivers_scope_check
+    CHECK ((server_id IS NULL) <> (os_id IS NULL));
+
+ALTER TABLE compliance_waivers DROP COLUMN IF EXISTS os_version;
+ALTER TABLE compliance_waivers DROP COLUMN IF EXISTS os_name;
+ALTER TABLE compliance_waivers DROP COLUMN IF EXISTS server_name;
+ALTER TABLE compliance_waivers DROP COLUMN IF EXISTS revoked_at;
@@ -0,0 +1,25 @@
+-- Waivers are kept for auditing. Revoking a waiver ends it instead of
+-- deleting it, and deleting a server or operating system keeps its waivers
+-- with the name of what they covered.
+
+ALTER TABLE compliance_waivers ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE compliance_waivers ADD COLUMN server_name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE compliance_waivers ADD COLUMN os_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE compliance_waivers ADD COLUMN os_version VARCHAR(100) NOT NULL DEFAULT '';
+UPDATE compliance_waivers w SET server_name = s.name FROM servers s WHERE s.id = w.server_id;
+UPDATE compliance_waivers w SET os_name = o.name, os_version = o.version FROM operating_systems o WHERE o.id = w.os_id;
+
+-- A waiver covers a server or an operating system, which it stops
+-- referencing once deleted
+ALTER TABLE compliance_waivers DROP CONSTRAINT compliance_waivers_scope_check;
+ALTER TABLE compliance_waivers ADD CONSTRAINT compliance_waivers_scope_check
+    CHECK ((server_name = '') <> (os_name = '') AND (server_id IS NULL OR os_id IS NULL));
+
+ALTER TABLE compliance_waivers DROP CONSTRAINT compliance_waivers_server_id_fkey;
+ALTER TABLE compliance_waivers ADD CONSTRAINT compliance_waivers_server_id_fkey
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE SET NULL;
+
+ALTER TABLE compliance_waivers DROP CONSTRAINT compliance_waivers_os_id_fkey;
+ALTER TABLE compliance_waivers ADD CONSTRAINT compliance_waivers_os_id_fkey
+    FOREIGN KEY (os_id) REFERENCES operating_systems(id) ON DELETE SET NULL;
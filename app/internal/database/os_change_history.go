@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"infra-dashboard/internal/models"
+)
+
+// OSChangeHistoryRepository provides database operations for operating system change history
+type OSChangeHistoryRepository struct {
+	db *DB
+}
+
+// NewOSChangeHistoryRepository creates a new operating system change history repository
+func NewOSChangeHistoryRepository(db *DB) *OSChangeHistoryRepository {
+	return &OSChangeHistoryRepository{db: db}
+}
+
+// osChangeHistorySelect selects operating system change history records in
+// the column order expected by scanOSChangeHistory
+const osChangeHistorySelect = `
+		SELECT id, os_id, os_name, os_version, change_type,
+		       changed_by, request_id, source, changes, changed_at
+		FROM os_change_history
+`
+
+// scanOSChangeHistory scans a row produced by osChangeHistorySelect
+func scanOSChangeHistory(row rowScanner) (models.OSChangeHistory, error) {
+	var record models.OSChangeHistory
+	var changes []byte
+	err := row.Scan(
+		&record.ID,
+		&record.OSID,
+		&record.OSName,
+		&record.OSVersion,
+		&record.ChangeType,
+		&record.ChangedBy,
+		&record.RequestID,
+		&record.Source,
+		&changes,
+		&record.ChangedAt,
+	)
+	if err != nil {
+		return record, err
+	}
+	if err := json.Unmarshal(changes, &record.Changes); err != nil {
+		return record, fmt.Errorf("failed to decode changes: %w", err)
+	}
+	return record, nil
+}
+
+// osChangeHistoryWhere builds the WHERE clause for an operating system change
+// history filter, appending its arguments to args
+func osChangeHistoryWhere(filter *models.OSChangeHistoryFilter, args []interface{}) (string, []interface{}) {
+	conditions := []string{"1=1"}
+
+	if filter != nil {
+		if filter.OSID != nil {
+			args = append(args, *filter.OSID)
+			conditions = append(conditions, fmt.Sprintf("os_id = $%d", len(args)))
+		}
+		if filter.ChangeType != nil {
+			args = append(args, *filter.ChangeType)
+			conditions = append(conditions, fmt.Sprintf("change_type = $%d", len(args)))
+		}
+		conditions, args = timeRangeConditions(conditions, args, "changed_at", filter.StartDate, filter.EndDate)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAll retrieves operating system change history records with optional filters, newest first
+func (r *OSChangeHistoryRepository) GetAll(filter *models.OSChangeHistoryFilter) ([]models.OSChangeHistory, error) {
+	where, args := osChangeHistoryWhere(filter, nil)
+	query := osChangeHistorySelect + where + " ORDER BY changed_at DESC, id DESC"
+
+	// Apply pagination
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter != nil && filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OS change history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.OSChangeHistory
+	for rows.Next() {
+		record, err := scanOSChangeHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan OS change history row: %w", err)
+		}
+		history = append(history, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return history, nil
+}
+
+// GetByOSID retrieves change history for a specific operating system
+func (r *OSChangeHistoryRepository) GetByOSID(osID int, limit int) ([]models.OSChangeHistory, error) {
+	return r.GetAll(&models.OSChangeHistoryFilter{OSID: &osID, Limit: limit})
+}
+
+// GetByID retrieves a single operating system change history record by its ID
+func (r *OSChangeHistoryRepository) GetByID(id int) (*models.OSChangeHistory, error) {
+	query := osChangeHistorySelect + `
+		WHERE id = $1
+	`
+
+	record, err := scanOSChangeHistory(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("OS change history record with id %d %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get OS change history record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// recordOSChange inserts the change history record of an operating system
+// mutation within its transaction. See models.NewOSChange for before and after.
+func recordOSChange(ctx context.Context, q queryer, before, after *models.OS) error {
+	record, changed := models.NewOSChange(before, after, models.ChangeMetadataFromContext(ctx))
+	if !changed {
+		return nil
+	}
+
+	changes, err := json.Marshal(record.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO os_change_history (os_id, os_name, os_version, change_type, changed_by, request_id, source, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = q.Exec(query,
+		record.OSID,
+		record.OSName,
+		record.OSVersion,
+		record.ChangeType,
+		record.ChangedBy,
+		record.RequestID,
+		record.Source,
+		string(changes),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record OS change: %w", err)
+	}
+
+	return nil
+}
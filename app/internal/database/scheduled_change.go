@@ -0,0 +1,303 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// parseScheduledWindow parses the RFC 3339 bounds of a maintenance window,
+// which must end after it starts
+func parseScheduledWindow(start, end string) (time.Time, time.Time, error) {
+	windowStart, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid window start: %v", ErrInvalidInput, err)
+	}
+	windowEnd, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid window end: %v", ErrInvalidInput, err)
+	}
+	if !windowEnd.After(windowStart) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: maintenance window must end after it starts", ErrInvalidInput)
+	}
+	return windowStart, windowEnd, nil
+}
+
+// resolvedFrom returns the status a scheduled change must be in to be
+// resolved to a final status: claimed changes are applied or fail, pending
+// ones expire or are cancelled
+func resolvedFrom(status string) (string, error) {
+	switch status {
+	case models.ScheduledChangeApplied, models.ScheduledChangeFailed:
+		return models.ScheduledChangeApplying, nil
+	case models.ScheduledChangeExpired, models.ScheduledChangeCancelled:
+		return models.ScheduledChangePending, nil
+	default:
+		return "", fmt.Errorf("%w: cannot resolve a scheduled change to %q", ErrInvalidInput, status)
+	}
+}
+
+// serverDeletedReason is the reason of the open changes resolved when their
+// server is deleted
+const serverDeletedReason = "server was deleted"
+
+// deletedServerResolutions maps the open statuses to the final status a
+// change reaches when its server is deleted: pending changes are cancelled
+// and changes being applied fail
+var deletedServerResolutions = map[string]string{
+	models.ScheduledChangePending:  models.ScheduledChangeCancelled,
+	models.ScheduledChangeApplying: models.ScheduledChangeFailed,
+}
+
+// resolveDeletedServerChanges resolves the open changes of a server about to
+// be deleted, and records its name on all of its changes, which lose their
+// server ID along with the server
+func resolveDeletedServerChanges(tx *sql.Tx, server *models.Server) error {
+	query := `
+		UPDATE scheduled_changes
+		SET status = CASE status WHEN $1 THEN $2 WHEN $3 THEN $4 END,
+			reason = $5, resolved_at = NOW()
+		WHERE server_id = $6 AND status IN ($1, $3)
+	`
+	_, err := tx.Exec(query,
+		models.ScheduledChangePending, deletedServerResolutions[models.ScheduledChangePending],
+		models.ScheduledChangeApplying, deletedServerResolutions[models.ScheduledChangeApplying],
+		serverDeletedReason, server.ID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve scheduled changes of deleted server: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE scheduled_changes SET server_name = $1 WHERE server_id = $2`, server.Name, server.ID); err != nil {
+		return fmt.Errorf("failed to record server name on scheduled changes: %w", err)
+	}
+	return nil
+}
+
+// ScheduledChangeRepository provides database operations for scheduled OS changes
+type ScheduledChangeRepository struct {
+	db *DB
+}
+
+// NewScheduledChangeRepository creates a new scheduled change repository
+func NewScheduledChangeRepository(db *DB) *ScheduledChangeRepository {
+	return &ScheduledChangeRepository{db: db}
+}
+
+const scheduledChangeSelect = `
+	SELECT id, server_id, server_name, os_id, window_start, window_end, status, requested_by, reason,
+		resolved_at, created_at, updated_at
+	FROM scheduled_changes
+`
+
+// scanScheduledChange scans a scheduled change row selected with scheduledChangeSelect
+func scanScheduledChange(row rowScanner) (models.ScheduledChange, error) {
+	var change models.ScheduledChange
+	var serverID sql.NullInt64
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&change.ID,
+		&serverID,
+		&change.ServerName,
+		&change.OSID,
+		&change.WindowStart,
+		&change.WindowEnd,
+		&change.Status,
+		&change.RequestedBy,
+		&change.Reason,
+		&resolvedAt,
+		&change.CreatedAt,
+		&change.UpdatedAt,
+	)
+	if err != nil {
+		return change, err
+	}
+
+	if serverID.Valid {
+		id := int(serverID.Int64)
+		change.ServerID = &id
+	}
+	if resolvedAt.Valid {
+		change.ResolvedAt = &resolvedAt.Time
+	}
+
+	return change, nil
+}
+
+// scheduledChangeWhere builds the WHERE clause and arguments for a scheduled change filter
+func scheduledChangeWhere(filter *models.ScheduledChangeFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter == nil {
+		return " WHERE " + strings.Join(conditions, " AND "), args
+	}
+
+	if filter.ServerID != nil {
+		args = append(args, *filter.ServerID)
+		conditions = append(conditions, fmt.Sprintf("server_id = $%d", len(args)))
+	}
+	if filter.Status != nil {
+		args = append(args, *filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAll retrieves scheduled changes with optional filters and pagination, earliest window first
+func (r *ScheduledChangeRepository) GetAll(filter *models.ScheduledChangeFilter) ([]models.ScheduledChange, error) {
+	where, args := scheduledChangeWhere(filter)
+	query := scheduledChangeSelect + where + " ORDER BY window_start, id"
+
+	// Apply pagination
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter != nil && filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.ScheduledChange
+	for rows.Next() {
+		change, err := scanScheduledChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return changes, nil
+}
+
+// Count returns the number of scheduled changes matching a filter, ignoring pagination
+func (r *ScheduledChangeRepository) Count(filter *models.ScheduledChangeFilter) (int, error) {
+	where, args := scheduledChangeWhere(filter)
+	query := `SELECT COUNT(*) FROM scheduled_changes` + where
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count scheduled changes: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetByID retrieves a scheduled change by its ID
+func (r *ScheduledChangeRepository) GetByID(id int) (*models.ScheduledChange, error) {
+	change, err := scanScheduledChange(r.db.QueryRow(scheduledChangeSelect+` WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("scheduled change with id %d %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get scheduled change: %w", err)
+	}
+
+	return &change, nil
+}
+
+// Create schedules a change requested by the actor of ctx
+func (r *ScheduledChangeRepository) Create(ctx context.Context, req *models.CreateScheduledChangeRequest) (*models.ScheduledChange, error) {
+	windowStart, windowEnd, err := parseScheduledWindow(req.WindowStart, req.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Selecting the server records its name and finds no row when it does not exist
+	query := `
+		INSERT INTO scheduled_changes (server_id, server_name, os_id, window_start, window_end, status, requested_by, created_at, updated_at)
+		SELECT id, name, $2, $3, $4, $5, $6, NOW(), NOW() FROM servers WHERE id = $1
+		RETURNING id
+	`
+
+	var id int
+	err = r.db.QueryRow(query, req.ServerID, req.OSID, windowStart, windowEnd, models.ScheduledChangePending,
+		models.ChangeMetadataFromContext(ctx).Actor).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows || isForeignKeyViolation(err) {
+			return nil, fmt.Errorf("scheduled server or operating system does not exist: %w", ErrInvalidReference)
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("server %d already has an open scheduled change: %w", req.ServerID, ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to create scheduled change: %w", err)
+	}
+
+	return r.GetByID(id)
+}
+
+// Claim moves a pending change to applying. The conditional update lets a
+// single executor claim a change, and keeps it from being cancelled while it
+// is applied.
+func (r *ScheduledChangeRepository) Claim(id int) (*models.ScheduledChange, error) {
+	query := `
+		UPDATE scheduled_changes
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+	`
+
+	result, err := r.db.Exec(query, models.ScheduledChangeApplying, id, models.ScheduledChangePending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim scheduled change: %w", err)
+	}
+
+	return r.transitioned(result, id, "claim")
+}
+
+// Resolve moves a claimed change to applied or failed, or a pending change
+// to expired or cancelled
+func (r *ScheduledChangeRepository) Resolve(id int, status, reason string) (*models.ScheduledChange, error) {
+	from, err := resolvedFrom(status)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE scheduled_changes
+		SET status = $1, reason = $2, resolved_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND status = $4
+	`
+
+	result, err := r.db.Exec(query, status, reason, id, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve scheduled change: %w", err)
+	}
+
+	return r.transitioned(result, id, "resolve")
+}
+
+// transitioned returns a change after a conditional status update, or
+// ErrConflict when the update matched no row because the change was in
+// another status
+func (r *ScheduledChangeRepository) transitioned(result sql.Result, id int, action string) (*models.ScheduledChange, error) {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	change, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("cannot %s scheduled change %d: it is %s: %w", action, id, change.Status, ErrConflict)
+	}
+
+	return change, nil
+}
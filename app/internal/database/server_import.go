@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"infra-dashboard/internal/models"
+)
+
+// Import creates and updates servers by name in a single transaction,
+// recording each change with the metadata of ctx. Every imported server,
+// changed or not, is marked as seen. Nothing is written when a row is invalid
+// or on a dry run; the report then describes what the import would do.
+func (r *ServerRepository) Import(ctx context.Context, rows []models.ServerImportRow, dryRun bool) (*models.ServerImportReport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	osIDs := make(map[[2]string]*models.OS)
+	report, err := models.PlanServerImport(rows, models.ServerImportLookup{
+		OS: func(name, version string) (*models.OS, error) {
+			key := [2]string{name, version}
+			if os, cached := osIDs[key]; cached {
+				return os, nil
+			}
+
+			var id int
+			err := tx.QueryRow(`SELECT id FROM operating_systems WHERE name = $1 AND version = $2`, name, version).Scan(&id)
+			if err == sql.ErrNoRows {
+				osIDs[key] = nil
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up operating system: %w", err)
+			}
+
+			os, err := getOS(tx, id, false)
+			if err != nil {
+				return nil, err
+			}
+			osIDs[key] = os
+			return os, nil
+		},
+		Server: func(name string) (*models.Server, error) {
+			// Lock imported servers so that concurrent changes are recorded in order
+			server, err := scanServer(tx.QueryRow(serverSelect+` WHERE s.name = $1 FOR UPDATE OF s`, name))
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up server: %w", err)
+			}
+			return &server, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.DryRun = dryRun
+	if dryRun || report.Failed > 0 {
+		return report, nil
+	}
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		switch row.Action {
+		case models.ImportActionCreate:
+			id, err := insertImportedServer(tx, row.After)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			row.ServerID = &id
+		case models.ImportActionUpdate:
+			if err := updateImportedServer(tx, row.After); err != nil {
+				return nil, fmt.Errorf("line %d: %w", row.Line, err)
+			}
+		case models.ImportActionUnchanged:
+			if err := markServerSeen(tx, *row.ServerID); err != nil {
+				return nil, fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			continue
+		default:
+			continue
+		}
+
+		after, err := getServer(tx, *row.ServerID, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordServerChange(ctx, tx, row.Before, after); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit server import: %w", err)
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// insertImportedServer inserts a server planned by an import and returns its ID
+func insertImportedServer(tx *sql.Tx, server *models.Server) (int, error) {
+	query := `
+		INSERT INTO servers (name, os_id, environment, role, owner_team, location, description, last_seen_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW(), NOW())
+		RETURNING id
+	`
+
+	var id int
+	err := tx.QueryRow(query,
+		server.Name,
+		server.OSID,
+		server.Environment,
+		server.Role,
+		server.OwnerTeam,
+		server.Location,
+		server.Description,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("server with name %q already exists: %w", server.Name, ErrConflict)
+		}
+		return 0, fmt.Errorf("failed to create server: %w", err)
+	}
+
+	return id, nil
+}
+
+// updateImportedServer writes every field of a server planned by an import
+func updateImportedServer(tx *sql.Tx, server *models.Server) error {
+	query := `
+		UPDATE servers
+		SET os_id = $1, environment = $2, role = $3, owner_team = $4, location = $5, description = $6,
+		    last_seen_at = NOW(), updated_at = NOW()
+		WHERE id = $7
+	`
+
+	_, err := tx.Exec(query,
+		server.OSID,
+		server.Environment,
+		server.Role,
+		server.OwnerTeam,
+		server.Location,
+		server.Description,
+		server.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+
+	return nil
+}
+
+// markServerSeen records that a server reported itself without changes. Its
+// updated_at is left alone.
+func markServerSeen(tx *sql.Tx, id int) error {
+	if _, err := tx.Exec(`UPDATE servers SET last_seen_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to mark server as seen: %w", err)
+	}
+	return nil
+}
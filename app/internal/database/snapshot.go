@@ -0,0 +1,134 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"infra-dashboard/internal/models"
+)
+
+// SnapshotRepository provides database operations for compliance snapshots
+type SnapshotRepository struct {
+	db *DB
+}
+
+// NewSnapshotRepository creates a new compliance snapshot repository
+func NewSnapshotRepository(db *DB) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+// snapshotWhere builds the WHERE clause and arguments for a snapshot filter
+func snapshotWhere(filter *models.SnapshotFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter != nil {
+		conditions, args = timeRangeConditions(conditions, args, "taken_at", filter.From, filter.To)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAll retrieves snapshots taken within the optional bounds of a filter,
+// oldest first unless the filter asks for the latest ones
+func (r *SnapshotRepository) GetAll(filter *models.SnapshotFilter) ([]models.ComplianceSnapshot, error) {
+	where, args := snapshotWhere(filter)
+	query := `
+		SELECT id, taken_at, source, total_servers, supported_servers,
+		       end_of_life_servers, ending_soon_servers, waived_servers, compliance_score
+		FROM compliance_snapshots
+	` + where
+
+	if filter != nil && filter.Latest {
+		query += " ORDER BY taken_at DESC, id DESC"
+	} else {
+		query += " ORDER BY taken_at, id"
+	}
+
+	// Apply pagination
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter != nil && filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query compliance snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []models.ComplianceSnapshot
+	for rows.Next() {
+		var s models.ComplianceSnapshot
+		err := rows.Scan(
+			&s.ID,
+			&s.TakenAt,
+			&s.Source,
+			&s.TotalServers,
+			&s.SupportedServers,
+			&s.EndOfLifeServers,
+			&s.EndingSoonServers,
+			&s.WaivedServers,
+			&s.ComplianceScore,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan compliance snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// Count returns the number of snapshots matching a filter, ignoring pagination
+func (r *SnapshotRepository) Count(filter *models.SnapshotFilter) (int, error) {
+	where, args := snapshotWhere(filter)
+	query := `SELECT COUNT(*) FROM compliance_snapshots` + where
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count compliance snapshots: %w", err)
+	}
+
+	return count, nil
+}
+
+// Create stores a compliance snapshot, taken now unless TakenAt is set
+func (r *SnapshotRepository) Create(snapshot *models.ComplianceSnapshot) (*models.ComplianceSnapshot, error) {
+	query := `
+		INSERT INTO compliance_snapshots (taken_at, source, total_servers, supported_servers,
+		                                  end_of_life_servers, ending_soon_servers, waived_servers, compliance_score)
+		VALUES (COALESCE($1::timestamptz, NOW()), $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, taken_at
+	`
+
+	var takenAt interface{}
+	if !snapshot.TakenAt.IsZero() {
+		takenAt = snapshot.TakenAt
+	}
+
+	created := *snapshot
+	err := r.db.QueryRow(query,
+		takenAt,
+		snapshot.Source,
+		snapshot.TotalServers,
+		snapshot.SupportedServers,
+		snapshot.EndOfLifeServers,
+		snapshot.EndingSoonServers,
+		snapshot.WaivedServers,
+		snapshot.ComplianceScore,
+	).Scan(&created.ID, &created.TakenAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compliance snapshot: %w", err)
+	}
+
+	return &created, nil
+}
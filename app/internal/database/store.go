@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// Sentinel errors shared by every store implementation. Callers should test
+// for them with errors.Is since implementations wrap them with context.
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a uniqueness or usage constraint is violated
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidReference is returned when a referenced record does not exist
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrInvalidInput is returned when request values cannot be parsed
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// ServerStore provides persistence operations for servers. Mutations record
+// a change history entry attributed with the models.ChangeMetadata of ctx.
+type ServerStore interface {
+	GetAll(filter *models.ServerFilter) ([]models.Server, error)
+	Count(filter *models.ServerFilter) (int, error)
+	GetByID(id int) (*models.Server, error)
+	Create(ctx context.Context, req *models.CreateServerRequest) (*models.Server, error)
+	Update(ctx context.Context, id int, req *models.UpdateServerRequest) (*models.Server, error)
+	Delete(ctx context.Context, id int) error
+	// Import creates and updates servers by name in a single transaction.
+	// Nothing is written when a row is invalid or dryRun is set.
+	Import(ctx context.Context, rows []models.ServerImportRow, dryRun bool) (*models.ServerImportReport, error)
+}
+
+// OSStore provides persistence operations for operating systems. Mutations
+// record a change history entry attributed with the models.ChangeMetadata of ctx.
+type OSStore interface {
+	GetAll(filter *models.OSFilter) ([]models.OS, error)
+	Count(filter *models.OSFilter) (int, error)
+	GetByID(id int) (*models.OS, error)
+	Create(ctx context.Context, req *models.CreateOSRequest) (*models.OS, error)
+	Update(ctx context.Context, id int, req *models.UpdateOSRequest) (*models.OS, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// ChangeHistoryStore provides read access to the server change history and
+// to the feed merging it with the operating system change history
+type ChangeHistoryStore interface {
+	GetAll(filter *models.ChangeHistoryFilter) ([]models.ServerChangeHistory, error)
+	GetFeed(filter *models.ChangeFeedFilter) ([]interface{}, error)
+	GetByServerID(serverID int, limit int) ([]models.ServerChangeHistory, error)
+	GetByID(id int) (*models.ServerChangeHistory, error)
+}
+
+// OSChangeHistoryStore provides read access to the operating system change history
+type OSChangeHistoryStore interface {
+	GetAll(filter *models.OSChangeHistoryFilter) ([]models.OSChangeHistory, error)
+	GetByOSID(osID int, limit int) ([]models.OSChangeHistory, error)
+	GetByID(id int) (*models.OSChangeHistory, error)
+}
+
+// WaiverStore provides persistence operations for compliance waivers
+type WaiverStore interface {
+	GetAll(filter *models.WaiverFilter) ([]models.Waiver, error)
+	Count(filter *models.WaiverFilter) (int, error)
+	GetByID(id int) (*models.Waiver, error)
+	Create(req *models.CreateWaiverRequest) (*models.Waiver, error)
+	Update(id int, req *models.UpdateWaiverRequest) (*models.Waiver, error)
+	Revoke(id int) (*models.Waiver, error)
+}
+
+// CampaignStore provides persistence operations for upgrade campaigns
+type CampaignStore interface {
+	GetAll(filter *models.CampaignFilter) ([]models.Campaign, error)
+	Count(filter *models.CampaignFilter) (int, error)
+	GetByID(id int) (*models.Campaign, error)
+	Create(req *models.CreateCampaignRequest) (*models.Campaign, error)
+	Update(id int, req *models.UpdateCampaignRequest) (*models.Campaign, error)
+	Delete(id int) error
+}
+
+// ScheduledChangeStore provides persistence operations for scheduled OS
+// changes. Changes are never deleted: they are resolved to a final status
+// and kept for auditing.
+type ScheduledChangeStore interface {
+	GetAll(filter *models.ScheduledChangeFilter) ([]models.ScheduledChange, error)
+	Count(filter *models.ScheduledChangeFilter) (int, error)
+	GetByID(id int) (*models.ScheduledChange, error)
+	// Create schedules a change requested by the actor of ctx. A server has
+	// at most one pending or applying change.
+	Create(ctx context.Context, req *models.CreateScheduledChangeRequest) (*models.ScheduledChange, error)
+	// Claim moves a pending change to applying before it is applied,
+	// returning ErrConflict when it is no longer pending
+	Claim(id int) (*models.ScheduledChange, error)
+	// Resolve moves a change to a final status: a claimed change to applied
+	// or failed, a pending one to expired or cancelled. It returns
+	// ErrConflict when the change is not in the status the final one
+	// follows.
+	Resolve(id int, status, reason string) (*models.ScheduledChange, error)
+}
+
+// SnapshotStore provides persistence operations for compliance snapshots
+type SnapshotStore interface {
+	GetAll(filter *models.SnapshotFilter) ([]models.ComplianceSnapshot, error)
+	Count(filter *models.SnapshotFilter) (int, error)
+	Create(snapshot *models.ComplianceSnapshot) (*models.ComplianceSnapshot, error)
+}
+
+// APIKeyStore provides persistence operations for API keys. Secrets are
+// never stored; keys are looked up by the hash of their secret.
+type APIKeyStore interface {
+	GetAll(filter *models.APIKeyFilter) ([]models.APIKey, error)
+	Count(filter *models.APIKeyFilter) (int, error)
+	GetByID(id int) (*models.APIKey, error)
+	GetByHash(hash string) (*models.APIKey, error)
+	Create(key *models.APIKey, hash string) (*models.APIKey, error)
+	Rotate(id int, prefix, hash string) (*models.APIKey, error)
+	Revoke(id int) (*models.APIKey, error)
+	MarkUsed(id int, at time.Time) error
+}
+
+// Stores groups the stores backing the API
+type Stores struct {
+	Servers         ServerStore
+	OS              OSStore
+	ChangeHistory   ChangeHistoryStore
+	OSChangeHistory OSChangeHistoryStore
+	Waivers         WaiverStore
+	Campaigns       CampaignStore
+	Changes         ScheduledChangeStore
+	Snapshots       SnapshotStore
+	APIKeys         APIKeyStore
+}
+
+// NewPostgresStores creates stores backed by a PostgreSQL database
+func NewPostgresStores(db *DB) *Stores {
+	return &Stores{
+		Servers:         NewServerRepository(db),
+		OS:              NewOSRepository(db),
+		ChangeHistory:   NewChangeHistoryRepository(db),
+		OSChangeHistory: NewOSChangeHistoryRepository(db),
+		Waivers:         NewWaiverRepository(db),
+		Campaigns:       NewCampaignRepository(db),
+		Changes:         NewScheduledChangeRepository(db),
+		Snapshots:       NewSnapshotRepository(db),
+		APIKeys:         NewAPIKeyRepository(db),
+	}
+}
+
+// NewMemoryStores creates stores backed by an in-memory database
+func NewMemoryStores(db *MemoryDB) *Stores {
+	return &Stores{
+		Servers:         NewMemoryServerRepository(db),
+		OS:              NewMemoryOSRepository(db),
+		ChangeHistory:   NewMemoryChangeHistoryRepository(db),
+		OSChangeHistory: NewMemoryOSChangeHistoryRepository(db),
+		Waivers:         NewMemoryWaiverRepository(db),
+		Campaigns:       NewMemoryCampaignRepository(db),
+		Changes:         NewMemoryScheduledChangeRepository(db),
+		Snapshots:       NewMemorySnapshotRepository(db),
+		APIKeys:         NewMemoryAPIKeyRepository(db),
+	}
+}
+
+var (
+	_ ServerStore          = (*ServerRepository)(nil)
+	_ OSStore              = (*OSRepository)(nil)
+	_ ChangeHistoryStore   = (*ChangeHistoryRepository)(nil)
+	_ OSChangeHistoryStore = (*OSChangeHistoryRepository)(nil)
+	_ WaiverStore          = (*WaiverRepository)(nil)
+	_ CampaignStore        = (*CampaignRepository)(nil)
+	_ ScheduledChangeStore = (*ScheduledChangeRepository)(nil)
+	_ SnapshotStore        = (*SnapshotRepository)(nil)
+	_ APIKeyStore          = (*APIKeyRepository)(nil)
+	_ ServerStore          = (*MemoryServerRepository)(nil)
+	_ OSStore              = (*MemoryOSRepository)(nil)
+	_ ChangeHistoryStore   = (*MemoryChangeHistoryRepository)(nil)
+	_ OSChangeHistoryStore = (*MemoryOSChangeHistoryRepository)(nil)
+	_ WaiverStore          = (*MemoryWaiverRepository)(nil)
+	_ CampaignStore        = (*MemoryCampaignRepository)(nil)
+	_ ScheduledChangeStore = (*MemoryScheduledChangeRepository)(nil)
+	_ SnapshotStore        = (*MemorySnapshotRepository)(nil)
+	_ APIKeyStore          = (*MemoryAPIKeyRepository)(nil)
+)
@@ -0,0 +1,305 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+// parseWaiverExpiry parses a waiver expiry date in YYYY-MM-DD format
+func parseWaiverExpiry(value string) (time.Time, error) {
+	expiresAt, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid expiry date format: %v", ErrInvalidInput, err)
+	}
+	return expiresAt, nil
+}
+
+// WaiverRepository provides database operations for compliance waivers
+type WaiverRepository struct {
+	db *DB
+}
+
+// NewWaiverRepository creates a new waiver repository
+func NewWaiverRepository(db *DB) *WaiverRepository {
+	return &WaiverRepository{db: db}
+}
+
+const waiverSelect = `
+	SELECT id, server_id, os_id, server_name, os_name, os_version, justification, approver,
+		expires_at, revoked_at, created_at, updated_at
+	FROM compliance_waivers
+`
+
+// scanWaiver scans a waiver row selected with waiverSelect
+func scanWaiver(row rowScanner) (models.Waiver, error) {
+	var waiver models.Waiver
+	var serverID, osID sql.NullInt64
+
+	err := row.Scan(
+		&waiver.ID,
+		&serverID,
+		&osID,
+		&waiver.ServerName,
+		&waiver.OSName,
+		&waiver.OSVersion,
+		&waiver.Justification,
+		&waiver.Approver,
+		&waiver.ExpiresAt,
+		&waiver.RevokedAt,
+		&waiver.CreatedAt,
+		&waiver.UpdatedAt,
+	)
+	if err != nil {
+		return waiver, err
+	}
+
+	if serverID.Valid {
+		waiver.ServerID = intPtr(int(serverID.Int64))
+	}
+	if osID.Valid {
+		waiver.OSID = intPtr(int(osID.Int64))
+	}
+
+	return waiver, nil
+}
+
+// waiverWhere builds the WHERE clause and arguments for a waiver filter
+func waiverWhere(filter *models.WaiverFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter == nil {
+		return " WHERE " + strings.Join(conditions, " AND "), args
+	}
+
+	if filter.ServerID != nil {
+		args = append(args, *filter.ServerID)
+		conditions = append(conditions, fmt.Sprintf("server_id = $%d", len(args)))
+	}
+	if filter.OSID != nil {
+		args = append(args, *filter.OSID)
+		conditions = append(conditions, fmt.Sprintf("os_id = $%d", len(args)))
+	}
+	if filter.Status != nil {
+		switch *filter.Status {
+		case models.WaiverStatusRevoked:
+			conditions = append(conditions, "revoked_at IS NOT NULL")
+		case models.WaiverStatusExpired:
+			args = append(args, time.Now())
+			conditions = append(conditions, fmt.Sprintf("revoked_at IS NULL AND expires_at <= $%d", len(args)))
+		default:
+			args = append(args, time.Now())
+			conditions = append(conditions, fmt.Sprintf("revoked_at IS NULL AND expires_at > $%d", len(args)))
+		}
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAll retrieves waivers with optional filters and pagination, soonest expiry first
+func (r *WaiverRepository) GetAll(filter *models.WaiverFilter) ([]models.Waiver, error) {
+	where, args := waiverWhere(filter)
+	query := waiverSelect + where + " ORDER BY expires_at, id"
+
+	// Apply pagination
+	if filter != nil && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter != nil && filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query waivers: %w", err)
+	}
+	defer rows.Close()
+
+	var waivers []models.Waiver
+	for rows.Next() {
+		waiver, err := scanWaiver(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan waiver: %w", err)
+		}
+		waivers = append(waivers, waiver)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return waivers, nil
+}
+
+// Count returns the number of waivers matching a filter, ignoring pagination
+func (r *WaiverRepository) Count(filter *models.WaiverFilter) (int, error) {
+	where, args := waiverWhere(filter)
+	query := `SELECT COUNT(*) FROM compliance_waivers` + where
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count waivers: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetByID retrieves a waiver by its ID
+func (r *WaiverRepository) GetByID(id int) (*models.Waiver, error) {
+	waiver, err := scanWaiver(r.db.QueryRow(waiverSelect+` WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("waiver with id %d %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get waiver: %w", err)
+	}
+
+	return &waiver, nil
+}
+
+// Create creates a new waiver
+func (r *WaiverRepository) Create(req *models.CreateWaiverRequest) (*models.Waiver, error) {
+	if (req.ServerID == nil) == (req.OSID == nil) {
+		return nil, fmt.Errorf("%w: a waiver must reference exactly one of a server or an operating system", ErrInvalidInput)
+	}
+
+	expiresAt, err := parseWaiverExpiry(req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// The waiver keeps the name of what it covers once it is deleted
+	var serverName, osName, osVersion string
+	if req.ServerID != nil {
+		err = r.db.QueryRow(`SELECT name FROM servers WHERE id = $1`, *req.ServerID).Scan(&serverName)
+	} else {
+		err = r.db.QueryRow(`SELECT name, version FROM operating_systems WHERE id = $1`, *req.OSID).Scan(&osName, &osVersion)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("waived server or operating system does not exist: %w", ErrInvalidReference)
+		}
+		return nil, fmt.Errorf("failed to get waived server or operating system: %w", err)
+	}
+
+	query := `
+		INSERT INTO compliance_waivers (server_id, os_id, server_name, os_name, os_version,
+			justification, approver, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		RETURNING id
+	`
+
+	var id int
+	err = r.db.QueryRow(query, req.ServerID, req.OSID, serverName, osName, osVersion,
+		req.Justification, req.Approver, expiresAt).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, fmt.Errorf("waived server or operating system does not exist: %w", ErrInvalidReference)
+		}
+		return nil, fmt.Errorf("failed to create waiver: %w", err)
+	}
+
+	return r.GetByID(id)
+}
+
+// Update updates the justification, approver or expiry of a waiver that has
+// not been revoked
+func (r *WaiverRepository) Update(id int, req *models.UpdateWaiverRequest) (*models.Waiver, error) {
+	setParts := []string{}
+	args := []interface{}{}
+
+	if req.Justification != "" {
+		args = append(args, req.Justification)
+		setParts = append(setParts, fmt.Sprintf("justification = $%d", len(args)))
+	}
+	if req.Approver != "" {
+		args = append(args, req.Approver)
+		setParts = append(setParts, fmt.Sprintf("approver = $%d", len(args)))
+	}
+	if req.ExpiresAt != "" {
+		expiresAt, err := parseWaiverExpiry(req.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, expiresAt)
+		setParts = append(setParts, fmt.Sprintf("expires_at = $%d", len(args)))
+	}
+
+	if len(setParts) == 0 {
+		return r.GetByID(id) // No updates, return existing waiver
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf(`
+		UPDATE compliance_waivers
+		SET %s, updated_at = NOW()
+		WHERE id = $%d AND revoked_at IS NULL
+		RETURNING id
+	`, strings.Join(setParts, ", "), len(args))
+
+	var waiverID int
+	if err := r.db.QueryRow(query, args...).Scan(&waiverID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.errRevoked(id, "update")
+		}
+		return nil, fmt.Errorf("failed to update waiver: %w", err)
+	}
+
+	return r.GetByID(waiverID)
+}
+
+// Revoke ends a waiver that has not been revoked. Revoked waivers are kept
+// for auditing.
+func (r *WaiverRepository) Revoke(id int) (*models.Waiver, error) {
+	query := `
+		UPDATE compliance_waivers
+		SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING id
+	`
+
+	var waiverID int
+	if err := r.db.QueryRow(query, id).Scan(&waiverID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.errRevoked(id, "revoke")
+		}
+		return nil, fmt.Errorf("failed to revoke waiver: %w", err)
+	}
+
+	return r.GetByID(waiverID)
+}
+
+// errRevoked returns the error of an update of a waiver that matched no
+// unrevoked waiver: ErrConflict when the waiver has been revoked and
+// ErrNotFound when it does not exist
+func (r *WaiverRepository) errRevoked(id int, action string) error {
+	if _, err := r.GetByID(id); err != nil {
+		return err
+	}
+	return fmt.Errorf("cannot %s waiver %d: it has been revoked: %w", action, id, ErrConflict)
+}
+
+// recordDeletedServerWaivers records the name of a server about to be
+// deleted on its waivers, which lose their server ID along with the server
+func recordDeletedServerWaivers(tx *sql.Tx, server *models.Server) error {
+	if _, err := tx.Exec(`UPDATE compliance_waivers SET server_name = $1 WHERE server_id = $2`, server.Name, server.ID); err != nil {
+		return fmt.Errorf("failed to record server name on waivers: %w", err)
+	}
+	return nil
+}
+
+// recordDeletedOSWaivers records the name and version of an operating system
+// about to be deleted on its waivers, which lose their OS ID along with it
+func recordDeletedOSWaivers(tx *sql.Tx, os *models.OS) error {
+	query := `UPDATE compliance_waivers SET os_name = $1, os_version = $2 WHERE os_id = $3`
+	if _, err := tx.Exec(query, os.Name, os.Version, os.ID); err != nil {
+		return fmt.Errorf("failed to record operating system name on waivers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// csvWriter writes RFC 4180 CSV with a header row
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w)}
+	if err := writer.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// WriteRow writes a record. Text starting with a formula character is
+// prefixed with a quote so that spreadsheets opening the file do not
+// evaluate it.
+func (c *csvWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+		if _, numeric := numericValue(value); !numeric && isFormula(record[i]) {
+			record[i] = "'" + record[i]
+		}
+	}
+	return c.w.Write(record)
+}
+
+// Flush writes the buffered records
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close flushes the remaining records
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// isFormula reports whether spreadsheets would read text as a formula
+func isFormula(text string) bool {
+	return text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0]))
+}
@@ -0,0 +1,212 @@
+// Package export writes tabular API responses as CSV, Markdown or XLSX.
+// Writers stream rows to the response as they are produced, so exports of
+// large inventories are never held in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats. JSON is the default representation of the API and is not
+// handled by this package.
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatXLSX     = "xlsx"
+)
+
+// Media types of the export formats
+const (
+	MediaTypeCSV      = "text/csv"
+	MediaTypeMarkdown = "text/markdown"
+	MediaTypeXLSX     = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// formatNames maps the accepted values of the format query parameter
+var formatNames = map[string]string{
+	"json":     FormatJSON,
+	"csv":      FormatCSV,
+	"markdown": FormatMarkdown,
+	"md":       FormatMarkdown,
+	"xlsx":     FormatXLSX,
+}
+
+// mediaTypes maps the media types accepted in the Accept header
+var mediaTypes = map[string]string{
+	"application/json":         FormatJSON,
+	MediaTypeCSV:               FormatCSV,
+	MediaTypeMarkdown:          FormatMarkdown,
+	"text/x-markdown":          FormatMarkdown,
+	MediaTypeXLSX:              FormatXLSX,
+	"application/vnd.ms-excel": FormatXLSX,
+}
+
+// Negotiate returns the format requested with the format query parameter or,
+// without it, the preferred supported media type of the Accept header. JSON
+// is returned when neither selects a supported format; an unknown format
+// parameter is an error.
+func Negotiate(r *http.Request) (string, error) {
+	if value := r.URL.Query().Get("format"); value != "" {
+		format, exists := formatNames[strings.ToLower(value)]
+		if !exists {
+			return "", fmt.Errorf("Invalid format parameter. Must be: json, csv, markdown, or xlsx")
+		}
+		return format, nil
+	}
+
+	type candidate struct {
+		format  string
+		quality float64
+	}
+	var candidates []candidate
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		format, exists := mediaTypes[mediaType]
+		if !exists {
+			continue
+		}
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{format, quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return FormatJSON, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].format, nil
+}
+
+// ContentType returns the Content-Type header of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return MediaTypeCSV + "; charset=utf-8"
+	case FormatMarkdown:
+		return MediaTypeMarkdown + "; charset=utf-8"
+	case FormatXLSX:
+		return MediaTypeXLSX
+	default:
+		return "application/json"
+	}
+}
+
+// FileName returns the name of an export file of the given base name
+func FileName(name, format string) string {
+	extension := format
+	if format == FormatMarkdown {
+		extension = "md"
+	}
+	return name + "." + extension
+}
+
+// Writer writes the rows of a table. Values may be strings, integers,
+// floats, booleans, times, pointers to strings, integers and times, or nil,
+// which is an empty cell.
+type Writer interface {
+	// WriteRow writes one row holding a value for each column
+	WriteRow(values ...interface{}) error
+	// Flush sends the buffered rows to the underlying writer
+	Flush() error
+	// Close completes the table and flushes it. It does not close the
+	// underlying writer.
+	Close() error
+}
+
+// NewWriter creates a writer of the given format and writes the header row
+// naming the columns. The name titles the table, such as the XLSX sheet.
+func NewWriter(w io.Writer, format, name string, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatMarkdown:
+		return newMarkdownWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, name, columns)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// SetHeaders sets the Content-Type of a format and a Content-Disposition
+// offering the export as a download of the given base name
+func SetHeaders(w http.ResponseWriter, format, name string) {
+	w.Header().Set("Content-Type", ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", FileName(name, format)))
+}
+
+// formatValue returns the text of a cell value
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case int:
+		return strconv.Itoa(v)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return formatTime(v)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return formatTime(*v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatTime returns a time in RFC 3339, or "" for the zero time. Dates are
+// passed as YYYY-MM-DD strings by callers.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// numericValue returns the number held by a cell value and whether it is one
+func numericValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int, int64, float64:
+		return formatValue(v), true
+	case *int:
+		if v != nil {
+			return strconv.Itoa(*v), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,177 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   string
+	}{
+		{"default", "", "", FormatJSON},
+		{"any", "", "*/*", FormatJSON},
+		{"csv", "", "text/csv", FormatCSV},
+		{"markdown", "", "text/markdown; charset=utf-8", FormatMarkdown},
+		{"xlsx", "", MediaTypeXLSX, FormatXLSX},
+		{"excel alias", "", "application/vnd.ms-excel", FormatXLSX},
+		{"quality", "", "text/csv;q=0.5, text/markdown", FormatMarkdown},
+		{"unsupported", "", "text/html", FormatJSON},
+		{"refused", "", "text/csv;q=0", FormatJSON},
+		{"query wins", "format=csv", "text/markdown", FormatCSV},
+		{"query alias", "format=MD", "", FormatMarkdown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/servers?"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			got, err := Negotiate(req)
+			if err != nil || got != tt.want {
+				t.Errorf("Negotiate() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/servers?format=pdf", nil)
+	if _, err := Negotiate(req); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func writeTable(t *testing.T, format string, rows ...[]interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, "Servers", []string{"id", "name", "notes"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	data := writeTable(t, FormatCSV,
+		[]interface{}{1, "web-01", "a, \"quoted\" note"},
+		[]interface{}{-2, "=HYPERLINK(\"x\")", nil},
+	)
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	want := [][]string{
+		{"id", "name", "notes"},
+		{"1", "web-01", "a, \"quoted\" note"},
+		{"-2", "'=HYPERLINK(\"x\")", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("Expected %d records, got %v", len(want), records)
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("Record %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	created := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	data := writeTable(t, FormatMarkdown, []interface{}{1, "a|b", "line 1\nline 2 " + formatValue(created)})
+
+	want := "| id | name | notes |\n" +
+		"| --- | --- | --- |\n" +
+		"| 1 | a\\|b | line 1<br>line 2 2025-03-01T12:00:00Z |\n"
+	if string(data) != want {
+		t.Errorf("Unexpected Markdown:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	data := writeTable(t, FormatXLSX, []interface{}{1, "db-01 <primary> & replica", nil})
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s is not well-formed XML: %v", f.Name, err)
+				break
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, exists := parts[name]; !exists {
+			t.Errorf("Workbook is missing %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Servers"`) {
+		t.Errorf("Unexpected workbook: %s", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`,
+		`<c r="A2"><v>1</v></c>`,
+		`<t xml:space="preserve">db-01 &lt;primary&gt; &amp; replica</t>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("Worksheet does not contain %s:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="C2"`) {
+		t.Errorf("Expected no cell for a nil value:\n%s", sheet)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(index); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	if got := sheetName("Compliance [2025/03]"); got != "Compliance _2025_03_" {
+		t.Errorf("Unexpected sheet name %q", got)
+	}
+	if got := sheetName(strings.Repeat("x", 40)); len(got) != maxSheetNameLength {
+		t.Errorf("Expected the sheet name to be truncated, got %q", got)
+	}
+}
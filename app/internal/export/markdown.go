@@ -0,0 +1,60 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// markdownEscaper escapes the characters that would break a table cell
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"|", "\\|",
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+// markdownWriter writes a GitHub-flavored Markdown table
+type markdownWriter struct {
+	w *bufio.Writer
+}
+
+func newMarkdownWriter(w io.Writer, columns []string) (*markdownWriter, error) {
+	writer := &markdownWriter{w: bufio.NewWriter(w)}
+
+	values := make([]interface{}, len(columns))
+	separator := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = column
+		separator[i] = "---"
+	}
+	if err := writer.WriteRow(values...); err != nil {
+		return nil, err
+	}
+	if _, err := writer.w.WriteString("| " + strings.Join(separator, " | ") + " |\n"); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// WriteRow writes a table row
+func (m *markdownWriter) WriteRow(values ...interface{}) error {
+	cells := make([]string, len(values))
+	for i, value := range values {
+		cells[i] = markdownEscaper.Replace(formatValue(value))
+	}
+	_, err := m.w.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	return err
+}
+
+// Flush writes the buffered rows
+func (m *markdownWriter) Flush() error {
+	return m.w.Flush()
+}
+
+// Close flushes the remaining rows
+func (m *markdownWriter) Close() error {
+	return m.Flush()
+}
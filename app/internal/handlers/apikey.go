@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"infra-dashboard/internal/auth"
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHandler handles API key management HTTP requests
+type APIKeyHandler struct {
+	repo database.APIKeyStore
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(repo database.APIKeyStore) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+// parseAPIKeyID returns the API key ID from the route variables
+func parseAPIKeyID(r *http.Request) (int, error) {
+	idStr, exists := mux.Vars(r)["id"]
+	if !exists {
+		return 0, fmt.Errorf("API key ID is required")
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid API key ID")
+	}
+
+	return id, nil
+}
+
+// parseAPIKeyFilter builds an API key filter from query parameters
+func parseAPIKeyFilter(r *http.Request) (*models.APIKeyFilter, error) {
+	query := r.URL.Query()
+	filter := &models.APIKeyFilter{}
+
+	if status := query.Get("status"); status != "" {
+		if status != models.APIKeyStatusActive && status != models.APIKeyStatusRevoked {
+			return nil, fmt.Errorf("Invalid status. Must be: active or revoked")
+		}
+		filter.Status = &status
+	}
+
+	if role := query.Get("role"); role != "" {
+		if err := models.ValidateRole(role); err != nil {
+			return nil, fmt.Errorf("Invalid role parameter: %v", err)
+		}
+		filter.Role = &role
+	}
+
+	return filter, nil
+}
+
+// GetAPIKeys handles GET /api-keys - retrieves API keys with optional filters
+// and offset-based pagination, oldest first. Secrets are never returned.
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAPIKeyFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting API keys: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	keys, err := h.repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting API keys: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writePageHeaders(w, r, total, filter.Limit, filter.Offset)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		log.Printf("Error encoding API keys response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetAPIKey handles GET /api-keys/{id} - retrieves an API key by ID
+func (h *APIKeyHandler) GetAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := parseAPIKeyID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error getting API key by ID %d: %v", id, err)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "API key not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		log.Printf("Error encoding API key response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateAPIKey handles POST /api-keys - issues a new API key. The key is
+// only returned in this response.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Basic validation
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || req.Role == "" {
+		http.Error(w, "Name and role are required", http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateRole(req.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdBy := ""
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+		createdBy = principal.Name
+	}
+
+	key, err := auth.IssueAPIKey(h.repo, req.Name, req.Role, createdBy)
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		if errors.Is(err, database.ErrConflict) {
+			http.Error(w, "An active API key with this name already exists", http.StatusConflict)
+		} else {
+			http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		log.Printf("Error encoding created API key response: %v", err)
+		return
+	}
+}
+
+// RotateAPIKey handles POST /api-keys/{id}/rotate - replaces the secret of an
+// API key. The previous key stops working and the new one is only returned
+// in this response.
+func (h *APIKeyHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := parseAPIKeyID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := auth.RotateAPIKey(h.repo, id)
+	if err != nil {
+		log.Printf("Error rotating API key with ID %d: %v", id, err)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "API key not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "API key has been revoked", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to rotate API key", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		log.Printf("Error encoding rotated API key response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RevokeAPIKey handles DELETE /api-keys/{id} - revokes an API key. Revoked
+// keys remain listed for auditing.
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := parseAPIKeyID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.repo.Revoke(id); err != nil {
+		log.Printf("Error revoking API key with ID %d: %v", id, err)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "API key not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "API key has already been revoked", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"infra-dashboard/internal/auth"
+	"infra-dashboard/internal/models"
+)
+
+func TestAPIKeyHandler_Lifecycle(t *testing.T) {
+	api, adminKey := newAuthTestAPI(t)
+	asAdmin := map[string]string{auth.APIKeyHeader: adminKey}
+
+	rec := api.doWithHeaders(t, http.MethodPost, "/api/v1/api-keys", models.CreateAPIKeyRequest{Name: "ci", Role: models.RoleOperator}, asAdmin)
+	expectStatus(t, rec, http.StatusCreated)
+
+	var issued models.IssuedAPIKey
+	decode(t, rec, &issued)
+	if issued.Key == "" || issued.Prefix == "" || issued.Key[:len(issued.Prefix)] != issued.Prefix || issued.CreatedBy != auth.BootstrapAdminKeyName {
+		t.Fatalf("Unexpected issued key: %+v", issued)
+	}
+
+	rec = api.doWithHeaders(t, http.MethodPost, "/api/v1/api-keys", models.CreateAPIKeyRequest{Name: "ci", Role: models.RoleViewer}, asAdmin)
+	expectStatus(t, rec, http.StatusConflict)
+
+	// The operator key can change servers but not manage keys
+	asOperator := map[string]string{auth.APIKeyHeader: issued.Key}
+	rec = api.doWithHeaders(t, http.MethodGet, "/api/v1/api-keys", nil, asOperator)
+	expectStatus(t, rec, http.StatusForbidden)
+	rec = api.doWithHeaders(t, http.MethodGet, "/api/v1/servers", nil, asOperator)
+	expectStatus(t, rec, http.StatusOK)
+
+	path := fmt.Sprintf("/api/v1/api-keys/%d", issued.ID)
+	rec = api.doWithHeaders(t, http.MethodGet, path, nil, asAdmin)
+	expectStatus(t, rec, http.StatusOK)
+	var key models.APIKey
+	decode(t, rec, &key)
+	if key.LastUsedAt == nil {
+		t.Errorf("Expected the last use of the key to be recorded: %+v", key)
+	}
+	if strings.Contains(rec.Body.String(), issued.Key) {
+		t.Error("Expected the secret not to be returned after creation")
+	}
+
+	rec = api.doWithHeaders(t, http.MethodPost, path+"/rotate", nil, asAdmin)
+	expectStatus(t, rec, http.StatusOK)
+	var rotated models.IssuedAPIKey
+	decode(t, rec, &rotated)
+	if rotated.Key == issued.Key || rotated.RotatedAt == nil {
+		t.Fatalf("Unexpected rotated key: %+v", rotated)
+	}
+	rec = api.doWithHeaders(t, http.MethodGet, "/api/v1/servers", nil, asOperator)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = api.doWithHeaders(t, http.MethodDelete, path, nil, asAdmin)
+	expectStatus(t, rec, http.StatusNoContent)
+	rec = api.doWithHeaders(t, http.MethodDelete, path, nil, asAdmin)
+	expectStatus(t, rec, http.StatusConflict)
+	rec = api.doWithHeaders(t, http.MethodGet, "/api/v1/servers", nil, map[string]string{auth.APIKeyHeader: rotated.Key})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = api.doWithHeaders(t, http.MethodGet, "/api/v1/api-keys?status=revoked", nil, asAdmin)
+	expectStatus(t, rec, http.StatusOK)
+	var keys []models.APIKey
+	decode(t, rec, &keys)
+	if len(keys) != 1 || keys[0].ID != issued.ID || rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Expected the revoked key to be listed, got %+v", keys)
+	}
+
+	rec = api.doWithHeaders(t, http.MethodPost, "/api/v1/api-keys", models.CreateAPIKeyRequest{Name: "bad", Role: "root"}, asAdmin)
+	expectStatus(t, rec, http.StatusBadRequest)
+	rec = api.doWithHeaders(t, http.MethodGet, "/api/v1/api-keys?role=root", nil, asAdmin)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestAPIKeyHandler_PrincipalIsChangeActor(t *testing.T) {
+	api, adminKey := newAuthTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	rec := api.doWithHeaders(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID},
+		map[string]string{auth.APIKeyHeader: adminKey, "X-Actor": "mallory"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = api.doWithHeaders(t, http.MethodGet, "/api/v1/history?resource_type=server", nil, map[string]string{auth.APIKeyHeader: adminKey})
+	expectStatus(t, rec, http.StatusOK)
+	var history []models.ServerChangeHistory
+	decode(t, rec, &history)
+	if len(history) != 1 || history[0].ChangedBy != auth.BootstrapAdminKeyName {
+		t.Errorf("Expected the change to be attributed to the API key, got %+v", history)
+	}
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// CampaignHandler handles upgrade campaign HTTP requests
+type CampaignHandler struct {
+	repo        database.CampaignStore
+	serverRepo  database.ServerStore
+	historyRepo database.ChangeHistoryStore
+}
+
+// NewCampaignHandler creates a new campaign handler
+func NewCampaignHandler(repo database.CampaignStore, serverRepo database.ServerStore, historyRepo database.ChangeHistoryStore) *CampaignHandler {
+	return &CampaignHandler{repo: repo, serverRepo: serverRepo, historyRepo: historyRepo}
+}
+
+// parseCampaignID returns the campaign ID from the route variables
+func parseCampaignID(r *http.Request) (int, error) {
+	idStr, exists := mux.Vars(r)["id"]
+	if !exists {
+		return 0, fmt.Errorf("Campaign ID is required")
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid campaign ID")
+	}
+
+	return id, nil
+}
+
+// parseCampaignFilter builds a campaign filter from query parameters
+func parseCampaignFilter(r *http.Request) (*models.CampaignFilter, error) {
+	query := r.URL.Query()
+	filter := &models.CampaignFilter{}
+
+	if owner := query.Get("owner"); owner != "" {
+		filter.Owner = &owner
+	}
+
+	for _, f := range []struct {
+		param string
+		dest  **int
+	}{
+		{"source_os_id", &filter.SourceOSID},
+		{"target_os_id", &filter.TargetOSID},
+	} {
+		if value := query.Get(f.param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s parameter", f.param)
+			}
+			*f.dest = &id
+		}
+	}
+
+	return filter, nil
+}
+
+// validateCampaignDeadline checks that a deadline is a YYYY-MM-DD date
+func validateCampaignDeadline(value string) error {
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return fmt.Errorf("Invalid deadline format. Use YYYY-MM-DD")
+	}
+	return nil
+}
+
+// GetCampaigns handles GET /campaigns - retrieves campaigns with optional
+// filters and offset-based pagination, soonest deadline first
+func (h *CampaignHandler) GetCampaigns(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseCampaignFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting campaigns: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	campaigns, err := h.repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting campaigns: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writePageHeaders(w, r, total, filter.Limit, filter.Offset)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(campaigns); err != nil {
+		log.Printf("Error encoding campaigns response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetCampaign handles GET /campaigns/{id} - retrieves a campaign by ID
+func (h *CampaignHandler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(campaign); err != nil {
+		log.Printf("Error encoding campaign response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateCampaign handles POST /campaigns - creates a new campaign
+func (h *CampaignHandler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Basic validation
+	if req.Name == "" || req.Owner == "" || req.Deadline == "" || req.SourceOSID == 0 || req.TargetOSID == 0 {
+		http.Error(w, "Name, owner, deadline, source OS ID and target OS ID are required", http.StatusBadRequest)
+		return
+	}
+	if req.SourceOSID == req.TargetOSID {
+		http.Error(w, "Source and target OS must differ", http.StatusBadRequest)
+		return
+	}
+	if req.Scope != nil && !req.Scope.IsEmpty() && len(req.ServerIDs) > 0 {
+		http.Error(w, "Specify either a scope or server IDs, not both", http.StatusBadRequest)
+		return
+	}
+	if err := validateCampaignDeadline(req.Deadline); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateCampaignFields(req.Name, req.Owner); err != nil {
+		http.Error(w, "Invalid "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Scope != nil {
+		if err := models.NewServerUtils().ValidateEnvironment(req.Scope.Environment); err != nil {
+			http.Error(w, "Invalid scope environment. Must be: prod, staging, or dev", http.StatusBadRequest)
+			return
+		}
+		if err := models.NewServerUtils().ValidateAttributes(req.Scope.Role, req.Scope.OwnerTeam, req.Scope.Location); err != nil {
+			http.Error(w, "Invalid scope "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	campaign, err := h.repo.Create(&req)
+	if err != nil {
+		log.Printf("Error creating campaign: %v", err)
+		switch {
+		case errors.Is(err, database.ErrInvalidReference):
+			http.Error(w, "Campaign operating system or server does not exist", http.StatusBadRequest)
+		case errors.Is(err, database.ErrInvalidInput):
+			http.Error(w, "Invalid campaign", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to create campaign", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(campaign); err != nil {
+		log.Printf("Error encoding created campaign response: %v", err)
+		return
+	}
+}
+
+// UpdateCampaign handles PUT /campaigns/{id} - updates the name, deadline or
+// owner of a campaign
+func (h *CampaignHandler) UpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	id, err := parseCampaignID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Deadline != "" {
+		if err := validateCampaignDeadline(req.Deadline); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := models.ValidateCampaignFields(req.Name, req.Owner); err != nil {
+		http.Error(w, "Invalid "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := h.repo.Update(id, &req)
+	if err != nil {
+		log.Printf("Error updating campaign with ID %d: %v", id, err)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Campaign not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrInvalidInput):
+			http.Error(w, "Invalid deadline format. Use YYYY-MM-DD", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to update campaign", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(campaign); err != nil {
+		log.Printf("Error encoding updated campaign response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteCampaign handles DELETE /campaigns/{id} - deletes a campaign
+func (h *CampaignHandler) DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	id, err := parseCampaignID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		log.Printf("Error deleting campaign with ID %d: %v", id, err)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Campaign not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to delete campaign", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCampaignProgress handles GET /campaigns/{id}/progress - returns the
+// completed, remaining and overdue servers of a campaign
+func (h *CampaignHandler) GetCampaignProgress(w http.ResponseWriter, r *http.Request) {
+	_, progress, ok := h.loadProgress(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(progress); err != nil {
+		log.Printf("Error encoding campaign progress response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetCampaignBurndown handles GET /campaigns/{id}/burndown - returns the
+// completed and remaining servers of a campaign at the end of each interval
+// since it was created, next to the ideal burndown
+func (h *CampaignHandler) GetCampaignBurndown(w http.ResponseWriter, r *http.Request) {
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+	if err := models.ValidateTrendInterval(interval); err != nil {
+		http.Error(w, "Invalid interval parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	campaign, progress, ok := h.loadProgress(w, r)
+	if !ok {
+		return
+	}
+
+	response := struct {
+		Interval string                 `json:"interval"`
+		Deadline time.Time              `json:"deadline"`
+		Total    int                    `json:"total_servers"`
+		Points   []models.BurndownPoint `json:"points"`
+	}{
+		Interval: interval,
+		Deadline: campaign.Deadline,
+		Total:    progress.TotalServers,
+		Points:   models.CampaignBurndown(*campaign, progress, interval, progress.GeneratedAt),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding campaign burndown response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// loadCampaign loads the campaign of the request, writing the error response
+// and returning false when it cannot be loaded
+func (h *CampaignHandler) loadCampaign(w http.ResponseWriter, r *http.Request) (*models.Campaign, bool) {
+	id, err := parseCampaignID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	campaign, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error getting campaign by ID %d: %v", id, err)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Campaign not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	return campaign, true
+}
+
+// loadProgress loads the campaign of the request and computes its progress
+// from the current servers and their OS changes
+func (h *CampaignHandler) loadProgress(w http.ResponseWriter, r *http.Request) (*models.Campaign, models.CampaignProgress, bool) {
+	campaign, ok := h.loadCampaign(w, r)
+	if !ok {
+		return nil, models.CampaignProgress{}, false
+	}
+
+	servers, err := h.serverRepo.GetAll(nil)
+	if err != nil {
+		log.Printf("Error getting servers for campaign %d: %v", campaign.ID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, models.CampaignProgress{}, false
+	}
+
+	changeType := models.ChangeTypeOSChanged
+	history, err := h.historyRepo.GetAll(&models.ChangeHistoryFilter{ChangeType: &changeType})
+	if err != nil {
+		log.Printf("Error getting OS changes for campaign %d: %v", campaign.ID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, models.CampaignProgress{}, false
+	}
+
+	return campaign, models.ComputeCampaignProgress(*campaign, servers, history, time.Now()), true
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestCampaignHandler_Progress(t *testing.T) {
+	api := newTestAPI(t)
+	debian11 := api.createOS(t, "Debian", "11", "2026-08-31")
+	debian12 := api.createOS(t, "Debian", "12", "2028-06-30")
+
+	var servers []models.Server
+	for _, req := range []models.CreateServerRequest{
+		{Name: "web-01", OSID: debian11.ID, Environment: "prod"},
+		{Name: "web-02", OSID: debian11.ID, Environment: "prod"},
+		{Name: "db-01", OSID: debian11.ID, Environment: "dev"},
+	} {
+		rec := api.do(t, http.MethodPost, "/api/v1/servers", req)
+		expectStatus(t, rec, http.StatusCreated)
+		var server models.Server
+		decode(t, rec, &server)
+		servers = append(servers, server)
+	}
+
+	deadline := time.Now().AddDate(0, 3, 0).Format("2006-01-02")
+	rec := api.do(t, http.MethodPost, "/api/v1/campaigns", models.CreateCampaignRequest{
+		Name: "Debian 12 in prod", SourceOSID: debian11.ID, TargetOSID: debian12.ID, Deadline: deadline, Owner: "platform",
+		Scope: &models.CampaignScope{Environment: "prod"},
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var campaign models.Campaign
+	decode(t, rec, &campaign)
+	if campaign.Selection != models.CampaignSelectionScope || campaign.Scope.Environment != "prod" {
+		t.Fatalf("Unexpected created campaign: %+v", campaign)
+	}
+
+	rec = api.do(t, http.MethodPut, fmt.Sprintf("/api/v1/servers/%d", servers[0].ID), models.UpdateServerRequest{OSID: debian12.ID})
+	expectStatus(t, rec, http.StatusOK)
+
+	path := fmt.Sprintf("/api/v1/campaigns/%d", campaign.ID)
+	rec = api.do(t, http.MethodGet, path+"/progress", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var progress models.CampaignProgress
+	decode(t, rec, &progress)
+	if progress.TotalServers != 2 || progress.CompletedCount != 1 || progress.RemainingCount != 1 || progress.OverdueCount != 0 ||
+		progress.CompletedServers[0].Name != "web-01" || progress.CompletedServers[0].CompletedAt == nil ||
+		progress.RemainingServers[0].Name != "web-02" {
+		t.Errorf("Unexpected campaign progress: %+v", progress)
+	}
+
+	// Moving the deadline into the past makes the remaining servers overdue
+	rec = api.do(t, http.MethodPut, path, models.UpdateCampaignRequest{Deadline: "2020-01-01"})
+	expectStatus(t, rec, http.StatusOK)
+	rec = api.do(t, http.MethodGet, path+"/progress", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &progress)
+	if progress.OverdueCount != 1 || progress.OverdueServers[0].Name != "web-02" {
+		t.Errorf("Expected web-02 to be overdue, got %+v", progress)
+	}
+
+	rec = api.do(t, http.MethodGet, path+"/burndown?interval=week", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var burndown struct {
+		Interval string                 `json:"interval"`
+		Total    int                    `json:"total_servers"`
+		Points   []models.BurndownPoint `json:"points"`
+	}
+	decode(t, rec, &burndown)
+	if burndown.Interval != models.IntervalWeek || burndown.Total != 2 || len(burndown.Points) != 1 ||
+		burndown.Points[0].Completed != 1 || burndown.Points[0].Remaining != 1 {
+		t.Errorf("Unexpected burndown: %+v", burndown)
+	}
+
+	// Explicit campaigns track the listed servers only
+	rec = api.do(t, http.MethodPost, "/api/v1/campaigns", models.CreateCampaignRequest{
+		Name: "Databases", SourceOSID: debian11.ID, TargetOSID: debian12.ID, Deadline: deadline, Owner: "dba",
+		ServerIDs: []int{servers[2].ID},
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var explicit models.Campaign
+	decode(t, rec, &explicit)
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/campaigns/%d/progress", explicit.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &progress)
+	if progress.TotalServers != 1 || progress.RemainingServers[0].Name != "db-01" {
+		t.Errorf("Unexpected explicit campaign progress: %+v", progress)
+	}
+
+	// A listed server cannot be deleted while the campaign exists
+	serverPath := fmt.Sprintf("/api/v1/servers/%d", servers[2].ID)
+	expectStatus(t, api.do(t, http.MethodDelete, serverPath, nil), http.StatusConflict)
+	expectStatus(t, api.do(t, http.MethodGet, serverPath, nil), http.StatusOK)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/campaigns?owner=dba", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var campaigns []models.Campaign
+	decode(t, rec, &campaigns)
+	if len(campaigns) != 1 || campaigns[0].ID != explicit.ID || rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Expected only the dba campaign, got %+v", campaigns)
+	}
+
+	// The target of a campaign cannot be deleted, even when no server runs it
+	debian13 := api.createOS(t, "Debian", "13", "2030-06-30")
+	rec = api.do(t, http.MethodPost, "/api/v1/campaigns", models.CreateCampaignRequest{
+		Name: "Debian 13", SourceOSID: debian12.ID, TargetOSID: debian13.ID, Deadline: deadline, Owner: "platform",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	rec = api.do(t, http.MethodDelete, fmt.Sprintf("/api/v1/os/%d", debian13.ID), nil)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = api.do(t, http.MethodDelete, path, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+	rec = api.do(t, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestCampaignHandler_Errors(t *testing.T) {
+	api := newTestAPI(t)
+	debian11 := api.createOS(t, "Debian", "11", "2026-08-31")
+	debian12 := api.createOS(t, "Debian", "12", "2028-06-30")
+	unknown := 999
+
+	valid := func(change func(req *models.CreateCampaignRequest)) models.CreateCampaignRequest {
+		req := models.CreateCampaignRequest{Name: "c", SourceOSID: debian11.ID, TargetOSID: debian12.ID, Deadline: "2027-06-30", Owner: "o"}
+		change(&req)
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"missing owner", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) { r.Owner = "" }), http.StatusBadRequest},
+		{"same OS", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) { r.TargetOSID = debian11.ID }), http.StatusBadRequest},
+		{"invalid deadline", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) { r.Deadline = "Q2 2027" }), http.StatusBadRequest},
+		{"scope and servers", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) {
+			r.Scope = &models.CampaignScope{Role: "web"}
+			r.ServerIDs = []int{1}
+		}), http.StatusBadRequest},
+		{"name too long", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) { r.Name = strings.Repeat("n", models.MaxCampaignFieldLength+1) }), http.StatusBadRequest},
+		{"scope location too long", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) {
+			r.Scope = &models.CampaignScope{Location: strings.Repeat("l", models.MaxServerAttributeLength+1)}
+		}), http.StatusBadRequest},
+		{"invalid environment", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) { r.Scope = &models.CampaignScope{Environment: "qa"} }), http.StatusBadRequest},
+		{"unknown OS", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) { r.TargetOSID = unknown }), http.StatusBadRequest},
+		{"unknown server", http.MethodPost, "/api/v1/campaigns", valid(func(r *models.CreateCampaignRequest) { r.ServerIDs = []int{unknown} }), http.StatusBadRequest},
+		{"invalid filter", http.MethodGet, "/api/v1/campaigns?source_os_id=debian", nil, http.StatusBadRequest},
+		{"progress unknown", http.MethodGet, "/api/v1/campaigns/999/progress", nil, http.StatusNotFound},
+		{"burndown invalid interval", http.MethodGet, "/api/v1/campaigns/999/burndown?interval=hour", nil, http.StatusBadRequest},
+		{"update owner too long", http.MethodPut, "/api/v1/campaigns/999", models.UpdateCampaignRequest{Owner: strings.Repeat("o", models.MaxCampaignFieldLength+1)}, http.StatusBadRequest},
+		{"update unknown", http.MethodPut, "/api/v1/campaigns/999", models.UpdateCampaignRequest{Owner: "o"}, http.StatusNotFound},
+		{"delete unknown", http.MethodDelete, "/api/v1/campaigns/999", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(t, tt.method, tt.path, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
@@ -1,31 +1,105 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"infra-dashboard/internal/auth"
 	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/export"
 	"infra-dashboard/internal/models"
 
 	"github.com/gorilla/mux"
 )
 
-// ChangeHistoryHandler handles HTTP requests for server change history
+// Limits of the change metadata, matching the changed_by and request_id
+// history columns
+const (
+	maxActorLength     = 255
+	maxRequestIDLength = 100
+)
+
+// changeContext returns the context of a mutating request carrying the change
+// metadata recorded in the history: the authenticated principal as actor,
+// or the X-Actor header when authentication is disabled, truncated to
+// maxActorLength, the request ID from the X-Request-ID header, generated when
+// missing or longer than maxRequestIDLength, and the API as source
+func changeContext(r *http.Request) context.Context {
+	return changeContextWithSource(r, models.ChangeSourceAPI)
+}
+
+// changeContextWithSource returns the context of a mutating request like
+// changeContext, recording changes as made through source
+func changeContextWithSource(r *http.Request, source string) context.Context {
+	requestID := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+	if utf8.RuneCountInString(requestID) > maxRequestIDLength {
+		log.Printf("Ignoring X-Request-ID header longer than %d characters", maxRequestIDLength)
+		requestID = ""
+	}
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	actor := strings.TrimSpace(r.Header.Get("X-Actor"))
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+		actor = principal.Name
+	}
+	if runes := []rune(actor); len(runes) > maxActorLength {
+		actor = string(runes[:maxActorLength])
+	}
+
+	return models.WithChangeMetadata(r.Context(), models.ChangeMetadata{
+		Actor:     actor,
+		RequestID: requestID,
+		Source:    source,
+	})
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ChangeHistoryHandler handles HTTP requests for server and operating system change history
 type ChangeHistoryHandler struct {
-	repo *database.ChangeHistoryRepository
+	repo   database.ChangeHistoryStore
+	osRepo database.OSChangeHistoryStore
 }
 
 // NewChangeHistoryHandler creates a new change history handler
-func NewChangeHistoryHandler(repo *database.ChangeHistoryRepository) *ChangeHistoryHandler {
-	return &ChangeHistoryHandler{repo: repo}
+func NewChangeHistoryHandler(repo database.ChangeHistoryStore, osRepo database.OSChangeHistoryStore) *ChangeHistoryHandler {
+	return &ChangeHistoryHandler{repo: repo, osRepo: osRepo}
 }
 
-// GetChangeHistory retrieves all change history with optional filters
+// GetChangeHistory retrieves server and operating system change history,
+// newest first, with optional filters. Each record has a resource_type.
 func (h *ChangeHistoryHandler) GetChangeHistory(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters for filters
 	filter := &models.ChangeHistoryFilter{}
+	osFilter := &models.OSChangeHistoryFilter{}
+
+	// Parse resource_type filter
+	resourceType := r.URL.Query().Get("resource_type")
+	if resourceType != "" {
+		if err := models.ValidateResourceType(resourceType); err != nil {
+			http.Error(w, "Invalid resource_type parameter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Parse server_id filter
 	if serverIDStr := r.URL.Query().Get("server_id"); serverIDStr != "" {
@@ -35,13 +109,28 @@ func (h *ChangeHistoryHandler) GetChangeHistory(w http.ResponseWriter, r *http.R
 			return
 		}
 		filter.ServerID = &serverID
+		resourceType = models.ResourceTypeServer
+	}
+
+	// Parse os_id filter
+	if osIDStr := r.URL.Query().Get("os_id"); osIDStr != "" {
+		osID, err := strconv.Atoi(osIDStr)
+		if err != nil {
+			http.Error(w, "Invalid os_id parameter", http.StatusBadRequest)
+			return
+		}
+		if filter.ServerID != nil {
+			http.Error(w, "server_id and os_id cannot be combined", http.StatusBadRequest)
+			return
+		}
+		osFilter.OSID = &osID
+		resourceType = models.ResourceTypeOS
 	}
 
 	// Parse change_type filter
 	if changeType := r.URL.Query().Get("change_type"); changeType != "" {
-		// Validate change_type
-		if changeType != "created" && changeType != "os_changed" && changeType != "deleted" {
-			http.Error(w, "Invalid change_type. Must be: created, os_changed, or deleted", http.StatusBadRequest)
+		if err := models.ValidateChangeType(changeType); err != nil {
+			http.Error(w, "Invalid change_type parameter: "+err.Error(), http.StatusBadRequest)
 			return
 		}
 		filter.ChangeType = &changeType
@@ -69,41 +158,82 @@ func (h *ChangeHistoryHandler) GetChangeHistory(w http.ResponseWriter, r *http.R
 		filter.EndDate = &endDate
 	}
 
-	// Parse limit (default 100)
-	limit := 100
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		parsedLimit, err := strconv.Atoi(limitStr)
-		if err != nil || parsedLimit < 1 {
-			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
-			return
-		}
-		limit = parsedLimit
+	osFilter.ChangeType = filter.ChangeType
+	osFilter.StartDate = filter.StartDate
+	osFilter.EndDate = filter.EndDate
+	feed := &models.ChangeFeedFilter{ResourceType: resourceType, Servers: *filter, OS: *osFilter}
+
+	format, err := export.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format != export.FormatJSON {
+		h.exportChangeHistory(w, r, feed, format)
+		return
 	}
-	filter.Limit = limit
 
-	// Parse offset (default 0)
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		parsedOffset, err := strconv.Atoi(offsetStr)
-		if err != nil || parsedOffset < 0 {
-			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
-			return
-		}
-		offset = parsedOffset
+	feed.Limit, feed.Offset, err = parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	filter.Offset = offset
 
-	// Get change history from repository
-	history, err := h.repo.GetAll(filter)
+	history, err := h.repo.GetFeed(feed)
 	if err != nil {
+		log.Printf("Error getting change history: %v", err)
 		http.Error(w, "Failed to retrieve change history", http.StatusInternalServerError)
 		return
 	}
+	if history == nil {
+		history = []interface{}{}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(history)
 }
 
+// changeHistoryExportColumns are the columns of change history exports
+var changeHistoryExportColumns = []string{
+	"changed_at", "resource_type", "resource_id", "resource_name", "change_type",
+	"changed_by", "source", "request_id", "changes",
+}
+
+// exportChangeHistory streams the merged change history feed in an export
+// format, reading it in batches
+func (h *ChangeHistoryHandler) exportChangeHistory(w http.ResponseWriter, r *http.Request, feed *models.ChangeFeedFilter, format string) {
+	limit, offset, err := parseExportPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records := &batchIterator[interface{}]{
+		offset: offset,
+		fetch: func(limit, offset int) ([]interface{}, error) {
+			page := *feed
+			page.Limit, page.Offset = limit, offset
+			return h.repo.GetFeed(&page)
+		},
+	}
+
+	streamExport(w, format, "change-history", changeHistoryExportColumns, records.next, limit, func(record interface{}) []interface{} {
+		switch record := record.(type) {
+		case models.ServerChangeHistory:
+			return []interface{}{
+				record.ChangedAt, models.ResourceTypeServer, record.ServerID, record.ServerName, record.ChangeType,
+				record.ChangedBy, record.Source, record.RequestID, formatChanges(record.Changes),
+			}
+		case models.OSChangeHistory:
+			return []interface{}{
+				record.ChangedAt, models.ResourceTypeOS, record.OSID, record.OSName + " " + record.OSVersion, record.ChangeType,
+				record.ChangedBy, record.Source, record.RequestID, formatChanges(record.Changes),
+			}
+		}
+		return nil
+	})
+}
+
 // GetServerChangeHistory retrieves change history for a specific server
 func (h *ChangeHistoryHandler) GetServerChangeHistory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -123,8 +253,8 @@ func (h *ChangeHistoryHandler) GetServerChangeHistory(w http.ResponseWriter, r *
 	limit := 50
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		parsedLimit, err := strconv.Atoi(limitStr)
-		if err != nil || parsedLimit < 1 {
-			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxPageSize {
+			http.Error(w, fmt.Sprintf("Invalid limit parameter. Must be between 1 and %d", maxPageSize), http.StatusBadRequest)
 			return
 		}
 		limit = parsedLimit
@@ -140,7 +270,39 @@ func (h *ChangeHistoryHandler) GetServerChangeHistory(w http.ResponseWriter, r *
 	json.NewEncoder(w).Encode(history)
 }
 
-// GetChangeHistoryByID retrieves a single change history record by its ID
+// GetOSChangeHistory retrieves change history for a specific operating system
+func (h *ChangeHistoryHandler) GetOSChangeHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid OS ID", http.StatusBadRequest)
+		return
+	}
+
+	// Parse limit (default 50)
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxPageSize {
+			http.Error(w, fmt.Sprintf("Invalid limit parameter. Must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	history, err := h.osRepo.GetByOSID(id, limit)
+	if err != nil {
+		log.Printf("Error getting change history of OS %d: %v", id, err)
+		http.Error(w, "Failed to retrieve change history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetChangeHistoryByID retrieves a single change history record by its ID.
+// Server and operating system records are numbered separately, so the
+// resource_type parameter selects which one, servers by default.
 func (h *ChangeHistoryHandler) GetChangeHistoryByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr, exists := vars["id"]
@@ -155,9 +317,26 @@ func (h *ChangeHistoryHandler) GetChangeHistoryByID(w http.ResponseWriter, r *ht
 		return
 	}
 
-	record, err := h.repo.GetByID(id)
+	resourceType := r.URL.Query().Get("resource_type")
+	if resourceType != "" {
+		if err := models.ValidateResourceType(resourceType); err != nil {
+			http.Error(w, "Invalid resource_type parameter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var record interface{}
+	if resourceType == models.ResourceTypeOS {
+		record, err = h.osRepo.GetByID(id)
+	} else {
+		record, err = h.repo.GetByID(id)
+	}
 	if err != nil {
-		http.Error(w, "Failed to retrieve change history record", http.StatusInternalServerError)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Change history record not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to retrieve change history record", http.StatusInternalServerError)
+		}
 		return
 	}
 
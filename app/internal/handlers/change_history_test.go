@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestChangeHistoryHandler(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+	debian := api.createOS(t, "Debian", "12", "2028-06-30")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	expectStatus(t, rec, http.StatusCreated)
+	var server models.Server
+	decode(t, rec, &server)
+
+	rec = api.do(t, http.MethodPut, fmt.Sprintf("/api/v1/servers/%d", server.ID), models.UpdateServerRequest{OSID: debian.ID})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/servers/%d/history", server.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var history []models.ServerChangeHistory
+	decode(t, rec, &history)
+	if len(history) != 2 || history[0].ChangeType != "os_changed" {
+		t.Fatalf("Unexpected server history: %+v", history)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?resource_type=server&change_type=created", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var created []models.ServerChangeHistory
+	decode(t, rec, &created)
+	if len(created) != 1 || created[0].ChangeType != "created" {
+		t.Errorf("Unexpected filtered history: %+v", created)
+	}
+
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/history/%d", history[0].ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history/999", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?change_type=moved", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestChangeHistoryHandler_ChangeMetadata(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	expectStatus(t, rec, http.StatusCreated)
+	var server models.Server
+	decode(t, rec, &server)
+
+	headers := map[string]string{"X-Actor": "alice@example.com", "X-Request-ID": "req-42"}
+	rec = api.doWithHeaders(t, http.MethodPut, fmt.Sprintf("/api/v1/servers/%d", server.ID), models.UpdateServerRequest{Name: "web-01a"}, headers)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?change_type=renamed", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var renamed []models.ServerChangeHistory
+	decode(t, rec, &renamed)
+	if len(renamed) != 1 {
+		t.Fatalf("Expected one renamed record, got %+v", renamed)
+	}
+	record := renamed[0]
+	if record.ChangedBy != "alice@example.com" || record.RequestID != "req-42" || record.Source != models.ChangeSourceAPI {
+		t.Errorf("Unexpected change metadata: %+v", record)
+	}
+	if change := record.Changes["name"]; change.Old != "web-01" || change.New != "web-01a" {
+		t.Errorf("Unexpected name change: %+v", record.Changes)
+	}
+
+	// Without headers the actor is unknown and a request ID is generated
+	rec = api.do(t, http.MethodGet, "/api/v1/history?resource_type=server&change_type=created", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var created []models.ServerChangeHistory
+	decode(t, rec, &created)
+	if len(created) != 1 || created[0].ChangedBy != "" || created[0].RequestID == "" {
+		t.Errorf("Unexpected created record metadata: %+v", created)
+	}
+}
+
+func TestChangeHistoryHandler_OversizedChangeMetadata(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	// Headers longer than the history columns do not fail the change
+	headers := map[string]string{"X-Actor": strings.Repeat("é", 300), "X-Request-ID": strings.Repeat("r", 101)}
+	rec := api.doWithHeaders(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID}, headers)
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?resource_type=server", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var history []models.ServerChangeHistory
+	decode(t, rec, &history)
+	if len(history) != 1 {
+		t.Fatalf("Expected one history record, got %+v", history)
+	}
+	if actor := history[0].ChangedBy; actor != strings.Repeat("é", maxActorLength) {
+		t.Errorf("Expected the actor to be truncated to %d characters, got %d", maxActorLength, utf8.RuneCountInString(actor))
+	}
+	if requestID := history[0].RequestID; requestID == "" || strings.HasPrefix(requestID, "rr") || len(requestID) > maxRequestIDLength {
+		t.Errorf("Expected a generated request ID, got %q", requestID)
+	}
+}
+
+func TestChangeHistoryHandler_OS(t *testing.T) {
+	api := newTestAPI(t)
+
+	rec := api.do(t, http.MethodPost, "/api/v1/os", models.CreateOSRequest{Name: "Ubuntu", Version: "20.04", EndOfSupport: "2025-04-30"})
+	expectStatus(t, rec, http.StatusCreated)
+	var os models.OS
+	decode(t, rec, &os)
+
+	headers := map[string]string{"X-Actor": "alice@example.com"}
+	rec = api.doWithHeaders(t, http.MethodPut, fmt.Sprintf("/api/v1/os/%d", os.ID), models.UpdateOSRequest{EndOfSupport: "2030-04-30"}, headers)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: os.ID})
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/os/%d/history", os.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var history []models.OSChangeHistory
+	decode(t, rec, &history)
+	if len(history) != 2 || history[0].ChangeType != models.ChangeTypeUpdated || history[1].ChangeType != models.ChangeTypeCreated {
+		t.Fatalf("Unexpected OS history: %+v", history)
+	}
+	update := history[0]
+	if change := update.Changes["end_of_support"]; change.Old != "2025-04-30" || change.New != "2030-04-30" || len(update.Changes) != 1 {
+		t.Errorf("Unexpected end of support change: %+v", update.Changes)
+	}
+	if update.ChangedBy != "alice@example.com" || update.OSName != "Ubuntu" || update.OSVersion != "20.04" {
+		t.Errorf("Unexpected OS change record: %+v", update)
+	}
+
+	// The global feed interleaves both resources, newest first
+	rec = api.do(t, http.MethodGet, "/api/v1/history", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var feed []struct {
+		ID           int    `json:"id"`
+		ResourceType string `json:"resource_type"`
+		ChangeType   string `json:"change_type"`
+	}
+	decode(t, rec, &feed)
+	if len(feed) != 3 || feed[0].ResourceType != models.ResourceTypeServer || feed[1].ResourceType != models.ResourceTypeOS || feed[1].ChangeType != models.ChangeTypeUpdated {
+		t.Fatalf("Unexpected history feed: %+v", feed)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?limit=1&offset=1", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &feed)
+	if len(feed) != 1 || feed[0].ResourceType != models.ResourceTypeOS || feed[0].ChangeType != models.ChangeTypeUpdated {
+		t.Errorf("Unexpected history page: %+v", feed)
+	}
+
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/history?os_id=%d&change_type=created", os.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &feed)
+	if len(feed) != 1 || feed[0].ResourceType != models.ResourceTypeOS {
+		t.Errorf("Unexpected OS feed: %+v", feed)
+	}
+
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/history/%d?resource_type=os", update.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var record models.OSChangeHistory
+	decode(t, rec, &record)
+	if record.ChangeType != models.ChangeTypeUpdated {
+		t.Errorf("Unexpected OS history record: %+v", record)
+	}
+
+	// Deleting an operating system keeps its history
+	rec = api.do(t, http.MethodPost, "/api/v1/os", models.CreateOSRequest{Name: "Debian", Version: "12", EndOfSupport: "2028-06-30"})
+	expectStatus(t, rec, http.StatusCreated)
+	var debian models.OS
+	decode(t, rec, &debian)
+	rec = api.do(t, http.MethodDelete, fmt.Sprintf("/api/v1/os/%d", debian.ID), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?resource_type=os&change_type=deleted", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var deleted []models.OSChangeHistory
+	decode(t, rec, &deleted)
+	if len(deleted) != 1 || deleted[0].OSID != nil || deleted[0].OSName != "Debian" {
+		t.Errorf("Unexpected deleted OS record: %+v", deleted)
+	}
+
+	// An offset past the end of the feed returns an empty page
+	rec = api.do(t, http.MethodGet, "/api/v1/history?offset=100", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+		t.Errorf("Expected an empty page, got %s", body)
+	}
+
+	for _, query := range []string{"resource_type=vm", "server_id=1&os_id=1", "limit=0", "limit=1001", "offset=-1"} {
+		rec = api.do(t, http.MethodGet, "/api/v1/history?"+query, nil)
+		expectStatus(t, rec, http.StatusBadRequest)
+	}
+	for _, path := range []string{fmt.Sprintf("/api/v1/os/%d/history?limit=1001", os.ID), "/api/v1/servers/1/history?limit=1001"} {
+		expectStatus(t, api.do(t, http.MethodGet, path, nil), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+// ComplianceHandler records compliance snapshots and reports their trend
+type ComplianceHandler struct {
+	repo       database.SnapshotStore
+	serverRepo database.ServerStore
+	waiverRepo database.WaiverStore
+	policy     models.CompliancePolicy
+}
+
+// NewComplianceHandler creates a new compliance handler. Snapshots are
+// scored with the given policy, normally the default one.
+func NewComplianceHandler(repo database.SnapshotStore, serverRepo database.ServerStore, waiverRepo database.WaiverStore, policy models.CompliancePolicy) *ComplianceHandler {
+	return &ComplianceHandler{repo: repo, serverRepo: serverRepo, waiverRepo: waiverRepo, policy: policy}
+}
+
+// TakeSnapshot computes the current compliance of the fleet and stores it
+func (h *ComplianceHandler) TakeSnapshot(source string) (*models.ComplianceSnapshot, error) {
+	servers, err := h.serverRepo.GetAll(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get servers: %w", err)
+	}
+
+	waivers, err := activeWaivers(h.waiverRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	complianceUtils := models.NewComplianceUtilsWithPolicy(h.policy).WithWaivers(waivers)
+	report := complianceUtils.GenerateComplianceReport(servers)
+	snapshot := models.NewComplianceSnapshot(report, complianceUtils.GetComplianceScore(servers), source)
+
+	return h.repo.Create(&snapshot)
+}
+
+// ScheduleSnapshots takes a snapshot every interval until ctx is done. A
+// snapshot is taken right away when the latest one is older than interval,
+// so that restarts do not leave gaps.
+func (h *ComplianceHandler) ScheduleSnapshots(ctx context.Context, interval time.Duration) {
+	since := time.Now().Add(-interval)
+	recent, err := h.repo.GetAll(&models.SnapshotFilter{From: &since, Limit: 1, Latest: true})
+	if err != nil {
+		log.Printf("Error checking latest compliance snapshot: %v", err)
+	}
+	if err == nil && len(recent) == 0 {
+		h.takeScheduledSnapshot()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.takeScheduledSnapshot()
+		}
+	}
+}
+
+// takeScheduledSnapshot takes a scheduled snapshot, logging the outcome
+func (h *ComplianceHandler) takeScheduledSnapshot() {
+	snapshot, err := h.TakeSnapshot(models.SnapshotSourceScheduled)
+	if err != nil {
+		log.Printf("Error taking scheduled compliance snapshot: %v", err)
+		return
+	}
+	log.Printf("Took compliance snapshot %d: score %.2f", snapshot.ID, snapshot.ComplianceScore)
+}
+
+// parseSnapshotRange parses the from and to query parameters
+func parseSnapshotRange(r *http.Request) (*models.SnapshotFilter, error) {
+	from, err := parseTimeParam(r, "from", false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseTimeParam(r, "to", true)
+	if err != nil {
+		return nil, err
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, fmt.Errorf("Invalid range: to is before from")
+	}
+
+	return &models.SnapshotFilter{From: from, To: to}, nil
+}
+
+// CreateSnapshot handles POST /compliance/snapshots - takes a snapshot on demand
+func (h *ComplianceHandler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.TakeSnapshot(models.SnapshotSourceManual)
+	if err != nil {
+		log.Printf("Error taking compliance snapshot: %v", err)
+		http.Error(w, "Failed to take compliance snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		log.Printf("Error encoding created snapshot response: %v", err)
+		return
+	}
+}
+
+// GetSnapshots handles GET /compliance/snapshots - lists snapshots taken
+// between from and to, oldest first, with offset-based pagination
+func (h *ComplianceHandler) GetSnapshots(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSnapshotRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting compliance snapshots: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	snapshots, err := h.repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting compliance snapshots: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writePageHeaders(w, r, total, filter.Limit, filter.Offset)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshots); err != nil {
+		log.Printf("Error encoding snapshots response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetTrend handles GET /compliance/trend - returns the compliance score and
+// counts at the end of each interval between from and to
+func (h *ComplianceHandler) GetTrend(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSnapshotRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+	if err := models.ValidateTrendInterval(interval); err != nil {
+		http.Error(w, "Invalid interval parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := h.repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting compliance snapshots for trend: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Interval string              `json:"interval"`
+		From     *time.Time          `json:"from,omitempty"`
+		To       *time.Time          `json:"to,omitempty"`
+		Points   []models.TrendPoint `json:"points"`
+	}{
+		Interval: interval,
+		From:     filter.From,
+		To:       filter.To,
+		Points:   models.ComplianceTrend(snapshots, interval),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding compliance trend response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
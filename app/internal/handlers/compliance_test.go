@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestComplianceHandler_Trend(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	eol := api.createOS(t, "CentOS", "7", now.AddDate(-1, 0, 0).Format("2006-01-02"))
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "legacy-01", OSID: eol.ID})
+	expectStatus(t, rec, http.StatusCreated)
+
+	// Two historical snapshots in the same quarter and one in the next
+	for _, s := range []models.ComplianceSnapshot{
+		{TakenAt: time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC), Source: models.SnapshotSourceScheduled, TotalServers: 4, EndOfLifeServers: 3, ComplianceScore: 10},
+		{TakenAt: time.Date(2025, time.March, 20, 0, 0, 0, 0, time.UTC), Source: models.SnapshotSourceScheduled, TotalServers: 4, EndOfLifeServers: 2, ComplianceScore: 40},
+		{TakenAt: time.Date(2025, time.May, 5, 0, 0, 0, 0, time.UTC), Source: models.SnapshotSourceScheduled, TotalServers: 4, EndOfLifeServers: 1, ComplianceScore: 70},
+	} {
+		if _, err := api.stores.Snapshots.Create(&s); err != nil {
+			t.Fatalf("Failed to create snapshot: %v", err)
+		}
+	}
+
+	rec = api.do(t, http.MethodPost, "/api/v1/compliance/snapshots", nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var snapshot models.ComplianceSnapshot
+	decode(t, rec, &snapshot)
+	if snapshot.Source != models.SnapshotSourceManual || snapshot.TotalServers != 1 || snapshot.EndOfLifeServers != 1 || snapshot.ComplianceScore != 0 {
+		t.Errorf("Unexpected on-demand snapshot: %+v", snapshot)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/compliance/trend?from=2025-01-01&to=2025-06-30&interval=quarter", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var trend struct {
+		Interval string              `json:"interval"`
+		Points   []models.TrendPoint `json:"points"`
+	}
+	decode(t, rec, &trend)
+	if len(trend.Points) != 2 {
+		t.Fatalf("Expected 2 quarters, got %+v", trend.Points)
+	}
+	if trend.Points[0].ComplianceScore != 40 || trend.Points[1].EndOfLifeServers != 1 {
+		t.Errorf("Unexpected trend points: %+v", trend.Points)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/compliance/snapshots?limit=2", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if total := rec.Header().Get("X-Total-Count"); total != "4" {
+		t.Errorf("Expected X-Total-Count 4, got %q", total)
+	}
+
+	for _, query := range []string{"interval=year", "from=yesterday", "from=2025-06-01&to=2025-01-01"} {
+		rec = api.do(t, http.MethodGet, "/api/v1/compliance/trend?"+query, nil)
+		expectStatus(t, rec, http.StatusBadRequest)
+	}
+}
+
+func TestComplianceHandler_ScheduleSnapshots(t *testing.T) {
+	api := newTestAPI(t)
+	handler := NewComplianceHandler(api.stores.Snapshots, api.stores.Servers, api.stores.Waivers, models.DefaultCompliancePolicy())
+
+	// With a cancelled context the scheduler only catches up on a missed snapshot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	handler.ScheduleSnapshots(ctx, time.Hour)
+	handler.ScheduleSnapshots(ctx, time.Hour)
+
+	snapshots, err := api.stores.Snapshots.GetAll(nil)
+	if err != nil {
+		t.Fatalf("Failed to get snapshots: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Source != models.SnapshotSourceScheduled {
+		t.Errorf("Expected a single scheduled snapshot, got %+v", snapshots)
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"infra-dashboard/internal/export"
+	"infra-dashboard/internal/models"
+)
+
+// exportBatchSize is the number of records read from a store at a time when
+// streaming an export, and the number of rows written between flushes
+const exportBatchSize = 500
+
+// parseExportPage parses the limit and offset query parameters of an export.
+// Exports include every matching record unless a limit is given, in which
+// case they cover the same page as the JSON response.
+func parseExportPage(r *http.Request) (limit, offset int, err error) {
+	query := r.URL.Query()
+	if query.Get("limit") == "" && query.Get("offset") == "" {
+		return 0, 0, nil
+	}
+	return parsePage(r)
+}
+
+// parseExportCursorPage parses the limit and cursor query parameters of an
+// export of a list paginated by keyset, like parseExportPage
+func parseExportCursorPage(r *http.Request, decode func(string) (*models.Cursor, error)) (limit int, after *models.Cursor, err error) {
+	query := r.URL.Query()
+	if query.Get("limit") == "" && query.Get("cursor") == "" {
+		return 0, nil, nil
+	}
+	return parseCursorPage(r, decode)
+}
+
+// batchIterator reads the records of a store in batches of exportBatchSize,
+// starting at offset
+type batchIterator[T any] struct {
+	fetch  func(limit, offset int) ([]T, error)
+	offset int
+	batch  []T
+	done   bool
+}
+
+// peek returns the next record without consuming it, and false when no
+// records are left
+func (it *batchIterator[T]) peek() (T, bool, error) {
+	if len(it.batch) == 0 && !it.done {
+		batch, err := it.fetch(exportBatchSize, it.offset)
+		if err != nil {
+			var zero T
+			return zero, false, err
+		}
+		it.batch = batch
+		it.offset += len(batch)
+		it.done = len(batch) < exportBatchSize
+	}
+	if len(it.batch) == 0 {
+		var zero T
+		return zero, false, nil
+	}
+	return it.batch[0], true, nil
+}
+
+// next returns and consumes the next record
+func (it *batchIterator[T]) next() (T, bool, error) {
+	item, ok, err := it.peek()
+	if ok {
+		it.batch = it.batch[1:]
+	}
+	return item, ok, err
+}
+
+// sliceIterator iterates over records already in memory
+func sliceIterator[T any](items []T) func() (T, bool, error) {
+	return func() (T, bool, error) {
+		if len(items) == 0 {
+			var zero T
+			return zero, false, nil
+		}
+		item := items[0]
+		items = items[1:]
+		return item, true, nil
+	}
+}
+
+// streamExport writes the records returned by next as a table of the given
+// format, flushing the response every exportBatchSize rows. At most limit
+// rows are written when limit is positive. Errors reading the first records
+// are reported with a 500 response; later errors can only end the response
+// early and are logged.
+func streamExport[T any](w http.ResponseWriter, format, name string, columns []string, next func() (T, bool, error), limit int, row func(T) []interface{}) {
+	item, ok, err := next()
+	if err != nil {
+		log.Printf("Error exporting %s: %v", name, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	export.SetHeaders(w, format, name)
+	writer, err := export.NewWriter(w, format, name, columns)
+	if err != nil {
+		log.Printf("Error exporting %s: %v", name, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	for written := 0; ok && (limit <= 0 || written < limit); written++ {
+		if err := writer.WriteRow(row(item)...); err != nil {
+			log.Printf("Error exporting %s: %v", name, err)
+			return
+		}
+		if (written+1)%exportBatchSize == 0 {
+			if err := writer.Flush(); err != nil {
+				log.Printf("Error exporting %s: %v", name, err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		if item, ok, err = next(); err != nil {
+			log.Printf("Error exporting %s: %v", name, err)
+			return
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Printf("Error exporting %s: %v", name, err)
+	}
+}
+
+// formatChanges summarizes changed fields as "field: old -> new", sorted by
+// field name
+func formatChanges(changes map[string]models.FieldChange) string {
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = fmt.Sprintf("%s: %s -> %s", field, changeValue(changes[field].Old), changeValue(changes[field].New))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// changeValue returns the text of a changed field value
+func changeValue(value interface{}) string {
+	if value == nil || value == "" {
+		return "(none)"
+	}
+	return fmt.Sprint(value)
+}
+
+// exportDate formats an optional date as YYYY-MM-DD, or "" when it is not set
+func exportDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
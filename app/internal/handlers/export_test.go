@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/export"
+	"infra-dashboard/internal/models"
+)
+
+// readCSV parses a CSV export, failing the test on invalid content
+func readCSV(t *testing.T, body string) [][]string {
+	t.Helper()
+
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV export %q: %v", body, err)
+	}
+	return records
+}
+
+func TestExport_Servers(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	// More servers than fit in one export batch
+	for i := 0; i < exportBatchSize+20; i++ {
+		environment := "dev"
+		if i%2 == 0 {
+			environment = "prod"
+		}
+		req := &models.CreateServerRequest{Name: fmt.Sprintf("srv-%04d", i), OSID: ubuntu.ID, Environment: environment}
+		if _, err := api.stores.Servers.Create(context.Background(), req); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+	}
+
+	rec := api.do(t, http.MethodGet, "/api/v1/servers?format=csv&environment=prod&sort=name", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if contentType := rec.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+		t.Errorf("Unexpected Content-Type %q", contentType)
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); disposition != `attachment; filename="servers.csv"` {
+		t.Errorf("Unexpected Content-Disposition %q", disposition)
+	}
+
+	records := readCSV(t, rec.Body.String())
+	prod := (exportBatchSize + 20 + 1) / 2
+	if len(records) != prod+1 || rec.Header().Get("X-Total-Count") != fmt.Sprint(prod) {
+		t.Fatalf("Expected %d prod servers, got %d records, total %s", prod, len(records)-1, rec.Header().Get("X-Total-Count"))
+	}
+	if strings.Join(records[0], ",") != strings.Join(serverExportColumns, ",") {
+		t.Errorf("Unexpected header %v", records[0])
+	}
+	if row := records[1]; row[1] != "srv-0000" || row[2] != "Ubuntu" || row[4] != "2027-04-01" || row[6] != "prod" {
+		t.Errorf("Unexpected first row %v", row)
+	}
+	for _, row := range records[1:] {
+		if row[6] != "prod" {
+			t.Fatalf("Expected only prod servers, got %v", row)
+		}
+	}
+
+	// A limit and cursor select the same page as the JSON response
+	rec = api.do(t, http.MethodGet, "/api/v1/servers?sort=name&limit=3", nil)
+	expectStatus(t, rec, http.StatusOK)
+	next := strings.Replace(nextLink(rec), "limit=3", "limit=2", 1)
+	rec = api.doWithHeaders(t, http.MethodGet, next, nil, map[string]string{"Accept": "text/markdown"})
+	expectStatus(t, rec, http.StatusOK)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "| ") || !strings.Contains(lines[2], "srv-0003") || !strings.Contains(lines[3], "srv-0004") {
+		t.Errorf("Unexpected Markdown page:\n%s", rec.Body.String())
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers?format=pdf", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestExport_OperatingSystems(t *testing.T) {
+	api := newTestAPI(t)
+	api.createOS(t, "Ubuntu", "18.04", "2023-05-31")
+	api.createOS(t, "Ubuntu", "24.04", "2029-04-30")
+	api.createOS(t, "Debian", "12", "2028-06-30")
+
+	rec := api.do(t, http.MethodGet, "/api/v1/os?format=csv&name=ubuntu&sort=version", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	records := readCSV(t, rec.Body.String())
+	if len(records) != 3 {
+		t.Fatalf("Expected 2 Ubuntu releases, got %v", records)
+	}
+	if row := records[1]; row[2] != "18.04" || row[3] != "2023-05-31" || row[4] != models.StatusEndOfLife {
+		t.Errorf("Unexpected row %v", row)
+	}
+	if row := records[2]; row[2] != "24.04" || row[4] != models.StatusSupported {
+		t.Errorf("Unexpected row %v", row)
+	}
+}
+
+func TestExport_ChangeHistory(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	expectStatus(t, rec, http.StatusCreated)
+	var server models.Server
+	decode(t, rec, &server)
+	rec = api.do(t, http.MethodPut, fmt.Sprintf("/api/v1/servers/%d", server.ID), models.UpdateServerRequest{Role: "web"})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?format=csv", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	records := readCSV(t, rec.Body.String())
+	if len(records) != 4 {
+		t.Fatalf("Expected the server and OS changes, got %v", records)
+	}
+	want := []struct{ resourceType, name, changeType string }{
+		{models.ResourceTypeServer, "web-01", models.ChangeTypeUpdated},
+		{models.ResourceTypeServer, "web-01", models.ChangeTypeCreated},
+		{models.ResourceTypeOS, "Ubuntu 22.04", models.ChangeTypeCreated},
+	}
+	for i, w := range want {
+		if row := records[i+1]; row[1] != w.resourceType || row[3] != w.name || row[4] != w.changeType {
+			t.Errorf("Row %d = %v, want %+v", i+1, row, w)
+		}
+	}
+	if changes := records[1][8]; changes != "role: (none) -> web" {
+		t.Errorf("Unexpected changes summary %q", changes)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?format=csv&resource_type=os", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if records := readCSV(t, rec.Body.String()); len(records) != 2 || records[1][1] != models.ResourceTypeOS {
+		t.Errorf("Expected only OS changes, got %v", records)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/history?format=csv&offset=1&limit=1", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if records := readCSV(t, rec.Body.String()); len(records) != 2 || records[1][4] != models.ChangeTypeCreated || records[1][1] != models.ResourceTypeServer {
+		t.Errorf("Expected the second change only, got %v", records)
+	}
+}
+
+func TestExport_Compliance(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	eol := api.createOS(t, "Ubuntu", "18.04", now.AddDate(-1, 0, 0).Format("2006-01-02"))
+	endingSoon := api.createOS(t, "Ubuntu", "20.04", now.AddDate(0, 2, 0).Format("2006-01-02"))
+	latest := api.createOS(t, "Ubuntu", "24.04", now.AddDate(5, 0, 0).Format("2006-01-02"))
+
+	for _, s := range []struct {
+		name string
+		osID int
+	}{{"web-01", latest.ID}, {"db-01", eol.ID}, {"cache-01", endingSoon.ID}} {
+		rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: s.name, OSID: s.osID, Environment: "prod"})
+		expectStatus(t, rec, http.StatusCreated)
+	}
+
+	rec := api.do(t, http.MethodGet, "/api/v1/servers/compliance?format=csv", nil)
+	expectStatus(t, rec, http.StatusOK)
+	records := readCSV(t, rec.Body.String())
+	if len(records) != 4 {
+		t.Fatalf("Expected a row per server, got %v", records)
+	}
+	for i, want := range []struct{ name, status string }{
+		{"db-01", models.StatusEndOfLife},
+		{"cache-01", models.StatusEndingSoon},
+		{"web-01", models.StatusSupported},
+	} {
+		if row := records[i+1]; row[1] != want.name || row[7] != want.status {
+			t.Errorf("Row %d = %v, want %s %s", i+1, row, want.name, want.status)
+		}
+	}
+	if tier := records[2][8]; tier == "" {
+		t.Errorf("Expected the tier of the ending soon server, got %v", records[2])
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers/compliance?format=xlsx", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if contentType := rec.Header().Get("Content-Type"); contentType != export.MediaTypeXLSX {
+		t.Errorf("Unexpected Content-Type %q", contentType)
+	}
+	body := rec.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Expected a workbook: %v", err)
+	}
+	if len(archive.File) != 6 {
+		t.Errorf("Expected 6 workbook parts, got %d", len(archive.File))
+	}
+}
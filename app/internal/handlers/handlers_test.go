@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"infra-dashboard/internal/auth"
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// testAPI wires the handlers to an in-memory store the same way cmd/main.go
+// wires them to PostgreSQL
+type testAPI struct {
+	stores *database.Stores
+	router *mux.Router
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	stores := database.NewMemoryStores(database.NewMemoryDB())
+	// A stricter named policy alongside the default one
+	policies, err := models.ParseCompliancePolicySet([]byte(`{
+		"policies": {
+			"strict": {
+				"tiers": [
+					{"name": "notice", "months": 12, "penalty": 0.25},
+					{"name": "urgent", "months": 3, "penalty": 1}
+				],
+				"end_of_life_penalty": 4,
+				"environments": {
+					"prod": {"lead_months": 12},
+					"dev": {"grace_days": 90}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse compliance policies: %v", err)
+	}
+
+	serverHandler := NewServerHandler(stores.Servers, stores.OS, stores.Waivers, stores.Changes, policies, models.DefaultUpgradeGraph())
+	osHandler := NewOSHandler(stores.OS, policies.Default)
+	changeHistoryHandler := NewChangeHistoryHandler(stores.ChangeHistory, stores.OSChangeHistory)
+	waiverHandler := NewWaiverHandler(stores.Waivers)
+	campaignHandler := NewCampaignHandler(stores.Campaigns, stores.Servers, stores.ChangeHistory)
+	scheduledChangeHandler := NewScheduledChangeHandler(stores.Changes, stores.Servers)
+	complianceHandler := NewComplianceHandler(stores.Snapshots, stores.Servers, stores.Waivers, policies.Default)
+	apiKeyHandler := NewAPIKeyHandler(stores.APIKeys)
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+
+	api.HandleFunc("/servers", serverHandler.GetServers).Methods("GET")
+	api.HandleFunc("/servers", serverHandler.CreateServer).Methods("POST")
+	api.HandleFunc("/servers/{id:[0-9]+}", serverHandler.GetServer).Methods("GET")
+	api.HandleFunc("/servers/{id:[0-9]+}", serverHandler.UpdateServer).Methods("PUT")
+	api.HandleFunc("/servers/{id:[0-9]+}", serverHandler.DeleteServer).Methods("DELETE")
+	api.HandleFunc("/servers/compliance", serverHandler.GetComplianceReport).Methods("GET")
+	api.HandleFunc("/servers/import", serverHandler.ImportServers).Methods("POST")
+	api.HandleFunc("/servers/register", serverHandler.RegisterServer).Methods("POST")
+
+	api.HandleFunc("/os", osHandler.GetOperatingSystems).Methods("GET")
+	api.HandleFunc("/os", osHandler.CreateOperatingSystem).Methods("POST")
+	api.HandleFunc("/os/{id:[0-9]+}", osHandler.GetOperatingSystem).Methods("GET")
+	api.HandleFunc("/os/{id:[0-9]+}", osHandler.UpdateOperatingSystem).Methods("PUT")
+	api.HandleFunc("/os/{id:[0-9]+}", osHandler.DeleteOperatingSystem).Methods("DELETE")
+
+	api.HandleFunc("/history", changeHistoryHandler.GetChangeHistory).Methods("GET")
+	api.HandleFunc("/history/{id:[0-9]+}", changeHistoryHandler.GetChangeHistoryByID).Methods("GET")
+	api.HandleFunc("/servers/{id:[0-9]+}/history", changeHistoryHandler.GetServerChangeHistory).Methods("GET")
+	api.HandleFunc("/os/{id:[0-9]+}/history", changeHistoryHandler.GetOSChangeHistory).Methods("GET")
+
+	api.HandleFunc("/waivers", waiverHandler.GetWaivers).Methods("GET")
+	api.HandleFunc("/waivers", waiverHandler.CreateWaiver).Methods("POST")
+	api.HandleFunc("/waivers/{id:[0-9]+}", waiverHandler.GetWaiver).Methods("GET")
+	api.HandleFunc("/waivers/{id:[0-9]+}", waiverHandler.UpdateWaiver).Methods("PUT")
+	api.HandleFunc("/waivers/{id:[0-9]+}", waiverHandler.RevokeWaiver).Methods("DELETE")
+
+	api.HandleFunc("/campaigns", campaignHandler.GetCampaigns).Methods("GET")
+	api.HandleFunc("/campaigns", campaignHandler.CreateCampaign).Methods("POST")
+	api.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.GetCampaign).Methods("GET")
+	api.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.UpdateCampaign).Methods("PUT")
+	api.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.DeleteCampaign).Methods("DELETE")
+	api.HandleFunc("/campaigns/{id:[0-9]+}/progress", campaignHandler.GetCampaignProgress).Methods("GET")
+	api.HandleFunc("/campaigns/{id:[0-9]+}/burndown", campaignHandler.GetCampaignBurndown).Methods("GET")
+
+	api.HandleFunc("/scheduled-changes", scheduledChangeHandler.GetScheduledChanges).Methods("GET")
+	api.HandleFunc("/scheduled-changes", scheduledChangeHandler.CreateScheduledChange).Methods("POST")
+	api.HandleFunc("/scheduled-changes/{id:[0-9]+}", scheduledChangeHandler.GetScheduledChange).Methods("GET")
+	api.HandleFunc("/scheduled-changes/{id:[0-9]+}", scheduledChangeHandler.CancelScheduledChange).Methods("DELETE")
+
+	api.HandleFunc("/compliance/snapshots", complianceHandler.GetSnapshots).Methods("GET")
+	api.HandleFunc("/compliance/snapshots", complianceHandler.CreateSnapshot).Methods("POST")
+	api.HandleFunc("/compliance/trend", complianceHandler.GetTrend).Methods("GET")
+	api.HandleFunc("/compliance/recommendations", serverHandler.GetComplianceRecommendations).Methods("GET")
+	api.HandleFunc("/compliance/schema", serverHandler.GetComplianceSchema).Methods("GET")
+
+	api.HandleFunc("/api-keys", apiKeyHandler.GetAPIKeys).Methods("GET")
+	api.HandleFunc("/api-keys", apiKeyHandler.CreateAPIKey).Methods("POST")
+	api.HandleFunc("/api-keys/{id:[0-9]+}", apiKeyHandler.GetAPIKey).Methods("GET")
+	api.HandleFunc("/api-keys/{id:[0-9]+}", apiKeyHandler.RevokeAPIKey).Methods("DELETE")
+	api.HandleFunc("/api-keys/{id:[0-9]+}/rotate", apiKeyHandler.RotateAPIKey).Methods("POST")
+
+	router.HandleFunc("/health", serverHandler.HealthCheck).Methods("GET")
+
+	return &testAPI{stores: stores, router: router}
+}
+
+// newAuthTestAPI returns a test API requiring authentication like cmd/main.go
+// does when AUTH_ENABLED is set, along with the secret of an admin API key
+func newAuthTestAPI(t *testing.T) (*testAPI, string) {
+	t.Helper()
+
+	api := newTestAPI(t)
+	admin, err := auth.EnsureAdminKey(api.stores.APIKeys, nil)
+	if err != nil {
+		t.Fatalf("Failed to issue admin API key: %v", err)
+	}
+	api.router.Use(auth.Middleware(auth.DefaultRules, auth.NewAPIKeyAuthenticator(api.stores.APIKeys)))
+
+	return api, admin.Key
+}
+
+// do performs a request against the router, encoding body as JSON when set
+func (a *testAPI) do(t *testing.T, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return a.doWithHeaders(t, method, path, body, nil)
+}
+
+// doWithHeaders performs a request like do with additional request headers
+func (a *testAPI) doWithHeaders(t *testing.T, method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("Failed to encode request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// createOS adds an operating system directly through the store
+func (a *testAPI) createOS(t *testing.T, name, version, endOfSupport string) *models.OS {
+	t.Helper()
+
+	os, err := a.stores.OS.Create(context.Background(), &models.CreateOSRequest{Name: name, Version: version, EndOfSupport: endOfSupport})
+	if err != nil {
+		t.Fatalf("Failed to create OS %s %s: %v", name, version, err)
+	}
+	return os
+}
+
+// decode unmarshals a JSON response body into v
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// nextLink returns the path of the next page in the Link header of a list
+// response, or an empty string on the last page
+func nextLink(rec *httptest.ResponseRecorder) string {
+	link := rec.Header().Get("Link")
+	if link == "" {
+		return ""
+	}
+	return link[strings.Index(link, "<")+1 : strings.Index(link, ">")]
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("Expected status %d, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	api := newTestAPI(t)
+
+	rec := api.do(t, http.MethodGet, "/health", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var body map[string]string
+	decode(t, rec, &body)
+	if body["status"] != "healthy" {
+		t.Errorf("Expected healthy status, got %q", body["status"])
+	}
+}
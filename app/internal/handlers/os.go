@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/export"
 	"infra-dashboard/internal/models"
 
 	"github.com/gorilla/mux"
@@ -14,23 +18,128 @@ import (
 
 // OSHandler handles operating system-related HTTP requests
 type OSHandler struct {
-	repo *database.OSRepository
+	repo   database.OSStore
+	policy models.CompliancePolicy
 }
 
-// NewOSHandler creates a new OS handler
-func NewOSHandler(repo *database.OSRepository) *OSHandler {
-	return &OSHandler{repo: repo}
+// NewOSHandler creates a new OS handler that classifies support status with
+// the given compliance policy
+func NewOSHandler(repo database.OSStore, policy models.CompliancePolicy) *OSHandler {
+	return &OSHandler{repo: repo, policy: policy}
 }
 
-// GetOperatingSystems handles GET /os - retrieves all operating systems
+// parseOSFilter builds an operating system filter from query parameters
+func parseOSFilter(r *http.Request) (*models.OSFilter, error) {
+	query := r.URL.Query()
+	filter := &models.OSFilter{}
+
+	// Parse string filters
+	for _, f := range []struct {
+		param string
+		dest  **string
+	}{
+		{"name", &filter.Name},
+		{"family", &filter.Family},
+		{"status", &filter.SupportStatus},
+		{"phase", &filter.Phase},
+	} {
+		if value := query.Get(f.param); value != "" {
+			*f.dest = &value
+		}
+	}
+
+	if filter.SupportStatus != nil {
+		if err := models.ValidateSupportStatus(*filter.SupportStatus); err != nil {
+			return nil, fmt.Errorf("Invalid status. Must be: supported, ending_soon, or eol")
+		}
+	}
+	if filter.Phase != nil {
+		if err := models.ValidatePhase(*filter.Phase); err != nil {
+			return nil, fmt.Errorf("Invalid phase. Must be: current, maintenance, extended, or eol")
+		}
+	}
+
+	// Parse date ranges
+	var err error
+	if filter.CreatedAfter, err = parseTimeParam(r, "created_after", false); err != nil {
+		return nil, err
+	}
+	if filter.CreatedBefore, err = parseTimeParam(r, "created_before", true); err != nil {
+		return nil, err
+	}
+	if filter.UpdatedAfter, err = parseTimeParam(r, "updated_after", false); err != nil {
+		return nil, err
+	}
+	if filter.UpdatedBefore, err = parseTimeParam(r, "updated_before", true); err != nil {
+		return nil, err
+	}
+
+	filter.Sort = models.DefaultOSSort
+	if sort := query.Get("sort"); sort != "" {
+		filter.Sort, err = models.ParseSort(sort, models.OSSortFields)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid sort parameter: %v", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// GetOperatingSystems handles GET /os - retrieves operating systems with
+// optional filters, sorting and cursor-based pagination
 func (h *OSHandler) GetOperatingSystems(w http.ResponseWriter, r *http.Request) {
-	oss, err := h.repo.GetAll()
+	filter, err := parseOSFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	cutoff := h.policy.EndingSoonCutoff(now)
+	filter.EndingSoonCutoff = &cutoff
+
+	format, err := export.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format != export.FormatJSON {
+		h.exportOperatingSystems(w, r, filter, format, now)
+		return
+	}
+
+	limit, after, err := parseCursorPage(r, func(value string) (*models.Cursor, error) {
+		return models.DecodeOSCursor(value, filter.Sort)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting operating systems: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// One more operating system than the page holds tells whether another
+	// page follows
+	filter.Limit, filter.After = limit+1, after
+	oss, err := h.repo.GetAll(filter)
 	if err != nil {
 		log.Printf("Error getting operating systems: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	var next *models.Cursor
+	if len(oss) > limit {
+		oss = oss[:limit]
+		cursor := models.OSCursor(oss[limit-1], filter.Sort)
+		next = &cursor
+	}
+
+	writeCursorPageHeaders(w, r, total, limit, next)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(oss); err != nil {
 		log.Printf("Error encoding operating systems response: %v", err)
@@ -39,6 +148,50 @@ func (h *OSHandler) GetOperatingSystems(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// osExportColumns are the columns of operating system exports
+var osExportColumns = []string{
+	"id", "name", "version", "end_of_support", "support_status", "lifecycle_phase", "lts",
+	"release_date", "end_of_standard_support", "end_of_extended_support", "created_at", "updated_at",
+}
+
+// exportOperatingSystems streams the operating systems matching filter in an
+// export format, with their support status under the policy at now
+func (h *OSHandler) exportOperatingSystems(w http.ResponseWriter, r *http.Request, filter *models.OSFilter, format string, now time.Time) {
+	limit, after, err := parseExportCursorPage(r, func(value string) (*models.Cursor, error) {
+		return models.DecodeOSCursor(value, filter.Sort)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting operating systems: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	oss := &batchIterator[models.OS]{
+		fetch: func(limit, _ int) ([]models.OS, error) {
+			page := *filter
+			page.Limit, page.After = limit, after
+			batch, err := h.repo.GetAll(&page)
+			if err == nil && len(batch) > 0 {
+				cursor := models.OSCursor(batch[len(batch)-1], filter.Sort)
+				after = &cursor
+			}
+			return batch, err
+		},
+	}
+	streamExport(w, format, "operating-systems", osExportColumns, oss.next, limit, func(os models.OS) []interface{} {
+		status, _ := h.policy.Classify(os.EndOfSupport, now)
+		return []interface{}{os.ID, os.Name, os.Version, os.EndOfSupport.Format("2006-01-02"), status, os.Phase(now), os.LTS,
+			exportDate(os.ReleaseDate), exportDate(os.EndOfStandardSupport), exportDate(os.EndOfExtendedSupport), os.CreatedAt, os.UpdatedAt}
+	})
+}
+
 // GetOperatingSystem handles GET /os/{id} - retrieves an operating system by ID
 func (h *OSHandler) GetOperatingSystem(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -57,7 +210,11 @@ func (h *OSHandler) GetOperatingSystem(w http.ResponseWriter, r *http.Request) {
 	os, err := h.repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error getting operating system by ID %d: %v", id, err)
-		http.Error(w, "Operating system not found", http.StatusNotFound)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Operating system not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -83,12 +240,15 @@ func (h *OSHandler) CreateOperatingSystem(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	os, err := h.repo.Create(&req)
+	os, err := h.repo.Create(changeContext(r), &req)
 	if err != nil {
 		log.Printf("Error creating operating system: %v", err)
-		if err.Error() == "invalid end of support date format" {
-			http.Error(w, "Invalid end of support date format. Use YYYY-MM-DD", http.StatusBadRequest)
-		} else {
+		switch {
+		case errors.Is(err, database.ErrInvalidInput):
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "An operating system with this name and version already exists", http.StatusConflict)
+		default:
 			http.Error(w, "Failed to create operating system", http.StatusInternalServerError)
 		}
 		return
@@ -123,12 +283,17 @@ func (h *OSHandler) UpdateOperatingSystem(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	os, err := h.repo.Update(id, &req)
+	os, err := h.repo.Update(changeContext(r), id, &req)
 	if err != nil {
 		log.Printf("Error updating operating system with ID %d: %v", id, err)
-		if err.Error() == "invalid end of support date format" {
-			http.Error(w, "Invalid end of support date format. Use YYYY-MM-DD", http.StatusBadRequest)
-		} else {
+		switch {
+		case errors.Is(err, database.ErrInvalidInput):
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Operating system not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "An operating system with this name and version already exists", http.StatusConflict)
+		default:
 			http.Error(w, "Failed to update operating system", http.StatusInternalServerError)
 		}
 		return
@@ -157,11 +322,14 @@ func (h *OSHandler) DeleteOperatingSystem(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.repo.Delete(changeContext(r), id); err != nil {
 		log.Printf("Error deleting operating system with ID %d: %v", id, err)
-		if err.Error() == "cannot delete operating system: servers are using it" {
-			http.Error(w, "Cannot delete operating system: servers are using it", http.StatusConflict)
-		} else {
+		switch {
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "Cannot delete operating system: servers run it, or scheduled changes or campaigns reference it", http.StatusConflict)
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Operating system not found", http.StatusNotFound)
+		default:
 			http.Error(w, "Failed to delete operating system", http.StatusInternalServerError)
 		}
 		return
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestOSHandler_CRUD(t *testing.T) {
+	api := newTestAPI(t)
+
+	rec := api.do(t, http.MethodPost, "/api/v1/os", models.CreateOSRequest{Name: "Ubuntu", Version: "24.04", EndOfSupport: "2029-04-01"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var created models.OS
+	decode(t, rec, &created)
+
+	path := fmt.Sprintf("/api/v1/os/%d", created.ID)
+	rec = api.do(t, http.MethodPut, path, models.UpdateOSRequest{EndOfSupport: "2034-04-01"})
+	expectStatus(t, rec, http.StatusOK)
+
+	var updated models.OS
+	decode(t, rec, &updated)
+	if updated.EndOfSupport.Year() != 2034 {
+		t.Errorf("Expected end of support in 2034, got %v", updated.EndOfSupport)
+	}
+
+	// Lifecycle dates are optional and kept when an update omits them
+	rec = api.do(t, http.MethodPut, path, models.UpdateOSRequest{ReleaseDate: "2024-04-25", EndOfExtendedSupport: "2036-04-25"})
+	expectStatus(t, rec, http.StatusOK)
+	rec = api.do(t, http.MethodPut, path, models.UpdateOSRequest{EndOfSupport: "2029-05-31"})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &updated)
+	if updated.ReleaseDate == nil || updated.EndOfExtendedSupport == nil || updated.EndOfExtendedSupport.Year() != 2036 {
+		t.Errorf("Expected release and extended support dates, got %+v", updated)
+	}
+
+	rec = api.do(t, http.MethodDelete, path, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = api.do(t, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestOSHandler_Errors(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	expectStatus(t, rec, http.StatusCreated)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"missing fields", http.MethodPost, "/api/v1/os", models.CreateOSRequest{Name: "Ubuntu"}, http.StatusBadRequest},
+		{"invalid date", http.MethodPost, "/api/v1/os", models.CreateOSRequest{Name: "Ubuntu", Version: "24.04", EndOfSupport: "April 2029"}, http.StatusBadRequest},
+		{"invalid release date", http.MethodPost, "/api/v1/os", models.CreateOSRequest{Name: "Ubuntu", Version: "24.04", EndOfSupport: "2029-04-01", ReleaseDate: "2024"}, http.StatusBadRequest},
+		{"duplicate", http.MethodPost, "/api/v1/os", models.CreateOSRequest{Name: "Ubuntu", Version: "22.04", EndOfSupport: "2027-04-01"}, http.StatusConflict},
+		{"update unknown", http.MethodPut, "/api/v1/os/999", models.UpdateOSRequest{Version: "1"}, http.StatusNotFound},
+		{"delete in use", http.MethodDelete, fmt.Sprintf("/api/v1/os/%d", ubuntu.ID), nil, http.StatusConflict},
+		{"delete unknown", http.MethodDelete, "/api/v1/os/999", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(t, tt.method, tt.path, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestOSHandler_ListFiltersAndPagination(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	api.createOS(t, "Debian", "11", now.AddDate(0, 3, 0).Format("2006-01-02"))
+	api.createOS(t, "Debian", "12", now.AddDate(2, 0, 0).Format("2006-01-02"))
+	api.createOS(t, "Ubuntu", "22.04", now.AddDate(1, 0, 0).Format("2006-01-02"))
+	api.createOS(t, "CentOS", "7", now.AddDate(-2, 0, 0).Format("2006-01-02"))
+
+	rec := api.do(t, http.MethodGet, "/api/v1/os?family=Debian&sort=-version", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var debian []models.OS
+	decode(t, rec, &debian)
+	if len(debian) != 2 || debian[0].Version != "12" {
+		t.Errorf("Expected Debian 12 first, got %+v", debian)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/os?name=ubuntu%2022", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var ubuntu []models.OS
+	decode(t, rec, &ubuntu)
+	if len(ubuntu) != 1 {
+		t.Errorf("Expected 1 match for 'ubuntu 22', got %d", len(ubuntu))
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/os?status=eol", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var eol []models.OS
+	decode(t, rec, &eol)
+	if len(eol) != 1 || eol[0].Name != "CentOS" {
+		t.Errorf("Expected only CentOS 7 to be end-of-life, got %+v", eol)
+	}
+
+	// Without standard or extended support dates, phases follow the end of support
+	rec = api.do(t, http.MethodGet, "/api/v1/os?phase=current&family=Debian", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var current []models.OS
+	decode(t, rec, &current)
+	if len(current) != 2 {
+		t.Errorf("Expected both Debian releases to be current, got %+v", current)
+	}
+	if !strings.Contains(rec.Body.String(), `"lifecycle_phase":"current"`) {
+		t.Errorf("Expected the lifecycle phase in %s", rec.Body.String())
+	}
+	expectStatus(t, api.do(t, http.MethodGet, "/api/v1/os?phase=supported", nil), http.StatusBadRequest)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/os?limit=3", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if total := rec.Header().Get("X-Total-Count"); total != "4" {
+		t.Errorf("Expected X-Total-Count 4, got %q", total)
+	}
+	if link := rec.Header().Get("Link"); !strings.Contains(link, "cursor=") || !strings.Contains(link, `rel="next"`) {
+		t.Errorf("Expected a next link with a cursor, got %q", link)
+	}
+
+	rec = api.do(t, http.MethodGet, nextLink(rec), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var last []models.OS
+	decode(t, rec, &last)
+	if len(last) != 1 || rec.Header().Get("Link") != "" {
+		t.Errorf("Expected the last OS without a next link, got %+v", last)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/os?sort=unknown", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+const (
+	// defaultPageSize is the number of items returned when no limit is given
+	defaultPageSize = 100
+	// maxPageSize is the largest accepted limit
+	maxPageSize = 1000
+)
+
+// parseLimit parses the limit query parameter
+func parseLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return defaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, fmt.Errorf("Invalid limit parameter. Must be between 1 and %d", maxPageSize)
+	}
+	return limit, nil
+}
+
+// parsePage parses the limit and offset query parameters of the lists
+// paginated by offset. Records created or deleted between requests shift
+// the following pages.
+func parsePage(r *http.Request) (limit, offset int, err error) {
+	if limit, err = parseLimit(r); err != nil {
+		return 0, 0, err
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("Invalid offset parameter")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// parseCursorPage parses the limit and cursor query parameters of the lists
+// paginated by keyset, decoding the cursor with decode. A nil cursor selects
+// the first page.
+func parseCursorPage(r *http.Request, decode func(string) (*models.Cursor, error)) (limit int, after *models.Cursor, err error) {
+	if limit, err = parseLimit(r); err != nil {
+		return 0, nil, err
+	}
+
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		after, err = decode(value)
+		if err != nil {
+			return 0, nil, fmt.Errorf("Invalid cursor parameter: %v", err)
+		}
+	}
+
+	return limit, after, nil
+}
+
+// writePageHeaders sets the X-Total-Count header and, when more items are
+// available, a Link header pointing at the next page
+func writePageHeaders(w http.ResponseWriter, r *http.Request, total, limit, offset int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	if offset+limit >= total {
+		return
+	}
+	writeNextLink(w, r, map[string]string{"offset": strconv.Itoa(offset + limit), "limit": strconv.Itoa(limit)})
+}
+
+// writeCursorPageHeaders sets the X-Total-Count header and, when next is
+// set, a Link header pointing at the page following the cursor
+func writeCursorPageHeaders(w http.ResponseWriter, r *http.Request, total, limit int, next *models.Cursor) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	if next == nil {
+		return
+	}
+	writeNextLink(w, r, map[string]string{"cursor": next.Encode(), "limit": strconv.Itoa(limit)})
+}
+
+// writeNextLink sets a Link header pointing at the request URL with the
+// given query parameters replaced
+func writeNextLink(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	next := *r.URL
+	query := next.Query()
+	for name, value := range params {
+		query.Set(name, value)
+	}
+	next.RawQuery = query.Encode()
+
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+}
+
+// parseTimeParam parses a YYYY-MM-DD or RFC 3339 query parameter. Dates
+// without a time are extended to the end of the day when endOfDay is set so
+// that upper bounds are inclusive.
+func parseTimeParam(r *http.Request, name string, endOfDay bool) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s format. Use YYYY-MM-DD or RFC 3339", name)
+	}
+	if endOfDay {
+		t = t.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ScheduledChangeHandler handles scheduled OS change HTTP requests
+type ScheduledChangeHandler struct {
+	repo       database.ScheduledChangeStore
+	serverRepo database.ServerStore
+}
+
+// NewScheduledChangeHandler creates a new scheduled change handler
+func NewScheduledChangeHandler(repo database.ScheduledChangeStore, serverRepo database.ServerStore) *ScheduledChangeHandler {
+	return &ScheduledChangeHandler{repo: repo, serverRepo: serverRepo}
+}
+
+// validateMaintenanceWindow checks that a maintenance window is made of RFC
+// 3339 times and ends in the future, after it starts
+func validateMaintenanceWindow(start, end string) error {
+	windowStart, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return fmt.Errorf("Invalid window start format. Use RFC 3339, e.g. 2025-01-15T22:00:00Z")
+	}
+	windowEnd, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return fmt.Errorf("Invalid window end format. Use RFC 3339, e.g. 2025-01-16T02:00:00Z")
+	}
+	if !windowEnd.After(windowStart) {
+		return fmt.Errorf("Window end must be after window start")
+	}
+	if !windowEnd.After(time.Now()) {
+		return fmt.Errorf("Window end must be in the future")
+	}
+	return nil
+}
+
+// parseScheduledChangeID returns the scheduled change ID from the route variables
+func parseScheduledChangeID(r *http.Request) (int, error) {
+	idStr, exists := mux.Vars(r)["id"]
+	if !exists {
+		return 0, fmt.Errorf("Scheduled change ID is required")
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid scheduled change ID")
+	}
+
+	return id, nil
+}
+
+// parseScheduledChangeFilter builds a scheduled change filter from query parameters
+func parseScheduledChangeFilter(r *http.Request) (*models.ScheduledChangeFilter, error) {
+	query := r.URL.Query()
+	filter := &models.ScheduledChangeFilter{}
+
+	if value := query.Get("server_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid server_id parameter")
+		}
+		filter.ServerID = &id
+	}
+
+	if status := query.Get("status"); status != "" {
+		if err := models.ValidateScheduledChangeStatus(status); err != nil {
+			return nil, fmt.Errorf("Invalid status. Must be: pending, applying, applied, failed, expired, or cancelled")
+		}
+		filter.Status = &status
+	}
+
+	return filter, nil
+}
+
+// GetScheduledChanges handles GET /scheduled-changes - retrieves scheduled
+// changes with optional filters and offset-based pagination, earliest window
+// first
+func (h *ScheduledChangeHandler) GetScheduledChanges(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseScheduledChangeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting scheduled changes: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	changes, err := h.repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting scheduled changes: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writePageHeaders(w, r, total, filter.Limit, filter.Offset)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(changes); err != nil {
+		log.Printf("Error encoding scheduled changes response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetScheduledChange handles GET /scheduled-changes/{id} - retrieves a
+// scheduled change by ID
+func (h *ScheduledChangeHandler) GetScheduledChange(w http.ResponseWriter, r *http.Request) {
+	id, err := parseScheduledChangeID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	change, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error getting scheduled change by ID %d: %v", id, err)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Scheduled change not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("Error encoding scheduled change response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateScheduledChange handles POST /scheduled-changes - schedules an OS
+// change of a server for a maintenance window
+func (h *ScheduledChangeHandler) CreateScheduledChange(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateScheduledChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Basic validation
+	if req.ServerID == 0 || req.OSID == 0 || req.WindowStart == "" || req.WindowEnd == "" {
+		http.Error(w, "Server ID, OS ID, window start and window end are required", http.StatusBadRequest)
+		return
+	}
+	if err := validateMaintenanceWindow(req.WindowStart, req.WindowEnd); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server, err := h.serverRepo.GetByID(req.ServerID)
+	if err != nil {
+		log.Printf("Error getting server %d to schedule a change: %v", req.ServerID, err)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Scheduled server or operating system does not exist", http.StatusBadRequest)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if server.OSID == req.OSID {
+		http.Error(w, "Server already runs this operating system", http.StatusBadRequest)
+		return
+	}
+
+	change, err := h.repo.Create(changeContext(r), &req)
+	if err != nil {
+		log.Printf("Error creating scheduled change: %v", err)
+		switch {
+		case errors.Is(err, database.ErrInvalidReference):
+			http.Error(w, "Scheduled server or operating system does not exist", http.StatusBadRequest)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "Server already has a pending or applying scheduled change", http.StatusConflict)
+		case errors.Is(err, database.ErrInvalidInput):
+			http.Error(w, "Invalid scheduled change", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to create scheduled change", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("Error encoding created scheduled change response: %v", err)
+		return
+	}
+}
+
+// CancelScheduledChange handles DELETE /scheduled-changes/{id} - cancels a
+// pending change. Cancelled changes are kept for auditing.
+func (h *ScheduledChangeHandler) CancelScheduledChange(w http.ResponseWriter, r *http.Request) {
+	id, err := parseScheduledChangeID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reason string
+	if actor := models.ChangeMetadataFromContext(changeContext(r)).Actor; actor != "" {
+		reason = "cancelled by " + actor
+	}
+
+	if _, err := h.repo.Resolve(id, models.ScheduledChangeCancelled, reason); err != nil {
+		log.Printf("Error cancelling scheduled change with ID %d: %v", id, err)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Scheduled change not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "Scheduled change is no longer pending", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to cancel scheduled change", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestScheduledChangeHandler_CRUD(t *testing.T) {
+	api := newTestAPI(t)
+	focal := api.createOS(t, "Ubuntu", "20.04", "2025-04-30")
+	noble := api.createOS(t, "Ubuntu", "24.04", "2029-04-30")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: focal.ID, Environment: "prod"})
+	expectStatus(t, rec, http.StatusCreated)
+	var server models.Server
+	decode(t, rec, &server)
+
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	req := models.CreateScheduledChangeRequest{
+		ServerID:    server.ID,
+		OSID:        noble.ID,
+		WindowStart: start.Format(time.RFC3339),
+		WindowEnd:   start.Add(4 * time.Hour).Format(time.RFC3339),
+	}
+	rec = api.doWithHeaders(t, http.MethodPost, "/api/v1/scheduled-changes", req, map[string]string{"X-Actor": "alice"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var change models.ScheduledChange
+	decode(t, rec, &change)
+	if change.Status != models.ScheduledChangePending || change.RequestedBy != "alice" || !change.WindowStart.Equal(start) {
+		t.Fatalf("Unexpected scheduled change: %+v", change)
+	}
+
+	// A server has at most one pending change
+	rec = api.do(t, http.MethodPost, "/api/v1/scheduled-changes", req)
+	expectStatus(t, rec, http.StatusConflict)
+
+	// The end-of-life server is reported with its remediation scheduled
+	rec = api.do(t, http.MethodGet, "/api/v1/servers/compliance", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var report models.ComplianceReport
+	decode(t, rec, &report)
+	if report.RemediationScheduledServers != 1 || len(report.RemediationScheduled) != 1 ||
+		report.RemediationScheduled[0].Change.ID != change.ID || report.EndOfLifeServers != 1 {
+		t.Errorf("Unexpected compliance report: %+v", report)
+	}
+
+	path := fmt.Sprintf("/api/v1/scheduled-changes/%d", change.ID)
+	rec = api.do(t, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/scheduled-changes?server_id=%d&status=pending", server.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var changes []models.ScheduledChange
+	decode(t, rec, &changes)
+	if len(changes) != 1 || changes[0].ID != change.ID || rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Unexpected scheduled changes: %+v", changes)
+	}
+
+	rec = api.doWithHeaders(t, http.MethodDelete, path, nil, map[string]string{"X-Actor": "bob"})
+	expectStatus(t, rec, http.StatusNoContent)
+
+	// Cancelled changes are kept and can no longer be cancelled
+	rec = api.do(t, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &change)
+	if change.Status != models.ScheduledChangeCancelled || change.Reason != "cancelled by bob" || change.ResolvedAt == nil {
+		t.Errorf("Unexpected cancelled change: %+v", change)
+	}
+	rec = api.do(t, http.MethodDelete, path, nil)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers/compliance", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &report)
+	if report.RemediationScheduledServers != 0 {
+		t.Errorf("Expected no remediation scheduled after cancelling, got %+v", report.RemediationScheduled)
+	}
+}
+
+func TestScheduledChangeHandler_Errors(t *testing.T) {
+	api := newTestAPI(t)
+	focal := api.createOS(t, "Ubuntu", "20.04", "2025-04-30")
+	noble := api.createOS(t, "Ubuntu", "24.04", "2029-04-30")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: focal.ID})
+	expectStatus(t, rec, http.StatusCreated)
+	var server models.Server
+	decode(t, rec, &server)
+
+	start := time.Now().Add(time.Hour).UTC()
+	window := func(serverID, osID int, start, end time.Time) models.CreateScheduledChangeRequest {
+		return models.CreateScheduledChangeRequest{ServerID: serverID, OSID: osID, WindowStart: start.Format(time.RFC3339), WindowEnd: end.Format(time.RFC3339)}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"missing fields", http.MethodPost, "/api/v1/scheduled-changes", models.CreateScheduledChangeRequest{ServerID: server.ID}, http.StatusBadRequest},
+		{"invalid window", http.MethodPost, "/api/v1/scheduled-changes", models.CreateScheduledChangeRequest{ServerID: server.ID, OSID: noble.ID, WindowStart: "2026-01-10", WindowEnd: "2026-01-11"}, http.StatusBadRequest},
+		{"window ends before it starts", http.MethodPost, "/api/v1/scheduled-changes", window(server.ID, noble.ID, start, start.Add(-time.Minute)), http.StatusBadRequest},
+		{"window in the past", http.MethodPost, "/api/v1/scheduled-changes", window(server.ID, noble.ID, start.AddDate(0, 0, -2), start.AddDate(0, 0, -1)), http.StatusBadRequest},
+		{"unknown server", http.MethodPost, "/api/v1/scheduled-changes", window(999, noble.ID, start, start.Add(time.Hour)), http.StatusBadRequest},
+		{"unknown OS", http.MethodPost, "/api/v1/scheduled-changes", window(server.ID, 999, start, start.Add(time.Hour)), http.StatusBadRequest},
+		{"same OS", http.MethodPost, "/api/v1/scheduled-changes", window(server.ID, focal.ID, start, start.Add(time.Hour)), http.StatusBadRequest},
+		{"invalid status filter", http.MethodGet, "/api/v1/scheduled-changes?status=done", nil, http.StatusBadRequest},
+		{"invalid server filter", http.MethodGet, "/api/v1/scheduled-changes?server_id=web", nil, http.StatusBadRequest},
+		{"missing change", http.MethodGet, "/api/v1/scheduled-changes/999", nil, http.StatusNotFound},
+		{"cancel missing change", http.MethodDelete, "/api/v1/scheduled-changes/999", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(t, tt.method, tt.path, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestScheduledChangeHandler_DeletedServer(t *testing.T) {
+	api := newTestAPI(t)
+	focal := api.createOS(t, "Ubuntu", "20.04", "2025-04-30")
+	noble := api.createOS(t, "Ubuntu", "24.04", "2029-04-30")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: focal.ID})
+	expectStatus(t, rec, http.StatusCreated)
+	var server models.Server
+	decode(t, rec, &server)
+
+	start := time.Now().Add(24 * time.Hour).UTC()
+	rec = api.do(t, http.MethodPost, "/api/v1/scheduled-changes", models.CreateScheduledChangeRequest{
+		ServerID: server.ID, OSID: noble.ID, WindowStart: start.Format(time.RFC3339), WindowEnd: start.Add(time.Hour).Format(time.RFC3339),
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var change models.ScheduledChange
+	decode(t, rec, &change)
+
+	// The targeted operating system cannot be deleted
+	rec = api.do(t, http.MethodDelete, fmt.Sprintf("/api/v1/os/%d", noble.ID), nil)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = api.do(t, http.MethodDelete, fmt.Sprintf("/api/v1/servers/%d", server.ID), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	// The change is kept, cancelled, with the name of the deleted server
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/scheduled-changes/%d", change.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &change)
+	if change.Status != models.ScheduledChangeCancelled || change.ServerID != nil || change.ServerName != "web-01" || change.Reason == "" {
+		t.Errorf("Unexpected change of the deleted server: %+v", change)
+	}
+}
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/export"
 	"infra-dashboard/internal/models"
 
 	"github.com/gorilla/mux"
@@ -14,24 +20,169 @@ import (
 
 // ServerHandler handles server-related HTTP requests
 type ServerHandler struct {
-	repo   *database.ServerRepository
-	osRepo *database.OSRepository
+	repo       database.ServerStore
+	osRepo     database.OSStore
+	waiverRepo database.WaiverStore
+	changeRepo database.ScheduledChangeStore
+	policies   models.CompliancePolicySet
+	upgrades   models.UpgradeGraph
 }
 
-// NewServerHandler creates a new server handler
-func NewServerHandler(repo *database.ServerRepository, osRepo *database.OSRepository) *ServerHandler {
-	return &ServerHandler{repo: repo, osRepo: osRepo}
+// NewServerHandler creates a new server handler. The default policy of the
+// set classifies support status; named policies can be selected per request
+// on the compliance report, which leaves out servers with active waivers,
+// lists servers with a pending scheduled change as remediation scheduled and
+// recommends upgrade paths under the upgrade graph.
+func NewServerHandler(repo database.ServerStore, osRepo database.OSStore, waiverRepo database.WaiverStore, changeRepo database.ScheduledChangeStore, policies models.CompliancePolicySet, upgrades models.UpgradeGraph) *ServerHandler {
+	return &ServerHandler{repo: repo, osRepo: osRepo, waiverRepo: waiverRepo, changeRepo: changeRepo, policies: policies, upgrades: upgrades}
 }
 
-// GetServers handles GET /servers - retrieves all servers
+// parseServerFilter builds a server filter from the query parameters shared
+// by the server list endpoints
+func parseServerFilter(r *http.Request) (*models.ServerFilter, error) {
+	query := r.URL.Query()
+	filter := &models.ServerFilter{}
+
+	// Parse string filters
+	for _, f := range []struct {
+		param string
+		dest  **string
+	}{
+		{"name", &filter.Name},
+		{"family", &filter.Family},
+		{"status", &filter.SupportStatus},
+		{"environment", &filter.Environment},
+		{"role", &filter.Role},
+		{"owner_team", &filter.OwnerTeam},
+		{"location", &filter.Location},
+	} {
+		if value := query.Get(f.param); value != "" {
+			*f.dest = &value
+		}
+	}
+
+	if filter.Environment != nil {
+		if err := models.NewServerUtils().ValidateEnvironment(*filter.Environment); err != nil {
+			return nil, fmt.Errorf("Invalid environment. Must be: prod, staging, or dev")
+		}
+	}
+
+	if filter.SupportStatus != nil {
+		if err := models.ValidateSupportStatus(*filter.SupportStatus); err != nil {
+			return nil, fmt.Errorf("Invalid status. Must be: supported, ending_soon, or eol")
+		}
+	}
+
+	if staleStr := query.Get("stale"); staleStr != "" {
+		stale, err := strconv.ParseBool(staleStr)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid stale parameter")
+		}
+		filter.Stale = &stale
+	}
+
+	if osIDStr := query.Get("os_id"); osIDStr != "" {
+		osID, err := strconv.Atoi(osIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid os_id parameter")
+		}
+		filter.OSID = &osID
+	}
+
+	// Parse date ranges
+	var err error
+	if filter.CreatedAfter, err = parseTimeParam(r, "created_after", false); err != nil {
+		return nil, err
+	}
+	if filter.CreatedBefore, err = parseTimeParam(r, "created_before", true); err != nil {
+		return nil, err
+	}
+	if filter.UpdatedAfter, err = parseTimeParam(r, "updated_after", false); err != nil {
+		return nil, err
+	}
+	if filter.UpdatedBefore, err = parseTimeParam(r, "updated_before", true); err != nil {
+		return nil, err
+	}
+	if filter.AsOf, err = parseTimeParam(r, "as_of", true); err != nil {
+		return nil, err
+	}
+
+	filter.Sort = models.DefaultServerSort
+	if sort := query.Get("sort"); sort != "" {
+		filter.Sort, err = models.ParseSort(sort, models.ServerSortFields)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid sort parameter: %v", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// GetServers handles GET /servers - retrieves servers with optional filters,
+// sorting and cursor-based pagination
 func (h *ServerHandler) GetServers(w http.ResponseWriter, r *http.Request) {
-	servers, err := h.repo.GetAll()
+	filter, err := parseServerFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if filter.AsOf != nil {
+		now = *filter.AsOf
+	}
+	cutoff := h.policies.Default.EndingSoonCutoff(now)
+	filter.EndingSoonCutoff = &cutoff
+	if filter.Stale != nil {
+		staleCutoff, enabled := h.policies.Default.StaleCutoff(now)
+		if !enabled {
+			http.Error(w, "Stale detection is disabled by the compliance policy", http.StatusBadRequest)
+			return
+		}
+		filter.StaleCutoff = &staleCutoff
+	}
+
+	format, err := export.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format != export.FormatJSON {
+		h.exportServers(w, r, filter, format, now)
+		return
+	}
+
+	limit, after, err := parseCursorPage(r, func(value string) (*models.Cursor, error) {
+		return models.DecodeServerCursor(value, filter.Sort)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting servers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// One more server than the page holds tells whether another page follows
+	filter.Limit, filter.After = limit+1, after
+	servers, err := h.repo.GetAll(filter)
 	if err != nil {
 		log.Printf("Error getting servers: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	var next *models.Cursor
+	if len(servers) > limit {
+		servers = servers[:limit]
+		cursor := models.ServerCursor(servers[limit-1], filter.Sort)
+		next = &cursor
+	}
+
+	writeCursorPageHeaders(w, r, total, limit, next)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(servers); err != nil {
 		log.Printf("Error encoding servers response: %v", err)
@@ -40,6 +191,59 @@ func (h *ServerHandler) GetServers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serverExportColumns are the columns of server exports
+var serverExportColumns = []string{
+	"id", "name", "os_name", "os_version", "end_of_support", "support_status",
+	"environment", "role", "owner_team", "location", "description", "extended_support", "last_seen_at", "created_at", "updated_at",
+}
+
+// exportServers streams the servers matching filter in an export format.
+// Support status is classified with the default policy at now, against the
+// end of extended support for servers enrolled in it.
+func (h *ServerHandler) exportServers(w http.ResponseWriter, r *http.Request, filter *models.ServerFilter, format string, now time.Time) {
+	limit, after, err := parseExportCursorPage(r, func(value string) (*models.Cursor, error) {
+		return models.DecodeServerCursor(value, filter.Sort)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting servers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	servers := &batchIterator[models.Server]{
+		fetch: func(limit, _ int) ([]models.Server, error) {
+			page := *filter
+			page.Limit, page.After = limit, after
+			batch, err := h.repo.GetAll(&page)
+			if err == nil && len(batch) > 0 {
+				cursor := models.ServerCursor(batch[len(batch)-1], filter.Sort)
+				after = &cursor
+			}
+			return batch, err
+		},
+	}
+	streamExport(w, format, "servers", serverExportColumns, servers.next, limit, func(server models.Server) []interface{} {
+		var osName, osVersion, endOfSupport, status string
+		if server.OS != nil {
+			osName, osVersion = server.OS.Name, server.OS.Version
+			endOfSupport = server.EndOfSupport().Format("2006-01-02")
+			status, _ = h.policies.Default.Classify(server.EndOfSupport(), now)
+		}
+		return []interface{}{
+			server.ID, server.Name, osName, osVersion, endOfSupport, status,
+			server.Environment, server.Role, server.OwnerTeam, server.Location, server.Description,
+			server.ExtendedSupport, server.LastSeenAt, server.CreatedAt, server.UpdatedAt,
+		}
+	})
+}
+
 // GetServer handles GET /servers/{id} - retrieves a server by ID
 func (h *ServerHandler) GetServer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -58,7 +262,11 @@ func (h *ServerHandler) GetServer(w http.ResponseWriter, r *http.Request) {
 	server, err := h.repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error getting server by ID %d: %v", id, err)
-		http.Error(w, "Server not found", http.StatusNotFound)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Server not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -84,10 +292,26 @@ func (h *ServerHandler) CreateServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	server, err := h.repo.Create(&req)
+	if err := models.NewServerUtils().ValidateEnvironment(req.Environment); err != nil {
+		http.Error(w, "Invalid environment. Must be: prod, staging, or dev", http.StatusBadRequest)
+		return
+	}
+	if err := models.NewServerUtils().ValidateAttributes(req.Role, req.OwnerTeam, req.Location); err != nil {
+		http.Error(w, "Invalid "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server, err := h.repo.Create(changeContext(r), &req)
 	if err != nil {
 		log.Printf("Error creating server: %v", err)
-		http.Error(w, "Failed to create server", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, database.ErrInvalidReference):
+			http.Error(w, "Operating system does not exist", http.StatusBadRequest)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "A server with this name already exists", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to create server", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -120,10 +344,28 @@ func (h *ServerHandler) UpdateServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	server, err := h.repo.Update(id, &req)
+	if err := models.NewServerUtils().ValidateEnvironment(req.Environment); err != nil {
+		http.Error(w, "Invalid environment. Must be: prod, staging, or dev", http.StatusBadRequest)
+		return
+	}
+	if err := models.NewServerUtils().ValidateAttributes(req.Role, req.OwnerTeam, req.Location); err != nil {
+		http.Error(w, "Invalid "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server, err := h.repo.Update(changeContext(r), id, &req)
 	if err != nil {
 		log.Printf("Error updating server with ID %d: %v", id, err)
-		http.Error(w, "Failed to update server", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Server not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrInvalidReference):
+			http.Error(w, "Operating system does not exist", http.StatusBadRequest)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "A server with this name already exists", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to update server", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -150,75 +392,310 @@ func (h *ServerHandler) DeleteServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.repo.Delete(changeContext(r), id); err != nil {
 		log.Printf("Error deleting server with ID %d: %v", id, err)
-		http.Error(w, "Failed to delete server", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "Cannot delete server: upgrade campaigns list it", http.StatusConflict)
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Server not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to delete server", http.StatusInternalServerError)
+		}
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetComplianceReport handles GET /servers/compliance - generates compliance report
-func (h *ServerHandler) GetComplianceReport(w http.ResponseWriter, r *http.Request) {
-	// Get all servers with OS information
-	servers, err := h.repo.GetAll()
+// parseCompliancePolicy selects the compliance policy for a request. The
+// policy parameter picks a named policy, while tiers, eol_penalty,
+// stale_after_days and exclude_stale override it, including its environment
+// policies, for this request only.
+func (h *ServerHandler) parseCompliancePolicy(r *http.Request) (models.CompliancePolicy, error) {
+	query := r.URL.Query()
+
+	policy, exists := h.policies.Lookup(query.Get("policy"))
+	if !exists {
+		return policy, fmt.Errorf("Unknown compliance policy: %s", query.Get("policy"))
+	}
+	policy = policy.Clone()
+
+	var overrides []func(p *models.CompliancePolicy)
+
+	if tiersStr := query.Get("tiers"); tiersStr != "" {
+		tiers, err := models.ParsePolicyTiers(tiersStr)
+		if err != nil {
+			return policy, fmt.Errorf("Invalid tiers parameter: %v", err)
+		}
+		overrides = append(overrides, func(p *models.CompliancePolicy) {
+			p.Tiers = append([]models.PolicyTier(nil), tiers...)
+		})
+	}
+
+	if penaltyStr := query.Get("eol_penalty"); penaltyStr != "" {
+		penalty, err := strconv.ParseFloat(penaltyStr, 64)
+		if err != nil {
+			return policy, fmt.Errorf("Invalid eol_penalty parameter")
+		}
+		overrides = append(overrides, func(p *models.CompliancePolicy) {
+			p.EndOfLifePenalty = penalty
+		})
+	}
+
+	if daysStr := query.Get("stale_after_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil {
+			return policy, fmt.Errorf("Invalid stale_after_days parameter")
+		}
+		overrides = append(overrides, func(p *models.CompliancePolicy) {
+			p.StaleAfterDays = days
+		})
+	}
+
+	if excludeStr := query.Get("exclude_stale"); excludeStr != "" {
+		exclude, err := strconv.ParseBool(excludeStr)
+		if err != nil {
+			return policy, fmt.Errorf("Invalid exclude_stale parameter")
+		}
+		overrides = append(overrides, func(p *models.CompliancePolicy) {
+			p.ExcludeStale = exclude
+		})
+	}
+
+	for _, override := range overrides {
+		override(&policy)
+		for env, envPolicy := range policy.Environments {
+			override(&envPolicy)
+			policy.Environments[env] = envPolicy
+		}
+	}
+
+	if err := policy.Validate(); err != nil {
+		return policy, fmt.Errorf("Invalid compliance policy: %v", err)
+	}
+
+	return policy, nil
+}
+
+// loadCompliance selects the compliance policy and as_of date of a request
+// and loads the servers it reports on. It writes an error response and
+// returns false when the request cannot be served.
+func (h *ServerHandler) loadCompliance(w http.ResponseWriter, r *http.Request) (*models.ComplianceUtils, []models.Server, bool) {
+	policy, err := h.parseCompliancePolicy(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	asOf, err := parseTimeParam(r, "as_of", true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	// Get all servers with OS information, rebuilt from the change history
+	// when reporting on a past date
+	servers, err := h.repo.GetAll(&models.ServerFilter{AsOf: asOf})
 	if err != nil {
 		log.Printf("Error getting servers for compliance report: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	// Active waivers exclude their servers from penalties and recommendations.
+	// Past reports consider every waiver in effect on that date.
+	var waivers []models.Waiver
+	if asOf != nil {
+		waivers, err = h.waiverRepo.GetAll(nil)
+	} else {
+		waivers, err = activeWaivers(h.waiverRepo)
+	}
+	if err != nil {
+		log.Printf("Error getting waivers for compliance report: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	complianceUtils := models.NewComplianceUtilsWithPolicy(policy).WithWaivers(waivers).WithUpgradeGraph(h.upgrades)
+	if asOf != nil {
+		return complianceUtils.AsOf(*asOf), servers, true
+	}
+
+	// Pending changes report their servers as remediation scheduled. Past
+	// reports do not, since changes keep only their current status.
+	status := models.ScheduledChangePending
+	changes, err := h.changeRepo.GetAll(&models.ScheduledChangeFilter{Status: &status})
+	if err != nil {
+		log.Printf("Error getting scheduled changes for compliance report: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return complianceUtils.WithScheduledChanges(changes), servers, true
+}
+
+// GetComplianceReport handles GET /servers/compliance - generates compliance report
+func (h *ServerHandler) GetComplianceReport(w http.ResponseWriter, r *http.Request) {
+	format, err := export.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Generate compliance report using utility functions
-	complianceUtils := models.NewComplianceUtils()
-	report := complianceUtils.GenerateComplianceReport(servers)
+	complianceUtils, servers, ok := h.loadCompliance(w, r)
+	if !ok {
+		return
+	}
+	if format != export.FormatJSON {
+		exportCompliance(w, complianceUtils, servers, format)
+		return
+	}
 
-	// Get all OS data for recommendations
-	allOS, err := h.osRepo.GetAll()
+	// Get all OS data for upgrade recommendations
+	allOS, err := h.osRepo.GetAll(nil)
 	if err != nil {
 		log.Printf("Error getting OS data for recommendations: %v", err)
-		// Continue without recommendations rather than failing
+		// Continue without upgrade recommendations rather than failing
 		allOS = []models.OS{}
 	}
 
-	// Add recommendations to the report
-	recommendations := complianceUtils.GetRecommendations(servers, allOS)
+	report := complianceUtils.GenerateDetailedReport(servers, allOS)
 
-	// Create extended report with compliance score and recommendations
-	extendedReport := struct {
-		models.ComplianceReport
-		ComplianceScore  float64  `json:"compliance_score"`
-		Recommendations  []string `json:"recommendations"`
-		ScoreDescription string   `json:"score_description"`
-	}{
-		ComplianceReport: report,
-		ComplianceScore:  complianceUtils.GetComplianceScore(servers),
-		Recommendations:  recommendations,
-		ScoreDescription: getScoreDescription(complianceUtils.GetComplianceScore(servers)),
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Link", `</api/v1/compliance/schema>; rel="describedby"`)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding compliance report response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseRecommendationFilter builds a recommendation filter from the
+// comma-separated severity and code query parameters
+func parseRecommendationFilter(r *http.Request) (models.RecommendationFilter, error) {
+	query := r.URL.Query()
+	var filter models.RecommendationFilter
+
+	if severities := query.Get("severity"); severities != "" {
+		for _, severity := range strings.Split(severities, ",") {
+			severity = strings.ToLower(strings.TrimSpace(severity))
+			if err := models.ValidateSeverity(severity); err != nil {
+				return filter, fmt.Errorf("Invalid severity. Must be: critical or warning")
+			}
+			filter.Severities = append(filter.Severities, severity)
+		}
 	}
 
+	if codes := query.Get("code"); codes != "" {
+		for _, code := range strings.Split(codes, ",") {
+			filter.Codes = append(filter.Codes, strings.TrimSpace(code))
+		}
+	}
+
+	return filter, nil
+}
+
+// GetComplianceRecommendations handles GET /compliance/recommendations -
+// lists the recommendations of the compliance report, optionally filtered by
+// severity and code
+func (h *ServerHandler) GetComplianceRecommendations(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRecommendationFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	complianceUtils, servers, ok := h.loadCompliance(w, r)
+	if !ok {
+		return
+	}
+
+	recommendations := models.FilterRecommendations(complianceUtils.GetRecommendations(servers), filter)
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(extendedReport); err != nil {
-		log.Printf("Error encoding compliance report response: %v", err)
+	if err := json.NewEncoder(w).Encode(recommendations); err != nil {
+		log.Printf("Error encoding compliance recommendations response: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
-// getScoreDescription provides human-readable description for compliance scores
-func getScoreDescription(score float64) string {
-	switch {
-	case score >= 90:
-		return "Excellent - Infrastructure is well maintained and compliant"
-	case score >= 75:
-		return "Good - Minor compliance issues that should be addressed"
-	case score >= 50:
-		return "Fair - Several compliance issues requiring attention"
-	case score >= 25:
-		return "Poor - Significant compliance issues need immediate action"
-	default:
-		return "Critical - Infrastructure has serious compliance problems"
+// GetComplianceSchema handles GET /compliance/schema - returns the JSON
+// Schema of the compliance report
+func (h *ServerHandler) GetComplianceSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	if _, err := w.Write(models.ComplianceReportSchema); err != nil {
+		log.Printf("Error writing compliance report schema: %v", err)
+	}
+}
+
+// complianceExportColumns are the columns of compliance report exports
+var complianceExportColumns = []string{
+	"server_id", "name", "environment", "owner_team", "os_name", "os_version", "end_of_support",
+	"status", "tier", "waived_status", "waiver_id", "waiver_approver", "waiver_expires_at",
+	"stale", "extended_support", "last_seen_at", "scheduled_change_id", "scheduled_window_start",
+}
+
+// complianceStatusOrder ranks statuses in compliance exports, most urgent first
+var complianceStatusOrder = map[string]int{
+	models.StatusEndOfLife:  0,
+	models.StatusEndingSoon: 1,
+	models.StatusWaived:     2,
+	models.StatusSupported:  3,
+	models.StatusStale:      4,
+}
+
+// exportCompliance streams the classification of every server in an export
+// format, most urgent first: end of life, ending soon, waived, supported and
+// excluded stale servers, each ordered by end of support date and name
+func exportCompliance(w http.ResponseWriter, complianceUtils *models.ComplianceUtils, servers []models.Server, format string) {
+	type row struct {
+		server         models.Server
+		classification models.ServerCompliance
+	}
+	rows := make([]row, len(servers))
+	for i, server := range servers {
+		rows[i] = row{server, complianceUtils.ClassifyServer(server)}
 	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if rankA, rankB := complianceStatusOrder[a.classification.Status], complianceStatusOrder[b.classification.Status]; rankA != rankB {
+			return rankA < rankB
+		}
+		if a.server.OS != nil && b.server.OS != nil && !a.server.EndOfSupport().Equal(b.server.EndOfSupport()) {
+			return a.server.EndOfSupport().Before(b.server.EndOfSupport())
+		}
+		return a.server.Name < b.server.Name
+	})
+
+	streamExport(w, format, "compliance", complianceExportColumns, sliceIterator(rows), 0, func(r row) []interface{} {
+		var osName, osVersion, endOfSupport, tier string
+		if r.server.OS != nil {
+			osName, osVersion = r.server.OS.Name, r.server.OS.Version
+			endOfSupport = r.server.EndOfSupport().Format("2006-01-02")
+		}
+		if r.classification.Tier != nil {
+			tier = r.classification.Tier.Name
+		}
+		var waiverID *int
+		var approver, expiresAt string
+		if waiver := r.classification.Waiver; waiver != nil {
+			waiverID = &waiver.ID
+			approver = waiver.Approver
+			expiresAt = waiver.ExpiresAt.Format("2006-01-02")
+		}
+		var changeID *int
+		var windowStart *time.Time
+		if change := r.classification.ScheduledChange; change != nil {
+			changeID = &change.ID
+			windowStart = &change.WindowStart
+		}
+		return []interface{}{
+			r.server.ID, r.server.Name, r.server.Environment, r.server.OwnerTeam, osName, osVersion, endOfSupport,
+			r.classification.Status, tier, r.classification.WaivedStatus, waiverID, approver, expiresAt,
+			r.classification.Stale, r.classification.ExtendedSupport, r.server.LastSeenAt, changeID, windowStart,
+		}
+	})
 }
 
 // HealthCheck handles GET /health - simple health check endpoint
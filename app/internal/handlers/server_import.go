@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+const (
+	// maxImportBytes bounds the size of a server import
+	maxImportBytes = 10 << 20
+	// maxImportRows bounds the number of rows of a server import
+	maxImportRows = 10000
+)
+
+// importFormat returns the format of a server import, csv or ndjson, from the
+// format query parameter or else the Content-Type header
+func importFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if format != "csv" && format != "ndjson" {
+			return "", fmt.Errorf("Invalid format. Must be: csv or ndjson")
+		}
+		return format, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return "csv", nil
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/json":
+		return "ndjson", nil
+	default:
+		return "", fmt.Errorf("Unsupported Content-Type. Use text/csv or application/x-ndjson")
+	}
+}
+
+// ImportServers handles POST /servers/import - creates and updates servers by
+// name from a CSV or NDJSON body in a single transaction. With dry_run=true
+// it reports what the import would do without writing anything.
+func (h *ServerHandler) ImportServers(w http.ResponseWriter, r *http.Request) {
+	format, err := importFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid dry_run parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []models.ServerImportRow
+	if format == "csv" {
+		rows, err = models.ParseServerImportCSV(body)
+	} else {
+		rows, err = models.ParseServerImportNDJSON(body)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Import exceeds %d bytes", maxImportBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid import: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(rows) == 0 {
+		http.Error(w, "Import contains no servers", http.StatusBadRequest)
+		return
+	}
+	if len(rows) > maxImportRows {
+		http.Error(w, fmt.Sprintf("Import exceeds %d servers", maxImportRows), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	report, err := h.repo.Import(changeContextWithSource(r, models.ChangeSourceImport), rows, dryRun)
+	if err != nil {
+		log.Printf("Error importing servers: %v", err)
+		if errors.Is(err, database.ErrConflict) {
+			http.Error(w, "A server was created concurrently, retry the import", http.StatusConflict)
+		} else {
+			http.Error(w, "Failed to import servers", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun && report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding server import response: %v", err)
+		return
+	}
+}
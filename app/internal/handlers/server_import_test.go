@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"infra-dashboard/internal/models"
+)
+
+// doImport posts a server import with the given content type
+func (a *testAPI) doImport(t *testing.T, query, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/servers/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServerHandler_Import(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+	api.createOS(t, "Debian", "12", "2028-06-30")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID, Environment: "prod"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	csvBody := "name,os_name,os_version,environment,role\n" +
+		"web-01,Debian,12,,\n" +
+		"web-02,Ubuntu,22.04,staging,web\n"
+
+	// A dry run reports the plan without writing anything
+	rec = api.doImport(t, "?dry_run=true", "text/csv", csvBody)
+	expectStatus(t, rec, http.StatusOK)
+	var report models.ServerImportReport
+	decode(t, rec, &report)
+	if !report.DryRun || report.Applied || report.Created != 1 || report.Updated != 1 || len(report.Rows) != 2 {
+		t.Fatalf("Unexpected dry run report: %+v", report)
+	}
+	if count, _ := api.stores.Servers.Count(&models.ServerFilter{}); count != 1 {
+		t.Errorf("Expected the dry run not to create servers, got %d", count)
+	}
+
+	rec = api.doImport(t, "", "text/csv", csvBody)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &report)
+	if report.DryRun || !report.Applied || report.Rows[1].ServerID == nil {
+		t.Fatalf("Unexpected import report: %+v", report)
+	}
+
+	servers, err := api.stores.Servers.GetAll(&models.ServerFilter{})
+	if err != nil || len(servers) != 2 {
+		t.Fatalf("Expected 2 servers, got %+v, %v", servers, err)
+	}
+	history, err := api.stores.ChangeHistory.GetAll(&models.ChangeHistoryFilter{})
+	if err != nil || len(history) != 3 || history[0].Source != models.ChangeSourceImport || history[0].RequestID != history[1].RequestID {
+		t.Errorf("Expected both imported changes recorded under one request, got %+v", history)
+	}
+
+	// Importing the same rows again changes nothing
+	rec = api.doImport(t, "", "application/x-ndjson", `{"name": "web-02", "os_name": "Ubuntu", "os_version": "22.04", "role": "web"}`)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &report)
+	if report.Unchanged != 1 || report.Rows[0].Action != models.ImportActionUnchanged {
+		t.Errorf("Expected the row to be unchanged, got %+v", report)
+	}
+}
+
+func TestServerHandler_ImportErrors(t *testing.T) {
+	api := newTestAPI(t)
+	api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	// One invalid row rolls back the whole import
+	rec := api.doImport(t, "", "application/x-ndjson", `{"name": "web-01", "os_name": "Ubuntu", "os_version": "22.04"}
+{"name": "web-02", "os_name": "Ubuntu", "os_version": "18.04"}`)
+	expectStatus(t, rec, http.StatusUnprocessableEntity)
+	var report models.ServerImportReport
+	decode(t, rec, &report)
+	if report.Applied || report.Failed != 1 || report.Rows[1].Line != 2 || report.Rows[1].Error == "" {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if count, _ := api.stores.Servers.Count(&models.ServerFilter{}); count != 0 {
+		t.Errorf("Expected no servers to be imported, got %d", count)
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"unsupported content type", "", "application/xml", "<servers/>", http.StatusUnsupportedMediaType},
+		{"invalid format", "?format=xlsx", "text/csv", "name\nweb-01\n", http.StatusUnsupportedMediaType},
+		{"invalid dry run", "?dry_run=maybe", "text/csv", "name\nweb-01\n", http.StatusBadRequest},
+		{"malformed JSON", "", "application/x-ndjson", "{", http.StatusBadRequest},
+		{"empty import", "?format=csv", "text/plain", "name\n", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, api.doImport(t, tt.query, tt.contentType, tt.body), tt.status)
+		})
+	}
+}
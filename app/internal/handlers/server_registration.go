@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+// maxRegistrationBytes bounds the size of a server registration
+const maxRegistrationBytes = 64 << 10
+
+// RegisterServer handles POST /servers/register - creates or updates a
+// server by name with the operating system detected from the os-release,
+// freebsd-version or uname output of the host. A changed OS is recorded as an
+// os_changed history entry; an OS missing from the catalog is rejected.
+func (h *ServerHandler) RegisterServer(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterServerRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRegistrationBytes)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	utils := models.NewServerUtils()
+	if err := utils.ValidateServerName(req.Name); err != nil {
+		http.Error(w, "Invalid name: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.ValidateEnvironment(req.Environment); err != nil {
+		http.Error(w, "Invalid environment. Must be: prod, staging, or dev", http.StatusBadRequest)
+		return
+	}
+	if err := utils.ValidateAttributes(req.Role, req.OwnerTeam, req.Location); err != nil {
+		http.Error(w, "Invalid "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	detected, err := models.DetectOS(req)
+	if err != nil {
+		http.Error(w, "Could not detect the operating system: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows := []models.ServerImportRow{{
+		Line:        1,
+		Name:        req.Name,
+		OSName:      detected.Name,
+		OSVersion:   detected.Version,
+		Environment: req.Environment,
+		Role:        req.Role,
+		OwnerTeam:   req.OwnerTeam,
+		Location:    req.Location,
+		Description: req.Description,
+	}}
+	report, err := h.repo.Import(changeContextWithSource(r, models.ChangeSourceRegistration), rows, false)
+	if err != nil {
+		log.Printf("Error registering server %s: %v", req.Name, err)
+		if errors.Is(err, database.ErrConflict) {
+			http.Error(w, "The server was created concurrently, retry the registration", http.StatusConflict)
+		} else {
+			http.Error(w, "Failed to register server", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// The name and environment are valid, so a failed row means the detected
+	// operating system is missing from the catalog
+	result := report.Rows[0]
+	if result.Action == models.ImportActionError {
+		http.Error(w, fmt.Sprintf("Operating system %s %s is not in the catalog", detected.Name, detected.Version), http.StatusUnprocessableEntity)
+		return
+	}
+
+	server, err := h.repo.GetByID(*result.ServerID)
+	if err != nil {
+		log.Printf("Error getting registered server %d: %v", *result.ServerID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if result.Action == models.ImportActionCreate {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(models.ServerRegistration{
+		Action:     result.Action,
+		Server:     server,
+		DetectedOS: detected,
+		Changes:    result.Changes,
+	}); err != nil {
+		log.Printf("Error encoding server registration response: %v", err)
+		return
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestServerHandler_Register(t *testing.T) {
+	api := newTestAPI(t)
+	api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+	noble := api.createOS(t, "Ubuntu", "24.04", "2029-04-01")
+
+	jammy := "NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nID=ubuntu\nID_LIKE=debian\n"
+	rec := api.do(t, http.MethodPost, "/api/v1/servers/register", models.RegisterServerRequest{
+		Name: "web-01", OSRelease: jammy, Environment: "prod", Role: "web",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var registration models.ServerRegistration
+	decode(t, rec, &registration)
+	if registration.Action != models.ImportActionCreate || registration.Server == nil || registration.Server.OS == nil ||
+		registration.Server.OS.Version != "22.04" || registration.DetectedOS.Source != models.OSSourceOSRelease {
+		t.Fatalf("Unexpected registration: %+v", registration)
+	}
+	id := registration.Server.ID
+
+	// Registering again without changes leaves the server and its attributes alone
+	rec = api.do(t, http.MethodPost, "/api/v1/servers/register", models.RegisterServerRequest{Name: "web-01", OSRelease: jammy})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &registration)
+	if registration.Action != models.ImportActionUnchanged || registration.Server.Role != "web" {
+		t.Errorf("Unexpected re-registration: %+v", registration)
+	}
+
+	// An upgraded host moves the server to the new OS
+	rec = api.do(t, http.MethodPost, "/api/v1/servers/register", models.RegisterServerRequest{
+		Name: "web-01", OSRelease: "NAME=\"Ubuntu\"\nVERSION_ID=\"24.04\"\nID=ubuntu\n",
+	})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &registration)
+	if registration.Action != models.ImportActionUpdate || registration.Server.ID != id || registration.Server.OSID != noble.ID {
+		t.Fatalf("Unexpected upgrade registration: %+v", registration)
+	}
+	history, err := api.stores.ChangeHistory.GetAll(&models.ChangeHistoryFilter{ServerID: &id})
+	if err != nil || len(history) != 2 {
+		t.Fatalf("Expected a creation and an OS change, got %+v, %v", history, err)
+	}
+	if history[0].ChangeType != models.ChangeTypeOSChanged || history[0].Source != models.ChangeSourceRegistration {
+		t.Errorf("Unexpected history entry: %+v", history[0])
+	}
+
+	// An OS missing from the catalog is reported rather than created
+	rec = api.do(t, http.MethodPost, "/api/v1/servers/register", models.RegisterServerRequest{
+		Name: "web-02", OSRelease: "ID=debian\nVERSION_ID=\"12\"\n",
+	})
+	expectStatus(t, rec, http.StatusUnprocessableEntity)
+	if body := rec.Body.String(); body != "Operating system Debian 12 is not in the catalog\n" {
+		t.Errorf("Unexpected error %q", body)
+	}
+
+	for _, req := range []models.RegisterServerRequest{
+		{Name: "web-03"},
+		{Name: "web-03", Uname: "Linux web-03 6.8.0-31-generic #31-Ubuntu SMP x86_64 GNU/Linux"},
+		{Name: "", OSRelease: jammy},
+		{Name: "web-03", OSRelease: jammy, Environment: "qa"},
+		{Name: "web-03", OSRelease: jammy, Location: strings.Repeat("x", models.MaxServerAttributeLength+1)},
+	} {
+		rec = api.do(t, http.MethodPost, "/api/v1/servers/register", req)
+		expectStatus(t, rec, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,613 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestServerHandler_CRUD(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+	debian := api.createOS(t, "Debian", "12", "2028-06-30")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var created models.Server
+	decode(t, rec, &created)
+	if created.Name != "web-01" || created.OS == nil || created.OS.Version != "22.04" {
+		t.Fatalf("Unexpected created server: %+v", created)
+	}
+
+	path := fmt.Sprintf("/api/v1/servers/%d", created.ID)
+	rec = api.do(t, http.MethodPut, path, models.UpdateServerRequest{OSID: debian.ID})
+	expectStatus(t, rec, http.StatusOK)
+
+	var updated models.Server
+	decode(t, rec, &updated)
+	if updated.OSID != debian.ID {
+		t.Errorf("Expected OS ID %d, got %d", debian.ID, updated.OSID)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var servers []models.Server
+	decode(t, rec, &servers)
+	if len(servers) != 1 {
+		t.Errorf("Expected 1 server, got %d", len(servers))
+	}
+
+	rec = api.do(t, http.MethodDelete, path, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = api.do(t, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestServerHandler_Errors(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID})
+	expectStatus(t, rec, http.StatusCreated)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"missing fields", http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-02"}, http.StatusBadRequest},
+		{"unknown OS", http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-02", OSID: 999}, http.StatusBadRequest},
+		{"duplicate name", http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: ubuntu.ID}, http.StatusConflict},
+		{"role too long", http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-02", OSID: ubuntu.ID, Role: strings.Repeat("r", models.MaxServerAttributeLength+1)}, http.StatusBadRequest},
+		{"owner team too long", http.MethodPut, "/api/v1/servers/1", models.UpdateServerRequest{OwnerTeam: strings.Repeat("é", models.MaxServerAttributeLength+1)}, http.StatusBadRequest},
+		{"update unknown server", http.MethodPut, "/api/v1/servers/999", models.UpdateServerRequest{Name: "x"}, http.StatusNotFound},
+		{"delete unknown server", http.MethodDelete, "/api/v1/servers/999", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(t, tt.method, tt.path, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestServerHandler_GetComplianceReport(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	eol := api.createOS(t, "CentOS", "7", now.AddDate(-1, 0, 0).Format("2006-01-02"))
+	supported := api.createOS(t, "Debian", "12", now.AddDate(3, 0, 0).Format("2006-01-02"))
+	api.createOS(t, "CentOS", "8", now.AddDate(-3, 0, 0).Format("2006-01-02"))
+	alma := api.createOS(t, "AlmaLinux", "8", now.AddDate(4, 0, 0).Format("2006-01-02"))
+
+	for i, osID := range []int{eol.ID, supported.ID, supported.ID} {
+		req := models.CreateServerRequest{Name: fmt.Sprintf("srv-%d", i), OSID: osID}
+		expectStatus(t, api.do(t, http.MethodPost, "/api/v1/servers", req), http.StatusCreated)
+	}
+
+	rec := api.do(t, http.MethodGet, "/api/v1/servers/compliance", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var report struct {
+		models.ComplianceReport
+		ComplianceScore float64                        `json:"compliance_score"`
+		Upgrades        []models.UpgradeRecommendation `json:"upgrades"`
+	}
+	decode(t, rec, &report)
+	if report.TotalServers != 3 || report.EndOfLifeServers != 1 {
+		t.Errorf("Unexpected report counts: %+v", report.ComplianceReport)
+	}
+	if report.ComplianceScore >= 100 {
+		t.Errorf("Expected score below 100, got %f", report.ComplianceScore)
+	}
+
+	// CentOS 7 migrates to AlmaLinux 8 rather than upgrading to CentOS 8
+	if len(report.Upgrades) != 1 {
+		t.Fatalf("Expected 1 upgrade recommendation, got %+v", report.Upgrades)
+	}
+	if upgrade := report.Upgrades[0]; upgrade.CurrentOS.ID != eol.ID || upgrade.TargetOS.ID != alma.ID ||
+		upgrade.Hops != 1 || !upgrade.Migration || len(upgrade.ServerIDs) != 1 || upgrade.Deadline.IsZero() {
+		t.Errorf("Unexpected upgrade recommendation %+v", upgrade)
+	}
+}
+
+func TestServerHandler_GetComplianceRecommendations(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	eol := api.createOS(t, "CentOS", "7", now.AddDate(-1, 0, 0).Format("2006-01-02"))
+	endingSoon := api.createOS(t, "Ubuntu", "20.04", now.AddDate(0, 2, 0).Format("2006-01-02"))
+
+	var ids []int
+	for i, osID := range []int{eol.ID, endingSoon.ID, eol.ID} {
+		req := models.CreateServerRequest{Name: fmt.Sprintf("srv-%d", i), OSID: osID}
+		rec := api.do(t, http.MethodPost, "/api/v1/servers", req)
+		expectStatus(t, rec, http.StatusCreated)
+		var server models.Server
+		decode(t, rec, &server)
+		ids = append(ids, server.ID)
+	}
+
+	tests := []struct {
+		query string
+		codes string
+	}{
+		{"", "[end_of_life ending_soon]"},
+		{"?severity=critical", "[end_of_life]"},
+		{"?severity=warning", "[ending_soon]"},
+		{"?severity=critical,warning&code=ending_soon", "[ending_soon]"},
+		{"?tiers=notice:1m:0.1", "[end_of_life]"},
+	}
+	for _, tt := range tests {
+		rec := api.do(t, http.MethodGet, "/api/v1/compliance/recommendations"+tt.query, nil)
+		expectStatus(t, rec, http.StatusOK)
+
+		var recs []models.Recommendation
+		decode(t, rec, &recs)
+		var codes []string
+		for _, r := range recs {
+			codes = append(codes, r.Code)
+		}
+		if fmt.Sprint(codes) != tt.codes {
+			t.Errorf("GET %s: expected codes %s, got %v", tt.query, tt.codes, codes)
+		}
+	}
+
+	rec := api.do(t, http.MethodGet, "/api/v1/compliance/recommendations?severity=critical", nil)
+	var recs []models.Recommendation
+	decode(t, rec, &recs)
+	if critical := recs[0]; critical.Severity != models.SeverityCritical || fmt.Sprint(critical.ServerIDs) != fmt.Sprint([]int{ids[0], ids[2]}) ||
+		fmt.Sprint(critical.OSIDs) != fmt.Sprint([]int{eol.ID}) || critical.Deadline == nil || critical.Message == "" {
+		t.Errorf("Unexpected critical recommendation %+v", critical)
+	}
+
+	for _, query := range []string{"?severity=urgent", "?severity=critical,", "?policy=unknown"} {
+		expectStatus(t, api.do(t, http.MethodGet, "/api/v1/compliance/recommendations"+query, nil), http.StatusBadRequest)
+	}
+
+	// The report links to its schema, which is served as JSON Schema
+	rec = api.do(t, http.MethodGet, "/api/v1/servers/compliance", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if link := rec.Header().Get("Link"); !strings.Contains(link, "/api/v1/compliance/schema") {
+		t.Errorf("Expected a link to the report schema, got %q", link)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/compliance/schema", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/schema+json" {
+		t.Errorf("Expected a JSON Schema content type, got %q", contentType)
+	}
+	var schema struct {
+		Schema string `json:"$schema"`
+	}
+	decode(t, rec, &schema)
+	if schema.Schema == "" {
+		t.Errorf("Expected a JSON Schema, got %s", rec.Body.String())
+	}
+}
+
+func TestServerHandler_GetComplianceReportPolicies(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	nineMonths := api.createOS(t, "Ubuntu", "20.04", now.AddDate(0, 9, 0).Format("2006-01-02"))
+	api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: nineMonths.ID})
+
+	type report struct {
+		models.ComplianceReport
+		ComplianceScore float64 `json:"compliance_score"`
+	}
+
+	tests := []struct {
+		query      string
+		endingSoon int
+		tier       string
+		score      float64
+	}{
+		// The default six-month window does not reach nine months out
+		{"", 0, "", 100},
+		{"?policy=strict", 1, "notice", 75},
+		{"?tiers=soon:1m:1,later:1y:1", -1, "", 0},
+		{"?tiers=soon:1m:1,later:400d:0.1", 1, "later", 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := api.do(t, http.MethodGet, "/api/v1/servers/compliance"+tt.query, nil)
+			if tt.endingSoon < 0 {
+				expectStatus(t, rec, http.StatusBadRequest)
+				return
+			}
+			expectStatus(t, rec, http.StatusOK)
+
+			var got report
+			decode(t, rec, &got)
+			if got.EndingSoonServers != tt.endingSoon {
+				t.Errorf("Expected %d ending soon servers, got %d", tt.endingSoon, got.EndingSoonServers)
+			}
+			if tt.tier != "" && got.TierCounts[tt.tier] != 1 {
+				t.Errorf("Expected the server in tier %s, got %v", tt.tier, got.TierCounts)
+			}
+			if got.ComplianceScore != tt.score {
+				t.Errorf("Expected score %.2f, got %.2f", tt.score, got.ComplianceScore)
+			}
+		})
+	}
+
+	for _, query := range []string{"?policy=unknown", "?eol_penalty=high", "?eol_penalty=NaN", "?eol_penalty=Inf", "?tiers=a:0d:1", "?tiers=a:6m:NaN", "?tiers=a:6m:+Inf"} {
+		expectStatus(t, api.do(t, http.MethodGet, "/api/v1/servers/compliance"+query, nil), http.StatusBadRequest)
+	}
+
+	// The list status filter follows the default policy
+	rec := api.do(t, http.MethodGet, "/api/v1/servers?status=supported", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var servers []models.Server
+	decode(t, rec, &servers)
+	if len(servers) != 1 {
+		t.Errorf("Expected the server to be supported under the default policy, got %d", len(servers))
+	}
+}
+
+func TestServerHandler_GetComplianceReportEnvironments(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	recentEOL := api.createOS(t, "Ubuntu", "18.04", now.AddDate(0, 0, -30).Format("2006-01-02"))
+	nineMonths := api.createOS(t, "Ubuntu", "20.04", now.AddDate(0, 9, 0).Format("2006-01-02"))
+
+	for _, req := range []models.CreateServerRequest{
+		{Name: "prod-01", OSID: nineMonths.ID, Environment: models.EnvironmentProd},
+		{Name: "dev-01", OSID: recentEOL.ID, Environment: models.EnvironmentDev},
+		{Name: "misc-01", OSID: recentEOL.ID},
+	} {
+		expectStatus(t, api.do(t, http.MethodPost, "/api/v1/servers", req), http.StatusCreated)
+	}
+
+	rec := api.do(t, http.MethodGet, "/api/v1/servers/compliance?policy=strict", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var report struct {
+		models.ComplianceReport
+		Recommendations []models.Recommendation `json:"recommendations"`
+	}
+	decode(t, rec, &report)
+
+	// prod must upgrade 12 months ahead, dev may run 90 days past end of life
+	expected := map[string]struct{ eol, endingSoon, supported int }{
+		models.EnvironmentProd:       {1, 0, 0},
+		models.EnvironmentDev:        {0, 1, 0},
+		models.EnvironmentUnassigned: {1, 0, 0},
+	}
+	for env, counts := range expected {
+		got, exists := report.Environments[env]
+		if !exists {
+			t.Errorf("Expected a breakdown for %s", env)
+			continue
+		}
+		if got.EndOfLifeServers != counts.eol || got.EndingSoonServers != counts.endingSoon || got.SupportedServers != counts.supported {
+			t.Errorf("Unexpected %s breakdown: %+v", env, got)
+		}
+	}
+	if report.Environments[models.EnvironmentProd].ComplianceScore != 0 {
+		t.Errorf("Expected prod score 0, got %.2f", report.Environments[models.EnvironmentProd].ComplianceScore)
+	}
+
+	if len(report.Recommendations) == 0 || report.Recommendations[0].Severity != models.SeverityCritical {
+		t.Errorf("Expected critical recommendations first, got %v", report.Recommendations)
+	}
+}
+
+func TestServerHandler_InventoryAttributes(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	servers := []models.CreateServerRequest{
+		{Name: "db-01", OSID: ubuntu.ID, Environment: models.EnvironmentProd, Role: "database", OwnerTeam: "data", Location: "eu-west-1"},
+		{Name: "db-02", OSID: ubuntu.ID, Environment: models.EnvironmentDev, Role: "database", OwnerTeam: "data", Location: "eu-west-1"},
+		{Name: "web-01", OSID: ubuntu.ID, Environment: models.EnvironmentProd, Role: "web", OwnerTeam: "frontend", Location: "us-east-1"},
+	}
+	for _, req := range servers {
+		expectStatus(t, api.do(t, http.MethodPost, "/api/v1/servers", req), http.StatusCreated)
+	}
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"environment=prod", 2},
+		{"role=database", 2},
+		{"owner_team=frontend", 1},
+		{"environment=prod&location=eu-west-1", 1},
+		{"location=ap-south-1", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := api.do(t, http.MethodGet, "/api/v1/servers?"+tt.query, nil)
+			expectStatus(t, rec, http.StatusOK)
+
+			var result []models.Server
+			decode(t, rec, &result)
+			if len(result) != tt.expected {
+				t.Errorf("Expected %d servers, got %d", tt.expected, len(result))
+			}
+		})
+	}
+
+	rec := api.do(t, http.MethodGet, "/api/v1/servers?environment=production", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "x-01", OSID: ubuntu.ID, Environment: "qa"})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// History records carry a snapshot of the server attributes
+	rec = api.do(t, http.MethodGet, "/api/v1/history?change_type=created", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var history []models.ServerChangeHistory
+	decode(t, rec, &history)
+	for _, record := range history {
+		if record.ServerName == "web-01" && (record.Environment != models.EnvironmentProd || record.OwnerTeam != "frontend") {
+			t.Errorf("Expected attribute snapshot in history, got %+v", record)
+		}
+	}
+}
+
+func TestServerHandler_ListFiltersAndSorting(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	eol := api.createOS(t, "CentOS", "7", now.AddDate(-1, 0, 0).Format("2006-01-02"))
+	endingSoon := api.createOS(t, "Ubuntu", "20.04", now.AddDate(0, 2, 0).Format("2006-01-02"))
+	supported := api.createOS(t, "Debian", "12", now.AddDate(3, 0, 0).Format("2006-01-02"))
+
+	for _, req := range []models.CreateServerRequest{
+		{Name: "app-legacy-01", OSID: eol.ID},
+		{Name: "web-02", OSID: endingSoon.ID},
+		{Name: "web-01", OSID: supported.ID},
+		{Name: "db-01", OSID: supported.ID},
+	} {
+		expectStatus(t, api.do(t, http.MethodPost, "/api/v1/servers", req), http.StatusCreated)
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"status=eol", []string{"app-legacy-01"}},
+		{"status=ending_soon", []string{"web-02"}},
+		{"status=supported&sort=name", []string{"db-01", "web-01"}},
+		{"name=WEB&sort=-name", []string{"web-02", "web-01"}},
+		{"family=Debian&sort=name", []string{"db-01", "web-01"}},
+		{fmt.Sprintf("os_id=%d", eol.ID), []string{"app-legacy-01"}},
+		{"sort=end_of_support,name", []string{"app-legacy-01", "web-02", "db-01", "web-01"}},
+		{"created_after=" + now.AddDate(0, 0, 1).Format("2006-01-02"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := api.do(t, http.MethodGet, "/api/v1/servers?"+tt.query, nil)
+			expectStatus(t, rec, http.StatusOK)
+
+			var result []models.Server
+			decode(t, rec, &result)
+			var names []string
+			for _, server := range result {
+				names = append(names, server.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+
+	for _, query := range []string{"status=unknown", "sort=password", "os_id=abc", "created_before=yesterday", "limit=0", "cursor=!!"} {
+		rec := api.do(t, http.MethodGet, "/api/v1/servers?"+query, nil)
+		expectStatus(t, rec, http.StatusBadRequest)
+	}
+}
+
+func TestServerHandler_LastSeen(t *testing.T) {
+	api := newTestAPI(t)
+	api.createOS(t, "Ubuntu", "22.04", time.Now().AddDate(3, 0, 0).Format("2006-01-02"))
+	os := api.createOS(t, "Debian", "12", time.Now().AddDate(3, 0, 0).Format("2006-01-02"))
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers/register", models.RegisterServerRequest{
+		Name: "web-01", OSRelease: "ID=ubuntu\nVERSION_ID=\"22.04\"\n", Environment: "prod",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var registration models.ServerRegistration
+	decode(t, rec, &registration)
+	if registration.Server.LastSeenAt == nil {
+		t.Fatalf("Expected a registered server to be seen, got %+v", registration.Server)
+	}
+	expectStatus(t, api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "db-01", OSID: os.ID}), http.StatusCreated)
+
+	// Both servers are fresh: web-01 just reported and db-01 never did
+	for query, expected := range map[string]string{
+		"stale=true":                  "[]",
+		"stale=false&sort=name":       "[db-01 web-01]",
+		"sort=last_seen_at,name":      "[web-01 db-01]",
+		"stale=false&environment=dev": "[]",
+	} {
+		rec := api.do(t, http.MethodGet, "/api/v1/servers?"+query, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var servers []models.Server
+		decode(t, rec, &servers)
+		names := []string{}
+		for _, server := range servers {
+			names = append(names, server.Name)
+		}
+		if fmt.Sprint(names) != expected {
+			t.Errorf("%s: expected %s, got %v", query, expected, names)
+		}
+	}
+	expectStatus(t, api.do(t, http.MethodGet, "/api/v1/servers?stale=maybe", nil), http.StatusBadRequest)
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers/compliance?exclude_stale=true", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var report struct {
+		models.ComplianceReport
+		ComplianceScore float64 `json:"compliance_score"`
+	}
+	decode(t, rec, &report)
+	if report.StaleServers != 0 || report.SupportedServers != 2 || !report.Policy.ExcludeStale || report.Policy.StaleAfterDays != 30 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	for _, query := range []string{"stale_after_days=-1", "stale_after_days=0&exclude_stale=true", "exclude_stale=maybe"} {
+		expectStatus(t, api.do(t, http.MethodGet, "/api/v1/servers/compliance?"+query, nil), http.StatusBadRequest)
+	}
+}
+
+func TestServerHandler_ExtendedSupport(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	rec := api.do(t, http.MethodPost, "/api/v1/os", models.CreateOSRequest{
+		Name: "Ubuntu", Version: "18.04", LTS: true,
+		EndOfSupport:         now.AddDate(-1, 0, 0).Format("2006-01-02"),
+		EndOfExtendedSupport: now.AddDate(4, 0, 0).Format("2006-01-02"),
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var esm models.OS
+	decode(t, rec, &esm)
+	if !esm.LTS || !strings.Contains(rec.Body.String(), `"lifecycle_phase":"extended"`) {
+		t.Errorf("Expected an LTS release in extended support, got %s", rec.Body.String())
+	}
+
+	rec = api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "web-01", OSID: esm.ID})
+	expectStatus(t, rec, http.StatusCreated)
+	var server models.Server
+	decode(t, rec, &server)
+
+	complianceReport := func() models.ComplianceReport {
+		rec := api.do(t, http.MethodGet, "/api/v1/servers/compliance", nil)
+		expectStatus(t, rec, http.StatusOK)
+		var report models.ComplianceReport
+		decode(t, rec, &report)
+		return report
+	}
+	if report := complianceReport(); report.EndOfLifeServers != 1 || report.ExtendedSupportServers != 0 {
+		t.Errorf("Expected web-01 to be end of life, got %+v", report)
+	}
+
+	// Enrolling the server evaluates it against the end of extended support
+	enrolled := true
+	rec = api.do(t, http.MethodPut, fmt.Sprintf("/api/v1/servers/%d", server.ID), models.UpdateServerRequest{ExtendedSupport: &enrolled})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &server)
+	if !server.ExtendedSupport {
+		t.Errorf("Expected web-01 to be enrolled, got %+v", server)
+	}
+	if report := complianceReport(); report.EndOfLifeServers != 0 || report.SupportedServers != 1 || report.ExtendedSupportServers != 1 {
+		t.Errorf("Expected web-01 to be supported through extended support, got %+v", report)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers?status=eol", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var eol []models.Server
+	decode(t, rec, &eol)
+	if len(eol) != 0 {
+		t.Errorf("Expected no end of life server, got %+v", eol)
+	}
+}
+
+func TestServerHandler_Pagination(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "22.04", "2027-04-01")
+
+	for i := 0; i < 5; i++ {
+		req := models.CreateServerRequest{Name: fmt.Sprintf("srv-%02d", i), OSID: ubuntu.ID}
+		expectStatus(t, api.do(t, http.MethodPost, "/api/v1/servers", req), http.StatusCreated)
+	}
+
+	var names []string
+	path := "/api/v1/servers?sort=name&limit=2"
+	for pages := 0; path != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("Pagination did not terminate")
+		}
+
+		rec := api.do(t, http.MethodGet, path, nil)
+		expectStatus(t, rec, http.StatusOK)
+		if total := rec.Header().Get("X-Total-Count"); total != "5" {
+			t.Errorf("Expected X-Total-Count 5, got %q", total)
+		}
+
+		var page []models.Server
+		decode(t, rec, &page)
+		for _, server := range page {
+			names = append(names, server.Name)
+		}
+
+		path = nextLink(rec)
+	}
+
+	expected := []string{"srv-00", "srv-01", "srv-02", "srv-03", "srv-04"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v across pages, got %v", expected, names)
+	}
+
+	// Servers created before the cursor do not shift the following pages
+	rec := api.do(t, http.MethodGet, "/api/v1/servers?sort=name&limit=2", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if link := rec.Header().Get("Link"); !strings.Contains(link, "cursor=") || strings.Contains(link, "offset=") {
+		t.Errorf("Expected a cursor in the next link, got %q", link)
+	}
+	req := models.CreateServerRequest{Name: "srv-000", OSID: ubuntu.ID}
+	expectStatus(t, api.do(t, http.MethodPost, "/api/v1/servers", req), http.StatusCreated)
+
+	rec = api.do(t, http.MethodGet, nextLink(rec), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var page []models.Server
+	decode(t, rec, &page)
+	if len(page) != 2 || page[0].Name != "srv-02" || page[1].Name != "srv-03" {
+		t.Errorf("Expected srv-02 and srv-03 after the cursor, got %+v", page)
+	}
+
+	// A cursor only applies to the sort order it was issued for
+	rec = api.do(t, http.MethodGet, "/api/v1/servers?sort=name&limit=2", nil)
+	cursor := nextLink(rec)[strings.Index(nextLink(rec), "cursor="):]
+	expectStatus(t, api.do(t, http.MethodGet, "/api/v1/servers?sort=-name&"+cursor, nil), http.StatusBadRequest)
+}
+
+func TestServerHandler_AsOf(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	eol := api.createOS(t, "CentOS", "7", now.AddDate(0, 0, -1).Format("2006-01-02"))
+
+	rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: "legacy-01", OSID: eol.ID})
+	expectStatus(t, rec, http.StatusCreated)
+
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	tomorrow := now.AddDate(0, 0, 1).Format("2006-01-02")
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers?as_of="+yesterday, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if total := rec.Header().Get("X-Total-Count"); total != "0" {
+		t.Errorf("Expected no servers before the first one was created, got %q", total)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers?status=eol&as_of="+tomorrow, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var servers []models.Server
+	decode(t, rec, &servers)
+	if len(servers) != 1 || servers[0].Name != "legacy-01" || servers[0].OS == nil {
+		t.Errorf("Expected legacy-01 rebuilt from history, got %+v", servers)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers/compliance?as_of="+yesterday, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var report models.ComplianceReport
+	decode(t, rec, &report)
+	if report.TotalServers != 0 || report.AsOf == nil {
+		t.Errorf("Expected an empty past report, got %+v", report)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/servers?as_of=last-week", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
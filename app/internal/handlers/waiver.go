@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// WaiverHandler handles compliance waiver HTTP requests
+type WaiverHandler struct {
+	repo database.WaiverStore
+}
+
+// NewWaiverHandler creates a new waiver handler
+func NewWaiverHandler(repo database.WaiverStore) *WaiverHandler {
+	return &WaiverHandler{repo: repo}
+}
+
+// validateWaiverExpiry checks that an expiry date is a YYYY-MM-DD date in the future
+func validateWaiverExpiry(value string) error {
+	expiresAt, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return fmt.Errorf("Invalid expiry date format. Use YYYY-MM-DD")
+	}
+	if !expiresAt.After(time.Now()) {
+		return fmt.Errorf("Expiry date must be in the future")
+	}
+	return nil
+}
+
+// activeWaivers returns the waivers that currently exclude servers from
+// compliance penalties
+func activeWaivers(repo database.WaiverStore) ([]models.Waiver, error) {
+	status := models.WaiverStatusActive
+	waivers, err := repo.GetAll(&models.WaiverFilter{Status: &status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active waivers: %w", err)
+	}
+	return waivers, nil
+}
+
+// parseWaiverID returns the waiver ID from the route variables
+func parseWaiverID(r *http.Request) (int, error) {
+	idStr, exists := mux.Vars(r)["id"]
+	if !exists {
+		return 0, fmt.Errorf("Waiver ID is required")
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid waiver ID")
+	}
+
+	return id, nil
+}
+
+// parseWaiverFilter builds a waiver filter from query parameters
+func parseWaiverFilter(r *http.Request) (*models.WaiverFilter, error) {
+	query := r.URL.Query()
+	filter := &models.WaiverFilter{}
+
+	for _, f := range []struct {
+		param string
+		dest  **int
+	}{
+		{"server_id", &filter.ServerID},
+		{"os_id", &filter.OSID},
+	} {
+		if value := query.Get(f.param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s parameter", f.param)
+			}
+			*f.dest = &id
+		}
+	}
+
+	if status := query.Get("status"); status != "" {
+		if status != models.WaiverStatusActive && status != models.WaiverStatusExpired && status != models.WaiverStatusRevoked {
+			return nil, fmt.Errorf("Invalid status. Must be one of: active, expired, revoked")
+		}
+		filter.Status = &status
+	}
+
+	return filter, nil
+}
+
+// GetWaivers handles GET /waivers - retrieves waivers with optional filters
+// and offset-based pagination, soonest expiry first
+func (h *WaiverHandler) GetWaivers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseWaiverFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		log.Printf("Error counting waivers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	waivers, err := h.repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting waivers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writePageHeaders(w, r, total, filter.Limit, filter.Offset)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(waivers); err != nil {
+		log.Printf("Error encoding waivers response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetWaiver handles GET /waivers/{id} - retrieves a waiver by ID
+func (h *WaiverHandler) GetWaiver(w http.ResponseWriter, r *http.Request) {
+	id, err := parseWaiverID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	waiver, err := h.repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error getting waiver by ID %d: %v", id, err)
+		if errors.Is(err, database.ErrNotFound) {
+			http.Error(w, "Waiver not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(waiver); err != nil {
+		log.Printf("Error encoding waiver response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateWaiver handles POST /waivers - creates a new waiver
+func (h *WaiverHandler) CreateWaiver(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWaiverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Basic validation
+	if (req.ServerID == nil) == (req.OSID == nil) {
+		http.Error(w, "Exactly one of server ID and OS ID is required", http.StatusBadRequest)
+		return
+	}
+	if req.Justification == "" || req.Approver == "" || req.ExpiresAt == "" {
+		http.Error(w, "Justification, approver, and expiry date are required", http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateWaiverApprover(req.Approver); err != nil {
+		http.Error(w, "Invalid "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateWaiverExpiry(req.ExpiresAt); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	waiver, err := h.repo.Create(&req)
+	if err != nil {
+		log.Printf("Error creating waiver: %v", err)
+		switch {
+		case errors.Is(err, database.ErrInvalidReference):
+			http.Error(w, "Waived server or operating system does not exist", http.StatusBadRequest)
+		case errors.Is(err, database.ErrInvalidInput):
+			http.Error(w, "Invalid waiver", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to create waiver", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(waiver); err != nil {
+		log.Printf("Error encoding created waiver response: %v", err)
+		return
+	}
+}
+
+// UpdateWaiver handles PUT /waivers/{id} - updates the justification,
+// approver or expiry date of a waiver that has not been revoked
+func (h *WaiverHandler) UpdateWaiver(w http.ResponseWriter, r *http.Request) {
+	id, err := parseWaiverID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateWaiverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.ValidateWaiverApprover(req.Approver); err != nil {
+		http.Error(w, "Invalid "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresAt != "" {
+		if err := validateWaiverExpiry(req.ExpiresAt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	waiver, err := h.repo.Update(id, &req)
+	if err != nil {
+		log.Printf("Error updating waiver with ID %d: %v", id, err)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Waiver not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "Waiver has been revoked", http.StatusConflict)
+		case errors.Is(err, database.ErrInvalidInput):
+			http.Error(w, "Invalid expiry date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to update waiver", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(waiver); err != nil {
+		log.Printf("Error encoding updated waiver response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RevokeWaiver handles DELETE /waivers/{id} - revokes a waiver. Revoked
+// waivers remain listed for auditing.
+func (h *WaiverHandler) RevokeWaiver(w http.ResponseWriter, r *http.Request) {
+	id, err := parseWaiverID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.repo.Revoke(id); err != nil {
+		log.Printf("Error revoking waiver with ID %d: %v", id, err)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			http.Error(w, "Waiver not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrConflict):
+			http.Error(w, "Waiver has already been revoked", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to revoke waiver", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/models"
+)
+
+func TestWaiverHandler_CRUD(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "18.04", "2023-05-31")
+	expiresAt := time.Now().AddDate(0, 6, 0).Format("2006-01-02")
+
+	rec := api.do(t, http.MethodPost, "/api/v1/waivers", models.CreateWaiverRequest{
+		OSID: &ubuntu.ID, Justification: "Vendor appliance", Approver: "alice", ExpiresAt: expiresAt,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var created models.Waiver
+	decode(t, rec, &created)
+	if created.OSID == nil || *created.OSID != ubuntu.ID || created.ServerID != nil {
+		t.Fatalf("Unexpected created waiver: %+v", created)
+	}
+
+	path := fmt.Sprintf("/api/v1/waivers/%d", created.ID)
+	rec = api.do(t, http.MethodPut, path, models.UpdateWaiverRequest{Approver: "bob"})
+	expectStatus(t, rec, http.StatusOK)
+
+	var updated models.Waiver
+	decode(t, rec, &updated)
+	if updated.Approver != "bob" || updated.Justification != "Vendor appliance" {
+		t.Errorf("Unexpected updated waiver: %+v", updated)
+	}
+
+	rec = api.do(t, http.MethodGet, fmt.Sprintf("/api/v1/waivers?os_id=%d&status=active", ubuntu.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var waivers []models.Waiver
+	decode(t, rec, &waivers)
+	if len(waivers) != 1 || rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Expected 1 active waiver, got %+v", waivers)
+	}
+
+	rec = api.do(t, http.MethodDelete, path, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	// Revoked waivers are kept for auditing
+	rec = api.do(t, http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var revoked models.Waiver
+	decode(t, rec, &revoked)
+	if revoked.RevokedAt == nil || revoked.OSName != "Ubuntu" || revoked.OSVersion != "18.04" {
+		t.Errorf("Unexpected revoked waiver: %+v", revoked)
+	}
+
+	rec = api.do(t, http.MethodGet, "/api/v1/waivers?status=revoked", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Expected 1 revoked waiver, got %s", rec.Header().Get("X-Total-Count"))
+	}
+
+	rec = api.do(t, http.MethodPut, path, models.UpdateWaiverRequest{Approver: "carol"})
+	expectStatus(t, rec, http.StatusConflict)
+	rec = api.do(t, http.MethodDelete, path, nil)
+	expectStatus(t, rec, http.StatusConflict)
+}
+
+func TestWaiverHandler_Errors(t *testing.T) {
+	api := newTestAPI(t)
+	ubuntu := api.createOS(t, "Ubuntu", "18.04", "2023-05-31")
+	future := time.Now().AddDate(1, 0, 0).Format("2006-01-02")
+	unknown := 999
+	longApprover := strings.Repeat("a", models.MaxWaiverApproverLength+1)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"no scope", http.MethodPost, "/api/v1/waivers", models.CreateWaiverRequest{Justification: "j", Approver: "a", ExpiresAt: future}, http.StatusBadRequest},
+		{"missing approver", http.MethodPost, "/api/v1/waivers", models.CreateWaiverRequest{OSID: &ubuntu.ID, Justification: "j", ExpiresAt: future}, http.StatusBadRequest},
+		{"past expiry", http.MethodPost, "/api/v1/waivers", models.CreateWaiverRequest{OSID: &ubuntu.ID, Justification: "j", Approver: "a", ExpiresAt: "2020-01-01"}, http.StatusBadRequest},
+		{"unknown server", http.MethodPost, "/api/v1/waivers", models.CreateWaiverRequest{ServerID: &unknown, Justification: "j", Approver: "a", ExpiresAt: future}, http.StatusBadRequest},
+		{"approver too long", http.MethodPost, "/api/v1/waivers", models.CreateWaiverRequest{OSID: &ubuntu.ID, Justification: "j", Approver: longApprover, ExpiresAt: future}, http.StatusBadRequest},
+		{"invalid status", http.MethodGet, "/api/v1/waivers?status=withdrawn", nil, http.StatusBadRequest},
+		{"update approver too long", http.MethodPut, "/api/v1/waivers/999", models.UpdateWaiverRequest{Approver: longApprover}, http.StatusBadRequest},
+		{"update unknown", http.MethodPut, "/api/v1/waivers/999", models.UpdateWaiverRequest{Approver: "a"}, http.StatusNotFound},
+		{"revoke unknown", http.MethodDelete, "/api/v1/waivers/999", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(t, tt.method, tt.path, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestWaiverHandler_ComplianceReport(t *testing.T) {
+	api := newTestAPI(t)
+	now := time.Now()
+	eol := api.createOS(t, "CentOS", "7", now.AddDate(-1, 0, 0).Format("2006-01-02"))
+
+	var ids []int
+	for i := 0; i < 2; i++ {
+		rec := api.do(t, http.MethodPost, "/api/v1/servers", models.CreateServerRequest{Name: fmt.Sprintf("appliance-%d", i), OSID: eol.ID})
+		expectStatus(t, rec, http.StatusCreated)
+		var server models.Server
+		decode(t, rec, &server)
+		ids = append(ids, server.ID)
+	}
+
+	// An active waiver on the first server and an expired one on the second
+	if _, err := api.stores.Waivers.Create(&models.CreateWaiverRequest{ServerID: &ids[0], Justification: "Vendor locked", Approver: "alice", ExpiresAt: now.AddDate(0, 1, 0).Format("2006-01-02")}); err != nil {
+		t.Fatalf("Failed to create waiver: %v", err)
+	}
+	if _, err := api.stores.Waivers.Create(&models.CreateWaiverRequest{ServerID: &ids[1], Justification: "Vendor locked", Approver: "alice", ExpiresAt: now.AddDate(0, 0, -1).Format("2006-01-02")}); err != nil {
+		t.Fatalf("Failed to create waiver: %v", err)
+	}
+
+	rec := api.do(t, http.MethodGet, "/api/v1/servers/compliance", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var report struct {
+		models.ComplianceReport
+		ComplianceScore float64 `json:"compliance_score"`
+	}
+	decode(t, rec, &report)
+	if report.EndOfLifeServers != 1 || report.WaivedServers != 1 {
+		t.Errorf("Unexpected report counts: %+v", report.ComplianceReport)
+	}
+	if len(report.Waived) != 1 || report.Waived[0].Server.ID != ids[0] || report.Waived[0].Status != models.StatusEndOfLife {
+		t.Errorf("Expected the first server in the waived section, got %+v", report.Waived)
+	}
+	if report.ComplianceScore != 0 {
+		t.Errorf("Expected score 0 from the unwaived end-of-life server, got %.2f", report.ComplianceScore)
+	}
+}
@@ -0,0 +1,215 @@
+// Package maintenance applies scheduled operating system changes during
+// their maintenance windows.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+// ChangeResult is the outcome of a due scheduled change
+type ChangeResult struct {
+	ChangeID int
+	ServerID int
+	Status   string // 'applied', 'failed' or 'expired'
+	Reason   string
+}
+
+// RunReport reports the outcome of an executor run
+type RunReport struct {
+	Applied int
+	Failed  int
+	Expired int
+	Changes []ChangeResult
+}
+
+// Summary describes the counts of a report in a sentence
+func (r *RunReport) Summary() string {
+	return fmt.Sprintf("%d applied, %d failed, %d expired", r.Applied, r.Failed, r.Expired)
+}
+
+// add counts the outcome of a change in the report
+func (r *RunReport) add(result ChangeResult) {
+	switch result.Status {
+	case models.ScheduledChangeApplied:
+		r.Applied++
+	case models.ScheduledChangeFailed:
+		r.Failed++
+	case models.ScheduledChangeExpired:
+		r.Expired++
+	}
+	r.Changes = append(r.Changes, result)
+}
+
+// Executor applies the pending scheduled changes whose maintenance window
+// has opened through the server store, so that each change is recorded in
+// the server change history like a change made through the API
+type Executor struct {
+	changes database.ScheduledChangeStore
+	servers database.ServerStore
+
+	// now returns the current time and can be replaced in tests
+	now func() time.Time
+}
+
+// NewExecutor creates an executor of the changes scheduled in changes
+func NewExecutor(changes database.ScheduledChangeStore, servers database.ServerStore) *Executor {
+	return &Executor{changes: changes, servers: servers, now: time.Now}
+}
+
+// Run applies every due change. Changes whose window closed before they
+// could be applied, e.g. while the service was down, are expired instead.
+// A change that cannot be applied is marked as failed with the error as its
+// reason without stopping the run.
+//
+// Each change is claimed before its server is updated, so a change that was
+// cancelled or claimed by another executor in the meantime is skipped.
+// Claimed changes still applying once their window has closed were
+// interrupted, and are resolved from the current OS of their server.
+func (e *Executor) Run(ctx context.Context) (*RunReport, error) {
+	status := models.ScheduledChangePending
+	pending, err := e.changes.GetAll(&models.ScheduledChangeFilter{Status: &status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending scheduled changes: %w", err)
+	}
+
+	report := &RunReport{}
+	for _, change := range pending {
+		now := e.now()
+		// Open changes of deleted servers were resolved with their server
+		if change.ServerID == nil || !change.Due(now) {
+			continue
+		}
+
+		result, err := e.execute(ctx, change, now)
+		if err != nil {
+			return report, err
+		}
+		if result != nil {
+			report.add(*result)
+		}
+	}
+
+	status = models.ScheduledChangeApplying
+	applying, err := e.changes.GetAll(&models.ScheduledChangeFilter{Status: &status})
+	if err != nil {
+		return report, fmt.Errorf("failed to get applying scheduled changes: %w", err)
+	}
+	for _, change := range applying {
+		if change.ServerID == nil || !change.Missed(e.now()) {
+			continue
+		}
+
+		result, err := e.recover(change)
+		if err != nil {
+			return report, err
+		}
+		if result != nil {
+			report.add(*result)
+		}
+	}
+
+	return report, nil
+}
+
+// execute expires a due change whose window has closed, or claims and
+// applies it, recording it in the change history on behalf of the actor who
+// scheduled it. It returns no result when the change was resolved or claimed
+// elsewhere in the meantime.
+func (e *Executor) execute(ctx context.Context, change models.ScheduledChange, now time.Time) (*ChangeResult, error) {
+	result := ChangeResult{ChangeID: change.ID, ServerID: *change.ServerID}
+	if change.Missed(now) {
+		result.Status = models.ScheduledChangeExpired
+		result.Reason = fmt.Sprintf("maintenance window closed at %s before the change was applied", change.WindowEnd.Format(time.RFC3339))
+		log.Printf("Scheduled change %d of server %d expired: %s", change.ID, result.ServerID, result.Reason)
+		return e.resolve(result)
+	}
+
+	if _, err := e.changes.Claim(change.ID); err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			log.Printf("Skipping scheduled change %d: %v", change.ID, err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim scheduled change %d: %w", change.ID, err)
+	}
+
+	ctx = models.WithChangeMetadata(ctx, models.ChangeMetadata{
+		Actor:     change.RequestedBy,
+		RequestID: fmt.Sprintf("scheduled-change-%d", change.ID),
+		Source:    models.ChangeSourceScheduled,
+	})
+	result.Status = models.ScheduledChangeApplied
+	if _, err := e.servers.Update(ctx, result.ServerID, &models.UpdateServerRequest{OSID: change.OSID}); err != nil {
+		result.Status, result.Reason = models.ScheduledChangeFailed, err.Error()
+		log.Printf("Error applying scheduled change %d to server %d: %v", change.ID, result.ServerID, err)
+	}
+
+	return e.resolve(result)
+}
+
+// resolve records the outcome of a change. It returns no result when the
+// change was resolved elsewhere in the meantime.
+func (e *Executor) resolve(result ChangeResult) (*ChangeResult, error) {
+	if _, err := e.changes.Resolve(result.ChangeID, result.Status, result.Reason); err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			log.Printf("Skipping scheduled change %d: %v", result.ChangeID, err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to resolve scheduled change %d: %w", result.ChangeID, err)
+	}
+	return &result, nil
+}
+
+// recover resolves a change whose application was interrupted after it was
+// claimed: applied when its server runs the target OS, failed otherwise
+func (e *Executor) recover(change models.ScheduledChange) (*ChangeResult, error) {
+	result := ChangeResult{ChangeID: change.ID, ServerID: *change.ServerID, Status: models.ScheduledChangeApplied}
+
+	server, err := e.servers.GetByID(result.ServerID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get server %d of scheduled change %d: %w", result.ServerID, change.ID, err)
+	}
+	if server == nil || server.OSID != change.OSID {
+		result.Status = models.ScheduledChangeFailed
+		result.Reason = "interrupted while the change was applied"
+	}
+
+	log.Printf("Resolving interrupted scheduled change %d of server %d as %s", change.ID, result.ServerID, result.Status)
+	return e.resolve(result)
+}
+
+// Schedule runs the executor right away and then every interval until ctx
+// is done, logging the outcome of runs that resolved changes
+func (e *Executor) Schedule(ctx context.Context, interval time.Duration) {
+	e.scheduledRun(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.scheduledRun(ctx)
+		}
+	}
+}
+
+// scheduledRun runs the executor, logging the outcome
+func (e *Executor) scheduledRun(ctx context.Context) {
+	report, err := e.Run(ctx)
+	if err != nil {
+		log.Printf("Error applying scheduled changes: %v", err)
+		return
+	}
+	if len(report.Changes) > 0 {
+		log.Printf("Applied scheduled changes: %s", report.Summary())
+	}
+}
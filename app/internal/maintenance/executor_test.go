@@ -0,0 +1,232 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"infra-dashboard/internal/database"
+	"infra-dashboard/internal/models"
+)
+
+// failingServers fails the updates of one server
+type failingServers struct {
+	database.ServerStore
+	serverID int
+}
+
+func (s *failingServers) Update(ctx context.Context, id int, req *models.UpdateServerRequest) (*models.Server, error) {
+	if id == s.serverID {
+		return nil, errors.New("agent unreachable")
+	}
+	return s.ServerStore.Update(ctx, id, req)
+}
+
+// cancellingServers tries to cancel the change being applied while it
+// updates the server
+type cancellingServers struct {
+	database.ServerStore
+	changes  database.ScheduledChangeStore
+	changeID int
+	err      error
+}
+
+func (s *cancellingServers) Update(ctx context.Context, id int, req *models.UpdateServerRequest) (*models.Server, error) {
+	_, s.err = s.changes.Resolve(s.changeID, models.ScheduledChangeCancelled, "")
+	return s.ServerStore.Update(ctx, id, req)
+}
+
+// staleChanges cancels a change after listing it, like a cancellation or
+// another executor racing the run
+type staleChanges struct {
+	database.ScheduledChangeStore
+	changeID int
+}
+
+func (s *staleChanges) GetAll(filter *models.ScheduledChangeFilter) ([]models.ScheduledChange, error) {
+	changes, err := s.ScheduledChangeStore.GetAll(filter)
+	if err == nil && filter.Status != nil && *filter.Status == models.ScheduledChangePending {
+		_, err = s.ScheduledChangeStore.Resolve(s.changeID, models.ScheduledChangeCancelled, "")
+	}
+	return changes, err
+}
+
+func TestExecutor_Run(t *testing.T) {
+	stores := database.NewMemoryStores(database.NewMemoryDB())
+	ctx := context.Background()
+
+	ubuntu, _ := stores.OS.Create(ctx, &models.CreateOSRequest{Name: "Ubuntu", Version: "20.04", EndOfSupport: "2025-04-30"})
+	noble, _ := stores.OS.Create(ctx, &models.CreateOSRequest{Name: "Ubuntu", Version: "24.04", EndOfSupport: "2029-04-30"})
+
+	var servers []*models.Server
+	for _, name := range []string{"web-01", "web-02", "db-01", "db-02"} {
+		server, err := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: name, OSID: ubuntu.ID})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		servers = append(servers, server)
+	}
+
+	now := time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC)
+	schedule := func(server *models.Server, start, end time.Time) *models.ScheduledChange {
+		t.Helper()
+		change, err := stores.Changes.Create(models.WithChangeMetadata(ctx, models.ChangeMetadata{Actor: "alice"}), &models.CreateScheduledChangeRequest{
+			ServerID: server.ID, OSID: noble.ID, WindowStart: start.Format(time.RFC3339), WindowEnd: end.Format(time.RFC3339),
+		})
+		if err != nil {
+			t.Fatalf("Failed to schedule change: %v", err)
+		}
+		return change
+	}
+	applied := schedule(servers[0], now.Add(-time.Hour), now.Add(time.Hour))
+	expired := schedule(servers[1], now.Add(-26*time.Hour), now.Add(-22*time.Hour))
+	failed := schedule(servers[2], now.Add(-time.Hour), now.Add(time.Hour))
+	upcoming := schedule(servers[3], now.Add(time.Hour), now.Add(3*time.Hour))
+
+	executor := NewExecutor(stores.Changes, &failingServers{ServerStore: stores.Servers, serverID: servers[2].ID})
+	executor.now = func() time.Time { return now }
+
+	report, err := executor.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Applied != 1 || report.Failed != 1 || report.Expired != 1 || len(report.Changes) != 3 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	for id, status := range map[int]string{
+		applied.ID:  models.ScheduledChangeApplied,
+		expired.ID:  models.ScheduledChangeExpired,
+		failed.ID:   models.ScheduledChangeFailed,
+		upcoming.ID: models.ScheduledChangePending,
+	} {
+		change, _ := stores.Changes.GetByID(id)
+		if change.Status != status {
+			t.Errorf("Expected change %d to be %s, got %s", id, status, change.Status)
+		}
+		if (status == models.ScheduledChangePending) != (change.ResolvedAt == nil) {
+			t.Errorf("Unexpected resolution time of change %d: %v", id, change.ResolvedAt)
+		}
+	}
+	if change, _ := stores.Changes.GetByID(failed.ID); change.Reason != "agent unreachable" {
+		t.Errorf("Expected the failure as the reason, got %q", change.Reason)
+	}
+
+	// Only the applied change moved its server, recorded on behalf of the requester
+	if server, _ := stores.Servers.GetByID(servers[0].ID); server.OSID != noble.ID {
+		t.Errorf("Expected web-01 to run Ubuntu 24.04, got OS %d", server.OSID)
+	}
+	for _, server := range servers[1:] {
+		if current, _ := stores.Servers.GetByID(server.ID); current.OSID != ubuntu.ID {
+			t.Errorf("Expected %s to keep its operating system, got OS %d", server.Name, current.OSID)
+		}
+	}
+	history, err := stores.ChangeHistory.GetByServerID(servers[0].ID, 1)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected the applied change in the history, got %+v, %v", history, err)
+	}
+	if history[0].ChangeType != models.ChangeTypeOSChanged || history[0].ChangedBy != "alice" ||
+		history[0].Source != models.ChangeSourceScheduled || history[0].RequestID != "scheduled-change-1" {
+		t.Errorf("Unexpected history entry: %+v", history[0])
+	}
+
+	// Resolved changes are not run again
+	report, err = executor.Run(ctx)
+	if err != nil || len(report.Changes) != 0 {
+		t.Errorf("Expected nothing to run, got %+v, %v", report, err)
+	}
+}
+
+func newTestChange(t *testing.T, stores *database.Stores, name string, start, end time.Time) (*models.Server, *models.ScheduledChange, *models.OS) {
+	t.Helper()
+	ctx := context.Background()
+
+	focal, _ := stores.OS.Create(ctx, &models.CreateOSRequest{Name: "Ubuntu " + name, Version: "20.04", EndOfSupport: "2025-04-30"})
+	noble, _ := stores.OS.Create(ctx, &models.CreateOSRequest{Name: "Ubuntu " + name, Version: "24.04", EndOfSupport: "2029-04-30"})
+	server, err := stores.Servers.Create(ctx, &models.CreateServerRequest{Name: name, OSID: focal.ID})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	change, err := stores.Changes.Create(ctx, &models.CreateScheduledChangeRequest{
+		ServerID: server.ID, OSID: noble.ID, WindowStart: start.Format(time.RFC3339), WindowEnd: end.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("Failed to schedule change: %v", err)
+	}
+	return server, change, noble
+}
+
+func TestExecutor_Claims(t *testing.T) {
+	stores := database.NewMemoryStores(database.NewMemoryDB())
+	now := time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC)
+
+	// A change cancelled after it was listed is not applied
+	server, change, _ := newTestChange(t, stores, "web-01", now.Add(-time.Hour), now.Add(time.Hour))
+	executor := NewExecutor(&staleChanges{ScheduledChangeStore: stores.Changes, changeID: change.ID}, stores.Servers)
+	executor.now = func() time.Time { return now }
+	report, err := executor.Run(context.Background())
+	if err != nil || len(report.Changes) != 0 {
+		t.Errorf("Expected the cancelled change to be skipped, got %+v, %v", report, err)
+	}
+	if current, _ := stores.Servers.GetByID(server.ID); current.OSID != server.OSID {
+		t.Errorf("Expected the server of the cancelled change to keep its OS, got %d", current.OSID)
+	}
+	if history, _ := stores.ChangeHistory.GetByServerID(server.ID, 10); len(history) != 1 {
+		t.Errorf("Expected no history for the cancelled change, got %+v", history)
+	}
+
+	// A claimed change can no longer be cancelled
+	server, change, noble := newTestChange(t, stores, "web-02", now.Add(-time.Hour), now.Add(time.Hour))
+	servers := &cancellingServers{ServerStore: stores.Servers, changes: stores.Changes, changeID: change.ID}
+	executor = NewExecutor(stores.Changes, servers)
+	executor.now = func() time.Time { return now }
+	if report, err := executor.Run(context.Background()); err != nil || report.Applied != 1 {
+		t.Errorf("Expected the change to be applied, got %+v, %v", report, err)
+	}
+	if !errors.Is(servers.err, database.ErrConflict) {
+		t.Errorf("Expected ErrConflict cancelling a claimed change, got %v", servers.err)
+	}
+	if applied, _ := stores.Changes.GetByID(change.ID); applied.Status != models.ScheduledChangeApplied {
+		t.Errorf("Expected the change to be applied, got %s", applied.Status)
+	}
+	if current, _ := stores.Servers.GetByID(server.ID); current.OSID != noble.ID {
+		t.Errorf("Expected the server to run the target OS, got %d", current.OSID)
+	}
+}
+
+func TestExecutor_InterruptedChanges(t *testing.T) {
+	stores := database.NewMemoryStores(database.NewMemoryDB())
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC)
+
+	// Claimed changes whose executor stopped before resolving them
+	_, updated, noble := newTestChange(t, stores, "web-01", now.Add(-3*time.Hour), now.Add(-time.Hour))
+	_, interrupted, _ := newTestChange(t, stores, "web-02", now.Add(-3*time.Hour), now.Add(-time.Hour))
+	_, running, _ := newTestChange(t, stores, "web-03", now.Add(-time.Hour), now.Add(time.Hour))
+	for _, change := range []*models.ScheduledChange{updated, interrupted, running} {
+		if _, err := stores.Changes.Claim(change.ID); err != nil {
+			t.Fatalf("Failed to claim change: %v", err)
+		}
+	}
+	if _, err := stores.Servers.Update(ctx, *updated.ServerID, &models.UpdateServerRequest{OSID: noble.ID}); err != nil {
+		t.Fatalf("Failed to update server: %v", err)
+	}
+
+	executor := NewExecutor(stores.Changes, stores.Servers)
+	executor.now = func() time.Time { return now }
+	report, err := executor.Run(ctx)
+	if err != nil || report.Applied != 1 || report.Failed != 1 {
+		t.Errorf("Unexpected report: %+v, %v", report, err)
+	}
+
+	for id, status := range map[int]string{
+		updated.ID:     models.ScheduledChangeApplied,
+		interrupted.ID: models.ScheduledChangeFailed,
+		running.ID:     models.ScheduledChangeApplying, // Its window is still open
+	} {
+		if change, _ := stores.Changes.GetByID(id); change.Status != status {
+			t.Errorf("Expected change %d to be %s, got %s", id, status, change.Status)
+		}
+	}
+}
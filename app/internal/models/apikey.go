@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Roles granted to API clients, in increasing order of privilege. Each role
+// includes the permissions of the roles before it.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// roleRanks orders the roles by privilege
+var roleRanks = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ValidateRole checks that a role is one of the known values
+func ValidateRole(role string) error {
+	if _, exists := roleRanks[role]; !exists {
+		return fmt.Errorf("invalid role %q: must be one of %s, %s, %s", role, RoleViewer, RoleOperator, RoleAdmin)
+	}
+	return nil
+}
+
+// RoleAllows reports whether role grants the permissions of the required role
+func RoleAllows(role, required string) bool {
+	rank, exists := roleRanks[role]
+	return exists && rank >= roleRanks[required]
+}
+
+// API key status filter values
+const (
+	APIKeyStatusActive  = "active"
+	APIKeyStatusRevoked = "revoked"
+)
+
+// APIKey is a credential granting a role to an API client. Only a hash of the
+// key is stored; the key itself is returned once, when it is issued.
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Role       string     `json:"role" db:"role"`
+	Prefix     string     `json:"prefix" db:"key_prefix"` // First characters of the key, to recognise it
+	CreatedBy  string     `json:"created_by,omitempty" db:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Active reports whether the key has not been revoked
+func (k APIKey) Active() bool {
+	return k.RevokedAt == nil
+}
+
+// IssuedAPIKey is an API key along with its secret, returned only when the
+// key is created or rotated
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" validate:"required"`
+	Role string `json:"role" validate:"required"` // 'viewer', 'operator', 'admin'
+}
+
+// APIKeyFilter represents filters and pagination for querying API keys
+type APIKeyFilter struct {
+	Status *string // 'active', 'revoked'
+	Role   *string
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		expected bool
+	}{
+		{RoleAdmin, RoleViewer, true},
+		{RoleAdmin, RoleAdmin, true},
+		{RoleOperator, RoleViewer, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleViewer, RoleOperator, false},
+		{"root", RoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		if got := RoleAllows(tt.role, tt.required); got != tt.expected {
+			t.Errorf("RoleAllows(%q, %q) = %v, expected %v", tt.role, tt.required, got, tt.expected)
+		}
+	}
+
+	if err := ValidateRole(RoleOperator); err != nil {
+		t.Errorf("Expected operator to be valid, got %v", err)
+	}
+	if err := ValidateRole("root"); err == nil {
+		t.Error("Expected an error for an unknown role")
+	}
+}
@@ -0,0 +1,249 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Campaign plans the migration of the servers running a source operating
+// system to a target operating system by a deadline. Its servers are
+// selected by Scope, or listed explicitly in ServerIDs.
+type Campaign struct {
+	ID         int           `json:"id" db:"id"`
+	Name       string        `json:"name" db:"name"`
+	SourceOSID int           `json:"source_os_id" db:"source_os_id"`
+	TargetOSID int           `json:"target_os_id" db:"target_os_id"`
+	Deadline   time.Time     `json:"deadline" db:"deadline"`
+	Owner      string        `json:"owner" db:"owner"`
+	Selection  string        `json:"selection" db:"selection"` // 'scope' or 'servers'
+	Scope      CampaignScope `json:"scope"`
+	ServerIDs  []int         `json:"server_ids,omitempty" db:"-"` // Explicit servers, ascending
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// Campaign selections: servers matching the scope, or the listed servers.
+// Listed servers cannot be deleted while the campaign exists.
+const (
+	CampaignSelectionScope   = "scope"
+	CampaignSelectionServers = "servers"
+)
+
+// CampaignScope selects the servers of a campaign by their inventory
+// attributes. Empty attributes match every server.
+type CampaignScope struct {
+	Environment string `json:"environment,omitempty" db:"scope_environment"`
+	Role        string `json:"role,omitempty" db:"scope_role"`
+	OwnerTeam   string `json:"owner_team,omitempty" db:"scope_owner_team"`
+	Location    string `json:"location,omitempty" db:"scope_location"`
+}
+
+// IsEmpty reports whether the scope matches every server
+func (s CampaignScope) IsEmpty() bool {
+	return s == CampaignScope{}
+}
+
+// Matches reports whether a server has every attribute of the scope
+func (s CampaignScope) Matches(server Server) bool {
+	return (s.Environment == "" || s.Environment == server.Environment) &&
+		(s.Role == "" || s.Role == server.Role) &&
+		(s.OwnerTeam == "" || s.OwnerTeam == server.OwnerTeam) &&
+		(s.Location == "" || s.Location == server.Location)
+}
+
+// PastDeadline reports whether the deadline day is over at now
+func (c Campaign) PastDeadline(now time.Time) bool {
+	return !now.Before(c.Deadline.AddDate(0, 0, 1))
+}
+
+// MaxCampaignFieldLength is the longest name or owner a campaign can have
+const MaxCampaignFieldLength = 255
+
+// ValidateCampaignFields checks that the name and owner of a campaign fit in
+// MaxCampaignFieldLength characters
+func ValidateCampaignFields(name, owner string) error {
+	if err := validateLength("name", name, MaxCampaignFieldLength); err != nil {
+		return err
+	}
+	return validateLength("owner", owner, MaxCampaignFieldLength)
+}
+
+// CreateCampaignRequest represents the request body for creating a campaign.
+// At most one of Scope and ServerIDs may be set; a campaign with neither
+// covers every server running the source OS.
+type CreateCampaignRequest struct {
+	Name       string         `json:"name" validate:"required"`
+	SourceOSID int            `json:"source_os_id" validate:"required"`
+	TargetOSID int            `json:"target_os_id" validate:"required"`
+	Deadline   string         `json:"deadline" validate:"required"` // Expected format: YYYY-MM-DD
+	Owner      string         `json:"owner" validate:"required"`
+	Scope      *CampaignScope `json:"scope,omitempty"`
+	ServerIDs  []int          `json:"server_ids,omitempty"`
+}
+
+// UpdateCampaignRequest represents the request body for updating a campaign.
+// The operating systems and servers of a campaign cannot be changed.
+type UpdateCampaignRequest struct {
+	Name     string `json:"name,omitempty"`
+	Deadline string `json:"deadline,omitempty"` // Expected format: YYYY-MM-DD
+	Owner    string `json:"owner,omitempty"`
+}
+
+// CampaignFilter represents filters and pagination for querying campaigns
+type CampaignFilter struct {
+	Owner      *string
+	SourceOSID *int
+	TargetOSID *int
+	Limit      int
+	Offset     int
+}
+
+// CampaignServer is a server of a campaign with the time it reached the
+// target operating system
+type CampaignServer struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	OSID        int        `json:"os_id"`
+	Environment string     `json:"environment,omitempty"`
+	OwnerTeam   string     `json:"owner_team,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// CampaignProgress is the state of the servers of a campaign. Overdue
+// servers are the remaining servers once the deadline has passed.
+type CampaignProgress struct {
+	CampaignID       int              `json:"campaign_id"`
+	Deadline         time.Time        `json:"deadline"`
+	TotalServers     int              `json:"total_servers"`
+	CompletedCount   int              `json:"completed"`
+	RemainingCount   int              `json:"remaining"`
+	OverdueCount     int              `json:"overdue"`
+	PercentComplete  float64          `json:"percent_complete"`
+	CompletedServers []CampaignServer `json:"completed_servers"`
+	RemainingServers []CampaignServer `json:"remaining_servers"`
+	OverdueServers   []CampaignServer `json:"overdue_servers"`
+	GeneratedAt      time.Time        `json:"generated_at"`
+}
+
+// ComputeCampaignProgress computes the progress of a campaign from the
+// current servers and their os_changed history. A campaign covers its
+// explicit servers, or the servers of its scope that run the source OS or
+// left it since the campaign was created. A server is completed once it runs
+// the target OS, at the time of its last move to it.
+func ComputeCampaignProgress(campaign Campaign, servers []Server, history []ServerChangeHistory, now time.Time) CampaignProgress {
+	left := make(map[int]bool)
+	completedAt := make(map[int]time.Time)
+	for _, record := range history {
+		if record.ChangeType != ChangeTypeOSChanged || record.ServerID == nil {
+			continue
+		}
+		id := *record.ServerID
+		if record.OldOSID != nil && *record.OldOSID == campaign.SourceOSID && !record.ChangedAt.Before(campaign.CreatedAt) {
+			left[id] = true
+		}
+		if record.NewOSID != nil && *record.NewOSID == campaign.TargetOSID && record.ChangedAt.After(completedAt[id]) {
+			completedAt[id] = record.ChangedAt
+		}
+	}
+
+	explicit := make(map[int]bool)
+	for _, id := range campaign.ServerIDs {
+		explicit[id] = true
+	}
+
+	progress := CampaignProgress{
+		CampaignID:       campaign.ID,
+		Deadline:         campaign.Deadline,
+		CompletedServers: []CampaignServer{},
+		RemainingServers: []CampaignServer{},
+		OverdueServers:   []CampaignServer{},
+		GeneratedAt:      now,
+	}
+	for _, server := range servers {
+		if campaign.Selection == CampaignSelectionServers {
+			if !explicit[server.ID] {
+				continue
+			}
+		} else if !campaign.Scope.Matches(server) || (server.OSID != campaign.SourceOSID && !left[server.ID]) {
+			continue
+		}
+
+		entry := CampaignServer{
+			ID:          server.ID,
+			Name:        server.Name,
+			OSID:        server.OSID,
+			Environment: server.Environment,
+			OwnerTeam:   server.OwnerTeam,
+		}
+		if server.OSID == campaign.TargetOSID {
+			if at, exists := completedAt[server.ID]; exists {
+				entry.CompletedAt = &at
+			}
+			progress.CompletedServers = append(progress.CompletedServers, entry)
+			continue
+		}
+		progress.RemainingServers = append(progress.RemainingServers, entry)
+	}
+
+	for _, list := range [][]CampaignServer{progress.CompletedServers, progress.RemainingServers} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	if campaign.PastDeadline(now) {
+		progress.OverdueServers = progress.RemainingServers
+	}
+
+	progress.CompletedCount = len(progress.CompletedServers)
+	progress.RemainingCount = len(progress.RemainingServers)
+	progress.OverdueCount = len(progress.OverdueServers)
+	progress.TotalServers = progress.CompletedCount + progress.RemainingCount
+	if progress.TotalServers > 0 {
+		progress.PercentComplete = float64(progress.CompletedCount) / float64(progress.TotalServers) * 100
+	}
+
+	return progress
+}
+
+// BurndownPoint is the number of servers of a campaign remaining at the end
+// of one interval, next to the ideal line from every server at creation to
+// none at the deadline
+type BurndownPoint struct {
+	PeriodStart time.Time `json:"period_start"`
+	Completed   int       `json:"completed"`
+	Remaining   int       `json:"remaining"`
+	Ideal       float64   `json:"ideal"`
+}
+
+// CampaignBurndown returns a point per interval from the creation of a
+// campaign to now. Servers of the campaign that completed without a recorded
+// move to the target OS count as completed from the creation of the
+// campaign.
+func CampaignBurndown(campaign Campaign, progress CampaignProgress, interval string, now time.Time) []BurndownPoint {
+	points := []BurndownPoint{}
+
+	end := campaign.Deadline.AddDate(0, 0, 1)
+	span := end.Sub(campaign.CreatedAt)
+	for start := PeriodStart(campaign.CreatedAt, interval); !start.After(now); start = NextPeriodStart(start, interval) {
+		periodEnd := NextPeriodStart(start, interval)
+		if periodEnd.After(now) {
+			periodEnd = now
+		}
+
+		point := BurndownPoint{PeriodStart: start}
+		for _, server := range progress.CompletedServers {
+			if server.CompletedAt == nil || server.CompletedAt.Before(periodEnd) {
+				point.Completed++
+			}
+		}
+		point.Remaining = progress.TotalServers - point.Completed
+
+		if elapsed := periodEnd.Sub(campaign.CreatedAt); span > 0 && elapsed < span {
+			point.Ideal = float64(progress.TotalServers) * (1 - float64(elapsed)/float64(span))
+			if point.Ideal > float64(progress.TotalServers) {
+				point.Ideal = float64(progress.TotalServers)
+			}
+		}
+		points = append(points, point)
+	}
+
+	return points
+}
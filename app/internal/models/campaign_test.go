@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// campaignServerNames returns the names of campaign servers
+func campaignServerNames(servers []CampaignServer) []string {
+	names := []string{}
+	for _, server := range servers {
+		names = append(names, server.Name)
+	}
+	return names
+}
+
+// osChange returns an os_changed history record
+func osChange(id, serverID, oldOSID, newOSID int, at time.Time) ServerChangeHistory {
+	return ServerChangeHistory{ID: id, ServerID: &serverID, ChangeType: ChangeTypeOSChanged, OldOSID: &oldOSID, NewOSID: &newOSID, ChangedAt: at}
+}
+
+func TestComputeCampaignProgress(t *testing.T) {
+	created := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	campaign := Campaign{
+		ID: 1, SourceOSID: 1, TargetOSID: 2, Selection: CampaignSelectionScope,
+		Deadline:  time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC),
+		Scope:     CampaignScope{Environment: EnvironmentProd},
+		CreatedAt: created,
+	}
+	servers := []Server{
+		{ID: 1, Name: "web-01", OSID: 2, Environment: EnvironmentProd},
+		{ID: 2, Name: "web-02", OSID: 1, Environment: EnvironmentProd},
+		{ID: 3, Name: "db-01", OSID: 1, Environment: EnvironmentDev},
+		{ID: 4, Name: "web-03", OSID: 3, Environment: EnvironmentProd},
+		{ID: 5, Name: "web-04", OSID: 2, Environment: EnvironmentProd},
+	}
+	history := []ServerChangeHistory{
+		osChange(1, 5, 1, 2, created.AddDate(0, -1, 0)), // Upgraded before the campaign
+		osChange(2, 1, 1, 2, created.AddDate(0, 0, 2)),
+		osChange(3, 4, 1, 3, created.AddDate(0, 0, 4)), // Moved to another OS
+	}
+
+	onTime := ComputeCampaignProgress(campaign, servers, history, created.AddDate(0, 0, 9))
+	if fmt.Sprint(campaignServerNames(onTime.CompletedServers)) != "[web-01]" ||
+		fmt.Sprint(campaignServerNames(onTime.RemainingServers)) != "[web-02 web-03]" ||
+		onTime.OverdueCount != 0 || onTime.TotalServers != 3 {
+		t.Errorf("Unexpected progress before the deadline: %+v", onTime)
+	}
+	if at := onTime.CompletedServers[0].CompletedAt; at == nil || !at.Equal(created.AddDate(0, 0, 2)) {
+		t.Errorf("Expected web-01 completed at its OS change, got %v", at)
+	}
+	if math.Abs(onTime.PercentComplete-100.0/3) > 0.001 {
+		t.Errorf("Expected a third of the campaign complete, got %f", onTime.PercentComplete)
+	}
+
+	// Remaining servers are overdue once the deadline day is over
+	late := ComputeCampaignProgress(campaign, servers, history, created.AddDate(0, 0, 10))
+	if fmt.Sprint(campaignServerNames(late.OverdueServers)) != "[web-02 web-03]" || late.OverdueCount != 2 {
+		t.Errorf("Unexpected overdue servers: %+v", late)
+	}
+
+	// Explicit servers are tracked whatever their OS, and deleted ones leave
+	// the campaign instead of widening it to the whole fleet
+	campaign.Selection = CampaignSelectionServers
+	campaign.ServerIDs = []int{3, 5}
+	explicit := ComputeCampaignProgress(campaign, servers, history, created)
+	if fmt.Sprint(campaignServerNames(explicit.CompletedServers)) != "[web-04]" ||
+		fmt.Sprint(campaignServerNames(explicit.RemainingServers)) != "[db-01]" {
+		t.Errorf("Unexpected progress of explicit servers: %+v", explicit)
+	}
+	campaign.ServerIDs = nil
+	if empty := ComputeCampaignProgress(campaign, servers, history, created); empty.TotalServers != 0 || empty.PercentComplete != 0 {
+		t.Errorf("Expected no server once the listed servers are deleted, got %+v", empty)
+	}
+}
+
+func TestCampaignBurndown(t *testing.T) {
+	created := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	campaign := Campaign{
+		ID: 1, SourceOSID: 1, TargetOSID: 2, Selection: CampaignSelectionScope,
+		Deadline:  time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC),
+		CreatedAt: created,
+	}
+	servers := []Server{
+		{ID: 1, Name: "web-01", OSID: 2},
+		{ID: 2, Name: "web-02", OSID: 1},
+		{ID: 3, Name: "web-03", OSID: 1},
+	}
+	history := []ServerChangeHistory{osChange(1, 1, 1, 2, created.Add(58*time.Hour))}
+	now := created.Add(84 * time.Hour)
+
+	progress := ComputeCampaignProgress(campaign, servers, history, now)
+	points := CampaignBurndown(campaign, progress, IntervalDay, now)
+
+	expected := []struct {
+		completed, remaining int
+		ideal                float64
+	}{
+		{0, 3, 2.7},
+		{0, 3, 2.4},
+		{1, 2, 2.1},
+		{1, 2, 1.95}, // The current day ends now
+	}
+	if len(points) != len(expected) {
+		t.Fatalf("Expected %d points, got %+v", len(expected), points)
+	}
+	for i, want := range expected {
+		point := points[i]
+		if !point.PeriodStart.Equal(created.AddDate(0, 0, i)) || point.Completed != want.completed ||
+			point.Remaining != want.remaining || math.Abs(point.Ideal-want.ideal) > 0.001 {
+			t.Errorf("Point %d = %+v, expected %+v", i, point, want)
+		}
+	}
+
+	// Past the deadline the ideal line stays at zero
+	late := created.AddDate(0, 0, 20)
+	points = CampaignBurndown(campaign, ComputeCampaignProgress(campaign, servers, history, late), IntervalWeek, late)
+	if last := points[len(points)-1]; last.Ideal != 0 || last.Remaining != 2 {
+		t.Errorf("Unexpected last point past the deadline: %+v", last)
+	}
+}
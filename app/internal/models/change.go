@@ -0,0 +1,157 @@
+package models
+
+import "context"
+
+// Change sources recorded with each change
+const (
+	ChangeSourceAPI          = "api"
+	ChangeSourceImport       = "import"
+	ChangeSourceRegistration = "registration"
+	ChangeSourceCatalogSync  = "catalog_sync"
+	ChangeSourceScheduled    = "scheduled_change"
+)
+
+// ChangeMetadata identifies who made a change, within which request and
+// through which channel
+type ChangeMetadata struct {
+	Actor     string
+	RequestID string
+	Source    string
+}
+
+type changeMetadataKey struct{}
+
+// WithChangeMetadata returns a copy of ctx carrying the change metadata
+func WithChangeMetadata(ctx context.Context, meta ChangeMetadata) context.Context {
+	return context.WithValue(ctx, changeMetadataKey{}, meta)
+}
+
+// ChangeMetadataFromContext returns the change metadata carried by ctx, or
+// empty metadata when there is none
+func ChangeMetadataFromContext(ctx context.Context) ChangeMetadata {
+	meta, _ := ctx.Value(changeMetadataKey{}).(ChangeMetadata)
+	return meta
+}
+
+// FieldChange holds the values of a field before and after a change. Old is
+// nil for created records and New is nil for deleted ones.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// serverFields returns the recorded fields of a server by their JSON name
+func serverFields(server *Server) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             server.Name,
+		"os_id":            server.OSID,
+		"environment":      server.Environment,
+		"role":             server.Role,
+		"owner_team":       server.OwnerTeam,
+		"location":         server.Location,
+		"description":      server.Description,
+		"extended_support": server.ExtendedSupport,
+	}
+}
+
+// DiffServers returns the fields that differ between two states of a server.
+// A nil before or after stands for a server being created or deleted, in
+// which case only the fields that are set are included.
+func DiffServers(before, after *Server) map[string]FieldChange {
+	var old, current map[string]interface{}
+	if before != nil {
+		old = serverFields(before)
+	}
+	if after != nil {
+		current = serverFields(after)
+	}
+	return diffFields(old, current)
+}
+
+// diffFields returns the fields that differ between two states of a record,
+// given as field values by JSON name. A nil state stands for a record being
+// created or deleted, in which case only the fields that are set are included.
+func diffFields(before, after map[string]interface{}) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	switch {
+	case before == nil:
+		for field, value := range after {
+			if value != "" && value != 0 && value != false {
+				changes[field] = FieldChange{New: value}
+			}
+		}
+	case after == nil:
+		for field, value := range before {
+			if value != "" && value != 0 && value != false {
+				changes[field] = FieldChange{Old: value}
+			}
+		}
+	default:
+		for field, value := range after {
+			if before[field] != value {
+				changes[field] = FieldChange{Old: before[field], New: value}
+			}
+		}
+	}
+	return changes
+}
+
+// NewServerChange builds the change history record of a server mutation
+// from its state before and after the change, with a nil before for a
+// creation and a nil after for a deletion. It returns false when no field
+// changed. The operating systems are taken from the OS attached to each
+// state. The new OS is recorded on every record but a deletion so that the
+// history can be replayed, the old OS only when it was changed or deleted.
+// ChangedAt is left to the store.
+func NewServerChange(before, after *Server, meta ChangeMetadata) (ServerChangeHistory, bool) {
+	changes := DiffServers(before, after)
+	if len(changes) == 0 {
+		return ServerChangeHistory{}, false
+	}
+
+	current := after
+	var changeType string
+	switch {
+	case before == nil:
+		changeType = ChangeTypeCreated
+	case after == nil:
+		changeType = ChangeTypeDeleted
+		current = before
+	case before.OSID != after.OSID:
+		changeType = ChangeTypeOSChanged
+	case len(changes) == 1 && before.Name != after.Name:
+		changeType = ChangeTypeRenamed
+	default:
+		changeType = ChangeTypeUpdated
+	}
+
+	id := current.ID
+	record := ServerChangeHistory{
+		ServerID:    &id,
+		ServerName:  current.Name,
+		ChangeType:  changeType,
+		Environment: current.Environment,
+		Role:        current.Role,
+		OwnerTeam:   current.OwnerTeam,
+		Location:    current.Location,
+		ChangedBy:   meta.Actor,
+		RequestID:   meta.RequestID,
+		Source:      meta.Source,
+		Changes:     changes,
+	}
+	if before != nil && before.OS != nil && (changeType == ChangeTypeOSChanged || changeType == ChangeTypeDeleted) {
+		record.OldOSID, record.OldOSName, record.OldOSVersion = osSnapshot(before.OS)
+	}
+	if after != nil && after.OS != nil {
+		record.NewOSID, record.NewOSName, record.NewOSVersion = osSnapshot(after.OS)
+	}
+
+	return record, true
+}
+
+// osSnapshot returns the ID, name and version of an operating system as
+// recorded in the change history
+func osSnapshot(os *OS) (*int, *string, *string) {
+	id, name, version := os.ID, os.Name, os.Version
+	return &id, &name, &version
+}
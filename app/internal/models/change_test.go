@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewServerChange(t *testing.T) {
+	ubuntu := &OS{ID: 1, Name: "Ubuntu", Version: "22.04"}
+	debian := &OS{ID: 2, Name: "Debian", Version: "12"}
+	server := Server{ID: 7, Name: "web-01", OSID: ubuntu.ID, OS: ubuntu, Environment: EnvironmentProd}
+	with := func(change func(s *Server)) *Server {
+		s := server
+		change(&s)
+		return &s
+	}
+
+	tests := []struct {
+		name       string
+		before     *Server
+		after      *Server
+		changeType string
+		fields     []string
+	}{
+		{"created", nil, &server, ChangeTypeCreated, []string{"name", "os_id", "environment"}},
+		{"deleted", &server, nil, ChangeTypeDeleted, []string{"name", "os_id", "environment"}},
+		{"renamed", &server, with(func(s *Server) { s.Name = "web-02" }), ChangeTypeRenamed, []string{"name"}},
+		{"os changed", &server, with(func(s *Server) { s.OSID, s.OS = debian.ID, debian }), ChangeTypeOSChanged, []string{"os_id"}},
+		{"renamed and updated", &server, with(func(s *Server) { s.Name, s.Role = "web-02", "frontend" }), ChangeTypeUpdated, []string{"name", "role"}},
+		{"updated", &server, with(func(s *Server) { s.Description = "Frontend" }), ChangeTypeUpdated, []string{"description"}},
+	}
+
+	meta := ChangeMetadata{Actor: "alice", RequestID: "req-1", Source: ChangeSourceAPI}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, changed := NewServerChange(tt.before, tt.after, meta)
+			if !changed {
+				t.Fatal("Expected a change to be recorded")
+			}
+			if record.ChangeType != tt.changeType {
+				t.Errorf("Expected change type %s, got %s", tt.changeType, record.ChangeType)
+			}
+			if len(record.Changes) != len(tt.fields) {
+				t.Errorf("Expected changed fields %v, got %+v", tt.fields, record.Changes)
+			}
+			for _, field := range tt.fields {
+				if _, exists := record.Changes[field]; !exists {
+					t.Errorf("Expected %s to be recorded as changed, got %+v", field, record.Changes)
+				}
+			}
+			if record.ChangedBy != "alice" || record.RequestID != "req-1" || record.ServerID == nil || *record.ServerID != 7 {
+				t.Errorf("Unexpected record: %+v", record)
+			}
+		})
+	}
+
+	moved, _ := NewServerChange(&server, with(func(s *Server) { s.OSID, s.OS = debian.ID, debian }), meta)
+	if *moved.OldOSName != "Ubuntu" || *moved.NewOSName != "Debian" {
+		t.Errorf("Expected old and new OS to be recorded, got %+v", moved)
+	}
+	renamed, _ := NewServerChange(&server, with(func(s *Server) { s.Name = "web-02" }), meta)
+	if renamed.OldOSID != nil || renamed.NewOSID == nil || *renamed.NewOSID != ubuntu.ID {
+		t.Errorf("Expected only the current OS on a rename, got %+v", renamed)
+	}
+
+	if _, changed := NewServerChange(&server, with(func(s *Server) {}), meta); changed {
+		t.Error("Expected no record when nothing changed")
+	}
+}
+
+func TestChangeMetadataFromContext(t *testing.T) {
+	if meta := ChangeMetadataFromContext(context.Background()); meta != (ChangeMetadata{}) {
+		t.Errorf("Expected empty metadata, got %+v", meta)
+	}
+
+	meta := ChangeMetadata{Actor: "bob", Source: ChangeSourceAPI}
+	if got := ChangeMetadataFromContext(WithChangeMetadata(context.Background(), meta)); got != meta {
+		t.Errorf("Expected %+v, got %+v", meta, got)
+	}
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor marks the end of a page of a list for keyset pagination: the values
+// of the sort fields of the last item of the page, in sort order, and its ID,
+// which breaks ties. The next page holds the items sorting after it, so
+// items created or deleted meanwhile do not shift the following pages.
+type Cursor struct {
+	// Sort is the sort expression of the list, as accepted by ParseSort
+	Sort string `json:"s"`
+	// Values are nil where the item has no value, such as the last seen
+	// time of a server that never reported
+	Values []*string `json:"v"`
+	ID     int       `json:"id"`
+}
+
+// Encode returns the opaque form of the cursor used in query parameters
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses an opaque cursor of a list sorted by fields
+func DecodeCursor(value string, fields []SortField) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if cursor.Sort != FormatSort(fields) || len(cursor.Values) != len(fields) {
+		return nil, fmt.Errorf("cursor does not match the sort order")
+	}
+
+	return &cursor, nil
+}
+
+// FormatSort returns the sort expression of fields, as accepted by ParseSort
+func FormatSort(fields []SortField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// cursorString returns a text cursor value
+func cursorString(value string) *string {
+	return &value
+}
+
+// cursorInt returns an integer cursor value
+func cursorInt(value int) *string {
+	return cursorString(strconv.Itoa(value))
+}
+
+// cursorTime returns a time cursor value, or nil when t is nil
+func cursorTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return cursorString(t.UTC().Format(time.RFC3339Nano))
+}
+
+// errCursorValue returns the error of an invalid cursor value
+func errCursorValue(field string) error {
+	return fmt.Errorf("invalid cursor value for %s", field)
+}
+
+// setCursorString sets dest to a text cursor value
+func setCursorString(dest *string, field string, value *string) error {
+	if value == nil {
+		return errCursorValue(field)
+	}
+	*dest = *value
+	return nil
+}
+
+// setCursorInt sets dest to an integer cursor value
+func setCursorInt(dest *int, field string, value *string) error {
+	if value == nil {
+		return errCursorValue(field)
+	}
+	n, err := strconv.Atoi(*value)
+	if err != nil {
+		return errCursorValue(field)
+	}
+	*dest = n
+	return nil
+}
+
+// setCursorTime sets dest to a time cursor value
+func setCursorTime(dest *time.Time, field string, value *string) error {
+	if value == nil {
+		return errCursorValue(field)
+	}
+	t, err := time.Parse(time.RFC3339Nano, *value)
+	if err != nil {
+		return errCursorValue(field)
+	}
+	*dest = t
+	return nil
+}
+
+// setCursorOptionalTime sets dest to a time cursor value, or to nil for a
+// nil value
+func setCursorOptionalTime(dest **time.Time, field string, value *string) error {
+	if value == nil {
+		*dest = nil
+		return nil
+	}
+	var t time.Time
+	if err := setCursorTime(&t, field, value); err != nil {
+		return err
+	}
+	*dest = &t
+	return nil
+}
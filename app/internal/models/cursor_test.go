@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServerCursor_RoundTrip(t *testing.T) {
+	seen := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	server := Server{
+		ID:         7,
+		Name:       "web-01",
+		OS:         &OS{Name: "Ubuntu", Version: "22.04", EndOfSupport: time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)},
+		LastSeenAt: &seen,
+		CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	fields := []SortField{{Field: "os_version", Desc: true}, {Field: "last_seen_at"}, {Field: "created_at"}}
+
+	cursor, err := DecodeServerCursor(ServerCursor(server, fields).Encode(), fields)
+	if err != nil {
+		t.Fatalf("Failed to decode cursor: %v", err)
+	}
+	decoded, err := cursor.Server(fields)
+	if err != nil {
+		t.Fatalf("Failed to read cursor values: %v", err)
+	}
+	if decoded.ID != 7 || decoded.OS.Version != "22.04" || !decoded.LastSeenAt.Equal(seen) || !decoded.CreatedAt.Equal(server.CreatedAt) {
+		t.Errorf("Unexpected server from cursor: %+v", decoded)
+	}
+
+	// Servers that never reported keep a NULL value
+	server.LastSeenAt = nil
+	cursor, err = DecodeServerCursor(ServerCursor(server, fields).Encode(), fields)
+	if err != nil {
+		t.Fatalf("Failed to decode cursor: %v", err)
+	}
+	if decoded, _ := cursor.Server(fields); decoded.LastSeenAt != nil {
+		t.Errorf("Expected no last seen time, got %v", decoded.LastSeenAt)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	fields := []SortField{{Field: "name"}}
+	valid := ServerCursor(Server{ID: 1, Name: "web-01"}, fields)
+
+	tests := []struct {
+		name   string
+		value  string
+		fields []SortField
+	}{
+		{name: "Not base64", value: "!!", fields: fields},
+		{name: "Not JSON", value: "bm90IGpzb24", fields: fields},
+		{name: "Other sort order", value: valid.Encode(), fields: []SortField{{Field: "name", Desc: true}}},
+		{name: "Missing ID", value: Cursor{Sort: "name", Values: valid.Values}.Encode(), fields: fields},
+		{name: "Invalid value", value: Cursor{Sort: "id", Values: []*string{cursorString("x")}, ID: 1}.Encode(), fields: []SortField{{Field: "id"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeServerCursor(tt.value, tt.fields); err == nil {
+				t.Errorf("Expected %q to be rejected", tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Server import actions reported for each row
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+	ImportActionError     = "error"
+)
+
+// ServerImportRow is a server to create or update in a bulk import. Servers
+// are matched by name and reference their operating system by name and
+// version. Empty fields leave the value of an existing server unchanged.
+type ServerImportRow struct {
+	Line        int    `json:"-"` // Line of the row in the import, for reporting
+	Name        string `json:"name"`
+	OSName      string `json:"os_name"`
+	OSVersion   string `json:"os_version"`
+	Environment string `json:"environment,omitempty"`
+	Role        string `json:"role,omitempty"`
+	OwnerTeam   string `json:"owner_team,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// serverImportColumns lists the accepted CSV columns
+var serverImportColumns = []string{"name", "os_name", "os_version", "environment", "role", "owner_team", "location", "description"}
+
+// ParseServerImportCSV parses a CSV import. The first record is a header
+// naming the columns, in any order; name is required.
+func ParseServerImportCSV(r io.Reader) ([]ServerImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !containsString(serverImportColumns, column) {
+			return nil, fmt.Errorf("unknown CSV column %q: expected %s", column, strings.Join(serverImportColumns, ", "))
+		}
+		if _, exists := columns[column]; exists {
+			return nil, fmt.Errorf("duplicate CSV column %q", column)
+		}
+		columns[column] = i
+	}
+	if _, exists := columns["name"]; !exists {
+		return nil, fmt.Errorf("CSV header must include a name column")
+	}
+
+	var rows []ServerImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(column string) string {
+			if i, exists := columns[column]; exists {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rows = append(rows, ServerImportRow{
+			Line:        line,
+			Name:        field("name"),
+			OSName:      field("os_name"),
+			OSVersion:   field("os_version"),
+			Environment: field("environment"),
+			Role:        field("role"),
+			OwnerTeam:   field("owner_team"),
+			Location:    field("location"),
+			Description: field("description"),
+		})
+	}
+
+	return rows, nil
+}
+
+// ParseServerImportNDJSON parses a newline-delimited JSON import holding one
+// server object per line. Blank lines are skipped.
+func ParseServerImportNDJSON(r io.Reader) ([]ServerImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []ServerImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+
+		var row ServerImportRow
+		if err := decoder.Decode(&row); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", line, err)
+		}
+		row.Line = line
+		row.Name = strings.TrimSpace(row.Name)
+		row.OSName = strings.TrimSpace(row.OSName)
+		row.OSVersion = strings.TrimSpace(row.OSVersion)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("invalid JSON: line exceeds 1 MiB")
+		}
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+
+	return rows, nil
+}
+
+// ServerImportResult reports what an import does, or would do on a dry run,
+// with one row
+type ServerImportResult struct {
+	Line     int                    `json:"line"`
+	Name     string                 `json:"name"`
+	Action   string                 `json:"action"` // 'create', 'update', 'unchanged', 'error'
+	ServerID *int                   `json:"server_id,omitempty"`
+	Changes  map[string]FieldChange `json:"changes,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+
+	// Before and After are the states of the server the row applies
+	Before *Server `json:"-"`
+	After  *Server `json:"-"`
+}
+
+// ServerImportReport summarizes a bulk import. Rows are applied only when
+// none of them has an error and the import is not a dry run.
+type ServerImportReport struct {
+	DryRun    bool                 `json:"dry_run"`
+	Applied   bool                 `json:"applied"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Failed    int                  `json:"failed"`
+	Rows      []ServerImportResult `json:"rows"`
+}
+
+// ServerImportLookup resolves the records an import refers to. Lookups
+// return nil without an error when no record matches.
+type ServerImportLookup struct {
+	OS     func(name, version string) (*OS, error)
+	Server func(name string) (*Server, error)
+}
+
+// PlanServerImport validates the rows of an import and works out whether
+// each creates, updates or leaves unchanged the server it names. Invalid rows
+// are reported with the error action; an error is only returned when a
+// lookup fails.
+func PlanServerImport(rows []ServerImportRow, lookup ServerImportLookup) (*ServerImportReport, error) {
+	utils := NewServerUtils()
+	report := &ServerImportReport{Rows: make([]ServerImportResult, 0, len(rows))}
+	seen := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		result := ServerImportResult{Line: row.Line, Name: row.Name}
+
+		existing, os, problem, err := resolveServerImportRow(row, lookup, utils, seen)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case problem != "":
+			result.Action = ImportActionError
+			result.Error = problem
+			report.Failed++
+		case existing == nil:
+			result.After = applyServerImportRow(&Server{}, os, row)
+			result.Changes = DiffServers(nil, result.After)
+			result.Action = ImportActionCreate
+			report.Created++
+		default:
+			result.Before = existing
+			result.After = applyServerImportRow(existing, os, row)
+			result.Changes = DiffServers(existing, result.After)
+			result.ServerID = intPtrOf(existing.ID)
+			if len(result.Changes) > 0 {
+				result.Action = ImportActionUpdate
+				report.Updated++
+			} else {
+				result.Changes = nil
+				result.Action = ImportActionUnchanged
+				report.Unchanged++
+			}
+		}
+
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report, nil
+}
+
+// resolveServerImportRow validates a row and looks up the existing server it
+// names and the operating system it references, which is nil when the row
+// keeps the OS of an existing server. Validation failures are returned as a
+// problem description.
+func resolveServerImportRow(row ServerImportRow, lookup ServerImportLookup, utils *ServerUtils, seen map[string]int) (*Server, *OS, string, error) {
+	if err := utils.ValidateServerName(row.Name); err != nil {
+		return nil, nil, err.Error(), nil
+	}
+	if line, exists := seen[row.Name]; exists {
+		return nil, nil, fmt.Sprintf("server %q is already imported on line %d", row.Name, line), nil
+	}
+	seen[row.Name] = row.Line
+
+	if err := utils.ValidateEnvironment(row.Environment); err != nil {
+		return nil, nil, err.Error(), nil
+	}
+	if err := utils.ValidateAttributes(row.Role, row.OwnerTeam, row.Location); err != nil {
+		return nil, nil, err.Error(), nil
+	}
+	if (row.OSName == "") != (row.OSVersion == "") {
+		return nil, nil, "os_name and os_version must be given together", nil
+	}
+
+	existing, err := lookup.Server(row.Name)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if row.OSName == "" {
+		if existing == nil {
+			return nil, nil, "os_name and os_version are required for a new server", nil
+		}
+		return existing, nil, "", nil
+	}
+
+	os, err := lookup.OS(row.OSName, row.OSVersion)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if os == nil {
+		return nil, nil, fmt.Sprintf("operating system %s %s does not exist", row.OSName, row.OSVersion), nil
+	}
+
+	return existing, os, "", nil
+}
+
+// applyServerImportRow returns the server with the fields set by the row,
+// and the operating system when os is not nil
+func applyServerImportRow(server *Server, os *OS, row ServerImportRow) *Server {
+	after := *server
+	after.Name = row.Name
+	if os != nil {
+		after.OSID = os.ID
+		after.OS = os
+	}
+	for _, f := range []struct {
+		dest  *string
+		value string
+	}{
+		{&after.Environment, row.Environment},
+		{&after.Role, row.Role},
+		{&after.OwnerTeam, row.OwnerTeam},
+		{&after.Location, row.Location},
+		{&after.Description, row.Description},
+	} {
+		if f.value != "" {
+			*f.dest = f.value
+		}
+	}
+	return &after
+}
+
+func intPtrOf(v int) *int {
+	return &v
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
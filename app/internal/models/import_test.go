@@ -0,0 +1,118 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseServerImportCSV(t *testing.T) {
+	input := "name,os_name,os_version,environment\n" +
+		"web-01, Ubuntu ,22.04,prod\n" +
+		"\n" +
+		"\"db-01\",Debian,12,\n"
+
+	rows, err := ParseServerImportCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %+v", rows)
+	}
+	if rows[0] != (ServerImportRow{Line: 2, Name: "web-01", OSName: "Ubuntu", OSVersion: "22.04", Environment: "prod"}) {
+		t.Errorf("Unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Line != 4 || rows[1].Name != "db-01" {
+		t.Errorf("Unexpected second row: %+v", rows[1])
+	}
+
+	for _, invalid := range []string{"name,os\nweb-01,Ubuntu\n", "os_name\nUbuntu\n", "name,name\na,b\n", "name,role\nweb-01\n"} {
+		if _, err := ParseServerImportCSV(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestParseServerImportNDJSON(t *testing.T) {
+	input := `{"name": "web-01", "os_name": "Ubuntu", "os_version": "22.04"}
+
+{"name": "db-01", "role": "database"}
+`
+	rows, err := ParseServerImportNDJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse NDJSON: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Line != 1 || rows[1].Line != 3 || rows[1].Role != "database" {
+		t.Fatalf("Unexpected rows: %+v", rows)
+	}
+
+	if _, err := ParseServerImportNDJSON(strings.NewReader(`{"name": "web-01", "os_id": 1}`)); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+	if _, err := ParseServerImportNDJSON(strings.NewReader("{\"name\": \"web-01\"}\n{")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error on line 2, got %v", err)
+	}
+}
+
+func TestPlanServerImport(t *testing.T) {
+	ubuntu := &OS{ID: 1, Name: "Ubuntu", Version: "22.04"}
+	debian := &OS{ID: 2, Name: "Debian", Version: "12"}
+	existing := map[string]*Server{
+		"web-01": {ID: 10, Name: "web-01", OSID: 1, OS: ubuntu, Environment: "prod"},
+		"web-02": {ID: 11, Name: "web-02", OSID: 1, OS: ubuntu, Role: "web"},
+	}
+	lookup := ServerImportLookup{
+		OS: func(name, version string) (*OS, error) {
+			for _, os := range []*OS{ubuntu, debian} {
+				if os.Name == name && os.Version == version {
+					return os, nil
+				}
+			}
+			return nil, nil
+		},
+		Server: func(name string) (*Server, error) {
+			return existing[name], nil
+		},
+	}
+
+	rows := []ServerImportRow{
+		{Line: 1, Name: "web-01", OSName: "Debian", OSVersion: "12"},
+		{Line: 2, Name: "web-02", Role: "web"},
+		{Line: 3, Name: "db-01", OSName: "Debian", OSVersion: "12", Environment: "staging"},
+		{Line: 4, Name: "db-02"},
+		{Line: 5, Name: "bad_name", OSName: "Debian", OSVersion: "12"},
+		{Line: 6, Name: "db-03", OSName: "Debian", OSVersion: "9"},
+		{Line: 7, Name: "db-01", OSName: "Debian", OSVersion: "12"},
+		{Line: 8, Name: "db-04", OSName: "Debian", OSVersion: "12", Environment: "qa"},
+		{Line: 9, Name: "db-05", OSName: "Debian"},
+		{Line: 10, Name: "db-06", OSName: "Debian", OSVersion: "12", OwnerTeam: strings.Repeat("t", MaxServerAttributeLength+1)},
+	}
+
+	report, err := PlanServerImport(rows, lookup)
+	if err != nil {
+		t.Fatalf("Failed to plan import: %v", err)
+	}
+
+	expected := []string{
+		ImportActionUpdate, ImportActionUnchanged, ImportActionCreate, ImportActionError, ImportActionError,
+		ImportActionError, ImportActionError, ImportActionError, ImportActionError, ImportActionError,
+	}
+	for i, action := range expected {
+		if report.Rows[i].Action != action {
+			t.Errorf("Line %d: expected %s, got %+v", rows[i].Line, action, report.Rows[i])
+		}
+	}
+	if report.Created != 1 || report.Updated != 1 || report.Unchanged != 1 || report.Failed != 7 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+
+	update := report.Rows[0]
+	if *update.ServerID != 10 || update.After.OSID != 2 || update.After.Environment != "prod" {
+		t.Errorf("Unexpected update: %+v, after %+v", update, update.After)
+	}
+	if change := update.Changes["os_id"]; change.Old != 1 || change.New != 2 || len(update.Changes) != 1 {
+		t.Errorf("Unexpected update changes: %+v", update.Changes)
+	}
+	if !strings.Contains(report.Rows[6].Error, "line 3") {
+		t.Errorf("Expected a duplicate name error, got %q", report.Rows[6].Error)
+	}
+}
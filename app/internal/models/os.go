@@ -1,27 +1,176 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // OS represents an operating system with support information
 type OS struct {
-	ID           int       `json:"id" db:"id"`
-	Name         string    `json:"name" db:"name"`
-	Version      string    `json:"version" db:"version"`
-	EndOfSupport time.Time `json:"end_of_support" db:"end_of_support"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID                   int        `json:"id" db:"id"`
+	Name                 string     `json:"name" db:"name"`
+	Version              string     `json:"version" db:"version"`
+	LTS                  bool       `json:"lts,omitempty" db:"lts"`
+	ReleaseDate          *time.Time `json:"release_date,omitempty" db:"release_date"`
+	EndOfStandardSupport *time.Time `json:"end_of_standard_support,omitempty" db:"end_of_standard_support"` // End of full support, after which only fixes are released
+	EndOfSupport         time.Time  `json:"end_of_support" db:"end_of_support"`
+	EndOfExtendedSupport *time.Time `json:"end_of_extended_support,omitempty" db:"end_of_extended_support"` // Paid extended support such as ESM or ELS
+	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Lifecycle phases of an operating system
+const (
+	PhaseCurrent     = "current"     // Before the end of standard support
+	PhaseMaintenance = "maintenance" // Between the end of standard support and the end of support
+	PhaseExtended    = "extended"    // Past the end of support, within paid extended support
+	PhaseEndOfLife   = "eol"         // Past the end of support and of any extended support
+)
+
+// ValidatePhase checks that a lifecycle phase filter is one of the known values
+func ValidatePhase(phase string) error {
+	switch phase {
+	case PhaseCurrent, PhaseMaintenance, PhaseExtended, PhaseEndOfLife:
+		return nil
+	default:
+		return fmt.Errorf("invalid phase %q: must be one of %s, %s, %s, %s",
+			phase, PhaseCurrent, PhaseMaintenance, PhaseExtended, PhaseEndOfLife)
+	}
+}
+
+// Phase returns the lifecycle phase of the operating system at now. An
+// operating system without an end of standard support date is current until
+// its end of support.
+func (os OS) Phase(now time.Time) string {
+	switch {
+	case !os.EndOfSupport.Before(now):
+		if os.EndOfStandardSupport != nil && os.EndOfStandardSupport.Before(now) {
+			return PhaseMaintenance
+		}
+		return PhaseCurrent
+	case os.EndOfExtendedSupport != nil && !os.EndOfExtendedSupport.Before(now):
+		return PhaseExtended
+	default:
+		return PhaseEndOfLife
+	}
+}
+
+// MarshalJSON encodes the operating system with its current lifecycle phase
+func (os OS) MarshalJSON() ([]byte, error) {
+	type record OS
+	return json.Marshal(struct {
+		record
+		LifecyclePhase string `json:"lifecycle_phase"`
+	}{record(os), os.Phase(time.Now())})
 }
 
 // CreateOSRequest represents the request body for creating an OS
 type CreateOSRequest struct {
-	Name         string `json:"name" validate:"required"`
-	Version      string `json:"version" validate:"required"`
-	EndOfSupport string `json:"end_of_support" validate:"required"` // Expected format: YYYY-MM-DD
+	Name                 string `json:"name" validate:"required"`
+	Version              string `json:"version" validate:"required"`
+	LTS                  bool   `json:"lts,omitempty"`
+	ReleaseDate          string `json:"release_date,omitempty"`
+	EndOfStandardSupport string `json:"end_of_standard_support,omitempty"`
+	EndOfSupport         string `json:"end_of_support" validate:"required"` // Expected format: YYYY-MM-DD
+	EndOfExtendedSupport string `json:"end_of_extended_support,omitempty"`
 }
 
 // UpdateOSRequest represents the request body for updating an OS
 type UpdateOSRequest struct {
-	Name         string `json:"name,omitempty"`
-	Version      string `json:"version,omitempty"`
-	EndOfSupport string `json:"end_of_support,omitempty"` // Expected format: YYYY-MM-DD
+	Name                 string `json:"name,omitempty"`
+	Version              string `json:"version,omitempty"`
+	LTS                  *bool  `json:"lts,omitempty"`
+	ReleaseDate          string `json:"release_date,omitempty"`
+	EndOfStandardSupport string `json:"end_of_standard_support,omitempty"`
+	EndOfSupport         string `json:"end_of_support,omitempty"` // Expected format: YYYY-MM-DD
+	EndOfExtendedSupport string `json:"end_of_extended_support,omitempty"`
+}
+
+// OSSortFields lists the fields operating systems can be sorted by
+var OSSortFields = []string{"id", "name", "version", "end_of_support", "created_at", "updated_at"}
+
+// DefaultOSSort orders the operating system lists that do not specify a
+// sort, by name and version
+var DefaultOSSort = []SortField{{Field: "name"}, {Field: "version"}}
+
+// OSCursor returns the cursor following os in a list sorted by fields
+func OSCursor(os OS, fields []SortField) Cursor {
+	values := make([]*string, len(fields))
+	for i, f := range fields {
+		switch f.Field {
+		case "id":
+			values[i] = cursorInt(os.ID)
+		case "name":
+			values[i] = cursorString(os.Name)
+		case "version":
+			values[i] = cursorString(os.Version)
+		case "end_of_support":
+			values[i] = cursorTime(&os.EndOfSupport)
+		case "created_at":
+			values[i] = cursorTime(&os.CreatedAt)
+		case "updated_at":
+			values[i] = cursorTime(&os.UpdatedAt)
+		}
+	}
+
+	return Cursor{Sort: FormatSort(fields), Values: values, ID: os.ID}
+}
+
+// DecodeOSCursor parses an opaque cursor of an operating system list sorted
+// by fields
+func DecodeOSCursor(value string, fields []SortField) (*Cursor, error) {
+	cursor, err := DecodeCursor(value, fields)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cursor.OS(fields); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// OS returns an operating system holding the values of a cursor of a list
+// sorted by fields, which the operating systems of the list can be compared
+// with
+func (c Cursor) OS(fields []SortField) (OS, error) {
+	os := OS{ID: c.ID}
+	for i, f := range fields {
+		var err error
+		switch value := c.Values[i]; f.Field {
+		case "id":
+			err = setCursorInt(&os.ID, f.Field, value)
+		case "name":
+			err = setCursorString(&os.Name, f.Field, value)
+		case "version":
+			err = setCursorString(&os.Version, f.Field, value)
+		case "end_of_support":
+			err = setCursorTime(&os.EndOfSupport, f.Field, value)
+		case "created_at":
+			err = setCursorTime(&os.CreatedAt, f.Field, value)
+		case "updated_at":
+			err = setCursorTime(&os.UpdatedAt, f.Field, value)
+		}
+		if err != nil {
+			return OS{}, err
+		}
+	}
+
+	return os, nil
+}
+
+// OSFilter represents filters, ordering and pagination for querying operating systems
+type OSFilter struct {
+	Name             *string    // Case-insensitive substring match on name or version
+	Family           *string    // Exact OS family name
+	SupportStatus    *string    // 'supported', 'ending_soon', 'eol'
+	EndingSoonCutoff *time.Time // End of the ending soon window, defaults to the default policy
+	Phase            *string    // 'current', 'maintenance', 'extended', 'eol'
+	CreatedAfter     *time.Time
+	CreatedBefore    *time.Time
+	UpdatedAfter     *time.Time
+	UpdatedBefore    *time.Time
+	Sort             []SortField // Defaults to DefaultOSSort
+	After            *Cursor     // Only the operating systems sorting after the cursor
+	Limit            int
 }
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Resource types of the global change history feed
+const (
+	ResourceTypeServer = "server"
+	ResourceTypeOS     = "os"
+)
+
+// ValidateResourceType checks that a resource type filter is one of the known values
+func ValidateResourceType(resourceType string) error {
+	switch resourceType {
+	case ResourceTypeServer, ResourceTypeOS:
+		return nil
+	default:
+		return fmt.Errorf("invalid resource_type %q: must be one of %s, %s",
+			resourceType, ResourceTypeServer, ResourceTypeOS)
+	}
+}
+
+// OSChangeHistory represents a change made to an operating system of the catalog
+type OSChangeHistory struct {
+	ID         int                    `json:"id" db:"id"`
+	OSID       *int                   `json:"os_id" db:"os_id"`
+	OSName     string                 `json:"os_name" db:"os_name"` // Name and version at the time of the change
+	OSVersion  string                 `json:"os_version" db:"os_version"`
+	ChangeType string                 `json:"change_type" db:"change_type"` // 'created', 'updated', 'deleted'
+	ChangedBy  string                 `json:"changed_by,omitempty" db:"changed_by"`
+	RequestID  string                 `json:"request_id,omitempty" db:"request_id"`
+	Source     string                 `json:"source,omitempty" db:"source"`
+	Changes    map[string]FieldChange `json:"changes,omitempty" db:"changes"` // Changed fields by JSON name
+	ChangedAt  time.Time              `json:"changed_at" db:"changed_at"`
+}
+
+// OSChangeHistoryFilter represents filters for querying operating system change history
+type OSChangeHistoryFilter struct {
+	OSID       *int
+	ChangeType *string
+	StartDate  *time.Time
+	EndDate    *time.Time
+	Limit      int
+	Offset     int
+}
+
+// MarshalJSON encodes the record with its resource type
+func (h OSChangeHistory) MarshalJSON() ([]byte, error) {
+	type record OSChangeHistory
+	return json.Marshal(struct {
+		ResourceType string `json:"resource_type"`
+		record
+	}{ResourceTypeOS, record(h)})
+}
+
+// osFields returns the recorded fields of an operating system by their JSON name
+func osFields(os *OS) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                    os.Name,
+		"version":                 os.Version,
+		"lts":                     os.LTS,
+		"release_date":            formatOptionalDate(os.ReleaseDate),
+		"end_of_standard_support": formatOptionalDate(os.EndOfStandardSupport),
+		"end_of_support":          os.EndOfSupport.Format("2006-01-02"),
+		"end_of_extended_support": formatOptionalDate(os.EndOfExtendedSupport),
+	}
+}
+
+// formatOptionalDate formats a date as YYYY-MM-DD, or returns an empty
+// string when it is not set
+func formatOptionalDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// DiffOS returns the fields that differ between two states of an operating
+// system. A nil before or after stands for an operating system being created
+// or deleted.
+func DiffOS(before, after *OS) map[string]FieldChange {
+	var old, current map[string]interface{}
+	if before != nil {
+		old = osFields(before)
+	}
+	if after != nil {
+		current = osFields(after)
+	}
+	return diffFields(old, current)
+}
+
+// NewOSChange builds the change history record of an operating system
+// mutation from its state before and after the change, with a nil before for
+// a creation and a nil after for a deletion. It returns false when no field
+// changed. ChangedAt is left to the store.
+func NewOSChange(before, after *OS, meta ChangeMetadata) (OSChangeHistory, bool) {
+	changes := DiffOS(before, after)
+	if len(changes) == 0 {
+		return OSChangeHistory{}, false
+	}
+
+	current := after
+	changeType := ChangeTypeUpdated
+	switch {
+	case before == nil:
+		changeType = ChangeTypeCreated
+	case after == nil:
+		changeType = ChangeTypeDeleted
+		current = before
+	}
+
+	id := current.ID
+	return OSChangeHistory{
+		OSID:       &id,
+		OSName:     current.Name,
+		OSVersion:  current.Version,
+		ChangeType: changeType,
+		ChangedBy:  meta.Actor,
+		RequestID:  meta.RequestID,
+		Source:     meta.Source,
+		Changes:    changes,
+	}, true
+}
+
+// ChangeFeedFilter selects a page of the merged server and operating system
+// change history, newest first
+type ChangeFeedFilter struct {
+	// ResourceType limits the feed to server or operating system records;
+	// both are included when it is empty
+	ResourceType string
+	// Servers and OS filter the records of each resource type. Their limit
+	// and offset are ignored.
+	Servers ChangeHistoryFilter
+	OS      OSChangeHistoryFilter
+	Limit   int
+	Offset  int
+}
+
+// MergeChangeHistory merges server and operating system change records into
+// a single feed, newest first. Each entry is encoded with its resource_type.
+func MergeChangeHistory(servers []ServerChangeHistory, oss []OSChangeHistory) []interface{} {
+	type entry struct {
+		record    interface{}
+		changedAt time.Time
+		id        int
+	}
+
+	entries := make([]entry, 0, len(servers)+len(oss))
+	for _, record := range servers {
+		entries = append(entries, entry{record, record.ChangedAt, record.ID})
+	}
+	for _, record := range oss {
+		entries = append(entries, entry{record, record.ChangedAt, record.ID})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].changedAt.Equal(entries[j].changedAt) {
+			return entries[i].changedAt.After(entries[j].changedAt)
+		}
+		return entries[i].id > entries[j].id
+	})
+
+	feed := make([]interface{}, len(entries))
+	for i, e := range entries {
+		feed[i] = e.record
+	}
+	return feed
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNewOSChange(t *testing.T) {
+	os := OS{ID: 3, Name: "Ubuntu", Version: "20.04", EndOfSupport: time.Date(2025, time.April, 30, 0, 0, 0, 0, time.UTC)}
+	extended := os
+	extended.EndOfSupport = time.Date(2030, time.April, 30, 0, 0, 0, 0, time.UTC)
+	meta := ChangeMetadata{Actor: "alice", Source: ChangeSourceAPI}
+
+	record, changed := NewOSChange(&os, &extended, meta)
+	if !changed || record.ChangeType != ChangeTypeUpdated || len(record.Changes) != 1 {
+		t.Fatalf("Unexpected update record: %+v", record)
+	}
+	if change := record.Changes["end_of_support"]; change.Old != "2025-04-30" || change.New != "2030-04-30" {
+		t.Errorf("Unexpected end of support change: %+v", change)
+	}
+
+	created, _ := NewOSChange(nil, &os, meta)
+	deleted, _ := NewOSChange(&os, nil, meta)
+	if created.ChangeType != ChangeTypeCreated || len(created.Changes) != 3 || deleted.ChangeType != ChangeTypeDeleted || *deleted.OSID != 3 {
+		t.Errorf("Unexpected created or deleted record: %+v, %+v", created, deleted)
+	}
+
+	if _, changed := NewOSChange(&os, &os, meta); changed {
+		t.Error("Expected no record when nothing changed")
+	}
+}
+
+func TestMergeChangeHistory(t *testing.T) {
+	base := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	servers := []ServerChangeHistory{
+		{ID: 2, ChangeType: ChangeTypeRenamed, ChangedAt: base.Add(3 * time.Hour)},
+		{ID: 1, ChangeType: ChangeTypeCreated, ChangedAt: base.Add(time.Hour)},
+	}
+	oss := []OSChangeHistory{
+		{ID: 1, ChangeType: ChangeTypeUpdated, ChangedAt: base.Add(2 * time.Hour)},
+	}
+
+	data, err := json.Marshal(MergeChangeHistory(servers, oss))
+	if err != nil {
+		t.Fatalf("Failed to marshal feed: %v", err)
+	}
+
+	var feed []struct {
+		ResourceType string `json:"resource_type"`
+		ChangeType   string `json:"change_type"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("Failed to unmarshal feed: %v", err)
+	}
+
+	expected := []string{"server:renamed", "os:updated", "server:created"}
+	if len(feed) != len(expected) {
+		t.Fatalf("Expected %d entries, got %+v", len(expected), feed)
+	}
+	for i, e := range feed {
+		if got := e.ResourceType + ":" + e.ChangeType; got != expected[i] {
+			t.Errorf("Entry %d: expected %s, got %s", i, expected[i], got)
+		}
+	}
+}
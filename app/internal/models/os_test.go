@@ -21,12 +21,56 @@ func TestOSJSONMarshal(t *testing.T) {
 		t.Fatalf("Failed to marshal OS to JSON: %v", err)
 	}
 
-	expected := `{"id":1,"name":"Ubuntu","version":"22.04","end_of_support":"2027-04-01T00:00:00Z","created_at":"2024-01-01T12:00:00Z","updated_at":"2024-01-01T12:00:00Z"}`
+	// The lifecycle phase is derived at the time of encoding
+	expected := `{"id":1,"name":"Ubuntu","version":"22.04","end_of_support":"2027-04-01T00:00:00Z","created_at":"2024-01-01T12:00:00Z","updated_at":"2024-01-01T12:00:00Z","lifecycle_phase":"` + os.Phase(time.Now()) + `"}`
 	if string(jsonData) != expected {
 		t.Errorf("JSON marshal result mismatch.\nExpected: %s\nGot: %s", expected, string(jsonData))
 	}
 }
 
+func TestOSPhase(t *testing.T) {
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	ubuntu := OS{
+		Name:                 "Ubuntu",
+		Version:              "22.04",
+		EndOfStandardSupport: date(2027, 4, 1),
+		EndOfSupport:         *date(2027, 6, 1),
+		EndOfExtendedSupport: date(2032, 4, 9),
+	}
+
+	tests := []struct {
+		os   OS
+		now  *time.Time
+		want string
+	}{
+		{ubuntu, date(2026, 1, 1), PhaseCurrent},
+		{ubuntu, date(2027, 4, 1), PhaseCurrent},
+		{ubuntu, date(2027, 5, 1), PhaseMaintenance},
+		{ubuntu, date(2027, 6, 1), PhaseMaintenance},
+		{ubuntu, date(2030, 1, 1), PhaseExtended},
+		{ubuntu, date(2033, 1, 1), PhaseEndOfLife},
+		// Without the optional dates an OS goes from current to end of life
+		{OS{EndOfSupport: *date(2027, 6, 1)}, date(2027, 5, 1), PhaseCurrent},
+		{OS{EndOfSupport: *date(2027, 6, 1)}, date(2030, 1, 1), PhaseEndOfLife},
+	}
+
+	for _, tt := range tests {
+		if got := tt.os.Phase(*tt.now); got != tt.want {
+			t.Errorf("Phase(%s) of %+v = %q, want %q", tt.now.Format("2006-01-02"), tt.os, got, tt.want)
+		}
+	}
+
+	if err := ValidatePhase(PhaseExtended); err != nil {
+		t.Errorf("ValidatePhase(%q) error = %v", PhaseExtended, err)
+	}
+	if err := ValidatePhase("supported"); err == nil {
+		t.Error("ValidatePhase accepted an unknown phase")
+	}
+}
+
 func TestOSJSONUnmarshal(t *testing.T) {
 	jsonData := `{"id":1,"name":"Ubuntu","version":"22.04","end_of_support":"2027-04-01T00:00:00Z","created_at":"2024-01-01T12:00:00Z","updated_at":"2024-01-01T12:00:00Z"}`
 
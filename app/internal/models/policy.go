@@ -0,0 +1,437 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PolicyTier is a warning window before an operating system reaches its end
+// of support. Servers inside the window are counted as ending soon and add
+// the tier penalty to the compliance score.
+type PolicyTier struct {
+	Name    string  `json:"name"`
+	Months  int     `json:"months,omitempty"`
+	Days    int     `json:"days,omitempty"`
+	Penalty float64 `json:"penalty"`
+}
+
+// Deadline returns the end of the tier window measured from now
+func (t PolicyTier) Deadline(now time.Time) time.Time {
+	return now.AddDate(0, t.Months, t.Days)
+}
+
+// Window returns a human-readable length of the tier window
+func (t PolicyTier) Window() string {
+	var parts []string
+	if t.Months != 0 {
+		parts = append(parts, pluralize(t.Months, "month"))
+	}
+	if t.Days != 0 {
+		parts = append(parts, pluralize(t.Days, "day"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ScoreBand describes compliance scores at or above MinScore
+type ScoreBand struct {
+	MinScore    float64 `json:"min_score"`
+	Description string  `json:"description"`
+}
+
+// EnvironmentUnassigned labels servers without an environment in
+// per-environment compliance breakdowns
+const EnvironmentUnassigned = "unassigned"
+
+// CompliancePolicy defines how servers are classified and scored in
+// compliance reports
+type CompliancePolicy struct {
+	// Tiers are the warning windows, ordered from the narrowest to the widest
+	Tiers []PolicyTier `json:"tiers"`
+	// EndOfLifePenalty is the score penalty for each end-of-life server
+	EndOfLifePenalty float64 `json:"end_of_life_penalty"`
+	// ScoreBands describe score ranges, ordered from the highest minimum score
+	ScoreBands []ScoreBand `json:"score_bands"`
+	// LeadMonths and LeadDays require upgrades before the vendor end of
+	// support: servers count as end of life this long before the date
+	LeadMonths int `json:"lead_months,omitempty"`
+	LeadDays   int `json:"lead_days,omitempty"`
+	// GraceDays allows servers to keep running this many days past the
+	// vendor end of support before they count as end of life
+	GraceDays int `json:"grace_days,omitempty"`
+	// StaleAfterDays marks servers that have not reported through a
+	// registration or an import for this many days as stale. Servers that
+	// never reported are not stale. Zero disables stale detection.
+	StaleAfterDays int `json:"stale_after_days"`
+	// ExcludeStale leaves stale servers out of the status counts, lists,
+	// score and recommendations, so that hosts decommissioned without being
+	// deleted do not count against compliance
+	ExcludeStale bool `json:"exclude_stale,omitempty"`
+	// Environments holds the policies applied to servers of an environment
+	// instead of this one. Omitted fields inherit from this policy.
+	Environments map[string]CompliancePolicy `json:"environments,omitempty"`
+}
+
+// DefaultCompliancePolicy returns the policy used when none is configured:
+// a single six-month warning window, the historical penalty weights, score
+// descriptions and servers stale after 30 days without a report
+func DefaultCompliancePolicy() CompliancePolicy {
+	return CompliancePolicy{
+		Tiers: []PolicyTier{
+			{Name: StatusEndingSoon, Months: 6, Penalty: 0.5},
+		},
+		EndOfLifePenalty: 2,
+		StaleAfterDays:   30,
+		ScoreBands: []ScoreBand{
+			{MinScore: 90, Description: "Excellent - Infrastructure is well maintained and compliant"},
+			{MinScore: 75, Description: "Good - Minor compliance issues that should be addressed"},
+			{MinScore: 50, Description: "Fair - Several compliance issues requiring attention"},
+			{MinScore: 25, Description: "Poor - Significant compliance issues need immediate action"},
+			{MinScore: 0, Description: "Critical - Infrastructure has serious compliance problems"},
+		},
+	}
+}
+
+// Clone returns a copy of the policy that can be modified without
+// affecting the original
+func (p CompliancePolicy) Clone() CompliancePolicy {
+	p.Tiers = append([]PolicyTier(nil), p.Tiers...)
+	p.ScoreBands = append([]ScoreBand(nil), p.ScoreBands...)
+	if p.Environments != nil {
+		environments := make(map[string]CompliancePolicy, len(p.Environments))
+		for env, policy := range p.Environments {
+			environments[env] = policy.Clone()
+		}
+		p.Environments = environments
+	}
+	return p
+}
+
+// Validate checks the policy and orders its tiers and score bands
+func (p *CompliancePolicy) Validate() error {
+	if len(p.Tiers) == 0 {
+		return fmt.Errorf("policy must define at least one tier")
+	}
+
+	// Compare windows from a fixed date so months of different lengths
+	// order the same way on every call
+	reference := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	seen := make(map[string]bool)
+	for _, tier := range p.Tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("policy tiers must have a name")
+		}
+		if seen[tier.Name] {
+			return fmt.Errorf("policy tier %q is defined more than once", tier.Name)
+		}
+		seen[tier.Name] = true
+
+		if tier.Months < 0 || tier.Days < 0 || !tier.Deadline(reference).After(reference) {
+			return fmt.Errorf("policy tier %q must have a positive window", tier.Name)
+		}
+		if tier.Penalty < 0 || !isFinite(tier.Penalty) {
+			return fmt.Errorf("policy tier %q must have a finite, non-negative penalty", tier.Name)
+		}
+	}
+	sort.SliceStable(p.Tiers, func(i, j int) bool {
+		return p.Tiers[i].Deadline(reference).Before(p.Tiers[j].Deadline(reference))
+	})
+
+	if p.EndOfLifePenalty < 0 || !isFinite(p.EndOfLifePenalty) {
+		return fmt.Errorf("end of life penalty must be finite and not negative")
+	}
+	if p.LeadMonths < 0 || p.LeadDays < 0 || p.GraceDays < 0 {
+		return fmt.Errorf("lead and grace periods must not be negative")
+	}
+	if (p.LeadMonths > 0 || p.LeadDays > 0) && p.GraceDays > 0 {
+		return fmt.Errorf("a policy cannot have both a lead and a grace period")
+	}
+	if p.StaleAfterDays < 0 {
+		return fmt.Errorf("stale after days must not be negative")
+	}
+	if p.ExcludeStale && p.StaleAfterDays == 0 {
+		return fmt.Errorf("excluding stale servers requires stale after days")
+	}
+
+	for _, band := range p.ScoreBands {
+		if !isFinite(band.MinScore) {
+			return fmt.Errorf("score band minimum scores must be finite")
+		}
+		if band.Description == "" {
+			return fmt.Errorf("score band %.0f must have a description", band.MinScore)
+		}
+	}
+	sort.SliceStable(p.ScoreBands, func(i, j int) bool {
+		return p.ScoreBands[i].MinScore > p.ScoreBands[j].MinScore
+	})
+
+	for env, policy := range p.Environments {
+		if err := NewServerUtils().ValidateEnvironment(env); err != nil || env == "" {
+			return fmt.Errorf("invalid policy environment %q", env)
+		}
+		if len(policy.Environments) > 0 {
+			return fmt.Errorf("environment policy %q cannot define environments", env)
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("environment policy %q: %w", env, err)
+		}
+		p.Environments[env] = policy
+	}
+
+	return nil
+}
+
+// isFinite reports whether v is neither NaN nor infinite
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// ForEnvironment returns the policy applied to servers of an environment
+func (p CompliancePolicy) ForEnvironment(environment string) CompliancePolicy {
+	if policy, exists := p.Environments[environment]; exists {
+		return policy
+	}
+	return p
+}
+
+// EffectiveEndOfSupport returns the date from which a server counts as end of
+// life under the policy, after applying its lead or grace period
+func (p CompliancePolicy) EffectiveEndOfSupport(endOfSupport time.Time) time.Time {
+	return endOfSupport.AddDate(0, -p.LeadMonths, p.GraceDays-p.LeadDays)
+}
+
+// Classify returns the support status of an end of support date under the
+// policy, together with the tier of an ending soon date
+func (p CompliancePolicy) Classify(endOfSupport, now time.Time) (string, *PolicyTier) {
+	effective := p.EffectiveEndOfSupport(endOfSupport)
+	if effective.Before(now) {
+		return StatusEndOfLife, nil
+	}
+	for i := range p.Tiers {
+		if effective.Before(p.Tiers[i].Deadline(now)) {
+			return StatusEndingSoon, &p.Tiers[i]
+		}
+	}
+	return StatusSupported, nil
+}
+
+// Penalty returns the score penalty of an end of support date under the policy
+func (p CompliancePolicy) Penalty(endOfSupport, now time.Time) float64 {
+	switch status, tier := p.Classify(endOfSupport, now); status {
+	case StatusEndOfLife:
+		return p.EndOfLifePenalty
+	case StatusEndingSoon:
+		return tier.Penalty
+	}
+	return 0
+}
+
+// Tier returns the narrowest tier whose window contains the end of support
+// date, or nil when the server is end of life or outside every window
+func (p CompliancePolicy) Tier(endOfSupport, now time.Time) *PolicyTier {
+	_, tier := p.Classify(endOfSupport, now)
+	return tier
+}
+
+// EndingSoonCutoff returns the end of the widest tier window measured from
+// now. Operating systems reaching end of support before it are ending soon.
+func (p CompliancePolicy) EndingSoonCutoff(now time.Time) time.Time {
+	cutoff := now
+	for _, tier := range p.Tiers {
+		if deadline := tier.Deadline(now); deadline.After(cutoff) {
+			cutoff = deadline
+		}
+	}
+	return cutoff
+}
+
+// StatusStale is the compliance status of a stale server under a policy
+// excluding stale servers
+const StatusStale = "stale"
+
+// StaleCutoff returns the time servers last seen before are stale, and false
+// when the policy disables stale detection
+func (p CompliancePolicy) StaleCutoff(now time.Time) (time.Time, bool) {
+	if p.StaleAfterDays <= 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -p.StaleAfterDays), true
+}
+
+// IsStale reports whether a server has not reported since the stale cutoff.
+// Servers that never reported are not stale.
+func (p CompliancePolicy) IsStale(server Server, now time.Time) bool {
+	cutoff, enabled := p.StaleCutoff(now)
+	return enabled && server.LastSeenAt != nil && server.LastSeenAt.Before(cutoff)
+}
+
+// ScoreDescription returns the description of the band a score falls in
+func (p CompliancePolicy) ScoreDescription(score float64) string {
+	for _, band := range p.ScoreBands {
+		if score >= band.MinScore {
+			return band.Description
+		}
+	}
+	return ""
+}
+
+// CompliancePolicySet holds the default compliance policy together with
+// named policies that can be selected per request
+type CompliancePolicySet struct {
+	Default  CompliancePolicy            `json:"default"`
+	Policies map[string]CompliancePolicy `json:"policies,omitempty"`
+}
+
+// DefaultCompliancePolicySet returns a set containing only the default policy
+func DefaultCompliancePolicySet() CompliancePolicySet {
+	return CompliancePolicySet{Default: DefaultCompliancePolicy()}
+}
+
+// ParseCompliancePolicySet parses a JSON policy set such as
+//
+//	{"default": {...}, "policies": {"security": {...}}}
+//
+// and validates every policy in it. Fields a policy omits keep the values of
+// the default policy, and an omitted default keeps DefaultCompliancePolicy.
+func ParseCompliancePolicySet(data []byte) (CompliancePolicySet, error) {
+	var raw struct {
+		Default  json.RawMessage            `json:"default"`
+		Policies map[string]json.RawMessage `json:"policies"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return CompliancePolicySet{}, fmt.Errorf("invalid compliance policy: %w", err)
+	}
+
+	parse := func(name string, data json.RawMessage) (CompliancePolicy, error) {
+		policy, err := parseCompliancePolicy(data, DefaultCompliancePolicy())
+		if err != nil {
+			return policy, fmt.Errorf("invalid compliance policy %q: %w", name, err)
+		}
+		if err := policy.Validate(); err != nil {
+			return policy, fmt.Errorf("invalid compliance policy %q: %w", name, err)
+		}
+		return policy, nil
+	}
+
+	var set CompliancePolicySet
+	var err error
+	if set.Default, err = parse("default", raw.Default); err != nil {
+		return set, err
+	}
+	for name, data := range raw.Policies {
+		policy, err := parse(name, data)
+		if err != nil {
+			return set, err
+		}
+		if set.Policies == nil {
+			set.Policies = make(map[string]CompliancePolicy)
+		}
+		set.Policies[name] = policy
+	}
+
+	return set, nil
+}
+
+// parseCompliancePolicy decodes a JSON policy over a copy of base. Each
+// environment policy is decoded over the resulting policy so that it
+// inherits every field it omits.
+func parseCompliancePolicy(data json.RawMessage, base CompliancePolicy) (CompliancePolicy, error) {
+	policy := base.Clone()
+	policy.Environments = nil
+	if len(data) == 0 {
+		return policy, nil
+	}
+
+	var raw struct {
+		Environments map[string]json.RawMessage `json:"environments"`
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return policy, err
+	}
+	if len(raw.Environments) == 0 {
+		return policy, nil
+	}
+
+	parent := policy.Clone()
+	parent.Environments = nil
+	policy.Environments = make(map[string]CompliancePolicy, len(raw.Environments))
+	for env, envData := range raw.Environments {
+		envPolicy, err := parseCompliancePolicy(envData, parent)
+		if err != nil {
+			return policy, fmt.Errorf("environment %q: %w", env, err)
+		}
+		policy.Environments[env] = envPolicy
+	}
+
+	return policy, nil
+}
+
+// Lookup returns the named policy, or the default policy for an empty name
+func (s CompliancePolicySet) Lookup(name string) (CompliancePolicy, bool) {
+	if name == "" || name == "default" {
+		return s.Default, true
+	}
+	policy, exists := s.Policies[name]
+	return policy, exists
+}
+
+// ParsePolicyTiers parses a compact tier list such as
+// "notice:12m:0.1,warning:6m:0.5,urgent:30d:1" where each tier is
+// name:window:penalty and the window is a number of months (m) or days (d)
+func ParsePolicyTiers(value string) ([]PolicyTier, error) {
+	var tiers []PolicyTier
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid tier %q: expected name:window:penalty", part)
+		}
+
+		tier := PolicyTier{Name: fields[0]}
+
+		window := fields[1]
+		if len(window) < 2 {
+			return nil, fmt.Errorf("invalid tier window %q: expected a number followed by m or d", window)
+		}
+		n, err := strconv.Atoi(window[:len(window)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier window %q: expected a number followed by m or d", window)
+		}
+		switch window[len(window)-1] {
+		case 'm':
+			tier.Months = n
+		case 'd':
+			tier.Days = n
+		default:
+			return nil, fmt.Errorf("invalid tier window %q: expected a number followed by m or d", window)
+		}
+
+		tier.Penalty, err = strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier penalty %q", fields[2])
+		}
+
+		tiers = append(tiers, tier)
+	}
+
+	return tiers, nil
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
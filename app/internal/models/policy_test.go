@@ -0,0 +1,282 @@
+package models
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCompliancePolicy_Tier(t *testing.T) {
+	policy := CompliancePolicy{
+		Tiers: []PolicyTier{
+			{Name: "notice", Months: 12, Penalty: 0.1},
+			{Name: "urgent", Days: 30, Penalty: 1},
+			{Name: "warning", Months: 6, Penalty: 0.5},
+		},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	// Validate orders tiers from the narrowest window to the widest
+	if policy.Tiers[0].Name != "urgent" || policy.Tiers[2].Name != "notice" {
+		t.Errorf("Expected tiers ordered urgent, warning, notice, got %+v", policy.Tiers)
+	}
+
+	now := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		endOfSupport time.Time
+		expected     string
+	}{
+		{"Already end of life", now.AddDate(0, 0, -1), ""},
+		{"Within 30 days", now.AddDate(0, 0, 10), "urgent"},
+		{"Within 6 months", now.AddDate(0, 4, 0), "warning"},
+		{"Within 12 months", now.AddDate(0, 9, 0), "notice"},
+		{"Beyond every tier", now.AddDate(2, 0, 0), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tier := policy.Tier(tt.endOfSupport, now)
+			got := ""
+			if tier != nil {
+				got = tier.Name
+			}
+			if got != tt.expected {
+				t.Errorf("Expected tier %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if cutoff := policy.EndingSoonCutoff(now); !cutoff.Equal(now.AddDate(1, 0, 0)) {
+		t.Errorf("Expected ending soon cutoff one year out, got %v", cutoff)
+	}
+}
+
+func TestCompliancePolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiers []PolicyTier
+	}{
+		{"No tiers", nil},
+		{"Missing name", []PolicyTier{{Months: 6}}},
+		{"Empty window", []PolicyTier{{Name: "warning"}}},
+		{"Negative penalty", []PolicyTier{{Name: "warning", Months: 6, Penalty: -1}}},
+		{"NaN penalty", []PolicyTier{{Name: "warning", Months: 6, Penalty: math.NaN()}}},
+		{"Infinite penalty", []PolicyTier{{Name: "warning", Months: 6, Penalty: math.Inf(1)}}},
+		{"Duplicate name", []PolicyTier{{Name: "warning", Months: 6}, {Name: "warning", Months: 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := CompliancePolicy{Tiers: tt.tiers}
+			if err := policy.Validate(); err == nil {
+				t.Error("Expected a validation error, got nil")
+			}
+		})
+	}
+
+	for _, penalty := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		policy := DefaultCompliancePolicy()
+		policy.EndOfLifePenalty = penalty
+		if err := policy.Validate(); err == nil {
+			t.Errorf("Expected an error for an end of life penalty of %v", penalty)
+		}
+	}
+}
+
+func TestCompliancePolicy_IsStale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	seen := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+
+	policy := DefaultCompliancePolicy()
+	tests := []struct {
+		name     string
+		lastSeen *time.Time
+		want     bool
+	}{
+		{"Never reported", nil, false},
+		{"Reported today", seen(0), false},
+		{"Reported at the threshold", seen(30), false},
+		{"Reported before the threshold", seen(31), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.IsStale(Server{LastSeenAt: tt.lastSeen}, now); got != tt.want {
+				t.Errorf("IsStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	policy.StaleAfterDays = 0
+	if policy.IsStale(Server{LastSeenAt: seen(365)}, now) {
+		t.Error("Expected stale detection to be disabled")
+	}
+	policy.ExcludeStale = true
+	if err := policy.Validate(); err == nil {
+		t.Error("Expected an error for excluding stale servers without a threshold")
+	}
+	policy.StaleAfterDays = -1
+	policy.ExcludeStale = false
+	if err := policy.Validate(); err == nil {
+		t.Error("Expected an error for a negative threshold")
+	}
+}
+
+func TestCompliancePolicy_ScoreDescription(t *testing.T) {
+	policy := DefaultCompliancePolicy()
+
+	if got := policy.ScoreDescription(95); got[:9] != "Excellent" {
+		t.Errorf("Expected Excellent for 95, got %q", got)
+	}
+	if got := policy.ScoreDescription(60); got[:4] != "Fair" {
+		t.Errorf("Expected Fair for 60, got %q", got)
+	}
+	if got := policy.ScoreDescription(0); got[:8] != "Critical" {
+		t.Errorf("Expected Critical for 0, got %q", got)
+	}
+}
+
+func TestParsePolicyTiers(t *testing.T) {
+	tiers, err := ParsePolicyTiers("notice:12m:0.1, urgent:30d:1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []PolicyTier{
+		{Name: "notice", Months: 12, Penalty: 0.1},
+		{Name: "urgent", Days: 30, Penalty: 1},
+	}
+	if len(tiers) != len(expected) {
+		t.Fatalf("Expected %d tiers, got %d", len(expected), len(tiers))
+	}
+	for i := range expected {
+		if tiers[i] != expected[i] {
+			t.Errorf("Expected tier %+v, got %+v", expected[i], tiers[i])
+		}
+	}
+
+	for _, invalid := range []string{"notice:12m", "notice:12w:1", "notice:m:1", "notice:12m:high"} {
+		if _, err := ParsePolicyTiers(invalid); err == nil {
+			t.Errorf("Expected error for %q, got nil", invalid)
+		}
+	}
+}
+
+func TestParseCompliancePolicySet(t *testing.T) {
+	set, err := ParseCompliancePolicySet([]byte(`{
+		"default": {"end_of_life_penalty": 3},
+		"policies": {
+			"security": {"tiers": [{"name": "notice", "months": 12, "penalty": 0.2}]}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Omitted fields keep the built-in defaults
+	if set.Default.EndOfLifePenalty != 3 || len(set.Default.Tiers) != 1 || set.Default.Tiers[0].Months != 6 {
+		t.Errorf("Unexpected default policy: %+v", set.Default)
+	}
+
+	security, exists := set.Lookup("security")
+	if !exists {
+		t.Fatal("Expected the security policy to exist")
+	}
+	if security.Tiers[0].Name != "notice" || security.EndOfLifePenalty != 2 || len(security.ScoreBands) == 0 {
+		t.Errorf("Unexpected security policy: %+v", security)
+	}
+
+	if _, exists := set.Lookup("ops"); exists {
+		t.Error("Expected unknown policy lookup to fail")
+	}
+
+	if _, err := ParseCompliancePolicySet([]byte(`{"default": {"tiers": []}}`)); err == nil {
+		t.Error("Expected error for a policy without tiers")
+	}
+}
+
+func TestParseCompliancePolicySet_Example(t *testing.T) {
+	data, err := os.ReadFile("../../compliance-policy.example.json")
+	if err != nil {
+		t.Fatalf("Failed to read example policy: %v", err)
+	}
+
+	set, err := ParseCompliancePolicySet(data)
+	if err != nil {
+		t.Fatalf("Example policy is invalid: %v", err)
+	}
+	if len(set.Default.Tiers) != 3 || set.Default.Tiers[0].Name != "urgent" {
+		t.Errorf("Unexpected example default tiers: %+v", set.Default.Tiers)
+	}
+	if _, exists := set.Lookup("security"); !exists {
+		t.Error("Expected the example to define a security policy")
+	}
+	if set.Default.StaleAfterDays != 14 || !set.Default.ExcludeStale {
+		t.Errorf("Unexpected example stale settings: %+v", set.Default)
+	}
+}
+
+func TestCompliancePolicy_Environments(t *testing.T) {
+	set, err := ParseCompliancePolicySet([]byte(`{
+		"default": {
+			"tiers": [{"name": "warning", "months": 6, "penalty": 0.5}],
+			"environments": {
+				"prod": {"lead_months": 12, "end_of_life_penalty": 4},
+				"dev": {"grace_days": 90, "tiers": [{"name": "notice", "days": 30, "penalty": 0.1}]}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	prod := set.Default.ForEnvironment(EnvironmentProd)
+	if prod.LeadMonths != 12 || prod.EndOfLifePenalty != 4 || prod.Tiers[0].Name != "warning" {
+		t.Errorf("Expected prod to inherit tiers and override the rest, got %+v", prod)
+	}
+	dev := set.Default.ForEnvironment(EnvironmentDev)
+	if dev.GraceDays != 90 || dev.EndOfLifePenalty != 2 || dev.Tiers[0].Name != "notice" {
+		t.Errorf("Expected dev to inherit the penalty and override the rest, got %+v", dev)
+	}
+	if staging := set.Default.ForEnvironment(EnvironmentStaging); staging.LeadMonths != 0 || len(staging.Environments) != 2 {
+		t.Errorf("Expected staging to use the default policy, got %+v", staging)
+	}
+
+	now := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		policy       CompliancePolicy
+		endOfSupport time.Time
+		expected     string
+	}{
+		{"Prod inside lead period", prod, now.AddDate(0, 9, 0), StatusEndOfLife},
+		{"Prod warned before lead period", prod, now.AddDate(1, 3, 0), StatusEndingSoon},
+		{"Prod beyond lead and tiers", prod, now.AddDate(2, 0, 0), StatusSupported},
+		{"Dev inside grace period", dev, now.AddDate(0, 0, -60), StatusEndingSoon},
+		{"Dev past grace period", dev, now.AddDate(0, 0, -120), StatusEndOfLife},
+		{"Default past end of support", set.Default, now.AddDate(0, 0, -1), StatusEndOfLife},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := tt.policy.Classify(tt.endOfSupport, now); status != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, status)
+			}
+		})
+	}
+
+	for _, invalid := range []string{
+		`{"default": {"environments": {"qa": {}}}}`,
+		`{"default": {"environments": {"prod": {"environments": {"dev": {}}}}}}`,
+		`{"default": {"lead_months": 1, "grace_days": 10}}`,
+	} {
+		if _, err := ParseCompliancePolicySet([]byte(invalid)); err == nil {
+			t.Errorf("Expected error for %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Support status filter values
+const (
+	StatusSupported  = "supported"
+	StatusEndingSoon = "ending_soon"
+	StatusEndOfLife  = "eol"
+)
+
+// ValidateSupportStatus checks that a support status filter is one of the known values
+func ValidateSupportStatus(status string) error {
+	switch status {
+	case StatusSupported, StatusEndingSoon, StatusEndOfLife:
+		return nil
+	default:
+		return fmt.Errorf("invalid status %q: must be one of %s, %s, %s",
+			status, StatusSupported, StatusEndingSoon, StatusEndOfLife)
+	}
+}
+
+// SortField represents a single sort key of a list query
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a sort expression such as "name,-created_at" where a
+// leading hyphen requests descending order. Only allowed fields are accepted.
+func ParseSort(value string, allowed []string) ([]SortField, error) {
+	var fields []SortField
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: part}
+		if strings.HasPrefix(part, "-") {
+			field = SortField{Field: part[1:], Desc: true}
+		} else if strings.HasPrefix(part, "+") {
+			field = SortField{Field: part[1:]}
+		}
+
+		valid := false
+		for _, name := range allowed {
+			if field.Field == name {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid sort field %q: must be one of %s", field.Field, strings.Join(allowed, ", "))
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("sort field %q specified more than once", field.Field)
+		}
+		seen[field.Field] = true
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
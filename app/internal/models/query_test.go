@@ -0,0 +1,78 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"name", "created_at", "os_version"}
+
+	tests := []struct {
+		name      string
+		input     string
+		expected  []SortField
+		expectErr bool
+	}{
+		{
+			name:     "Single ascending field",
+			input:    "name",
+			expected: []SortField{{Field: "name"}},
+		},
+		{
+			name:     "Mixed directions",
+			input:    "-created_at,name",
+			expected: []SortField{{Field: "created_at", Desc: true}, {Field: "name"}},
+		},
+		{
+			name:     "Explicit ascending and whitespace",
+			input:    " +os_version , -name ",
+			expected: []SortField{{Field: "os_version"}, {Field: "name", Desc: true}},
+		},
+		{
+			name:     "Empty",
+			input:    "",
+			expected: nil,
+		},
+		{
+			name:      "Unknown field",
+			input:     "password",
+			expectErr: true,
+		},
+		{
+			name:      "Duplicate field",
+			input:     "name,-name",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := ParseSort(tt.input, allowed)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Expected error for input %q, but got none", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for input %q: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+func TestValidateSupportStatus(t *testing.T) {
+	for _, status := range []string{StatusSupported, StatusEndingSoon, StatusEndOfLife} {
+		if err := ValidateSupportStatus(status); err != nil {
+			t.Errorf("Unexpected error for status %q: %v", status, err)
+		}
+	}
+
+	if err := ValidateSupportStatus("expired"); err == nil {
+		t.Error("Expected error for unknown status, but got none")
+	}
+}
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// Sources an operating system can be detected from
+const (
+	OSSourceOSRelease      = "os_release"
+	OSSourceFreeBSDVersion = "freebsd_version"
+	OSSourceUname          = "uname"
+)
+
+// RegisterServerRequest registers a server by name from what the host
+// reports about itself, without knowing the ID of its operating system. One
+// of OSRelease, FreeBSDVersion and Uname is required; when several are
+// given they are used in that order. Empty attributes leave the value of an
+// existing server unchanged.
+type RegisterServerRequest struct {
+	Name           string `json:"name"`
+	OSRelease      string `json:"os_release,omitempty"`      // Contents of /etc/os-release
+	FreeBSDVersion string `json:"freebsd_version,omitempty"` // Output of freebsd-version
+	Uname          string `json:"uname,omitempty"`           // Output of uname -a
+	Environment    string `json:"environment,omitempty"`
+	Role           string `json:"role,omitempty"`
+	OwnerTeam      string `json:"owner_team,omitempty"`
+	Location       string `json:"location,omitempty"`
+	Description    string `json:"description,omitempty"`
+}
+
+// DetectedOS is an operating system detected from host data, normalized to
+// the names and versions of the catalog
+type DetectedOS struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"` // 'os_release', 'freebsd_version', 'uname'
+}
+
+// ServerRegistration reports the outcome of a server registration
+type ServerRegistration struct {
+	Action     string                 `json:"action"` // 'create', 'update', 'unchanged'
+	Server     *Server                `json:"server"`
+	DetectedOS DetectedOS             `json:"detected_os"`
+	Changes    map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// osReleaseFamilies maps os-release IDs to catalog OS names
+var osReleaseFamilies = map[string]string{
+	"debian":  "Debian",
+	"ubuntu":  "Ubuntu",
+	"rhel":    "RedHat",
+	"redhat":  "RedHat",
+	"centos":  "CentOS",
+	"freebsd": "FreeBSD",
+	"openbsd": "OpenBSD",
+}
+
+// osReleaseNames maps substrings of os-release NAME values to catalog OS
+// names, for files whose ID is missing or unknown
+var osReleaseNames = []struct {
+	substring string
+	family    string
+}{
+	{"red hat", "RedHat"},
+	{"centos", "CentOS"},
+	{"ubuntu", "Ubuntu"},
+	{"debian", "Debian"},
+	{"freebsd", "FreeBSD"},
+	{"openbsd", "OpenBSD"},
+}
+
+// DetectOS detects the operating system of a registration request
+func DetectOS(req RegisterServerRequest) (DetectedOS, error) {
+	switch {
+	case strings.TrimSpace(req.OSRelease) != "":
+		return ParseOSRelease(req.OSRelease)
+	case strings.TrimSpace(req.FreeBSDVersion) != "":
+		return ParseFreeBSDVersion(req.FreeBSDVersion)
+	case strings.TrimSpace(req.Uname) != "":
+		return ParseUname(req.Uname)
+	default:
+		return DetectedOS{}, fmt.Errorf("one of os_release, freebsd_version or uname is required")
+	}
+}
+
+// ParseOSRelease detects the operating system described by the contents of
+// an os-release file from its ID, VERSION_ID and NAME fields
+func ParseOSRelease(content string) (DetectedOS, error) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		fields[strings.TrimSpace(key)] = unquoteOSReleaseValue(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return DetectedOS{}, fmt.Errorf("invalid os-release: %w", err)
+	}
+
+	family := osReleaseFamilies[strings.ToLower(fields["ID"])]
+	if family == "" {
+		name := strings.ToLower(fields["NAME"])
+		for _, n := range osReleaseNames {
+			if name != "" && strings.Contains(name, n.substring) {
+				family = n.family
+				break
+			}
+		}
+	}
+	if family == "" {
+		if fields["ID"] == "" && fields["NAME"] == "" {
+			return DetectedOS{}, fmt.Errorf("os-release has no ID or NAME")
+		}
+		return DetectedOS{}, fmt.Errorf("unsupported operating system %q: expected Debian, Ubuntu, RedHat, CentOS, FreeBSD or OpenBSD", firstNonEmpty(fields["NAME"], fields["ID"]))
+	}
+
+	version := normalizeOSVersion(family, fields["VERSION_ID"])
+	if version == "" {
+		return DetectedOS{}, fmt.Errorf("os-release of %s has no VERSION_ID", family)
+	}
+
+	return DetectedOS{Name: family, Version: version, Source: OSSourceOSRelease}, nil
+}
+
+// ParseFreeBSDVersion detects the FreeBSD release reported by
+// freebsd-version, such as 14.1-RELEASE-p3
+func ParseFreeBSDVersion(output string) (DetectedOS, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return DetectedOS{}, fmt.Errorf("freebsd-version output is empty")
+	}
+	// freebsd-version -ku prints the kernel and userland versions; the
+	// userland, printed last, is what gets upgraded
+	version := normalizeOSVersion("FreeBSD", fields[len(fields)-1])
+	if version == "" {
+		return DetectedOS{}, fmt.Errorf("invalid freebsd-version output %q", strings.TrimSpace(output))
+	}
+	return DetectedOS{Name: "FreeBSD", Version: version, Source: OSSourceFreeBSDVersion}, nil
+}
+
+// ParseUname detects the operating system reported by uname -a. Only the BSDs
+// can be detected: on Linux the kernel release does not identify the
+// distribution.
+func ParseUname(output string) (DetectedOS, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return DetectedOS{}, fmt.Errorf("invalid uname output %q: expected uname -a", strings.TrimSpace(output))
+	}
+
+	var family string
+	switch fields[0] {
+	case "FreeBSD":
+		family = "FreeBSD"
+	case "OpenBSD":
+		family = "OpenBSD"
+	case "Linux":
+		return DetectedOS{}, fmt.Errorf("uname does not identify the Linux distribution: send the contents of /etc/os-release instead")
+	default:
+		return DetectedOS{}, fmt.Errorf("unsupported operating system %q: expected Debian, Ubuntu, RedHat, CentOS, FreeBSD or OpenBSD", fields[0])
+	}
+
+	version := normalizeOSVersion(family, fields[2])
+	if version == "" {
+		return DetectedOS{}, fmt.Errorf("invalid %s release %q in uname output", family, fields[2])
+	}
+	return DetectedOS{Name: family, Version: version, Source: OSSourceUname}, nil
+}
+
+// normalizeOSVersion converts a reported version to the form the catalog uses
+// for the family: the major version for Debian, CentOS and Red Hat, and
+// major.minor for the others. Release suffixes such as -RELEASE-p3 are
+// dropped. An empty string is returned for versions that do not start with
+// a number.
+func normalizeOSVersion(family, version string) string {
+	version = strings.TrimSpace(version)
+	if i := strings.IndexAny(version, "-_ +"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	for i, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			parts = parts[:i]
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	switch family {
+	case "Debian", "CentOS", "RedHat":
+		return parts[0]
+	default:
+		if len(parts) > 2 {
+			parts = parts[:2]
+		}
+		return strings.Join(parts, ".")
+	}
+}
+
+// unquoteOSReleaseValue removes the shell quoting of an os-release value
+func unquoteOSReleaseValue(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	switch quote := value[0]; {
+	case quote == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1]
+	case quote == '"' && value[len(value)-1] == '"':
+		var b strings.Builder
+		inner := value[1 : len(value)-1]
+		for i := 0; i < len(inner); i++ {
+			if inner[i] == '\\' && i+1 < len(inner) && strings.ContainsRune("\"\\$`", rune(inner[i+1])) {
+				i++
+			}
+			b.WriteByte(inner[i])
+		}
+		return b.String()
+	}
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
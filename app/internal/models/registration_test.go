@@ -0,0 +1,105 @@
+package models
+
+import "testing"
+
+func TestParseOSRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    DetectedOS
+	}{
+		{
+			"Ubuntu",
+			"PRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\nNAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nVERSION=\"22.04.4 LTS (Jammy Jellyfish)\"\nID=ubuntu\nID_LIKE=debian\n",
+			DetectedOS{Name: "Ubuntu", Version: "22.04", Source: OSSourceOSRelease},
+		},
+		{
+			"Debian",
+			"PRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nNAME=\"Debian GNU/Linux\"\nVERSION_ID=\"12\"\nID=debian\n",
+			DetectedOS{Name: "Debian", Version: "12", Source: OSSourceOSRelease},
+		},
+		{
+			"Red Hat minor release",
+			"NAME=\"Red Hat Enterprise Linux\"\nVERSION=\"9.3 (Plow)\"\nID=\"rhel\"\nID_LIKE=\"fedora\"\nVERSION_ID=\"9.3\"\n",
+			DetectedOS{Name: "RedHat", Version: "9", Source: OSSourceOSRelease},
+		},
+		{
+			"CentOS single quotes",
+			"NAME='CentOS Linux'\nVERSION_ID='7'\nID='centos'\n",
+			DetectedOS{Name: "CentOS", Version: "7", Source: OSSourceOSRelease},
+		},
+		{
+			"FreeBSD",
+			"NAME=FreeBSD\nVERSION=\"14.1-RELEASE-p3\"\nVERSION_ID=\"14.1\"\nID=freebsd\n",
+			DetectedOS{Name: "FreeBSD", Version: "14.1", Source: OSSourceOSRelease},
+		},
+		{
+			"name fallback",
+			"# comment\nNAME=\"Red Hat Enterprise Linux Server\"\nVERSION_ID=\"7.9\"\n",
+			DetectedOS{Name: "RedHat", Version: "7", Source: OSSourceOSRelease},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOSRelease(tt.content)
+			if err != nil || got != tt.want {
+				t.Errorf("ParseOSRelease() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{
+		"",
+		"ID=arch\nNAME=\"Arch Linux\"\n",
+		"ID=debian\nNAME=\"Debian GNU/Linux\"\nPRETTY_NAME=\"Debian GNU/Linux trixie/sid\"\n",
+	} {
+		if got, err := ParseOSRelease(invalid); err == nil {
+			t.Errorf("Expected an error for %q, got %+v", invalid, got)
+		}
+	}
+}
+
+func TestParseUname(t *testing.T) {
+	got, err := ParseUname("FreeBSD host1 14.1-RELEASE-p3 FreeBSD 14.1-RELEASE-p3 GENERIC amd64")
+	if err != nil || got != (DetectedOS{Name: "FreeBSD", Version: "14.1", Source: OSSourceUname}) {
+		t.Errorf("Unexpected FreeBSD detection: %+v, %v", got, err)
+	}
+	got, err = ParseUname("OpenBSD fw1 7.4 GENERIC.MP#1397 amd64")
+	if err != nil || got != (DetectedOS{Name: "OpenBSD", Version: "7.4", Source: OSSourceUname}) {
+		t.Errorf("Unexpected OpenBSD detection: %+v, %v", got, err)
+	}
+
+	for _, invalid := range []string{
+		"Linux web1 5.15.0-91-generic #101-Ubuntu SMP x86_64 GNU/Linux",
+		"Darwin mac 23.0.0 Darwin Kernel Version 23.0.0",
+		"FreeBSD",
+	} {
+		if got, err := ParseUname(invalid); err == nil {
+			t.Errorf("Expected an error for %q, got %+v", invalid, got)
+		}
+	}
+}
+
+func TestParseFreeBSDVersion(t *testing.T) {
+	// freebsd-version -ku prints the kernel then the userland version
+	got, err := ParseFreeBSDVersion("14.0-RELEASE-p6\n14.1-RELEASE-p3\n")
+	if err != nil || got != (DetectedOS{Name: "FreeBSD", Version: "14.1", Source: OSSourceFreeBSDVersion}) {
+		t.Errorf("Unexpected detection: %+v, %v", got, err)
+	}
+	if _, err := ParseFreeBSDVersion("CURRENT"); err == nil {
+		t.Error("Expected an error for a version without a number")
+	}
+}
+
+func TestDetectOS(t *testing.T) {
+	got, err := DetectOS(RegisterServerRequest{
+		OSRelease: "ID=ubuntu\nVERSION_ID=\"24.04\"\n",
+		Uname:     "Linux web1 6.8.0 x86_64",
+	})
+	if err != nil || got.Name != "Ubuntu" || got.Version != "24.04" {
+		t.Errorf("Expected os-release to take precedence, got %+v, %v", got, err)
+	}
+	if _, err := DetectOS(RegisterServerRequest{Name: "web1"}); err == nil {
+		t.Error("Expected an error without host data")
+	}
+}
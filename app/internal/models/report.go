@@ -0,0 +1,108 @@
+package models
+
+import (
+	_ "embed"
+	"fmt"
+	"time"
+)
+
+// ComplianceReportSchema is the JSON Schema of the detailed compliance report
+//
+//go:embed schema/compliance-report.schema.json
+var ComplianceReportSchema []byte
+
+// Recommendation severities, from the most to the least urgent
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+)
+
+// severityRank ranks recommendation severities, most urgent first
+var severityRank = map[string]int{
+	SeverityCritical: 0,
+	SeverityWarning:  1,
+}
+
+// ValidateSeverity checks that a recommendation severity filter is one of
+// the known values
+func ValidateSeverity(severity string) error {
+	if _, known := severityRank[severity]; !known {
+		return fmt.Errorf("invalid severity %q: must be one of %s, %s", severity, SeverityCritical, SeverityWarning)
+	}
+	return nil
+}
+
+// Recommendation codes, identifying what a recommendation asks for
+const (
+	RecommendationEndOfLife  = "end_of_life"   // Upgrade servers past end of support
+	RecommendationEndingSoon = "ending_soon"   // Upgrade servers in a policy tier before end of support
+	RecommendationStale      = "stale_servers" // Confirm or delete servers that stopped reporting
+)
+
+// Recommendation is an action recommended by the compliance report for a
+// group of servers
+type Recommendation struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	// Environment is the environment of the servers, when it has its own policy
+	Environment string `json:"environment,omitempty"`
+	// Tier is the policy tier of ending soon servers
+	Tier      string `json:"tier,omitempty"`
+	ServerIDs []int  `json:"server_ids"`
+	// OSIDs lists the operating systems the servers run
+	OSIDs []int `json:"os_ids"`
+	// Deadline is the earliest end of support of the servers, unset for
+	// stale servers
+	Deadline *time.Time `json:"deadline,omitempty"`
+	Message  string     `json:"message"`
+
+	penalty float64
+}
+
+// RecommendationFilter selects recommendations by severity and code. Empty
+// lists select every recommendation.
+type RecommendationFilter struct {
+	Severities []string
+	Codes      []string
+}
+
+// Matches reports whether a recommendation is selected by the filter
+func (f RecommendationFilter) Matches(rec Recommendation) bool {
+	return (len(f.Severities) == 0 || containsString(f.Severities, rec.Severity)) &&
+		(len(f.Codes) == 0 || containsString(f.Codes, rec.Code))
+}
+
+// FilterRecommendations returns the recommendations selected by the filter
+func FilterRecommendations(recs []Recommendation, filter RecommendationFilter) []Recommendation {
+	selected := make([]Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		if filter.Matches(rec) {
+			selected = append(selected, rec)
+		}
+	}
+	return selected
+}
+
+// DetailedComplianceReport is the compliance report with its score and the
+// recommendations derived from it, as served by the API and described by
+// ComplianceReportSchema
+type DetailedComplianceReport struct {
+	ComplianceReport
+	ComplianceScore  float64                 `json:"compliance_score"`
+	Recommendations  []Recommendation        `json:"recommendations"`
+	Upgrades         []UpgradeRecommendation `json:"upgrades"`
+	ScoreDescription string                  `json:"score_description"`
+}
+
+// GenerateDetailedReport generates the compliance report of the servers with
+// their score, recommendations and upgrades to releases of allOS
+func (u *ComplianceUtils) GenerateDetailedReport(servers []Server, allOS []OS) DetailedComplianceReport {
+	score := u.GetComplianceScore(servers)
+	return DetailedComplianceReport{
+		ComplianceReport: u.GenerateComplianceReport(servers),
+		ComplianceScore:  score,
+		Recommendations:  u.GetRecommendations(servers),
+		Upgrades:         u.GetUpgradeRecommendations(servers, allOS),
+		ScoreDescription: u.GetScoreDescription(score),
+	}
+}
@@ -0,0 +1,265 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recommendationSummaries describes recommendations as "severity: message"
+// strings
+func recommendationSummaries(recs []Recommendation) []string {
+	summaries := make([]string, 0, len(recs))
+	for _, rec := range recs {
+		summaries = append(summaries, rec.Severity+": "+rec.Message)
+	}
+	return summaries
+}
+
+func TestComplianceUtils_GetRecommendationsFields(t *testing.T) {
+	now := time.Now()
+	eol := OS{ID: 1, Name: "Ubuntu", Version: "18.04", EndOfSupport: now.AddDate(0, -2, 0)}
+	olderEOL := OS{ID: 2, Name: "CentOS", Version: "7", EndOfSupport: now.AddDate(-1, 0, 0)}
+	endingSoon := OS{ID: 3, Name: "Ubuntu", Version: "20.04", EndOfSupport: now.AddDate(0, 3, 0)}
+	lastSeen := now.AddDate(0, 0, -60)
+
+	servers := []Server{
+		{ID: 4, Name: "web-02", OSID: eol.ID, OS: &eol},
+		{ID: 2, Name: "db-01", OSID: olderEOL.ID, OS: &olderEOL},
+		{ID: 3, Name: "web-01", OSID: eol.ID, OS: &eol, LastSeenAt: &lastSeen},
+		{ID: 1, Name: "app-01", OSID: endingSoon.ID, OS: &endingSoon},
+	}
+
+	recs := NewComplianceUtils().GetRecommendations(servers)
+	if len(recs) != 3 {
+		t.Fatalf("Expected 3 recommendations, got %v", recommendationSummaries(recs))
+	}
+
+	critical := recs[0]
+	if critical.Severity != SeverityCritical || critical.Code != RecommendationEndOfLife || critical.Tier != "" ||
+		fmt.Sprint(critical.ServerIDs) != "[2 3 4]" || fmt.Sprint(critical.OSIDs) != "[1 2]" ||
+		critical.Deadline == nil || !critical.Deadline.Equal(olderEOL.EndOfSupport) {
+		t.Errorf("Unexpected end of life recommendation %+v", critical)
+	}
+
+	warning := recs[1]
+	if warning.Severity != SeverityWarning || warning.Code != RecommendationEndingSoon || warning.Tier != "ending_soon" ||
+		fmt.Sprint(warning.ServerIDs) != "[1]" || fmt.Sprint(warning.OSIDs) != "[3]" ||
+		warning.Deadline == nil || !warning.Deadline.Equal(endingSoon.EndOfSupport) {
+		t.Errorf("Unexpected ending soon recommendation %+v", warning)
+	}
+
+	stale := recs[2]
+	if stale.Severity != SeverityWarning || stale.Code != RecommendationStale ||
+		fmt.Sprint(stale.ServerIDs) != "[3]" || stale.Deadline != nil ||
+		stale.Message != "1 servers have not reported within the stale threshold; confirm they still exist or delete them" {
+		t.Errorf("Unexpected stale recommendation %+v", stale)
+	}
+
+	tests := []struct {
+		filter RecommendationFilter
+		want   []string
+	}{
+		{RecommendationFilter{}, []string{RecommendationEndOfLife, RecommendationEndingSoon, RecommendationStale}},
+		{RecommendationFilter{Severities: []string{SeverityCritical}}, []string{RecommendationEndOfLife}},
+		{RecommendationFilter{Severities: []string{SeverityWarning}}, []string{RecommendationEndingSoon, RecommendationStale}},
+		{RecommendationFilter{Severities: []string{SeverityWarning}, Codes: []string{RecommendationStale}}, []string{RecommendationStale}},
+		{RecommendationFilter{Codes: []string{"unknown"}}, []string{}},
+	}
+	for _, tt := range tests {
+		codes := []string{}
+		for _, rec := range FilterRecommendations(recs, tt.filter) {
+			codes = append(codes, rec.Code)
+		}
+		if fmt.Sprint(codes) != fmt.Sprint(tt.want) {
+			t.Errorf("FilterRecommendations(%+v) = %v, want %v", tt.filter, codes, tt.want)
+		}
+	}
+}
+
+func TestValidateSeverity(t *testing.T) {
+	for _, severity := range []string{SeverityCritical, SeverityWarning} {
+		if err := ValidateSeverity(severity); err != nil {
+			t.Errorf("ValidateSeverity(%q) error = %v", severity, err)
+		}
+	}
+	for _, severity := range []string{"", "CRITICAL", "info"} {
+		if err := ValidateSeverity(severity); err == nil {
+			t.Errorf("ValidateSeverity(%q) succeeded", severity)
+		}
+	}
+}
+
+// validateSchema checks a decoded JSON value against the subset of JSON
+// Schema used by ComplianceReportSchema, returning the first violation
+func validateSchema(root, schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		def, ok := root["$defs"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unresolved reference %s", path, ref)
+		}
+		return validateSchema(root, def, value, path)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || allowed == value
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+
+	if types, ok := schema["type"]; ok {
+		var allowed []interface{}
+		if name, ok := types.(string); ok {
+			allowed = []interface{}{name}
+		} else {
+			allowed = types.([]interface{})
+		}
+		matched := false
+		for _, name := range allowed {
+			switch name {
+			case "object":
+				_, ok := value.(map[string]interface{})
+				matched = matched || ok
+			case "array":
+				_, ok := value.([]interface{})
+				matched = matched || ok
+			case "string":
+				_, ok := value.(string)
+				matched = matched || ok
+			case "boolean":
+				_, ok := value.(bool)
+				matched = matched || ok
+			case "number":
+				_, ok := value.(float64)
+				matched = matched || ok
+			case "integer":
+				n, ok := value.(float64)
+				matched = matched || (ok && n == math.Trunc(n))
+			case "null":
+				matched = matched || value == nil
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: %v is not of type %v", path, value, allowed)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range asSlice(schema["required"]) {
+			if _, ok := v[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, field := range v {
+			fieldSchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				switch additional := schema["additionalProperties"].(type) {
+				case bool:
+					if !additional {
+						return fmt.Errorf("%s: unexpected property %s", path, name)
+					}
+					continue
+				case map[string]interface{}:
+					fieldSchema = additional
+				default:
+					continue
+				}
+			}
+			if err := validateSchema(root, fieldSchema, field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// asSlice returns a decoded JSON array, or nil for any other value
+func asSlice(value interface{}) []interface{} {
+	slice, _ := value.([]interface{})
+	return slice
+}
+
+func TestComplianceReportSchema(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(ComplianceReportSchema, &schema); err != nil {
+		t.Fatalf("Invalid compliance report schema: %v", err)
+	}
+
+	now := time.Now()
+	lastSeen := now.AddDate(0, 0, -60)
+	extended := now.AddDate(2, 0, 0)
+	serverID := 4
+	eol := OS{ID: 1, Name: "CentOS", Version: "7", EndOfSupport: now.AddDate(-1, 0, 0)}
+	endingSoon := OS{ID: 2, Name: "Ubuntu", Version: "20.04", LTS: true, EndOfSupport: now.AddDate(0, 3, 0), EndOfExtendedSupport: &extended}
+	catalog := []OS{eol, endingSoon,
+		{ID: 3, Name: "AlmaLinux", Version: "8", EndOfSupport: now.AddDate(4, 0, 0)},
+		{ID: 4, Name: "Ubuntu", Version: "24.04", LTS: true, EndOfSupport: now.AddDate(4, 0, 0)},
+	}
+	servers := []Server{
+		{ID: 1, Name: "db-01", OSID: eol.ID, OS: &eol, Environment: EnvironmentProd},
+		{ID: 2, Name: "web-01", OSID: endingSoon.ID, OS: &endingSoon, LastSeenAt: &lastSeen},
+		{ID: 3, Name: "web-02", OSID: endingSoon.ID, OS: &endingSoon, ExtendedSupport: true},
+		{ID: 4, Name: "ci-01", OSID: eol.ID, OS: &eol},
+	}
+	waivers := []Waiver{{ID: 1, ServerID: &serverID, Justification: "Vendor appliance", Approver: "alice", ExpiresAt: now.AddDate(0, 1, 0)}}
+
+	policy := DefaultCompliancePolicy()
+	prod := DefaultCompliancePolicy()
+	prod.LeadMonths = 12
+	policy.Environments = map[string]CompliancePolicy{EnvironmentProd: prod}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Unexpected policy error: %v", err)
+	}
+	asOf := now
+	scheduledID := 1
+	changes := []ScheduledChange{{ID: 1, ServerID: &scheduledID, ServerName: "db-01", OSID: 3, Status: ScheduledChangePending, RequestedBy: "bob",
+		WindowStart: now.AddDate(0, 0, 7), WindowEnd: now.AddDate(0, 0, 8), CreatedAt: now, UpdatedAt: now}}
+	utils := NewComplianceUtilsWithPolicy(policy).WithWaivers(waivers).WithScheduledChanges(changes).AsOf(asOf)
+
+	full := utils.GenerateDetailedReport(servers, catalog)
+	if len(full.Waived) == 0 || len(full.StaleList) == 0 || len(full.Recommendations) == 0 || len(full.Upgrades) == 0 ||
+		len(full.RemediationScheduled) == 0 {
+		t.Fatalf("Expected every list of the report to be populated, got %+v", full)
+	}
+
+	for _, report := range []DetailedComplianceReport{full, NewComplianceUtils().GenerateDetailedReport(nil, nil)} {
+		data, err := json.Marshal(report)
+		if err != nil {
+			t.Fatalf("Failed to encode report: %v", err)
+		}
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		if err := validateSchema(schema, schema, value, "report"); err != nil {
+			t.Errorf("Report does not match its schema: %v\n%s", err, data)
+		}
+	}
+
+	// The validator rejects reports that drift from the schema
+	var value map[string]interface{}
+	data, _ := json.Marshal(full)
+	json.Unmarshal(data, &value)
+	value["recommendations"].([]interface{})[0].(map[string]interface{})["severity"] = "CRITICAL"
+	if err := validateSchema(schema, schema, value, "report"); err == nil {
+		t.Error("Expected an upper-case severity to be rejected")
+	}
+}
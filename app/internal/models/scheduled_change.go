@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduledChange moves a server to another operating system during a
+// maintenance window instead of right away. Pending changes are applied by
+// the maintenance executor once their window opens; the outcome is kept for
+// auditing, also after the server is deleted.
+type ScheduledChange struct {
+	ID       int  `json:"id" db:"id"`
+	ServerID *int `json:"server_id" db:"server_id"` // Null once the server is deleted
+	// ServerName is the name of the server when the change was scheduled, or
+	// when the server was deleted
+	ServerName  string    `json:"server_name" db:"server_name"`
+	OSID        int       `json:"os_id" db:"os_id"` // Target operating system
+	WindowStart time.Time `json:"window_start" db:"window_start"`
+	WindowEnd   time.Time `json:"window_end" db:"window_end"`
+	Status      string    `json:"status" db:"status"` // 'pending', 'applying', 'applied', 'failed', 'expired' or 'cancelled'
+	// RequestedBy is the actor who scheduled the change, recorded in the
+	// change history when it is applied
+	RequestedBy string `json:"requested_by,omitempty" db:"requested_by"`
+	// Reason explains why a change failed, expired or was cancelled
+	Reason     string     `json:"reason,omitempty" db:"reason"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"` // When the change left the pending status
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Scheduled change statuses. A due change is claimed by moving it to
+// applying before the server is updated; every other status is final.
+const (
+	ScheduledChangePending   = "pending"
+	ScheduledChangeApplying  = "applying"
+	ScheduledChangeApplied   = "applied"
+	ScheduledChangeFailed    = "failed"
+	ScheduledChangeExpired   = "expired"
+	ScheduledChangeCancelled = "cancelled"
+)
+
+// ValidateScheduledChangeStatus checks that a scheduled change status is one
+// of the known values
+func ValidateScheduledChangeStatus(status string) error {
+	switch status {
+	case ScheduledChangePending, ScheduledChangeApplying, ScheduledChangeApplied, ScheduledChangeFailed, ScheduledChangeExpired, ScheduledChangeCancelled:
+		return nil
+	default:
+		return fmt.Errorf("invalid scheduled change status %q: must be one of %s, %s, %s, %s, %s, %s", status,
+			ScheduledChangePending, ScheduledChangeApplying, ScheduledChangeApplied, ScheduledChangeFailed, ScheduledChangeExpired, ScheduledChangeCancelled)
+	}
+}
+
+// Due reports whether a pending change should be applied or expired at now:
+// its window has opened
+func (c ScheduledChange) Due(now time.Time) bool {
+	return c.Status == ScheduledChangePending && !now.Before(c.WindowStart)
+}
+
+// Missed reports whether the window of a change has closed at now
+func (c ScheduledChange) Missed(now time.Time) bool {
+	return !now.Before(c.WindowEnd)
+}
+
+// CreateScheduledChangeRequest represents the request body for scheduling
+// an operating system change
+type CreateScheduledChangeRequest struct {
+	ServerID    int    `json:"server_id" validate:"required"`
+	OSID        int    `json:"os_id" validate:"required"`
+	WindowStart string `json:"window_start" validate:"required"` // Expected format: RFC 3339
+	WindowEnd   string `json:"window_end" validate:"required"`   // Expected format: RFC 3339
+}
+
+// ScheduledChangeFilter represents filters and pagination for querying
+// scheduled changes
+type ScheduledChangeFilter struct {
+	ServerID *int
+	Status   *string
+	Limit    int
+	Offset   int
+}
+
+// ScheduledRemediation is an end-of-life or ending soon server with a
+// pending change to another operating system
+type ScheduledRemediation struct {
+	Server Server `json:"server"`
+	// Status is the support status of the server until the change is applied
+	Status string          `json:"status"`
+	Change ScheduledChange `json:"change"`
+}
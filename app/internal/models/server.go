@@ -2,24 +2,196 @@ package models
 
 import "time"
 
+// Server environments
+const (
+	EnvironmentProd    = "prod"
+	EnvironmentStaging = "staging"
+	EnvironmentDev     = "dev"
+)
+
 // Server represents a server in the infrastructure
 type Server struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	OSID      int       `json:"os_id" db:"os_id"`
-	OS        *OS       `json:"os,omitempty" db:"-"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID              int        `json:"id" db:"id"`
+	Name            string     `json:"name" db:"name"`
+	OSID            int        `json:"os_id" db:"os_id"`
+	OS              *OS        `json:"os,omitempty" db:"-"`
+	Environment     string     `json:"environment,omitempty" db:"environment"` // 'prod', 'staging', 'dev'
+	Role            string     `json:"role,omitempty" db:"role"`
+	OwnerTeam       string     `json:"owner_team,omitempty" db:"owner_team"`
+	Location        string     `json:"location,omitempty" db:"location"` // Datacenter or region
+	Description     string     `json:"description,omitempty" db:"description"`
+	ExtendedSupport bool       `json:"extended_support,omitempty" db:"extended_support"` // Enrolled in the paid extended support of its OS
+	LastSeenAt      *time.Time `json:"last_seen_at,omitempty" db:"last_seen_at"`         // Last registration or import, nil if the host never reported
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// EndOfSupport returns the end of support date the server is evaluated
+// against: the end of extended support of its OS when the server is enrolled
+// in extended support and the OS offers it, else the end of support of its
+// OS. The server must have its OS attached.
+func (s Server) EndOfSupport() time.Time {
+	if s.ExtendedSupport && s.OS.EndOfExtendedSupport != nil {
+		return *s.OS.EndOfExtendedSupport
+	}
+	return s.OS.EndOfSupport
+}
+
+// InExtendedSupport reports whether the server relies on paid extended
+// support at now: it is enrolled and its OS is past end of support, but
+// within extended support
+func (s Server) InExtendedSupport(now time.Time) bool {
+	return s.ExtendedSupport && s.OS != nil && s.OS.Phase(now) == PhaseExtended
 }
 
 // CreateServerRequest represents the request body for creating a server
 type CreateServerRequest struct {
-	Name string `json:"name" validate:"required"`
-	OSID int    `json:"os_id" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	OSID        int    `json:"os_id" validate:"required"`
+	Environment string `json:"environment,omitempty"`
+	Role        string `json:"role,omitempty"`
+	OwnerTeam   string `json:"owner_team,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Description string `json:"description,omitempty"`
+	// ExtendedSupport enrolls the server in the extended support of its OS
+	ExtendedSupport bool `json:"extended_support,omitempty"`
 }
 
 // UpdateServerRequest represents the request body for updating a server
 type UpdateServerRequest struct {
-	Name string `json:"name,omitempty"`
-	OSID int    `json:"os_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	OSID        int    `json:"os_id,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Role        string `json:"role,omitempty"`
+	OwnerTeam   string `json:"owner_team,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Description string `json:"description,omitempty"`
+	// ExtendedSupport enrolls or withdraws the server from the extended
+	// support of its OS; nil keeps the current enrollment
+	ExtendedSupport *bool `json:"extended_support,omitempty"`
+}
+
+// ServerSortFields lists the fields servers can be sorted by
+var ServerSortFields = []string{
+	"id", "name", "os_name", "os_version", "end_of_support",
+	"environment", "role", "owner_team", "location", "last_seen_at", "created_at", "updated_at",
+}
+
+// DefaultServerSort orders the server lists that do not specify a sort,
+// newest first
+var DefaultServerSort = []SortField{{Field: "created_at", Desc: true}}
+
+// ServerCursor returns the cursor following server in a list sorted by fields
+func ServerCursor(server Server, fields []SortField) Cursor {
+	var os OS
+	if server.OS != nil {
+		os = *server.OS
+	}
+
+	values := make([]*string, len(fields))
+	for i, f := range fields {
+		switch f.Field {
+		case "id":
+			values[i] = cursorInt(server.ID)
+		case "name":
+			values[i] = cursorString(server.Name)
+		case "os_name":
+			values[i] = cursorString(os.Name)
+		case "os_version":
+			values[i] = cursorString(os.Version)
+		case "end_of_support":
+			values[i] = cursorTime(&os.EndOfSupport)
+		case "environment":
+			values[i] = cursorString(server.Environment)
+		case "role":
+			values[i] = cursorString(server.Role)
+		case "owner_team":
+			values[i] = cursorString(server.OwnerTeam)
+		case "location":
+			values[i] = cursorString(server.Location)
+		case "last_seen_at":
+			values[i] = cursorTime(server.LastSeenAt)
+		case "created_at":
+			values[i] = cursorTime(&server.CreatedAt)
+		case "updated_at":
+			values[i] = cursorTime(&server.UpdatedAt)
+		}
+	}
+
+	return Cursor{Sort: FormatSort(fields), Values: values, ID: server.ID}
+}
+
+// DecodeServerCursor parses an opaque cursor of a server list sorted by fields
+func DecodeServerCursor(value string, fields []SortField) (*Cursor, error) {
+	cursor, err := DecodeCursor(value, fields)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cursor.Server(fields); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Server returns a server holding the values of a cursor of a list sorted by
+// fields, which the servers of the list can be compared with
+func (c Cursor) Server(fields []SortField) (Server, error) {
+	server := Server{ID: c.ID, OS: &OS{}}
+	for i, f := range fields {
+		var err error
+		switch value := c.Values[i]; f.Field {
+		case "id":
+			err = setCursorInt(&server.ID, f.Field, value)
+		case "name":
+			err = setCursorString(&server.Name, f.Field, value)
+		case "os_name":
+			err = setCursorString(&server.OS.Name, f.Field, value)
+		case "os_version":
+			err = setCursorString(&server.OS.Version, f.Field, value)
+		case "end_of_support":
+			err = setCursorTime(&server.OS.EndOfSupport, f.Field, value)
+		case "environment":
+			err = setCursorString(&server.Environment, f.Field, value)
+		case "role":
+			err = setCursorString(&server.Role, f.Field, value)
+		case "owner_team":
+			err = setCursorString(&server.OwnerTeam, f.Field, value)
+		case "location":
+			err = setCursorString(&server.Location, f.Field, value)
+		case "last_seen_at":
+			err = setCursorOptionalTime(&server.LastSeenAt, f.Field, value)
+		case "created_at":
+			err = setCursorTime(&server.CreatedAt, f.Field, value)
+		case "updated_at":
+			err = setCursorTime(&server.UpdatedAt, f.Field, value)
+		}
+		if err != nil {
+			return Server{}, err
+		}
+	}
+
+	return server, nil
+}
+
+// ServerFilter represents filters, ordering and pagination for querying servers
+type ServerFilter struct {
+	Name             *string // Case-insensitive substring match
+	Family           *string // OS family name
+	OSID             *int
+	SupportStatus    *string    // 'supported', 'ending_soon', 'eol'
+	EndingSoonCutoff *time.Time // End of the ending soon window, defaults to the default policy
+	Environment      *string
+	Role             *string
+	OwnerTeam        *string
+	Location         *string
+	Stale            *bool      // Whether the server was last seen before StaleCutoff
+	StaleCutoff      *time.Time // Defaults to the threshold of the default policy
+	CreatedAfter     *time.Time
+	CreatedBefore    *time.Time
+	UpdatedAfter     *time.Time
+	UpdatedBefore    *time.Time
+	AsOf             *time.Time  // Rebuild the fleet at this time from the change history
+	Sort             []SortField // Defaults to DefaultServerSort
+	After            *Cursor     // Only the servers sorting after the cursor
+	Limit            int
 }
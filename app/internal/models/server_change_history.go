@@ -1,20 +1,43 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
 
 // ServerChangeHistory represents a change made to a server
 type ServerChangeHistory struct {
-	ID           int       `json:"id" db:"id"`
-	ServerID     *int      `json:"server_id" db:"server_id"`
-	ServerName   string    `json:"server_name" db:"server_name"`
-	ChangeType   string    `json:"change_type" db:"change_type"` // 'created', 'os_changed', 'deleted'
-	OldOSID      *int      `json:"old_os_id,omitempty" db:"old_os_id"`
-	NewOSID      *int      `json:"new_os_id,omitempty" db:"new_os_id"`
-	OldOSName    *string   `json:"old_os_name,omitempty" db:"old_os_name"`
-	OldOSVersion *string   `json:"old_os_version,omitempty" db:"old_os_version"`
-	NewOSName    *string   `json:"new_os_name,omitempty" db:"new_os_name"`
-	NewOSVersion *string   `json:"new_os_version,omitempty" db:"new_os_version"`
-	ChangedAt    time.Time `json:"changed_at" db:"changed_at"`
+	ID           int                    `json:"id" db:"id"`
+	ServerID     *int                   `json:"server_id" db:"server_id"`
+	ServerName   string                 `json:"server_name" db:"server_name"`
+	ChangeType   string                 `json:"change_type" db:"change_type"` // 'created', 'os_changed', 'renamed', 'updated', 'deleted'
+	OldOSID      *int                   `json:"old_os_id,omitempty" db:"old_os_id"`
+	NewOSID      *int                   `json:"new_os_id,omitempty" db:"new_os_id"`
+	OldOSName    *string                `json:"old_os_name,omitempty" db:"old_os_name"`
+	OldOSVersion *string                `json:"old_os_version,omitempty" db:"old_os_version"`
+	NewOSName    *string                `json:"new_os_name,omitempty" db:"new_os_name"`
+	NewOSVersion *string                `json:"new_os_version,omitempty" db:"new_os_version"`
+	Environment  string                 `json:"environment,omitempty" db:"environment"` // Server attributes at the time of the change
+	Role         string                 `json:"role,omitempty" db:"role"`
+	OwnerTeam    string                 `json:"owner_team,omitempty" db:"owner_team"`
+	Location     string                 `json:"location,omitempty" db:"location"`
+	ChangedBy    string                 `json:"changed_by,omitempty" db:"changed_by"` // Actor who made the change
+	RequestID    string                 `json:"request_id,omitempty" db:"request_id"`
+	Source       string                 `json:"source,omitempty" db:"source"`   // 'api', ...
+	Changes      map[string]FieldChange `json:"changes,omitempty" db:"changes"` // Changed fields by JSON name
+	ChangedAt    time.Time              `json:"changed_at" db:"changed_at"`
+}
+
+// MarshalJSON encodes the record with its resource type
+func (h ServerChangeHistory) MarshalJSON() ([]byte, error) {
+	type record ServerChangeHistory
+	return json.Marshal(struct {
+		ResourceType string `json:"resource_type"`
+		record
+	}{ResourceTypeServer, record(h)})
 }
 
 // ChangeHistoryFilter represents filters for querying change history
@@ -26,3 +49,139 @@ type ChangeHistoryFilter struct {
 	Limit      int
 	Offset     int
 }
+
+// Change types recorded in the server change history
+const (
+	ChangeTypeCreated   = "created"
+	ChangeTypeOSChanged = "os_changed"
+	ChangeTypeRenamed   = "renamed"
+	ChangeTypeUpdated   = "updated"
+	ChangeTypeDeleted   = "deleted"
+)
+
+// ValidateChangeType checks that a change type filter is one of the known values
+func ValidateChangeType(changeType string) error {
+	switch changeType {
+	case ChangeTypeCreated, ChangeTypeOSChanged, ChangeTypeRenamed, ChangeTypeUpdated, ChangeTypeDeleted:
+		return nil
+	default:
+		return fmt.Errorf("invalid change_type %q: must be one of %s, %s, %s, %s, %s", changeType,
+			ChangeTypeCreated, ChangeTypeOSChanged, ChangeTypeRenamed, ChangeTypeUpdated, ChangeTypeDeleted)
+	}
+}
+
+// ReplayServerHistory rebuilds the servers that existed at asOf by replaying
+// the change history recorded up to that time, in any order. Operating
+// systems are attached from the catalog by ID, or by name and version when
+// the ID is no longer known, so their end of support is the current one.
+//
+// History of deleted servers loses its server ID, so those records are
+// matched by server name, following renames, and the rebuilt servers have
+// no ID.
+func ReplayServerHistory(history []ServerChangeHistory, oss []OS, asOf time.Time) []Server {
+	records := make([]ServerChangeHistory, 0, len(history))
+	for _, record := range history {
+		if !record.ChangedAt.After(asOf) {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].ChangedAt.Equal(records[j].ChangedAt) {
+			return records[i].ChangedAt.Before(records[j].ChangedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+
+	byID := make(map[int]OS, len(oss))
+	byRelease := make(map[string]OS, len(oss))
+	for _, os := range oss {
+		byID[os.ID] = os
+		byRelease[os.Name+" "+os.Version] = os
+	}
+	findOS := func(id *int, name, version *string) *OS {
+		if id != nil {
+			if os, exists := byID[*id]; exists {
+				return &os
+			}
+		}
+		if name != nil && version != nil {
+			if os, exists := byRelease[*name+" "+*version]; exists {
+				return &os
+			}
+		}
+		return nil
+	}
+
+	servers := make(map[string]*Server)
+	var order []string
+	for _, record := range records {
+		key := "name:" + record.ServerName
+		if record.ServerID != nil {
+			key = "id:" + strconv.Itoa(*record.ServerID)
+		} else if change, renamed := record.Changes["name"]; renamed {
+			// Carry a server matched by name over to its new name
+			if oldName, ok := change.Old.(string); ok && oldName != record.ServerName {
+				renameKey(servers, order, "name:"+oldName, key)
+			}
+		}
+
+		if record.ChangeType == ChangeTypeDeleted {
+			delete(servers, key)
+			continue
+		}
+
+		server, exists := servers[key]
+		if !exists {
+			server = &Server{CreatedAt: record.ChangedAt}
+			if record.ServerID != nil {
+				server.ID = *record.ServerID
+			}
+			servers[key] = server
+			order = append(order, key)
+		}
+
+		server.Name = record.ServerName
+		server.Environment = record.Environment
+		server.Role = record.Role
+		server.OwnerTeam = record.OwnerTeam
+		server.Location = record.Location
+		server.UpdatedAt = record.ChangedAt
+		// Enrollment in extended support is only recorded when it changes
+		if change, changed := record.Changes["extended_support"]; changed {
+			server.ExtendedSupport = change.New == true
+		}
+		server.OS = findOS(record.NewOSID, record.NewOSName, record.NewOSVersion)
+		server.OSID = 0
+		if server.OS != nil {
+			server.OSID = server.OS.ID
+		}
+	}
+
+	var result []Server
+	for _, key := range order {
+		if server, exists := servers[key]; exists {
+			result = append(result, *server)
+			// Keys reused after a deletion are listed again
+			delete(servers, key)
+		}
+	}
+
+	return result
+}
+
+// renameKey moves the server replayed under oldKey, if any, to newKey,
+// keeping its position in order
+func renameKey(servers map[string]*Server, order []string, oldKey, newKey string) {
+	server, exists := servers[oldKey]
+	if !exists {
+		return
+	}
+	delete(servers, oldKey)
+	servers[newKey] = server
+	for i := len(order) - 1; i >= 0; i-- {
+		if order[i] == oldKey {
+			order[i] = newKey
+			break
+		}
+	}
+}
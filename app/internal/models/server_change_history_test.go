@@ -289,3 +289,78 @@ func TestServerChangeHistoryJSONOmitEmpty(t *testing.T) {
 
 	_ = jsonString // Use the variable to avoid unused variable error
 }
+
+func TestReplayServerHistory(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 12, 0, 0, 0, time.UTC) }
+	str := func(s string) *string { return &s }
+	id := func(i int) *int { return &i }
+
+	centos := OS{ID: 1, Name: "CentOS", Version: "7", EndOfSupport: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)}
+	rocky := OS{ID: 2, Name: "Rocky Linux", Version: "9"}
+
+	history := []ServerChangeHistory{
+		{ID: 1, ServerID: id(10), ServerName: "web-01", ChangeType: ChangeTypeCreated, NewOSID: id(1), NewOSName: str("CentOS"), NewOSVersion: str("7"), Environment: "prod", ChangedAt: day(1)},
+		{ID: 2, ServerName: "db-01", ChangeType: ChangeTypeCreated, NewOSName: str("CentOS"), NewOSVersion: str("7"), ChangedAt: day(2)},
+		{ID: 3, ServerID: id(10), ServerName: "web-01", ChangeType: ChangeTypeOSChanged, OldOSID: id(1), NewOSID: id(2), NewOSName: str("Rocky Linux"), NewOSVersion: str("9"), Environment: "prod", ChangedAt: day(5)},
+		{ID: 4, ServerName: "db-01", ChangeType: ChangeTypeDeleted, OldOSName: str("CentOS"), OldOSVersion: str("7"), ChangedAt: day(8)},
+		{ID: 5, ServerName: "db-01", ChangeType: ChangeTypeCreated, NewOSID: id(2), NewOSName: str("Rocky Linux"), NewOSVersion: str("9"), ChangedAt: day(9)},
+	}
+	oss := []OS{centos, rocky}
+
+	tests := []struct {
+		asOf     time.Time
+		expected map[string]string // Server name to OS name
+	}{
+		{day(1).Add(-time.Hour), map[string]string{}},
+		{day(3), map[string]string{"web-01": "CentOS", "db-01": "CentOS"}},
+		{day(6), map[string]string{"web-01": "Rocky Linux", "db-01": "CentOS"}},
+		{day(8), map[string]string{"web-01": "Rocky Linux"}},
+		{day(10), map[string]string{"web-01": "Rocky Linux", "db-01": "Rocky Linux"}},
+	}
+
+	for _, tt := range tests {
+		servers := ReplayServerHistory(history, oss, tt.asOf)
+		if len(servers) != len(tt.expected) {
+			t.Errorf("As of %v: expected %d servers, got %+v", tt.asOf, len(tt.expected), servers)
+			continue
+		}
+		for _, server := range servers {
+			if server.OS == nil || server.OS.Name != tt.expected[server.Name] {
+				t.Errorf("As of %v: unexpected OS for %s: %+v", tt.asOf, server.Name, server.OS)
+			}
+		}
+	}
+
+	servers := ReplayServerHistory(history, oss, day(6))
+	for _, server := range servers {
+		switch server.Name {
+		case "web-01":
+			if server.ID != 10 || server.OSID != 2 || server.Environment != "prod" || !server.CreatedAt.Equal(day(1)) || !server.UpdatedAt.Equal(day(5)) {
+				t.Errorf("Unexpected rebuilt web-01: %+v", server)
+			}
+		case "db-01":
+			// Matched to the catalog by name and version, and without an ID once deleted
+			if server.ID != 0 || server.OSID != centos.ID || !server.OS.EndOfSupport.Equal(centos.EndOfSupport) {
+				t.Errorf("Unexpected rebuilt db-01: %+v", server)
+			}
+		}
+	}
+}
+
+func TestReplayServerHistory_RenamedThenDeleted(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 12, 0, 0, 0, time.UTC) }
+
+	// Deleting the server cleared the server ID of its whole history
+	history := []ServerChangeHistory{
+		{ID: 1, ServerName: "a", ChangeType: ChangeTypeCreated, ChangedAt: day(1)},
+		{ID: 2, ServerName: "b", ChangeType: ChangeTypeRenamed, Changes: map[string]FieldChange{"name": {Old: "a", New: "b"}}, ChangedAt: day(2)},
+		{ID: 3, ServerName: "b", ChangeType: ChangeTypeDeleted, ChangedAt: day(3)},
+	}
+
+	if servers := ReplayServerHistory(history, nil, day(2)); len(servers) != 1 || servers[0].Name != "b" || !servers[0].CreatedAt.Equal(day(1)) {
+		t.Errorf("Expected the renamed server, got %+v", servers)
+	}
+	if servers := ReplayServerHistory(history, nil, day(4)); len(servers) != 0 {
+		t.Errorf("Expected no server after the deletion, got %+v", servers)
+	}
+}
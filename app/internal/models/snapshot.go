@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ComplianceSnapshot records the compliance summary of the fleet under the
+// default policy at a point in time
+type ComplianceSnapshot struct {
+	ID                int       `json:"id" db:"id"`
+	TakenAt           time.Time `json:"taken_at" db:"taken_at"`
+	Source            string    `json:"source" db:"source"`
+	TotalServers      int       `json:"total_servers" db:"total_servers"`
+	SupportedServers  int       `json:"supported_servers" db:"supported_servers"`
+	EndOfLifeServers  int       `json:"end_of_life_servers" db:"end_of_life_servers"`
+	EndingSoonServers int       `json:"ending_soon_servers" db:"ending_soon_servers"`
+	WaivedServers     int       `json:"waived_servers" db:"waived_servers"`
+	ComplianceScore   float64   `json:"compliance_score" db:"compliance_score"`
+}
+
+// Snapshot sources
+const (
+	SnapshotSourceScheduled = "scheduled"
+	SnapshotSourceManual    = "manual"
+)
+
+// NewComplianceSnapshot summarizes a compliance report and its score
+func NewComplianceSnapshot(report ComplianceReport, score float64, source string) ComplianceSnapshot {
+	return ComplianceSnapshot{
+		TakenAt:           report.GeneratedAt,
+		Source:            source,
+		TotalServers:      report.TotalServers,
+		SupportedServers:  report.SupportedServers,
+		EndOfLifeServers:  report.EndOfLifeServers,
+		EndingSoonServers: report.EndingSoonServers,
+		WaivedServers:     report.WaivedServers,
+		ComplianceScore:   score,
+	}
+}
+
+// SnapshotFilter represents filters and pagination for querying snapshots
+type SnapshotFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+	// Latest orders snapshots newest first instead of oldest first
+	Latest bool
+}
+
+// Trend intervals
+const (
+	IntervalDay     = "day"
+	IntervalWeek    = "week"
+	IntervalMonth   = "month"
+	IntervalQuarter = "quarter"
+)
+
+// ValidateTrendInterval checks that a trend interval is one of the known values
+func ValidateTrendInterval(interval string) error {
+	switch interval {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalQuarter:
+		return nil
+	default:
+		return fmt.Errorf("invalid interval %q: must be one of %s, %s, %s, %s",
+			interval, IntervalDay, IntervalWeek, IntervalMonth, IntervalQuarter)
+	}
+}
+
+// PeriodStart returns the start of the interval containing t, in UTC. Weeks
+// start on Monday.
+func PeriodStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch interval {
+	case IntervalWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case IntervalQuarter:
+		month := (t.Month()-1)/3*3 + 1
+		return time.Date(t.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// NextPeriodStart returns the start of the interval following the one
+// starting at start
+func NextPeriodStart(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	case IntervalQuarter:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// TrendPoint is the compliance of the fleet at the end of one interval, taken
+// from the last snapshot of the interval
+type TrendPoint struct {
+	PeriodStart       time.Time `json:"period_start"`
+	TakenAt           time.Time `json:"taken_at"`
+	TotalServers      int       `json:"total_servers"`
+	EndOfLifeServers  int       `json:"end_of_life_servers"`
+	EndingSoonServers int       `json:"ending_soon_servers"`
+	WaivedServers     int       `json:"waived_servers"`
+	ComplianceScore   float64   `json:"compliance_score"`
+}
+
+// ComplianceTrend groups snapshots ordered by time into intervals and keeps
+// the last snapshot of each one. Intervals without snapshots are left out.
+func ComplianceTrend(snapshots []ComplianceSnapshot, interval string) []TrendPoint {
+	points := []TrendPoint{}
+
+	for _, s := range snapshots {
+		point := TrendPoint{
+			PeriodStart:       PeriodStart(s.TakenAt, interval),
+			TakenAt:           s.TakenAt,
+			TotalServers:      s.TotalServers,
+			EndOfLifeServers:  s.EndOfLifeServers,
+			EndingSoonServers: s.EndingSoonServers,
+			WaivedServers:     s.WaivedServers,
+			ComplianceScore:   s.ComplianceScore,
+		}
+
+		if n := len(points); n > 0 && points[n-1].PeriodStart.Equal(point.PeriodStart) {
+			points[n-1] = point
+			continue
+		}
+		points = append(points, point)
+	}
+
+	return points
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// A Thursday
+	ts := time.Date(2025, time.August, 14, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		interval string
+		expected time.Time
+	}{
+		{IntervalDay, time.Date(2025, time.August, 14, 0, 0, 0, 0, time.UTC)},
+		{IntervalWeek, time.Date(2025, time.August, 11, 0, 0, 0, 0, time.UTC)},
+		{IntervalMonth, time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)},
+		{IntervalQuarter, time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := PeriodStart(ts, tt.interval); !got.Equal(tt.expected) {
+			t.Errorf("PeriodStart(%s) = %v, expected %v", tt.interval, got, tt.expected)
+		}
+	}
+
+	// Sundays belong to the week started on the previous Monday
+	sunday := time.Date(2025, time.August, 17, 23, 0, 0, 0, time.UTC)
+	if got := PeriodStart(sunday, IntervalWeek); got.Day() != 11 {
+		t.Errorf("Expected Sunday to fall in the week of the 11th, got %v", got)
+	}
+}
+
+func TestComplianceTrend(t *testing.T) {
+	snapshot := func(month time.Month, day int, score float64, eol int) ComplianceSnapshot {
+		return ComplianceSnapshot{
+			TakenAt:          time.Date(2025, month, day, 0, 0, 0, 0, time.UTC),
+			TotalServers:     10,
+			EndOfLifeServers: eol,
+			ComplianceScore:  score,
+		}
+	}
+	snapshots := []ComplianceSnapshot{
+		snapshot(time.January, 15, 60, 4),
+		snapshot(time.March, 31, 70, 3),
+		snapshot(time.April, 1, 72, 3),
+		snapshot(time.September, 30, 90, 1),
+	}
+
+	trend := ComplianceTrend(snapshots, IntervalQuarter)
+	if len(trend) != 3 {
+		t.Fatalf("Expected 3 quarters, got %+v", trend)
+	}
+	if trend[0].ComplianceScore != 70 || trend[0].EndOfLifeServers != 3 {
+		t.Errorf("Expected the last snapshot of Q1, got %+v", trend[0])
+	}
+	if trend[2].PeriodStart.Month() != time.July || trend[2].ComplianceScore != 90 {
+		t.Errorf("Unexpected Q3 point: %+v", trend[2])
+	}
+
+	if trend := ComplianceTrend(nil, IntervalDay); trend == nil || len(trend) != 0 {
+		t.Errorf("Expected an empty trend, got %v", trend)
+	}
+}
@@ -0,0 +1,287 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UpgradeRules are the in-place upgrade rules of an OS family
+type UpgradeRules struct {
+	// InPlace allows in-place upgrades to newer releases of the family. It
+	// defaults to true; families that can only be left through a migration
+	// set it to false.
+	InPlace *bool `json:"in_place,omitempty"`
+	// LTSOnly only upgrades to releases flagged LTS
+	LTSOnly bool `json:"lts_only,omitempty"`
+	// NextMajorOnly only upgrades to the next major version, so that later
+	// major versions are reached in several hops
+	NextMajorOnly bool `json:"next_major_only,omitempty"`
+}
+
+// allowsInPlace reports whether in-place upgrades are allowed
+func (r UpgradeRules) allowsInPlace() bool {
+	return r.InPlace == nil || *r.InPlace
+}
+
+// UpgradeMigration is a migration from the releases of an OS family to the
+// releases of another family, such as CentOS to Rocky Linux
+type UpgradeMigration struct {
+	From string `json:"from"`
+	// FromVersion restricts the migration to a version or major version of
+	// From; any release of From migrates when it is empty
+	FromVersion string `json:"from_version,omitempty"`
+	To          string `json:"to"`
+	// ToVersion is the version or major version of To migrated to; releases
+	// of the same major version as the source are migrated to when it is empty
+	ToVersion string `json:"to_version,omitempty"`
+}
+
+// UpgradeGraph describes how servers may move between operating systems:
+// in-place upgrades within a family under its rules, and migrations across
+// families. Families without rules may upgrade in place to any newer release.
+type UpgradeGraph struct {
+	Families   map[string]UpgradeRules `json:"families,omitempty"`
+	Migrations []UpgradeMigration      `json:"migrations,omitempty"`
+}
+
+// DefaultUpgradeGraph returns the built-in upgrade graph. CentOS Linux has
+// no supported in-place upgrade and is migrated to Rocky Linux or AlmaLinux
+// of the same major version, or from CentOS 7 to AlmaLinux 8 with ELevate.
+func DefaultUpgradeGraph() UpgradeGraph {
+	inPlace := false
+	return UpgradeGraph{
+		Families: map[string]UpgradeRules{
+			"CentOS": {InPlace: &inPlace},
+		},
+		Migrations: []UpgradeMigration{
+			{From: "CentOS", To: "Rocky Linux"},
+			{From: "CentOS", To: "AlmaLinux"},
+			{From: "CentOS", FromVersion: "7", To: "AlmaLinux", ToVersion: "8"},
+		},
+	}
+}
+
+// ParseUpgradeGraph parses and validates a JSON upgrade graph. Unknown
+// fields are rejected so that misspelled rules are not silently ignored.
+func ParseUpgradeGraph(data []byte) (UpgradeGraph, error) {
+	var graph UpgradeGraph
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&graph); err != nil {
+		return graph, fmt.Errorf("invalid upgrade graph: %w", err)
+	}
+	if err := graph.Validate(); err != nil {
+		return graph, fmt.Errorf("invalid upgrade graph: %w", err)
+	}
+	return graph, nil
+}
+
+// Validate checks that every migration names its families
+func (g UpgradeGraph) Validate() error {
+	for i, migration := range g.Migrations {
+		if strings.TrimSpace(migration.From) == "" || strings.TrimSpace(migration.To) == "" {
+			return fmt.Errorf("migration %d must have a from and a to family", i+1)
+		}
+		if migration.From == migration.To {
+			return fmt.Errorf("migration %d must move to another family than %s", i+1, migration.From)
+		}
+	}
+	return nil
+}
+
+// majorVersion returns the part of a version before its first dot
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
+
+// versionMatches reports whether a version is pattern or has pattern as its
+// major version
+func versionMatches(version, pattern string) bool {
+	return version == pattern || majorVersion(version) == pattern
+}
+
+// osKey identifies an operating system by name and version
+func osKey(os OS) string {
+	return os.Name + " " + os.Version
+}
+
+// upgradeTargets returns the releases an OS can move to in a single hop
+// under the graph, from the catalog grouped by family
+func (g UpgradeGraph) upgradeTargets(from OS, families map[string][]OS) []OS {
+	var targets []OS
+
+	if rules := g.Families[from.Name]; rules.allowsInPlace() {
+		var newer []OS
+		for _, os := range families[from.Name] {
+			if CompareVersions(os.Version, from.Version) > 0 && (!rules.LTSOnly || os.LTS) {
+				newer = append(newer, os)
+			}
+		}
+
+		if rules.NextMajorOnly {
+			// Newer releases of the current major version are minor updates;
+			// otherwise only the lowest newer major version is reachable
+			nextMajor := ""
+			for _, os := range newer {
+				major := majorVersion(os.Version)
+				if major != majorVersion(from.Version) && (nextMajor == "" || CompareVersions(major, nextMajor) < 0) {
+					nextMajor = major
+				}
+			}
+			for _, os := range newer {
+				if major := majorVersion(os.Version); major == majorVersion(from.Version) || major == nextMajor {
+					targets = append(targets, os)
+				}
+			}
+		} else {
+			targets = append(targets, newer...)
+		}
+	}
+
+	for _, migration := range g.Migrations {
+		if migration.From != from.Name || (migration.FromVersion != "" && !versionMatches(from.Version, migration.FromVersion)) {
+			continue
+		}
+		toVersion := migration.ToVersion
+		if toVersion == "" {
+			toVersion = majorVersion(from.Version)
+		}
+		for _, os := range families[migration.To] {
+			if versionMatches(os.Version, toVersion) {
+				targets = append(targets, os)
+			}
+		}
+	}
+
+	return targets
+}
+
+// UpgradePath is a sequence of upgrades and migrations from an operating
+// system to a target release
+type UpgradePath struct {
+	// Steps holds each release moved to, ending with the target
+	Steps []OS
+}
+
+// Target returns the release the path ends with
+func (p UpgradePath) Target() OS {
+	return p.Steps[len(p.Steps)-1]
+}
+
+// Hops returns the number of upgrades and migrations of the path
+func (p UpgradePath) Hops() int {
+	return len(p.Steps)
+}
+
+// UpgradePaths returns the shortest path from an operating system to every
+// release of the catalog reachable under the graph, in the order they are
+// reached
+func (g UpgradeGraph) UpgradePaths(from OS, catalog []OS) []UpgradePath {
+	families := make(map[string][]OS)
+	for _, os := range catalog {
+		families[os.Name] = append(families[os.Name], os)
+	}
+	for family := range families {
+		sort.SliceStable(families[family], func(i, j int) bool {
+			return CompareVersions(families[family][i].Version, families[family][j].Version) < 0
+		})
+	}
+
+	// Breadth-first, so that each release is reached by a shortest path
+	var paths []UpgradePath
+	visited := map[string]bool{osKey(from): true}
+	queue := []UpgradePath{{}}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+
+		current := from
+		if len(path.Steps) > 0 {
+			current = path.Target()
+		}
+		for _, target := range g.upgradeTargets(current, families) {
+			if visited[osKey(target)] {
+				continue
+			}
+			visited[osKey(target)] = true
+
+			next := UpgradePath{Steps: append(append([]OS{}, path.Steps...), target)}
+			paths = append(paths, next)
+			queue = append(queue, next)
+		}
+	}
+
+	return paths
+}
+
+// OSRef is a reference to an operating system in recommendations
+type OSRef struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// newOSRef returns the reference to an operating system
+func newOSRef(os OS) OSRef {
+	return OSRef{ID: os.ID, Name: os.Name, Version: os.Version}
+}
+
+// UpgradeRecommendation recommends a target release for the servers running
+// an operating system, with the path leading to it
+type UpgradeRecommendation struct {
+	ServerIDs   []int    `json:"server_ids"`
+	ServerNames []string `json:"server_names"`
+	CurrentOS   OSRef    `json:"current_os"`
+	TargetOS    OSRef    `json:"recommended_os"`
+	// Path lists each release moved to, ending with the recommended one
+	Path []OSRef `json:"path"`
+	Hops int     `json:"hops"`
+	// Migration reports whether the path leaves the family of the current OS
+	Migration bool `json:"migration"`
+	// Status is the most urgent support status of the servers
+	Status string `json:"status"`
+	// Deadline is the earliest end of support the servers are evaluated
+	// against, by which they should have moved
+	Deadline time.Time `json:"deadline"`
+
+	severity float64
+}
+
+// recommendUpgrade chooses the path to recommend from the paths reachable
+// from an OS: the fewest hops to a release supported under policy at now,
+// then the latest end of support. When no reachable release is supported,
+// the one supported the longest is recommended if it outlives the current
+// OS. It returns false when no path improves on the current OS.
+func recommendUpgrade(current OS, paths []UpgradePath, policy CompliancePolicy, now time.Time) (UpgradePath, bool) {
+	var best UpgradePath
+	bestSupported, found := false, false
+	for _, path := range paths {
+		target := path.Target()
+		if !target.EndOfSupport.After(current.EndOfSupport) {
+			continue
+		}
+		status, _ := policy.Classify(target.EndOfSupport, now)
+		supported := status == StatusSupported
+
+		switch {
+		case !found:
+		case supported != bestSupported:
+			if !supported {
+				continue
+			}
+		case supported && path.Hops() != best.Hops():
+			if path.Hops() > best.Hops() {
+				continue
+			}
+		case !target.EndOfSupport.After(best.Target().EndOfSupport):
+			continue
+		}
+		best, bestSupported, found = path, supported, true
+	}
+	return best, found
+}
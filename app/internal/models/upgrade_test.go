@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upgradeSummaries describes upgrade recommendations as
+// "[servers] current -> target" strings
+func upgradeSummaries(recs []UpgradeRecommendation) []string {
+	summaries := make([]string, 0, len(recs))
+	for _, rec := range recs {
+		summaries = append(summaries, fmt.Sprintf("%v %s %s -> %s %s",
+			rec.ServerNames, rec.CurrentOS.Name, rec.CurrentOS.Version, rec.TargetOS.Name, rec.TargetOS.Version))
+	}
+	return summaries
+}
+
+// pathVersions describes the steps of a path as "Name Version" strings
+func pathVersions(path UpgradePath) string {
+	var steps []string
+	for _, step := range path.Steps {
+		steps = append(steps, osKey(step))
+	}
+	return strings.Join(steps, ", ")
+}
+
+func TestUpgradeGraph_UpgradePaths(t *testing.T) {
+	now := time.Now()
+	years := func(n int) time.Time { return now.AddDate(n, 0, 0) }
+	catalog := []OS{
+		{ID: 1, Name: "Ubuntu", Version: "18.04", LTS: true, EndOfSupport: years(-2)},
+		{ID: 2, Name: "Ubuntu", Version: "20.04", LTS: true, EndOfSupport: years(-1)},
+		{ID: 3, Name: "Ubuntu", Version: "21.10", EndOfSupport: years(-3)},
+		{ID: 4, Name: "Ubuntu", Version: "22.04", LTS: true, EndOfSupport: years(1)},
+		{ID: 5, Name: "Ubuntu", Version: "24.04", LTS: true, EndOfSupport: years(3)},
+		{ID: 6, Name: "CentOS", Version: "7", EndOfSupport: years(-1)},
+		{ID: 7, Name: "CentOS", Version: "8", EndOfSupport: years(-3)},
+		{ID: 8, Name: "Rocky Linux", Version: "8", EndOfSupport: years(3)},
+		{ID: 9, Name: "Rocky Linux", Version: "9", EndOfSupport: years(6)},
+		{ID: 10, Name: "AlmaLinux", Version: "8", EndOfSupport: years(3)},
+	}
+
+	graph := DefaultUpgradeGraph()
+	graph.Families["Ubuntu"] = UpgradeRules{LTSOnly: true, NextMajorOnly: true}
+
+	tests := []struct {
+		from OS
+		want []string
+	}{
+		// Only the next LTS release is reachable in one hop
+		{catalog[0], []string{"Ubuntu 20.04", "Ubuntu 20.04, Ubuntu 22.04", "Ubuntu 20.04, Ubuntu 22.04, Ubuntu 24.04"}},
+		{catalog[2], []string{"Ubuntu 22.04", "Ubuntu 22.04, Ubuntu 24.04"}},
+		// CentOS does not upgrade in place; CentOS 7 migrates with ELevate
+		{catalog[5], []string{"AlmaLinux 8"}},
+		{catalog[6], []string{"Rocky Linux 8", "AlmaLinux 8", "Rocky Linux 8, Rocky Linux 9"}},
+	}
+
+	for _, tt := range tests {
+		paths := graph.UpgradePaths(tt.from, catalog)
+		var got []string
+		for _, path := range paths {
+			got = append(got, pathVersions(path))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("UpgradePaths(%s) = %q, want %q", osKey(tt.from), got, tt.want)
+		}
+	}
+
+	// Without rules, a family upgrades in place to any newer release
+	paths := DefaultUpgradeGraph().UpgradePaths(catalog[0], catalog)
+	if len(paths) != 4 || paths[3].Hops() != 1 || paths[3].Target().Version != "24.04" {
+		t.Errorf("Unexpected default Ubuntu paths %+v", paths)
+	}
+}
+
+func TestComplianceUtils_GetUpgradeRecommendations(t *testing.T) {
+	now := time.Now()
+	years := func(n int) time.Time { return now.AddDate(n, 0, 0) }
+	centos7 := OS{ID: 1, Name: "CentOS", Version: "7", EndOfSupport: years(-1)}
+	centos8 := OS{ID: 2, Name: "CentOS", Version: "8", EndOfSupport: years(-3)}
+	ubuntu20 := OS{ID: 3, Name: "Ubuntu", Version: "20.04", LTS: true, EndOfSupport: now.AddDate(0, 3, 0)}
+	catalog := []OS{
+		centos7, centos8, ubuntu20,
+		{ID: 4, Name: "AlmaLinux", Version: "8", EndOfSupport: years(3)},
+		{ID: 5, Name: "Ubuntu", Version: "22.04", LTS: true, EndOfSupport: years(2)},
+		{ID: 6, Name: "Ubuntu", Version: "24.04", LTS: true, EndOfSupport: years(4)},
+	}
+
+	servers := []Server{
+		{ID: 1, Name: "db-02", OS: &centos7},
+		{ID: 2, Name: "db-01", OS: &centos7},
+		{ID: 3, Name: "web-01", OS: &ubuntu20},
+		{ID: 4, Name: "legacy-01", OS: &centos8},
+	}
+
+	graph := DefaultUpgradeGraph()
+	graph.Families["Ubuntu"] = UpgradeRules{LTSOnly: true, NextMajorOnly: true}
+	recs := NewComplianceUtils().WithUpgradeGraph(graph).GetUpgradeRecommendations(servers, catalog)
+
+	// End-of-life servers come first, the largest group leading
+	if got := upgradeSummaries(recs); fmt.Sprint(got) != "[[db-01 db-02] CentOS 7 -> AlmaLinux 8 [legacy-01] CentOS 8 -> AlmaLinux 8 [web-01] Ubuntu 20.04 -> Ubuntu 22.04]" {
+		t.Fatalf("Unexpected recommendations %v", got)
+	}
+	centos := recs[0]
+	if fmt.Sprint(centos.ServerIDs) != "[2 1]" || fmt.Sprint(centos.ServerNames) != "[db-01 db-02]" ||
+		centos.TargetOS.Name != "AlmaLinux" || centos.Hops != 1 || !centos.Migration ||
+		centos.Status != StatusEndOfLife || !centos.Deadline.Equal(centos7.EndOfSupport) {
+		t.Errorf("Unexpected CentOS recommendation %+v", centos)
+	}
+
+	// The next LTS release is supported, so it is recommended in one hop
+	// rather than 24.04 in two
+	ubuntu := recs[2]
+	if ubuntu.TargetOS.Version != "22.04" || ubuntu.Hops != 1 || ubuntu.Migration || ubuntu.Status != StatusEndingSoon {
+		t.Errorf("Unexpected Ubuntu recommendation %+v", ubuntu)
+	}
+
+	// A server enrolled in extended support has until its end
+	extended := years(1)
+	esm := ubuntu20
+	esm.EndOfExtendedSupport = &extended
+	recs = NewComplianceUtils().WithUpgradeGraph(graph).GetUpgradeRecommendations([]Server{{ID: 5, Name: "web-02", OS: &esm, ExtendedSupport: true}}, catalog)
+	if len(recs) != 1 || !recs[0].Deadline.Equal(extended) || recs[0].Status != StatusSupported {
+		t.Errorf("Unexpected recommendation for an enrolled server %+v", recs)
+	}
+
+	// Deadlines honour the lead period of the environment policy, like the
+	// support status
+	policy := DefaultCompliancePolicy()
+	policy.Environments = map[string]CompliancePolicy{
+		EnvironmentProd: func() CompliancePolicy {
+			p := DefaultCompliancePolicy()
+			p.LeadMonths = 6
+			return p
+		}(),
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+	utils := NewComplianceUtilsWithPolicy(policy).WithUpgradeGraph(graph)
+	recs = utils.GetUpgradeRecommendations([]Server{{ID: 6, Name: "web-03", Environment: EnvironmentProd, OS: &ubuntu20}}, catalog)
+	lead := ubuntu20.EndOfSupport.AddDate(0, -6, 0)
+	if len(recs) != 1 || !recs[0].Deadline.Equal(lead) || recs[0].Status != StatusEndOfLife {
+		t.Errorf("Expected a deadline 6 months before the end of support, got %+v", recs)
+	}
+	if generic := utils.GetRecommendations([]Server{{ID: 6, Name: "web-03", Environment: EnvironmentProd, OS: &ubuntu20}}); len(generic) != 1 || generic[0].Deadline == nil || !generic[0].Deadline.Equal(lead) {
+		t.Errorf("Expected the recommendation deadline 6 months before the end of support, got %+v", generic)
+	}
+}
+
+func TestParseUpgradeGraph(t *testing.T) {
+	graph, err := ParseUpgradeGraph([]byte(`{
+		"families": {"Ubuntu": {"lts_only": true, "next_major_only": true}, "CentOS": {"in_place": false}},
+		"migrations": [{"from": "CentOS", "from_version": "7", "to": "AlmaLinux", "to_version": "8"}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseUpgradeGraph() error = %v", err)
+	}
+	if rules := graph.Families["Ubuntu"]; !rules.LTSOnly || !rules.NextMajorOnly || !rules.allowsInPlace() {
+		t.Errorf("Unexpected Ubuntu rules %+v", rules)
+	}
+	if graph.Families["CentOS"].allowsInPlace() || len(graph.Migrations) != 1 {
+		t.Errorf("Unexpected graph %+v", graph)
+	}
+
+	for _, data := range []string{
+		`{"families": {"Ubuntu": {"lts": true}}}`,
+		`{"migrations": [{"from": "CentOS"}]}`,
+		`{"migrations": [{"from": "CentOS", "to": "CentOS"}]}`,
+		`[]`,
+	} {
+		if _, err := ParseUpgradeGraph([]byte(data)); err == nil {
+			t.Errorf("ParseUpgradeGraph(%s) succeeded", data)
+		}
+	}
+}
@@ -3,16 +3,22 @@ package models
 import (
 	"fmt"
 	"sort"
-	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// MaxServerAttributeLength is the longest role, owner team or location a
+// server, or a campaign scope, can have
+const MaxServerAttributeLength = 100
+
 // OSUtils provides utility functions for operating system operations
-type OSUtils struct{}
+type OSUtils struct {
+	policy CompliancePolicy
+}
 
-// NewOSUtils creates a new OSUtils instance
+// NewOSUtils creates a new OSUtils instance using the default compliance policy
 func NewOSUtils() *OSUtils {
-	return &OSUtils{}
+	return &OSUtils{policy: DefaultCompliancePolicy()}
 }
 
 // GroupOSByFamily groups operating systems by their family/distribution name
@@ -23,10 +29,10 @@ func (u *OSUtils) GroupOSByFamily(oss []OS) map[string][]OS {
 		grouped[os.Name] = append(grouped[os.Name], os)
 	}
 
-	// Sort versions within each group
+	// Sort versions within each group, oldest first
 	for family := range grouped {
-		sort.Slice(grouped[family], func(i, j int) bool {
-			return grouped[family][i].Version < grouped[family][j].Version
+		sort.SliceStable(grouped[family], func(i, j int) bool {
+			return CompareVersions(grouped[family][i].Version, grouped[family][j].Version) < 0
 		})
 	}
 
@@ -64,9 +70,8 @@ func (u *OSUtils) FilterByEndOfSupport(oss []OS, status SupportStatus) []OS {
 				filtered = append(filtered, os)
 			}
 		case SupportStatusEndingSoon:
-			// Consider "ending soon" as within 6 months
-			sixMonthsFromNow := now.AddDate(0, 6, 0)
-			if os.EndOfSupport.After(now) && os.EndOfSupport.Before(sixMonthsFromNow) {
+			// Consider "ending soon" as within the widest policy tier
+			if os.EndOfSupport.After(now) && os.EndOfSupport.Before(u.policy.EndingSoonCutoff(now)) {
 				filtered = append(filtered, os)
 			}
 		}
@@ -83,8 +88,7 @@ func (u *OSUtils) GetSupportStatusString(os OS) string {
 		return "End of Life"
 	}
 
-	sixMonthsFromNow := now.AddDate(0, 6, 0)
-	if os.EndOfSupport.Before(sixMonthsFromNow) {
+	if os.EndOfSupport.Before(u.policy.EndingSoonCutoff(now)) {
 		return "Ending Soon"
 	}
 
@@ -108,11 +112,13 @@ const (
 )
 
 // ServerUtils provides utility functions for server operations
-type ServerUtils struct{}
+type ServerUtils struct {
+	policy CompliancePolicy
+}
 
-// NewServerUtils creates a new ServerUtils instance
+// NewServerUtils creates a new ServerUtils instance using the default compliance policy
 func NewServerUtils() *ServerUtils {
-	return &ServerUtils{}
+	return &ServerUtils{policy: DefaultCompliancePolicy()}
 }
 
 // GroupServersByOS groups servers by their operating system
@@ -129,35 +135,33 @@ func (u *ServerUtils) GroupServersByOS(servers []Server) map[string][]Server {
 	return grouped
 }
 
-// GetServersWithEndOfLifeOS returns servers running end-of-life operating systems
+// GetServersWithEndOfLifeOS returns servers running end-of-life operating
+// systems under the policy of their environment
 func (u *ServerUtils) GetServersWithEndOfLifeOS(servers []Server) []Server {
-	now := time.Now()
-	var eolServers []Server
-
-	for _, server := range servers {
-		if server.OS != nil && server.OS.EndOfSupport.Before(now) {
-			eolServers = append(eolServers, server)
-		}
-	}
-
-	return eolServers
+	return u.serversWithStatus(servers, StatusEndOfLife)
 }
 
 // GetServersWithEndingSoonOS returns servers with OS support ending soon
+// under the policy of their environment
 func (u *ServerUtils) GetServersWithEndingSoonOS(servers []Server) []Server {
+	return u.serversWithStatus(servers, StatusEndingSoon)
+}
+
+// serversWithStatus returns the servers whose OS has the given support status
+func (u *ServerUtils) serversWithStatus(servers []Server, status string) []Server {
 	now := time.Now()
-	sixMonthsFromNow := now.AddDate(0, 6, 0)
-	var endingSoonServers []Server
+	var matches []Server
 
 	for _, server := range servers {
-		if server.OS != nil &&
-			server.OS.EndOfSupport.After(now) &&
-			server.OS.EndOfSupport.Before(sixMonthsFromNow) {
-			endingSoonServers = append(endingSoonServers, server)
+		if server.OS == nil {
+			continue
+		}
+		if s, _ := u.policy.ForEnvironment(server.Environment).Classify(server.EndOfSupport(), now); s == status {
+			matches = append(matches, server)
 		}
 	}
 
-	return endingSoonServers
+	return matches
 }
 
 // GetOSDistribution returns a count of servers by operating system
@@ -229,65 +233,304 @@ func (u *ServerUtils) ValidateServerName(name string) error {
 	return nil
 }
 
+// ValidateEnvironment checks that an environment is one of the known values.
+// An empty environment is allowed and means the environment is not set.
+func (u *ServerUtils) ValidateEnvironment(environment string) error {
+	switch environment {
+	case "", EnvironmentProd, EnvironmentStaging, EnvironmentDev:
+		return nil
+	default:
+		return fmt.Errorf("invalid environment %q: must be one of %s, %s, %s",
+			environment, EnvironmentProd, EnvironmentStaging, EnvironmentDev)
+	}
+}
+
+// ValidateAttributes checks that the role, owner team and location of a
+// server fit in MaxServerAttributeLength characters
+func (u *ServerUtils) ValidateAttributes(role, ownerTeam, location string) error {
+	for _, attribute := range []struct{ name, value string }{
+		{"role", role}, {"owner team", ownerTeam}, {"location", location},
+	} {
+		if err := validateLength(attribute.name, attribute.value, MaxServerAttributeLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLength checks that a value has at most max characters, the unit
+// of the VARCHAR columns it is stored in
+func validateLength(name, value string, max int) error {
+	if utf8.RuneCountInString(value) > max {
+		return fmt.Errorf("%s cannot exceed %d characters", name, max)
+	}
+	return nil
+}
+
 // ComplianceReport represents a compliance analysis report
 type ComplianceReport struct {
-	TotalServers         int            `json:"total_servers"`
-	SupportedServers     int            `json:"supported_servers"`
-	EndOfLifeServers     int            `json:"end_of_life_servers"`
-	EndingSoonServers    int            `json:"ending_soon_servers"`
-	OSDistribution       map[string]int `json:"os_distribution"`
-	OSFamilyDistribution map[string]int `json:"os_family_distribution"`
-	EndOfLifeList        []Server       `json:"end_of_life_list"`
-	EndingSoonList       []Server       `json:"ending_soon_list"`
-	GeneratedAt          time.Time      `json:"generated_at"`
+	TotalServers      int `json:"total_servers"`
+	SupportedServers  int `json:"supported_servers"`
+	EndOfLifeServers  int `json:"end_of_life_servers"`
+	EndingSoonServers int `json:"ending_soon_servers"`
+	WaivedServers     int `json:"waived_servers"`
+	StaleServers      int `json:"stale_servers"`
+	// RemediationScheduledServers counts the end-of-life and ending soon
+	// servers with a pending scheduled OS change
+	RemediationScheduledServers int `json:"remediation_scheduled_servers"`
+	// ExtendedSupportServers counts the servers past the end of support of
+	// their OS that are covered by its paid extended support
+	ExtendedSupportServers int            `json:"extended_support_servers"`
+	OSDistribution         map[string]int `json:"os_distribution"`
+	OSFamilyDistribution   map[string]int `json:"os_family_distribution"`
+	// TierCounts counts ending soon servers by the policy tier they fall in
+	TierCounts map[string]int `json:"tier_counts"`
+	// Environments breaks the counts and score down by server environment
+	Environments   map[string]EnvironmentCompliance `json:"environments"`
+	EndOfLifeList  []Server                         `json:"end_of_life_list"`
+	EndingSoonList []Server                         `json:"ending_soon_list"`
+	// Waived lists the servers excluded from penalties by an active waiver
+	Waived []WaivedServer `json:"waived"`
+	// RemediationScheduled lists the end-of-life and ending soon servers
+	// with a pending scheduled OS change, soonest window first. They keep
+	// counting against the score until the change is applied.
+	RemediationScheduled []ScheduledRemediation `json:"remediation_scheduled"`
+	// StaleList lists the servers that have not reported within the stale
+	// threshold of their policy, least recently seen first, whether or not
+	// the policy excludes them from the counts above
+	StaleList []Server         `json:"stale_list"`
+	Policy    CompliancePolicy `json:"policy"`
+	// AsOf is the time the fleet was rebuilt and classified at, when the
+	// report describes a past state
+	AsOf        *time.Time `json:"as_of,omitempty"`
+	GeneratedAt time.Time  `json:"generated_at"`
+}
+
+// EnvironmentCompliance summarizes compliance for the servers of one
+// environment under the policy of that environment
+type EnvironmentCompliance struct {
+	TotalServers      int `json:"total_servers"`
+	SupportedServers  int `json:"supported_servers"`
+	EndOfLifeServers  int `json:"end_of_life_servers"`
+	EndingSoonServers int `json:"ending_soon_servers"`
+	WaivedServers     int `json:"waived_servers"`
+	StaleServers      int `json:"stale_servers"`
+	// RemediationScheduledServers counts the end-of-life and ending soon
+	// servers with a pending scheduled OS change
+	RemediationScheduledServers int            `json:"remediation_scheduled_servers"`
+	TierCounts                  map[string]int `json:"tier_counts"`
+	ComplianceScore             float64        `json:"compliance_score"`
+	ScoreDescription            string         `json:"score_description"`
 }
 
 // ComplianceUtils provides utility functions for compliance reporting
 type ComplianceUtils struct {
+	policy      CompliancePolicy
+	upgrades    UpgradeGraph
+	waivers     []Waiver
+	scheduled   []ScheduledChange
+	asOf        *time.Time
 	serverUtils *ServerUtils
 	osUtils     *OSUtils
 }
 
-// NewComplianceUtils creates a new ComplianceUtils instance
+// NewComplianceUtils creates a new ComplianceUtils instance using the default compliance policy
 func NewComplianceUtils() *ComplianceUtils {
+	return NewComplianceUtilsWithPolicy(DefaultCompliancePolicy())
+}
+
+// NewComplianceUtilsWithPolicy creates a new ComplianceUtils instance that
+// classifies and scores servers with the given policy, or with the policy
+// of their environment when it defines one
+func NewComplianceUtilsWithPolicy(policy CompliancePolicy) *ComplianceUtils {
 	return &ComplianceUtils{
-		serverUtils: NewServerUtils(),
-		osUtils:     NewOSUtils(),
+		policy:      policy,
+		upgrades:    DefaultUpgradeGraph(),
+		serverUtils: &ServerUtils{policy: policy},
+		osUtils:     &OSUtils{policy: policy},
 	}
 }
 
-// GenerateComplianceReport creates a comprehensive compliance report
-func (u *ComplianceUtils) GenerateComplianceReport(servers []Server) ComplianceReport {
-	endOfLifeServers := u.serverUtils.GetServersWithEndOfLifeOS(servers)
-	endingSoonServers := u.serverUtils.GetServersWithEndingSoonOS(servers)
+// WithWaivers returns a copy of the utilities that excludes servers covered
+// by an active waiver from penalties and recommendations. Expired waivers
+// are ignored, so their servers count again.
+func (u *ComplianceUtils) WithWaivers(waivers []Waiver) *ComplianceUtils {
+	c := *u
+	c.waivers = waivers
+	return &c
+}
 
-	report := ComplianceReport{
-		TotalServers:         len(servers),
-		SupportedServers:     len(servers) - len(endOfLifeServers) - len(endingSoonServers),
-		EndOfLifeServers:     len(endOfLifeServers),
-		EndingSoonServers:    len(endingSoonServers),
-		OSDistribution:       u.serverUtils.GetOSDistribution(servers),
-		OSFamilyDistribution: u.serverUtils.GetOSFamilyDistribution(servers),
-		EndOfLifeList:        endOfLifeServers,
-		EndingSoonList:       endingSoonServers,
-		GeneratedAt:          time.Now(),
+// WithScheduledChanges returns a copy of the utilities that reports the
+// end-of-life and ending soon servers with a pending change as remediation
+// scheduled. Changes that are resolved or whose window has closed are
+// ignored.
+func (u *ComplianceUtils) WithScheduledChanges(changes []ScheduledChange) *ComplianceUtils {
+	c := *u
+	c.scheduled = changes
+	return &c
+}
+
+// WithUpgradeGraph returns a copy of the utilities that recommends upgrade
+// paths under the given graph instead of the default one
+func (u *ComplianceUtils) WithUpgradeGraph(graph UpgradeGraph) *ComplianceUtils {
+	c := *u
+	c.upgrades = graph
+	return &c
+}
+
+// AsOf returns a copy of the utilities that classifies servers at the given
+// time instead of now, for reports on a past state of the fleet
+func (u *ComplianceUtils) AsOf(t time.Time) *ComplianceUtils {
+	c := *u
+	c.asOf = &t
+	return &c
+}
+
+// now returns the time servers are classified at
+func (u *ComplianceUtils) now() time.Time {
+	if u.asOf != nil {
+		return *u.asOf
 	}
+	return time.Now()
+}
 
-	return report
+// waiverFor returns the active waiver covering a server, preferring waivers
+// on the server itself over waivers on its operating system
+func (u *ComplianceUtils) waiverFor(server Server, now time.Time) *Waiver {
+	var match *Waiver
+	for i := range u.waivers {
+		waiver := &u.waivers[i]
+		if !waiver.Active(now) || !waiver.Covers(server) {
+			continue
+		}
+		if match == nil || (waiver.ServerID != nil && match.ServerID == nil) {
+			match = waiver
+		}
+	}
+	return match
 }
 
-// GetComplianceScore calculates a compliance score (0-100)
-func (u *ComplianceUtils) GetComplianceScore(servers []Server) float64 {
-	if len(servers) == 0 {
-		return 100.0
+// scheduledChangeFor returns the pending change of a server whose window has
+// not closed, if any
+func (u *ComplianceUtils) scheduledChangeFor(server Server, now time.Time) *ScheduledChange {
+	var match *ScheduledChange
+	for i := range u.scheduled {
+		change := &u.scheduled[i]
+		if change.ServerID == nil || *change.ServerID != server.ID || change.Status != ScheduledChangePending || change.Missed(now) {
+			continue
+		}
+		if match == nil || change.WindowStart.Before(match.WindowStart) {
+			match = change
+		}
+	}
+	return match
+}
+
+// environmentLabel returns the key of a server environment in breakdowns
+func environmentLabel(environment string) string {
+	if environment == "" {
+		return EnvironmentUnassigned
+	}
+	return environment
+}
+
+// classify returns the support status, tier and score penalty of a server
+// under the policy of its environment. Stale servers are stale when the
+// policy excludes them, servers without an OS are supported, and servers
+// covered by an active waiver are waived without a penalty.
+func (u *ComplianceUtils) classify(server Server, now time.Time) (string, *PolicyTier, float64) {
+	policy := u.policy.ForEnvironment(server.Environment)
+	if policy.ExcludeStale && policy.IsStale(server, now) {
+		return StatusStale, nil, 0
+	}
+	if server.OS == nil {
+		return StatusSupported, nil, 0
+	}
+
+	status, tier := policy.Classify(server.EndOfSupport(), now)
+	if status != StatusSupported && u.waiverFor(server, now) != nil {
+		return StatusWaived, nil, 0
+	}
+	return status, tier, policy.Penalty(server.EndOfSupport(), now)
+}
+
+// ServerCompliance is the compliance classification of a single server
+type ServerCompliance struct {
+	Status string      // 'supported', 'ending_soon', 'eol', 'waived' or 'stale'
+	Tier   *PolicyTier // Tier of an ending soon server
+	Waiver *Waiver     // Active waiver of a waived server
+	// WaivedStatus is the status a waived server would have without its waiver
+	WaivedStatus string
+	// Stale reports whether the server has not reported within the stale
+	// threshold, whether or not the policy excludes it
+	Stale bool
+	// ExtendedSupport reports whether the server is past the end of support
+	// of its OS but covered by its paid extended support
+	ExtendedSupport bool
+	// ScheduledChange is the pending OS change of an end-of-life or ending
+	// soon server
+	ScheduledChange *ScheduledChange
+}
+
+// ClassifyServer returns the compliance classification of a server under the
+// policy of its environment
+func (u *ComplianceUtils) ClassifyServer(server Server) ServerCompliance {
+	now := u.now()
+	status, tier, _ := u.classify(server, now)
+	classification := ServerCompliance{
+		Status: status,
+		Tier:   tier,
+		Stale:  u.policy.ForEnvironment(server.Environment).IsStale(server, now),
+
+		ExtendedSupport: server.InExtendedSupport(now),
+	}
+	if status == StatusWaived {
+		classification.Waiver = u.waiverFor(server, now)
+		classification.WaivedStatus, _ = u.policy.ForEnvironment(server.Environment).Classify(server.EndOfSupport(), now)
+	}
+	if status == StatusEndOfLife || status == StatusEndingSoon {
+		classification.ScheduledChange = u.scheduledChangeFor(server, now)
+	}
+	return classification
+}
+
+// tierCounts counts servers by the policy tier their OS falls in. Every
+// tier of the given policies is present, even when no server falls in it.
+func (u *ComplianceUtils) tierCounts(servers []Server, now time.Time, policies ...CompliancePolicy) map[string]int {
+	counts := make(map[string]int)
+	for _, policy := range policies {
+		for _, tier := range policy.Tiers {
+			counts[tier.Name] = 0
+		}
+	}
+
+	for _, server := range servers {
+		if _, tier, _ := u.classify(server, now); tier != nil {
+			counts[tier.Name]++
+		}
 	}
 
-	endOfLifeServers := u.serverUtils.GetServersWithEndOfLifeOS(servers)
-	endingSoonServers := u.serverUtils.GetServersWithEndingSoonOS(servers)
+	return counts
+}
 
-	// End of life servers heavily impact score, ending soon servers have moderate impact
-	penalty := float64(len(endOfLifeServers))*2 + float64(len(endingSoonServers))*0.5
-	score := (float64(len(servers)) - penalty) / float64(len(servers)) * 100
+// score calculates the compliance score (0-100) of servers from the
+// penalties of their environment policies. Excluded stale servers do not
+// count.
+func (u *ComplianceUtils) score(servers []Server, now time.Time) float64 {
+	counted := 0
+	penalty := 0.0
+	for _, server := range servers {
+		status, _, p := u.classify(server, now)
+		if status == StatusStale {
+			continue
+		}
+		counted++
+		penalty += p
+	}
+	if counted == 0 {
+		return 100.0
+	}
+	score := (float64(counted) - penalty) / float64(counted) * 100
 
 	if score < 0 {
 		return 0
@@ -296,79 +539,347 @@ func (u *ComplianceUtils) GetComplianceScore(servers []Server) float64 {
 	return score
 }
 
-// GetRecommendations provides upgrade recommendations
-func (u *ComplianceUtils) GetRecommendations(servers []Server, allOS []OS) []string {
-	var recommendations []string
+// GenerateComplianceReport creates a comprehensive compliance report
+func (u *ComplianceUtils) GenerateComplianceReport(servers []Server) ComplianceReport {
+	now := u.now()
+
+	var endOfLifeServers, endingSoonServers, staleServers []Server
+	var waived []WaivedServer
+	var scheduled []ScheduledRemediation
+	excluded, extended := 0, 0
+	for _, server := range servers {
+		if u.policy.ForEnvironment(server.Environment).IsStale(server, now) {
+			staleServers = append(staleServers, server)
+		}
+
+		status, _, _ := u.classify(server, now)
+		if status != StatusStale && server.InExtendedSupport(now) {
+			extended++
+		}
+
+		if status == StatusEndOfLife || status == StatusEndingSoon {
+			if change := u.scheduledChangeFor(server, now); change != nil {
+				scheduled = append(scheduled, ScheduledRemediation{Server: server, Status: status, Change: *change})
+			}
+		}
+
+		switch status {
+		case StatusStale:
+			excluded++
+		case StatusEndOfLife:
+			endOfLifeServers = append(endOfLifeServers, server)
+		case StatusEndingSoon:
+			endingSoonServers = append(endingSoonServers, server)
+		case StatusWaived:
+			status, _ := u.policy.ForEnvironment(server.Environment).Classify(server.EndOfSupport(), now)
+			waived = append(waived, WaivedServer{Server: server, Status: status, Waiver: *u.waiverFor(server, now)})
+		}
+	}
 
-	endOfLifeServers := u.serverUtils.GetServersWithEndOfLifeOS(servers)
-	endingSoonServers := u.serverUtils.GetServersWithEndingSoonOS(servers)
+	sort.SliceStable(staleServers, func(i, j int) bool {
+		return staleServers[i].LastSeenAt.Before(*staleServers[j].LastSeenAt)
+	})
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return scheduled[i].Change.WindowStart.Before(scheduled[j].Change.WindowStart)
+	})
 
-	if len(endOfLifeServers) > 0 {
-		recommendations = append(recommendations,
-			fmt.Sprintf("CRITICAL: %d servers are running end-of-life operating systems and need immediate updates", len(endOfLifeServers)))
+	policies := []CompliancePolicy{u.policy}
+	for _, policy := range u.policy.Environments {
+		policies = append(policies, policy)
+	}
+
+	report := ComplianceReport{
+		TotalServers:                len(servers),
+		SupportedServers:            len(servers) - len(endOfLifeServers) - len(endingSoonServers) - len(waived) - excluded,
+		EndOfLifeServers:            len(endOfLifeServers),
+		EndingSoonServers:           len(endingSoonServers),
+		WaivedServers:               len(waived),
+		StaleServers:                len(staleServers),
+		RemediationScheduledServers: len(scheduled),
+		ExtendedSupportServers:      extended,
+		OSDistribution:              u.serverUtils.GetOSDistribution(servers),
+		OSFamilyDistribution:        u.serverUtils.GetOSFamilyDistribution(servers),
+		TierCounts:                  u.tierCounts(servers, now, policies...),
+		Environments:                make(map[string]EnvironmentCompliance),
+		EndOfLifeList:               endOfLifeServers,
+		EndingSoonList:              endingSoonServers,
+		Waived:                      waived,
+		RemediationScheduled:        scheduled,
+		StaleList:                   staleServers,
+		Policy:                      u.policy,
+		AsOf:                        u.asOf,
+		GeneratedAt:                 time.Now(),
+	}
+
+	byEnvironment := make(map[string][]Server)
+	for _, server := range servers {
+		label := environmentLabel(server.Environment)
+		byEnvironment[label] = append(byEnvironment[label], server)
 	}
 
-	if len(endingSoonServers) > 0 {
-		recommendations = append(recommendations,
-			fmt.Sprintf("WARNING: %d servers are running operating systems that will reach end-of-life within 6 months", len(endingSoonServers)))
+	for label, group := range byEnvironment {
+		policy := u.policy.ForEnvironment(group[0].Environment)
+		summary := EnvironmentCompliance{
+			TotalServers: len(group),
+			TierCounts:   u.tierCounts(group, now, policy),
+		}
+		for _, server := range group {
+			if policy.IsStale(server, now) {
+				summary.StaleServers++
+			}
+			status, _, _ := u.classify(server, now)
+			if (status == StatusEndOfLife || status == StatusEndingSoon) && u.scheduledChangeFor(server, now) != nil {
+				summary.RemediationScheduledServers++
+			}
+			switch status {
+			case StatusStale:
+			case StatusEndOfLife:
+				summary.EndOfLifeServers++
+			case StatusEndingSoon:
+				summary.EndingSoonServers++
+			case StatusWaived:
+				summary.WaivedServers++
+			default:
+				summary.SupportedServers++
+			}
+		}
+		summary.ComplianceScore = u.score(group, now)
+		summary.ScoreDescription = policy.ScoreDescription(summary.ComplianceScore)
+		report.Environments[label] = summary
 	}
 
-	// Group all OS by family to find the one with the latest EndOfSupport date
-	groupedOS := u.osUtils.GroupOSByFamily(allOS)
+	return report
+}
+
+// GetComplianceScore calculates a compliance score (0-100)
+func (u *ComplianceUtils) GetComplianceScore(servers []Server) float64 {
+	return u.score(servers, u.now())
+}
+
+// GetScoreDescription returns the description of the score band a score
+// falls in under the policy
+func (u *ComplianceUtils) GetScoreDescription(score float64) string {
+	return u.policy.ScoreDescription(score)
+}
 
-	// For each server, check if there's an OS in the same family with a later EndOfSupport date
-	osUpgradeNeeded := make(map[string]struct {
-		serverNames   []string
-		currentOS     string
-		recommendedOS string
+// sortRecommendations orders recommendations by severity, then by the
+// policy penalty of the servers involved, then by the number of servers
+func sortRecommendations(recs []Recommendation) []Recommendation {
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Severity != recs[j].Severity {
+			return severityRank[recs[i].Severity] < severityRank[recs[j].Severity]
+		}
+		if recs[i].penalty != recs[j].penalty {
+			return recs[i].penalty > recs[j].penalty
+		}
+		if len(recs[i].ServerIDs) != len(recs[j].ServerIDs) {
+			return len(recs[i].ServerIDs) > len(recs[j].ServerIDs)
+		}
+		return recs[i].Message < recs[j].Message
 	})
+	return recs
+}
+
+// addServer adds a server to a recommendation, keeping its server and OS IDs
+// sorted and its deadline at the earliest deadline of its servers. A nil
+// deadline leaves it unchanged.
+func (rec *Recommendation) addServer(server Server, deadline *time.Time) {
+	i := sort.SearchInts(rec.ServerIDs, server.ID)
+	rec.ServerIDs = append(rec.ServerIDs, 0)
+	copy(rec.ServerIDs[i+1:], rec.ServerIDs[i:])
+	rec.ServerIDs[i] = server.ID
+
+	if i := sort.SearchInts(rec.OSIDs, server.OSID); i == len(rec.OSIDs) || rec.OSIDs[i] != server.OSID {
+		rec.OSIDs = append(rec.OSIDs, 0)
+		copy(rec.OSIDs[i+1:], rec.OSIDs[i:])
+		rec.OSIDs[i] = server.OSID
+	}
+
+	if deadline != nil && (rec.Deadline == nil || deadline.Before(*rec.Deadline)) {
+		rec.Deadline = deadline
+	}
+}
 
+// GetRecommendations provides recommendations ordered by severity:
+// end-of-life servers first, then ending soon servers by tier penalty, then
+// stale servers. Servers covered by an active waiver are left out. Upgrade
+// paths are recommended by GetUpgradeRecommendations.
+func (u *ComplianceUtils) GetRecommendations(servers []Server) []Recommendation {
+	now := u.now()
+
+	// Group end-of-life and ending soon servers by the environment policy
+	// that classified them, naming the environment only when it has its own
+	type group struct {
+		rec  *Recommendation
+		tier *PolicyTier
+	}
+	groups := make(map[string]*group)
 	for _, server := range servers {
-		if server.OS == nil {
+		status, tier, penalty := u.classify(server, now)
+		if status == StatusSupported || status == StatusWaived || status == StatusStale {
 			continue
 		}
 
-		// Get the OS family for this server
-		osFamily := server.OS.Name
-		osKey := fmt.Sprintf("%s %s", server.OS.Name, server.OS.Version)
+		environment := ""
+		if _, exists := u.policy.Environments[server.Environment]; exists {
+			environment = server.Environment
+		}
+		key := environment + "/" + status
+		if tier != nil {
+			key += "/" + tier.Name
+		}
 
-		// Find the OS with the latest EndOfSupport date in the same family
-		if familyOSList, exists := groupedOS[osFamily]; exists {
-			var bestOS *OS
-			for i := range familyOSList {
-				if bestOS == nil || familyOSList[i].EndOfSupport.After(bestOS.EndOfSupport) {
-					bestOS = &familyOSList[i]
-				}
+		g, exists := groups[key]
+		if !exists {
+			rec := &Recommendation{
+				Severity: SeverityCritical, Code: RecommendationEndOfLife,
+				Environment: environment, ServerIDs: []int{}, OSIDs: []int{}, penalty: penalty,
+			}
+			if tier != nil {
+				rec.Severity, rec.Code, rec.Tier = SeverityWarning, RecommendationEndingSoon, tier.Name
 			}
+			g = &group{rec: rec, tier: tier}
+			groups[key] = g
+		}
+		// The deadline is the end of support under the environment policy,
+		// moved by its lead or grace period
+		deadline := u.policy.ForEnvironment(server.Environment).EffectiveEndOfSupport(server.EndOfSupport())
+		g.rec.addServer(server, &deadline)
+	}
+
+	recs := make([]Recommendation, 0, len(groups)+1)
+	for _, g := range groups {
+		rec := g.rec
+		subject := fmt.Sprintf("%d servers", len(rec.ServerIDs))
+		if rec.Environment != "" {
+			subject = fmt.Sprintf("%d %s servers", len(rec.ServerIDs), rec.Environment)
+		}
+
+		if g.tier == nil {
+			rec.Message = fmt.Sprintf("%s are running end-of-life operating systems and need immediate updates", subject)
+		} else {
+			rec.Message = fmt.Sprintf("%s are running operating systems that will reach end-of-life within %s (%s)",
+				subject, g.tier.Window(), g.tier.Name)
+		}
+		recs = append(recs, *rec)
+	}
+
+	// Stale servers may have been decommissioned without being deleted
+	stale := Recommendation{Severity: SeverityWarning, Code: RecommendationStale, ServerIDs: []int{}, OSIDs: []int{}}
+	for _, server := range servers {
+		if u.policy.ForEnvironment(server.Environment).IsStale(server, now) {
+			stale.addServer(server, nil)
+		}
+	}
+	if len(stale.ServerIDs) > 0 {
+		stale.Message = fmt.Sprintf("%d servers have not reported within the stale threshold; confirm they still exist or delete them", len(stale.ServerIDs))
+		recs = append(recs, stale)
+	}
 
-			// If there's an OS with a later EndOfSupport date, recommend it
-			if bestOS != nil && bestOS.EndOfSupport.After(server.OS.EndOfSupport) {
-				bestOSKey := fmt.Sprintf("%s %s", bestOS.Name, bestOS.Version)
-				if entry, exists := osUpgradeNeeded[osKey]; exists {
-					entry.serverNames = append(entry.serverNames, server.Name)
-					osUpgradeNeeded[osKey] = entry
-				} else {
-					osUpgradeNeeded[osKey] = struct {
-						serverNames   []string
-						currentOS     string
-						recommendedOS string
-					}{
-						serverNames:   []string{server.Name},
-						currentOS:     osKey,
-						recommendedOS: bestOSKey,
-					}
-				}
+	return sortRecommendations(recs)
+}
+
+// statusUrgency ranks support statuses, most urgent highest
+var statusUrgency = map[string]int{
+	StatusEndOfLife:  2,
+	StatusEndingSoon: 1,
+}
+
+// GetUpgradeRecommendations recommends a target release and the path to it
+// under the upgrade graph for the servers of each operating system that can
+// move to a release supported for longer. Servers on the same OS are grouped
+// when their environment policies agree on the target. Recommendations are
+// ordered by the policy penalty of their servers, then by their number.
+// Servers covered by an active waiver or excluded as stale are left out.
+func (u *ComplianceUtils) GetUpgradeRecommendations(servers []Server, allOS []OS) []UpgradeRecommendation {
+	now := u.now()
+
+	paths := make(map[string][]UpgradePath)
+	groups := make(map[string]*UpgradeRecommendation)
+	var keys []string
+	for _, server := range servers {
+		if server.OS == nil {
+			continue
+		}
+		status, _, penalty := u.classify(server, now)
+		if status == StatusWaived || status == StatusStale {
+			continue
+		}
+
+		current := *server.OS
+		if _, planned := paths[osKey(current)]; !planned {
+			paths[osKey(current)] = u.upgrades.UpgradePaths(current, allOS)
+		}
+		policy := u.policy.ForEnvironment(server.Environment)
+		path, found := recommendUpgrade(current, paths[osKey(current)], policy, now)
+		if !found {
+			continue
+		}
+		deadline := policy.EffectiveEndOfSupport(server.EndOfSupport())
+
+		key := osKey(current) + "/" + osKey(path.Target())
+		rec, exists := groups[key]
+		if !exists {
+			rec = &UpgradeRecommendation{
+				CurrentOS: newOSRef(current),
+				TargetOS:  newOSRef(path.Target()),
+				Hops:      path.Hops(),
+				Status:    status,
+				Deadline:  deadline,
 			}
+			for _, step := range path.Steps {
+				rec.Path = append(rec.Path, newOSRef(step))
+				rec.Migration = rec.Migration || step.Name != current.Name
+			}
+			groups[key] = rec
+			keys = append(keys, key)
+		}
+
+		rec.ServerIDs = append(rec.ServerIDs, server.ID)
+		rec.ServerNames = append(rec.ServerNames, server.Name)
+		if statusUrgency[status] > statusUrgency[rec.Status] {
+			rec.Status = status
+		}
+		if deadline.Before(rec.Deadline) {
+			rec.Deadline = deadline
+		}
+		if penalty > rec.severity {
+			rec.severity = penalty
 		}
 	}
 
-	// Generate recommendations from the collected data
-	for _, upgrade := range osUpgradeNeeded {
-		serverList := fmt.Sprintf("[%s]", strings.Join(upgrade.serverNames, ", "))
-		recommendations = append(recommendations,
-			fmt.Sprintf("SUGGESTION: Consider upgrading servers %s from %s to %s",
-				serverList, upgrade.currentOS, upgrade.recommendedOS))
+	recs := make([]UpgradeRecommendation, 0, len(keys))
+	for _, key := range keys {
+		rec := groups[key]
+		// List servers by name, keeping their IDs in the same order
+		sort.Sort(serversByName{rec.ServerIDs, rec.ServerNames})
+		recs = append(recs, *rec)
 	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].severity != recs[j].severity {
+			return recs[i].severity > recs[j].severity
+		}
+		if len(recs[i].ServerIDs) != len(recs[j].ServerIDs) {
+			return len(recs[i].ServerIDs) > len(recs[j].ServerIDs)
+		}
+		if recs[i].CurrentOS.Name != recs[j].CurrentOS.Name {
+			return recs[i].CurrentOS.Name < recs[j].CurrentOS.Name
+		}
+		return CompareVersions(recs[i].CurrentOS.Version, recs[j].CurrentOS.Version) < 0
+	})
+	return recs
+}
+
+// serversByName sorts the parallel server IDs and names of a recommendation
+// by name
+type serversByName struct {
+	ids   []int
+	names []string
+}
 
-	return recommendations
+func (s serversByName) Len() int           { return len(s.names) }
+func (s serversByName) Less(i, j int) bool { return s.names[i] < s.names[j] }
+func (s serversByName) Swap(i, j int) {
+	s.ids[i], s.ids[j] = s.ids[j], s.ids[i]
+	s.names[i], s.names[j] = s.names[j], s.names[i]
 }
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -60,6 +62,27 @@ func TestOSUtils_GetLatestVersionByFamily(t *testing.T) {
 	}
 }
 
+func TestOSUtils_GetLatestVersionByFamily_NumericVersions(t *testing.T) {
+	utils := NewOSUtils()
+
+	oss := []OS{
+		{ID: 1, Name: "Debian", Version: "10", EndOfSupport: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Name: "Debian", Version: "9", EndOfSupport: time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, Name: "FreeBSD", Version: "14.3", EndOfSupport: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)},
+		{ID: 4, Name: "FreeBSD", Version: "9.3", EndOfSupport: time.Date(2016, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{ID: 5, Name: "RedHat", Version: "8.10", EndOfSupport: time.Date(2029, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{ID: 6, Name: "RedHat", Version: "8.9", EndOfSupport: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	latest := utils.GetLatestVersionByFamily(oss)
+
+	for family, version := range map[string]string{"Debian": "10", "FreeBSD": "14.3", "RedHat": "8.10"} {
+		if latest[family].Version != version {
+			t.Errorf("Expected latest %s to be %s, got %s", family, version, latest[family].Version)
+		}
+	}
+}
+
 func TestOSUtils_FilterByEndOfSupport(t *testing.T) {
 	utils := NewOSUtils()
 	now := time.Now()
@@ -352,6 +375,53 @@ func TestServerUtils_ValidateServerName(t *testing.T) {
 	}
 }
 
+func TestServerUtils_ValidateAttributes(t *testing.T) {
+	utils := NewServerUtils()
+
+	// Lengths are counted in characters, like VARCHAR columns
+	limit := strings.Repeat("é", MaxServerAttributeLength)
+	if err := utils.ValidateAttributes(limit, limit, limit); err != nil {
+		t.Errorf("Unexpected error at the limit: %v", err)
+	}
+	for _, attributes := range [][3]string{
+		{limit + "x", "", ""},
+		{"", limit + "x", ""},
+		{"", "", limit + "x"},
+	} {
+		if err := utils.ValidateAttributes(attributes[0], attributes[1], attributes[2]); err == nil {
+			t.Errorf("Expected an error for %d-character attributes", MaxServerAttributeLength+1)
+		}
+	}
+}
+
+func TestServerUtils_ValidateEnvironment(t *testing.T) {
+	utils := NewServerUtils()
+
+	tests := []struct {
+		input     string
+		expectErr bool
+	}{
+		{input: "", expectErr: false},
+		{input: EnvironmentProd, expectErr: false},
+		{input: EnvironmentStaging, expectErr: false},
+		{input: EnvironmentDev, expectErr: false},
+		{input: "production", expectErr: true},
+		{input: "PROD", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err := utils.ValidateEnvironment(tt.input)
+			if tt.expectErr && err == nil {
+				t.Errorf("Expected error for input %q, but got none", tt.input)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error for input %q: %v", tt.input, err)
+			}
+		})
+	}
+}
+
 func TestComplianceUtils_GenerateComplianceReport(t *testing.T) {
 	utils := NewComplianceUtils()
 	now := time.Now()
@@ -473,7 +543,7 @@ func TestComplianceUtils_GetRecommendations(t *testing.T) {
 		{ID: 3, Name: "Ubuntu", Version: "22.04", EndOfSupport: now.AddDate(2, 0, 0)},
 	}
 
-	recommendations := utils.GetRecommendations(servers, allOS)
+	recommendations := utils.GetRecommendations(servers)
 
 	if len(recommendations) < 2 {
 		t.Errorf("Expected at least 2 recommendations, got %d", len(recommendations))
@@ -483,19 +553,401 @@ func TestComplianceUtils_GetRecommendations(t *testing.T) {
 	hasCritical := false
 	hasWarning := false
 	for _, rec := range recommendations {
-		if len(rec) > 8 && rec[:8] == "CRITICAL" {
+		if rec.Severity == SeverityCritical && rec.Code == RecommendationEndOfLife {
 			hasCritical = true
 		}
-		if len(rec) > 7 && rec[:7] == "WARNING" {
+		if rec.Severity == SeverityWarning && rec.Code == RecommendationEndingSoon {
 			hasWarning = true
 		}
 	}
 
 	if !hasCritical {
-		t.Error("Expected a CRITICAL recommendation for end-of-life servers")
+		t.Error("Expected a critical recommendation for end-of-life servers")
 	}
 
 	if !hasWarning {
-		t.Error("Expected a WARNING recommendation for ending-soon servers")
+		t.Error("Expected a warning recommendation for ending-soon servers")
+	}
+
+	// The end-of-life server is the most urgent to upgrade
+	upgrades := upgradeSummaries(utils.GetUpgradeRecommendations(servers, allOS))
+	if want := "[[server1] Ubuntu 18.04 -> Ubuntu 22.04 [server2] Ubuntu 20.04 -> Ubuntu 22.04]"; fmt.Sprint(upgrades) != want {
+		t.Errorf("Expected upgrades %s, got %v", want, upgrades)
+	}
+}
+
+func TestComplianceUtils_WithPolicy(t *testing.T) {
+	policy := CompliancePolicy{
+		Tiers: []PolicyTier{
+			{Name: "notice", Months: 12, Penalty: 0.25},
+			{Name: "urgent", Months: 1, Penalty: 1},
+		},
+		EndOfLifePenalty: 3,
+		ScoreBands:       DefaultCompliancePolicy().ScoreBands,
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	utils := NewComplianceUtilsWithPolicy(policy)
+	now := time.Now()
+
+	servers := []Server{
+		{ID: 1, Name: "server1", OS: &OS{Name: "Ubuntu", Version: "18.04", EndOfSupport: now.AddDate(-1, 0, 0)}}, // EOL (penalty: 3)
+		{ID: 2, Name: "server2", OS: &OS{Name: "Ubuntu", Version: "20.04", EndOfSupport: now.AddDate(0, 0, 10)}}, // Urgent (penalty: 1)
+		{ID: 3, Name: "server3", OS: &OS{Name: "Ubuntu", Version: "22.04", EndOfSupport: now.AddDate(0, 9, 0)}},  // Notice (penalty: 0.25)
+		{ID: 4, Name: "server4", OS: &OS{Name: "Ubuntu", Version: "24.04", EndOfSupport: now.AddDate(3, 0, 0)}},  // Supported
+	}
+
+	report := utils.GenerateComplianceReport(servers)
+	if report.EndOfLifeServers != 1 || report.EndingSoonServers != 2 || report.SupportedServers != 1 {
+		t.Errorf("Unexpected report counts: %+v", report)
+	}
+	if report.TierCounts["urgent"] != 1 || report.TierCounts["notice"] != 1 {
+		t.Errorf("Unexpected tier counts: %v", report.TierCounts)
+	}
+
+	// (4 - 3 - 1 - 0.25) / 4 * 100 is negative and clamps to zero
+	if score := utils.GetComplianceScore(servers); score != 0 {
+		t.Errorf("Expected compliance score 0, got %.2f", score)
+	}
+	if score := utils.GetComplianceScore(servers[2:]); score != 87.5 {
+		t.Errorf("Expected compliance score 87.5, got %.2f", score)
+	}
+
+	warnings := 0
+	for _, rec := range utils.GetRecommendations(servers) {
+		if rec.Severity == SeverityWarning {
+			warnings++
+		}
+	}
+	if warnings != 2 {
+		t.Errorf("Expected one warning per populated tier, got %d", warnings)
+	}
+}
+
+func TestComplianceUtils_EnvironmentPolicies(t *testing.T) {
+	policy := DefaultCompliancePolicy()
+	policy.Environments = map[string]CompliancePolicy{
+		EnvironmentProd: func() CompliancePolicy {
+			p := DefaultCompliancePolicy()
+			p.LeadMonths = 12
+			p.EndOfLifePenalty = 4
+			return p
+		}(),
+		EnvironmentDev: func() CompliancePolicy {
+			p := DefaultCompliancePolicy()
+			p.GraceDays = 90
+			return p
+		}(),
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	utils := NewComplianceUtilsWithPolicy(policy)
+	now := time.Now()
+	recentEOL := &OS{Name: "Ubuntu", Version: "18.04", EndOfSupport: now.AddDate(0, 0, -30)}
+	nineMonths := &OS{Name: "Ubuntu", Version: "20.04", EndOfSupport: now.AddDate(0, 9, 0)}
+	latest := &OS{Name: "Ubuntu", Version: "24.04", EndOfSupport: now.AddDate(5, 0, 0)}
+
+	servers := []Server{
+		{ID: 1, Name: "prod-01", Environment: EnvironmentProd, OS: nineMonths},  // EOL under the 12 month lead
+		{ID: 2, Name: "dev-01", Environment: EnvironmentDev, OS: recentEOL},     // Within the 90 day grace
+		{ID: 3, Name: "stg-01", Environment: EnvironmentStaging, OS: recentEOL}, // EOL under the default policy
+		{ID: 4, Name: "stg-02", Environment: EnvironmentStaging, OS: latest},
+		{ID: 5, Name: "misc-01", OS: latest},
+	}
+
+	report := utils.GenerateComplianceReport(servers)
+	if report.EndOfLifeServers != 2 || report.EndingSoonServers != 1 || report.SupportedServers != 2 {
+		t.Errorf("Unexpected report counts: eol=%d soon=%d supported=%d",
+			report.EndOfLifeServers, report.EndingSoonServers, report.SupportedServers)
+	}
+
+	if len(report.Environments) != 4 {
+		t.Fatalf("Expected 4 environment breakdowns, got %v", report.Environments)
+	}
+	if prod := report.Environments[EnvironmentProd]; prod.EndOfLifeServers != 1 || prod.ComplianceScore != 0 {
+		t.Errorf("Unexpected prod breakdown: %+v", prod)
+	}
+	if dev := report.Environments[EnvironmentDev]; dev.EndingSoonServers != 1 || dev.ComplianceScore != 50 {
+		t.Errorf("Unexpected dev breakdown: %+v", dev)
+	}
+	if staging := report.Environments[EnvironmentStaging]; staging.TotalServers != 2 || staging.ComplianceScore != 0 {
+		t.Errorf("Unexpected staging breakdown: %+v", staging)
+	}
+	if misc := report.Environments[EnvironmentUnassigned]; misc.SupportedServers != 1 || misc.ComplianceScore != 100 {
+		t.Errorf("Unexpected unassigned breakdown: %+v", misc)
+	}
+
+	// Recommendations are ordered by level and then by policy penalty, so
+	// the prod end-of-life server (penalty 4) comes before staging (penalty 2)
+	allOS := []OS{*recentEOL, *nineMonths, *latest}
+	for i := 0; i < 5; i++ {
+		recommendations := recommendationSummaries(utils.GetRecommendations(servers))
+		expected := []string{
+			"critical: 1 prod servers are running end-of-life operating systems and need immediate updates",
+			"critical: 1 servers are running end-of-life operating systems and need immediate updates",
+			"warning: 1 dev servers are running operating systems that will reach end-of-life within 6 months (ending_soon)",
+		}
+		if len(recommendations) != len(expected) {
+			t.Fatalf("Expected %d recommendations, got %v", len(expected), recommendations)
+		}
+		for j := range expected {
+			if recommendations[j] != expected[j] {
+				t.Errorf("Recommendation %d: expected %q, got %q", j, expected[j], recommendations[j])
+			}
+		}
+
+		upgrades := upgradeSummaries(utils.GetUpgradeRecommendations(servers, allOS))
+		if want := "[[prod-01] Ubuntu 20.04 -> Ubuntu 24.04 [dev-01 stg-01] Ubuntu 18.04 -> Ubuntu 24.04]"; fmt.Sprint(upgrades) != want {
+			t.Errorf("Expected upgrades %s, got %v", want, upgrades)
+		}
+	}
+}
+
+func TestComplianceUtils_Waivers(t *testing.T) {
+	now := time.Now()
+	eol := &OS{ID: 1, Name: "Ubuntu", Version: "18.04", EndOfSupport: now.AddDate(-1, 0, 0)}
+	endingSoon := &OS{ID: 2, Name: "Ubuntu", Version: "20.04", EndOfSupport: now.AddDate(0, 3, 0)}
+	latest := &OS{ID: 3, Name: "Ubuntu", Version: "24.04", EndOfSupport: now.AddDate(5, 0, 0)}
+
+	servers := []Server{
+		{ID: 1, Name: "web-01", OSID: eol.ID, OS: eol},              // Waived by server
+		{ID: 2, Name: "web-02", OSID: eol.ID, OS: eol},              // Waiver expired
+		{ID: 3, Name: "db-01", OSID: endingSoon.ID, OS: endingSoon}, // Waived by OS
+		{ID: 4, Name: "db-02", OSID: latest.ID, OS: latest},         // Supported, waiver has no effect
+	}
+	web01, web02 := 1, 2
+	revokedAt := now.Add(-time.Hour)
+	waivers := []Waiver{
+		{ID: 1, ServerID: &web01, Approver: "alice", ExpiresAt: now.AddDate(0, 1, 0)},
+		{ID: 2, ServerID: &web02, Approver: "bob", ExpiresAt: now.AddDate(0, 0, -1)},
+		{ID: 3, OSID: &endingSoon.ID, Approver: "carol", ExpiresAt: now.AddDate(0, 1, 0)},
+		{ID: 4, OSID: &latest.ID, Approver: "dave", ExpiresAt: now.AddDate(0, 1, 0)},
+		{ID: 5, ServerID: &web02, Approver: "erin", ExpiresAt: now.AddDate(0, 1, 0), RevokedAt: &revokedAt},
+	}
+
+	utils := NewComplianceUtils().WithWaivers(waivers)
+	report := utils.GenerateComplianceReport(servers)
+
+	if report.EndOfLifeServers != 1 || report.EndingSoonServers != 0 || report.WaivedServers != 2 || report.SupportedServers != 1 {
+		t.Errorf("Unexpected report counts: eol=%d soon=%d waived=%d supported=%d",
+			report.EndOfLifeServers, report.EndingSoonServers, report.WaivedServers, report.SupportedServers)
+	}
+	if len(report.EndOfLifeList) != 1 || report.EndOfLifeList[0].Name != "web-02" {
+		t.Errorf("Expected only the server with an expired waiver to be end of life, got %v", report.EndOfLifeList)
+	}
+	if len(report.Waived) != 2 {
+		t.Fatalf("Expected 2 waived servers, got %v", report.Waived)
+	}
+	for _, waived := range report.Waived {
+		switch waived.Server.Name {
+		case "web-01":
+			if waived.Status != StatusEndOfLife || waived.Waiver.Approver != "alice" {
+				t.Errorf("Unexpected waived entry: %+v", waived)
+			}
+		case "db-01":
+			if waived.Status != StatusEndingSoon || waived.Waiver.Approver != "carol" {
+				t.Errorf("Unexpected waived entry: %+v", waived)
+			}
+		default:
+			t.Errorf("Unexpected waived server %s", waived.Server.Name)
+		}
+	}
+	if summary := report.Environments[EnvironmentUnassigned]; summary.WaivedServers != 2 || summary.ComplianceScore != 50 {
+		t.Errorf("Unexpected environment breakdown: %+v", summary)
+	}
+
+	if c := utils.ClassifyServer(servers[2]); c.Status != StatusWaived || c.WaivedStatus != StatusEndingSoon || c.Waiver == nil || c.Waiver.ID != 3 {
+		t.Errorf("Unexpected classification of db-01: %+v", c)
+	}
+	if c := utils.ClassifyServer(servers[1]); c.Status != StatusEndOfLife || c.Waiver != nil {
+		t.Errorf("Unexpected classification of web-02: %+v", c)
+	}
+
+	// Only the end-of-life penalty of web-02 counts against the score
+	if score := utils.GetComplianceScore(servers); score != 50 {
+		t.Errorf("Expected score 50, got %.2f", score)
+	}
+	if score := NewComplianceUtils().GetComplianceScore(servers); score != 0 {
+		t.Errorf("Expected score 0 without waivers, got %.2f", score)
+	}
+
+	// The waived servers get no upgrade recommendation
+	if upgrades := upgradeSummaries(utils.GetUpgradeRecommendations(servers, []OS{*eol, *endingSoon, *latest})); fmt.Sprint(upgrades) != "[[web-02] Ubuntu 18.04 -> Ubuntu 24.04]" {
+		t.Errorf("Unexpected upgrades %v", upgrades)
+	}
+
+	recommendations := recommendationSummaries(utils.GetRecommendations(servers))
+	expected := []string{
+		"critical: 1 servers are running end-of-life operating systems and need immediate updates",
+	}
+	if len(recommendations) != len(expected) {
+		t.Fatalf("Expected %d recommendations, got %v", len(expected), recommendations)
+	}
+	for i := range expected {
+		if recommendations[i] != expected[i] {
+			t.Errorf("Recommendation %d: expected %q, got %q", i, expected[i], recommendations[i])
+		}
+	}
+}
+
+func TestComplianceUtils_ScheduledChanges(t *testing.T) {
+	now := time.Now()
+	eol := &OS{ID: 1, Name: "Ubuntu", Version: "18.04", EndOfSupport: now.AddDate(-1, 0, 0)}
+	endingSoon := &OS{ID: 2, Name: "Ubuntu", Version: "20.04", EndOfSupport: now.AddDate(0, 3, 0)}
+	latest := &OS{ID: 3, Name: "Ubuntu", Version: "24.04", EndOfSupport: now.AddDate(5, 0, 0)}
+
+	servers := []Server{
+		{ID: 1, Name: "web-01", OSID: eol.ID, OS: eol},              // Scheduled
+		{ID: 2, Name: "web-02", OSID: eol.ID, OS: eol},              // Window closed
+		{ID: 3, Name: "db-01", OSID: endingSoon.ID, OS: endingSoon}, // Scheduled first
+		{ID: 4, Name: "db-02", OSID: latest.ID, OS: latest},         // Supported, change has no effect
+		{ID: 5, Name: "db-03", OSID: eol.ID, OS: eol},               // Change cancelled
+	}
+	id := func(i int) *int { return &i }
+	changes := []ScheduledChange{
+		{ID: 1, ServerID: id(1), OSID: latest.ID, Status: ScheduledChangePending, WindowStart: now.AddDate(0, 0, 7), WindowEnd: now.AddDate(0, 0, 8)},
+		{ID: 2, ServerID: id(2), OSID: latest.ID, Status: ScheduledChangePending, WindowStart: now.AddDate(0, 0, -2), WindowEnd: now.AddDate(0, 0, -1)},
+		{ID: 3, ServerID: id(3), OSID: latest.ID, Status: ScheduledChangePending, WindowStart: now.AddDate(0, 0, 1), WindowEnd: now.AddDate(0, 0, 2)},
+		{ID: 4, ServerID: id(4), OSID: eol.ID, Status: ScheduledChangePending, WindowStart: now.AddDate(0, 0, 1), WindowEnd: now.AddDate(0, 0, 2)},
+		{ID: 5, ServerID: id(5), OSID: latest.ID, Status: ScheduledChangeCancelled, WindowStart: now.AddDate(0, 0, 1), WindowEnd: now.AddDate(0, 0, 2)},
+	}
+
+	utils := NewComplianceUtils().WithScheduledChanges(changes)
+	report := utils.GenerateComplianceReport(servers)
+
+	if report.RemediationScheduledServers != 2 || len(report.RemediationScheduled) != 2 {
+		t.Fatalf("Expected 2 servers with remediation scheduled, got %+v", report.RemediationScheduled)
+	}
+	if first := report.RemediationScheduled[0]; first.Server.Name != "db-01" || first.Status != StatusEndingSoon || first.Change.ID != 3 {
+		t.Errorf("Expected the earliest window first, got %+v", first)
+	}
+	if second := report.RemediationScheduled[1]; second.Server.Name != "web-01" || second.Status != StatusEndOfLife {
+		t.Errorf("Unexpected scheduled remediation: %+v", second)
+	}
+	if summary := report.Environments[EnvironmentUnassigned]; summary.RemediationScheduledServers != 2 {
+		t.Errorf("Unexpected environment breakdown: %+v", summary)
+	}
+
+	// Scheduled servers keep their status and penalty until the change is applied
+	if report.EndOfLifeServers != 3 || report.EndingSoonServers != 1 {
+		t.Errorf("Unexpected report counts: eol=%d soon=%d", report.EndOfLifeServers, report.EndingSoonServers)
+	}
+	if score, unscheduled := utils.GetComplianceScore(servers), NewComplianceUtils().GetComplianceScore(servers); score != unscheduled {
+		t.Errorf("Expected scheduled changes not to affect the score, got %.2f and %.2f", score, unscheduled)
+	}
+
+	if c := utils.ClassifyServer(servers[0]); c.Status != StatusEndOfLife || c.ScheduledChange == nil || c.ScheduledChange.ID != 1 {
+		t.Errorf("Unexpected classification of web-01: %+v", c)
+	}
+	if c := utils.ClassifyServer(servers[1]); c.ScheduledChange != nil {
+		t.Errorf("Expected no scheduled change for web-02, got %+v", c.ScheduledChange)
+	}
+}
+
+func TestComplianceUtils_StaleServers(t *testing.T) {
+	now := time.Now()
+	eol := &OS{ID: 1, Name: "Ubuntu", Version: "18.04", EndOfSupport: now.AddDate(-1, 0, 0)}
+	latest := &OS{ID: 2, Name: "Ubuntu", Version: "24.04", EndOfSupport: now.AddDate(5, 0, 0)}
+	seen := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+
+	servers := []Server{
+		{ID: 1, Name: "web-01", OS: eol, Environment: EnvironmentProd, LastSeenAt: seen(90)}, // Stale
+		{ID: 2, Name: "web-02", OS: eol, Environment: EnvironmentProd, LastSeenAt: seen(1)},
+		{ID: 3, Name: "db-01", OS: latest, Environment: EnvironmentProd, LastSeenAt: seen(45)}, // Stale
+		{ID: 4, Name: "db-02", OS: latest, Environment: EnvironmentProd},                       // Never reported
+	}
+
+	// Stale servers are reported but still counted by default
+	report := NewComplianceUtils().GenerateComplianceReport(servers)
+	if report.StaleServers != 2 || len(report.StaleList) != 2 || report.StaleList[0].Name != "web-01" || report.StaleList[1].Name != "db-01" {
+		t.Errorf("Unexpected stale servers: %d %v", report.StaleServers, report.StaleList)
+	}
+	if report.EndOfLifeServers != 2 || report.SupportedServers != 2 {
+		t.Errorf("Unexpected counts: eol=%d supported=%d", report.EndOfLifeServers, report.SupportedServers)
+	}
+
+	// A policy excluding stale servers leaves them out of the counts and score
+	policy := DefaultCompliancePolicy()
+	policy.ExcludeStale = true
+	utils := NewComplianceUtilsWithPolicy(policy)
+	report = utils.GenerateComplianceReport(servers)
+	if report.TotalServers != 4 || report.StaleServers != 2 || report.EndOfLifeServers != 1 || report.SupportedServers != 1 {
+		t.Errorf("Unexpected counts: total=%d stale=%d eol=%d supported=%d",
+			report.TotalServers, report.StaleServers, report.EndOfLifeServers, report.SupportedServers)
+	}
+	if len(report.EndOfLifeList) != 1 || report.EndOfLifeList[0].Name != "web-02" {
+		t.Errorf("Expected only web-02 to be listed as end of life, got %v", report.EndOfLifeList)
+	}
+	if summary := report.Environments[EnvironmentProd]; summary.StaleServers != 2 || summary.SupportedServers != 1 || summary.ComplianceScore != 0 {
+		t.Errorf("Unexpected environment breakdown: %+v", summary)
+	}
+	if score := utils.GetComplianceScore(servers); score != 0 {
+		t.Errorf("Expected score 0 from web-02 and db-02 only, got %.2f", score)
+	}
+	if score := utils.GetComplianceScore(servers[:1]); score != 100 {
+		t.Errorf("Expected score 100 when every server is excluded, got %.2f", score)
+	}
+	if c := utils.ClassifyServer(servers[0]); c.Status != StatusStale || !c.Stale {
+		t.Errorf("Unexpected classification of web-01: %+v", c)
+	}
+
+	// Excluded stale servers get no upgrade recommendation
+	if upgrades := upgradeSummaries(utils.GetUpgradeRecommendations(servers, []OS{*eol, *latest})); fmt.Sprint(upgrades) != "[[web-02] Ubuntu 18.04 -> Ubuntu 24.04]" {
+		t.Errorf("Unexpected upgrades %v", upgrades)
+	}
+
+	recommendations := recommendationSummaries(utils.GetRecommendations(servers))
+	expected := []string{
+		"critical: 1 servers are running end-of-life operating systems and need immediate updates",
+		"warning: 2 servers have not reported within the stale threshold; confirm they still exist or delete them",
+	}
+	if len(recommendations) != len(expected) {
+		t.Fatalf("Expected %d recommendations, got %v", len(expected), recommendations)
+	}
+	for i := range expected {
+		if recommendations[i] != expected[i] {
+			t.Errorf("Recommendation %d: expected %q, got %q", i, expected[i], recommendations[i])
+		}
+	}
+}
+
+func TestComplianceUtils_ExtendedSupport(t *testing.T) {
+	now := time.Now()
+	extended := now.AddDate(3, 0, 0)
+	esm := &OS{ID: 1, Name: "Ubuntu", Version: "18.04", EndOfSupport: now.AddDate(-1, 0, 0), EndOfExtendedSupport: &extended}
+	eol := &OS{ID: 2, Name: "CentOS", Version: "7", EndOfSupport: now.AddDate(-1, 0, 0)}
+
+	servers := []Server{
+		{ID: 1, Name: "web-01", OS: esm, ExtendedSupport: true},
+		{ID: 2, Name: "web-02", OS: esm},
+		// Enrolling does not help when the OS offers no extended support
+		{ID: 3, Name: "db-01", OS: eol, ExtendedSupport: true},
+	}
+
+	utils := NewComplianceUtils()
+	report := utils.GenerateComplianceReport(servers)
+	if report.SupportedServers != 1 || report.EndOfLifeServers != 2 || report.ExtendedSupportServers != 1 {
+		t.Errorf("Unexpected counts: supported=%d eol=%d extended=%d",
+			report.SupportedServers, report.EndOfLifeServers, report.ExtendedSupportServers)
+	}
+	if len(report.EndOfLifeList) != 2 || report.EndOfLifeList[0].Name != "web-02" || report.EndOfLifeList[1].Name != "db-01" {
+		t.Errorf("Unexpected end of life servers %v", report.EndOfLifeList)
+	}
+
+	if c := utils.ClassifyServer(servers[0]); c.Status != StatusSupported || !c.ExtendedSupport {
+		t.Errorf("Unexpected classification of web-01: %+v", c)
+	}
+	if c := utils.ClassifyServer(servers[2]); c.Status != StatusEndOfLife || c.ExtendedSupport {
+		t.Errorf("Unexpected classification of db-01: %+v", c)
+	}
+	if got := servers[0].EndOfSupport(); !got.Equal(extended) {
+		t.Errorf("Expected web-01 to be evaluated against %v, got %v", extended, got)
 	}
 }
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionNumberPattern matches the leading dotted numeric part of a version
+// such as "22.04", "8.10" or "v14.3". The first group holds the numbers.
+const VersionNumberPattern = `^[vV]?([0-9]+(?:\.[0-9]+)*)`
+
+// VersionPrereleasePattern matches a pre-release label such as "rc1",
+// "beta" or "-dev2" in a lowercased version. The first group holds the
+// label and the second its optional number.
+const VersionPrereleasePattern = `(?:^|[^a-z])(alpha|beta|rc|preview|pre|dev)([0-9]*)(?:[^a-z]|$)`
+
+var (
+	versionNumberRegexp     = regexp.MustCompile(VersionNumberPattern)
+	versionPrereleaseRegexp = regexp.MustCompile(VersionPrereleasePattern)
+)
+
+// Pre-release ranks. Releases rank above every pre-release of the same
+// version; labels such as "LTS" or "RELEASE" do not affect ordering.
+const (
+	RankDev = iota
+	RankAlpha
+	RankBeta
+	RankReleaseCandidate
+	RankRelease
+)
+
+// Version is an operating system version broken into comparable parts
+type Version struct {
+	Raw              string
+	Numbers          []int64
+	PrereleaseRank   int
+	PrereleaseNumber int64
+}
+
+// ParseVersion splits a version string into its numeric components and
+// pre-release label. It accepts dotted numeric versions ("12", "9.3"),
+// Ubuntu YY.MM releases ("22.04"), RHEL minor releases ("8.10") and
+// suffixes such as "-LTS", " LTS", "-RELEASE" or "-rc1".
+func ParseVersion(raw string) Version {
+	v := Version{Raw: raw, PrereleaseRank: RankRelease}
+
+	if m := versionNumberRegexp.FindStringSubmatch(raw); m != nil {
+		for _, part := range strings.Split(m[1], ".") {
+			n, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				n = 1<<63 - 1
+			}
+			v.Numbers = append(v.Numbers, n)
+		}
+	}
+
+	if m := versionPrereleaseRegexp.FindStringSubmatch(strings.ToLower(raw)); m != nil {
+		switch m[1] {
+		case "dev":
+			v.PrereleaseRank = RankDev
+		case "alpha":
+			v.PrereleaseRank = RankAlpha
+		case "beta":
+			v.PrereleaseRank = RankBeta
+		default:
+			v.PrereleaseRank = RankReleaseCandidate
+		}
+		if m[2] != "" {
+			v.PrereleaseNumber, _ = strconv.ParseInt(m[2], 10, 64)
+		}
+	}
+
+	return v
+}
+
+// Compare returns -1, 0 or 1 depending on whether v sorts before, equal to
+// or after other. Numeric components are compared in order, with a shorter
+// version sorting before a longer one sharing its prefix ("8" < "8.1").
+// Versions without a numeric part sort after numeric ones. Remaining ties
+// are broken by the raw string so the ordering is total.
+func (v Version) Compare(other Version) int {
+	switch {
+	case len(v.Numbers) == 0 && len(other.Numbers) > 0:
+		return 1
+	case len(v.Numbers) > 0 && len(other.Numbers) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Numbers) && i < len(other.Numbers); i++ {
+		if c := compareInt64(v.Numbers[i], other.Numbers[i]); c != 0 {
+			return c
+		}
+	}
+	if c := compareInt64(int64(len(v.Numbers)), int64(len(other.Numbers))); c != 0 {
+		return c
+	}
+
+	if c := compareInt64(int64(v.PrereleaseRank), int64(other.PrereleaseRank)); c != 0 {
+		return c
+	}
+	if c := compareInt64(v.PrereleaseNumber, other.PrereleaseNumber); c != 0 {
+		return c
+	}
+
+	return strings.Compare(v.Raw, other.Raw)
+}
+
+// CompareVersions compares two version strings, see Version.Compare
+func CompareVersions(a, b string) int {
+	return ParseVersion(a).Compare(ParseVersion(b))
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
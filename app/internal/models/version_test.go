@@ -0,0 +1,68 @@
+package models
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"9", "10", -1},
+		{"10", "9", 1},
+		{"9.3", "14.3", -1},
+		{"20.04", "22.04", -1},
+		{"22.04", "22.10", -1},
+		{"8.9", "8.10", -1},
+		{"8", "8.1", -1},
+		{"12", "12", 0},
+		{"22.04-LTS", "22.04.1", -1},
+		{"24.04-rc1", "24.04", -1},
+		{"14.0-BETA2", "14.0-RC1", -1},
+		{"14.0-RC1", "14.0-RC2", -1},
+		{"7.0-dev", "7.0-alpha1", -1},
+		{"14.0rc1", "14.0-RELEASE", -1},
+		{"v2", "10", -1},
+		{"rolling", "1", 1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("CompareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.expected {
+			t.Errorf("CompareVersions(%q, %q) = %d, expected %d", tt.b, tt.a, got, -tt.expected)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	v := ParseVersion("13.2-RC3")
+	if len(v.Numbers) != 2 || v.Numbers[0] != 13 || v.Numbers[1] != 2 {
+		t.Errorf("Expected numbers [13 2], got %v", v.Numbers)
+	}
+	if v.PrereleaseRank != RankReleaseCandidate || v.PrereleaseNumber != 3 {
+		t.Errorf("Expected release candidate 3, got rank %d number %d", v.PrereleaseRank, v.PrereleaseNumber)
+	}
+
+	// "LTS" is a label, not a pre-release
+	if lts := ParseVersion("22.04 LTS"); lts.PrereleaseRank != RankRelease {
+		t.Errorf("Expected 22.04 LTS to be a release, got rank %d", lts.PrereleaseRank)
+	}
+}
+
+func TestCompareVersions_Sort(t *testing.T) {
+	versions := []string{"10", "9", "13", "11", "12", "4", "14.3", "9.3", "14.0", "13.5"}
+	sort.Slice(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) < 0
+	})
+
+	expected := []string{"4", "9", "9.3", "10", "11", "12", "13", "13.5", "14.0", "14.3"}
+	for i := range expected {
+		if versions[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, versions)
+		}
+	}
+}
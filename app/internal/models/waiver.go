@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// Waiver accepts the compliance risk of a server, or of every server running
+// an operating system, until it expires or is revoked. Waived servers are
+// excluded from compliance penalties and recommendations while the waiver is
+// active. Waivers are kept for auditing once they end.
+type Waiver struct {
+	ID       int  `json:"id" db:"id"`
+	ServerID *int `json:"server_id,omitempty" db:"server_id"` // Null once the server is deleted
+	OSID     *int `json:"os_id,omitempty" db:"os_id"`         // Null once the operating system is deleted
+	// ServerName, OSName and OSVersion name what the waiver covers when it
+	// was granted, or when the server or operating system was deleted
+	ServerName    string     `json:"server_name,omitempty" db:"server_name"`
+	OSName        string     `json:"os_name,omitempty" db:"os_name"`
+	OSVersion     string     `json:"os_version,omitempty" db:"os_version"`
+	Justification string     `json:"justification" db:"justification"`
+	Approver      string     `json:"approver" db:"approver"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Active reports whether the waiver was in effect at the given time: created
+// by then, not yet expired and not yet revoked
+func (w Waiver) Active(now time.Time) bool {
+	if w.RevokedAt != nil && !now.Before(*w.RevokedAt) {
+		return false
+	}
+	return !now.Before(w.CreatedAt) && now.Before(w.ExpiresAt)
+}
+
+// Status returns the status of the waiver at the given time: revoked, active
+// or expired
+func (w Waiver) Status(now time.Time) string {
+	switch {
+	case w.RevokedAt != nil:
+		return WaiverStatusRevoked
+	case w.Active(now):
+		return WaiverStatusActive
+	default:
+		return WaiverStatusExpired
+	}
+}
+
+// Covers reports whether the waiver applies to a server
+func (w Waiver) Covers(server Server) bool {
+	if w.ServerID != nil {
+		return *w.ServerID == server.ID
+	}
+	return w.OSID != nil && *w.OSID == server.OSID
+}
+
+// MaxWaiverApproverLength is the longest approver a waiver can have
+const MaxWaiverApproverLength = 255
+
+// ValidateWaiverApprover checks that the approver of a waiver fits in
+// MaxWaiverApproverLength characters
+func ValidateWaiverApprover(approver string) error {
+	return validateLength("approver", approver, MaxWaiverApproverLength)
+}
+
+// CreateWaiverRequest represents the request body for creating a waiver.
+// Exactly one of ServerID and OSID must be set.
+type CreateWaiverRequest struct {
+	ServerID      *int   `json:"server_id,omitempty"`
+	OSID          *int   `json:"os_id,omitempty"`
+	Justification string `json:"justification" validate:"required"`
+	Approver      string `json:"approver" validate:"required"`
+	ExpiresAt     string `json:"expires_at" validate:"required"` // Expected format: YYYY-MM-DD
+}
+
+// UpdateWaiverRequest represents the request body for updating a waiver. The
+// scope of a waiver cannot be changed, and revoked waivers cannot be updated.
+type UpdateWaiverRequest struct {
+	Justification string `json:"justification,omitempty"`
+	Approver      string `json:"approver,omitempty"`
+	ExpiresAt     string `json:"expires_at,omitempty"` // Expected format: YYYY-MM-DD
+}
+
+// StatusWaived is the compliance status of a server whose end-of-life or
+// ending soon operating system is covered by an active waiver
+const StatusWaived = "waived"
+
+// Waiver status filter values
+const (
+	WaiverStatusActive  = "active"
+	WaiverStatusExpired = "expired"
+	WaiverStatusRevoked = "revoked"
+)
+
+// WaiverFilter represents filters and pagination for querying waivers
+type WaiverFilter struct {
+	ServerID *int
+	OSID     *int
+	Status   *string // 'active', 'expired' or 'revoked'
+	Limit    int
+	Offset   int
+}
+
+// WaivedServer is a server excluded from compliance penalties by a waiver
+type WaivedServer struct {
+	Server Server `json:"server"`
+	// Status is the support status the server would have without the waiver
+	Status string `json:"status"`
+	Waiver Waiver `json:"waiver"`
+}
@@ -7,7 +7,7 @@
 -- DELETE FROM servers WHERE name LIKE '%-sample-%';
 
 -- Insert 300 sample servers
--- The OS IDs are calculated based on the order in init.sql starting from ID 1
+-- OS IDs are resolved by name and version from the seeded operating_systems catalog
 
 -- DEBIAN SERVERS (210 servers - 70%)
 -- Mix of supported and EOL Debian versions